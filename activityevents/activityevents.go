@@ -82,3 +82,18 @@ type ActivityEventDataGame struct {
 type ActivityEventDataTag struct {
 	TagID int64 `json:"tag_id"`
 }
+
+type ActivityEventDataSweep struct {
+	Operation             string  `json:"operation"`
+	UnassignedSubmissions []int64 `json:"unassigned_submission_ids"`
+	RemindedSubmissions   []int64 `json:"reminded_submission_ids"`
+	RejectedSubmissions   []int64 `json:"rejected_submission_ids"`
+}
+
+type ActivityEventDataAutounfreeze struct {
+	Operation      string    `json:"operation"`
+	ReleasedBefore time.Time `json:"released_before"`
+	UnfrozenGames  []string  `json:"unfrozen_game_uuids"`
+	ExemptedGames  []string  `json:"exempted_game_uuids"`
+	FailedGames    []string  `json:"failed_game_uuids"`
+}
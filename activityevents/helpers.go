@@ -333,3 +333,38 @@ func BuildGameRedirectEvent(userID int64, fromGameUUID, toGameUUID string) *Acti
 		},
 	}
 }
+
+// BuildSubmissionSweepEvent is used for a run of the stalled submission sweeper which changed something
+func BuildSubmissionSweepEvent(userID int64, unassigned, reminded, rejected []int64) *ActivityEvent {
+	return &ActivityEvent{
+		ID:        -1,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		Area:      aea.Admin(),
+		Operation: aeo.Update(),
+		Data: &ActivityEventDataSweep{
+			Operation:             "sweep-stalled-submissions",
+			UnassignedSubmissions: unassigned,
+			RemindedSubmissions:   reminded,
+			RejectedSubmissions:   rejected,
+		},
+	}
+}
+
+// BuildAutounfreezeEvent is used for every run of the autounfreezer
+func BuildAutounfreezeEvent(userID int64, releasedBefore time.Time, unfrozen, exempted, failed []string) *ActivityEvent {
+	return &ActivityEvent{
+		ID:        -1,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		Area:      aea.Admin(),
+		Operation: aeo.Update(),
+		Data: &ActivityEventDataAutounfreeze{
+			Operation:      "autounfreeze",
+			ReleasedBefore: releasedBefore,
+			UnfrozenGames:  unfrozen,
+			ExemptedGames:  exempted,
+			FailedGames:    failed,
+		},
+	}
+}
@@ -37,6 +37,12 @@ func ConnectBot(l *logrus.Entry, token string) *discordgo.Session {
 	return dg
 }
 
+// Ping checks that discord accepts the bot token
+func (b *bot) Ping() error {
+	_, err := b.session.User("@me")
+	return err
+}
+
 // GetJoinedAtForUser returns time the user joined the Flashpoint server
 func (b *bot) GetJoinedAtForUser(uid int64) (time.Time, error) {
 	b.l.WithField("uid", uid).Info("getting flashpoint role ID for user")
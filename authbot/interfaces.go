@@ -10,4 +10,5 @@ type DiscordRoleReader interface {
 	GetFlashpointRoles() ([]types.DiscordRole, error)
 	GetFlashpointUserInfo(uid int64, roles []types.DiscordRole) (*types.FlashpointDiscordUser, error)
 	GetJoinedAtForUser(uid int64) (time.Time, error)
+	Ping() error
 }
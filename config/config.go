@@ -2,9 +2,14 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"reflect"
 	"strconv"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
@@ -43,11 +48,8 @@ type Config struct {
 	FlashfreezeIngestDirFullPath  string
 	SubmissionsDirFullPath        string
 	SubmissionImagesDirFullPath   string
+	BlobStoreDirFullPath          string
 	SystemUid                     int64
-	ImagesCdn                     string
-	ImagesCdnCompressed           bool
-	ImagesCdnApiKey               string
-	MinLauncherVersion            string
 	DataPacksDir                  string
 	FrozenPacksDir                string
 	ImagesDir                     string
@@ -55,66 +57,280 @@ type Config struct {
 	DeletedImagesDir              string
 	FlashpointSourceOnlyMode      bool
 	FlashpointSourceOnlyAdminMode bool
-	RecommendationEngineURL       string
-	DoNotUnfreezeGameList         []string
+	StorageDriver                 string
+	S3Endpoint                    string
+	S3AccessKeyID                 string
+	S3SecretAccessKey             string
+	S3Bucket                      string
+	S3Region                      string
+	S3UseSSL                      bool
+	S3StagingDir                  string
+	QuarantineDirFullPath         string
+	MetadataSnapshotsDirFullPath  string
+	ReviewQueueIdleHours          int64
+	SweeperUnassignInactiveDays   int64
+	SweeperRemindAfterDays        int64
+	SweeperRejectGraceDays        int64
+	MetricsToken                  string
+	TracingEnabled                bool
+	TracingOTLPEndpoint           string
+	ShutdownDrainSeconds          int64
+	SubmissionProcessingWorkers   int64
+	SubmissionProcessingAttempts  int64
+	DoNotUnfreezeGameList         []string // legacy, imported once into the autounfreezer exemptions
+
+	reloadable atomic.Pointer[Reloadable]
 }
 
-func EnvString(name string) string {
-	s := os.Getenv(name)
+// Reloadable holds the settings which can change without a restart, they are reloaded from the config file on SIGHUP
+type Reloadable struct {
+	MinLauncherVersion        string
+	ImagesCdn                 string
+	ImagesCdnCompressed       bool
+	ImagesCdnApiKey           string
+	StoragePresignedDownloads bool
+	RecommendationEngineURL   string
+}
+
+// Reloadable returns the current reloadable settings, the returned value must not be modified
+func (c *Config) Reloadable() *Reloadable {
+	return c.reloadable.Load()
+}
+
+// lookup returns the value of the setting, the env variables take precedence over the config file
+func lookup(name string) string {
+	if s := os.Getenv(name); s != "" {
+		return s
+	}
+	return fileValue(name)
+}
+
+func parseString(name string) (string, error) {
+	s := lookup(name)
 	if s == "" {
-		panic(fmt.Sprintf("env variable '%s' is not set", name))
+		return "", fmt.Errorf("env variable '%s' is not set", name)
 	}
-	return s
+	return s, nil
 }
 
-func EnvInt(name string) int64 {
-	s := os.Getenv(name)
+func parseInt(name string) (int64, error) {
+	s := lookup(name)
 	if s == "" {
-		panic(fmt.Sprintf("env variable '%s' is not set", name))
+		return 0, fmt.Errorf("env variable '%s' is not set", name)
 	}
 	i, err := strconv.ParseInt(s, 10, 64)
 	if err != nil {
-		panic(err)
+		return 0, fmt.Errorf("invalid value of env variable '%s': %w", name, err)
 	}
-	return i
+	return i, nil
 }
 
-func EnvBool(name string) bool {
-	s := os.Getenv(name)
+func parseBool(name string) (bool, error) {
+	s := lookup(name)
 	if s == "" {
-		panic(fmt.Sprintf("env variable '%s' is not set", name))
+		return false, fmt.Errorf("env variable '%s' is not set", name)
 	} else if s == "True" {
-		return true
+		return true, nil
 	} else if s == "False" {
-		return false
+		return false, nil
 	}
-	panic(fmt.Sprintf("invalid value of env variable '%s'", name))
+	return false, fmt.Errorf("invalid value of env variable '%s'", name)
 }
 
-func EnvJSONList(name string) []string {
-	s := os.Getenv(name)
+func parseJSONList(name string) ([]string, error) {
+	s := lookup(name)
 	if s == "" {
-		panic(fmt.Sprintf("env variable '%s' is not set", name))
+		return nil, fmt.Errorf("env variable '%s' is not set", name)
 	}
 
 	var result []string
 	err := json.Unmarshal([]byte(s), &result)
 	if err != nil {
-		panic(fmt.Sprintf("invalid json env variable '%s': %v", name, err))
+		return nil, fmt.Errorf("invalid json env variable '%s': %v", name, err)
 	}
 
-	return result
+	return result, nil
 }
 
-func GetConfig(l *logrus.Entry) *Config {
+func EnvString(name string) string {
+	s, err := parseString(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+// EnvOptionalString returns an empty string if the variable is not set
+func EnvOptionalString(name string) string {
+	return lookup(name)
+}
+
+func EnvInt(name string) int64 {
+	i, err := parseInt(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return i
+}
+
+func EnvBool(name string) bool {
+	b, err := parseBool(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return b
+}
+
+// EnvOptionalBool returns false if the variable is not set
+func EnvOptionalBool(name string) bool {
+	if lookup(name) == "" {
+		return false
+	}
+	return EnvBool(name)
+}
+
+func EnvJSONList(name string) []string {
+	l, err := parseJSONList(name)
+	if err != nil {
+		panic(err.Error())
+	}
+	return l
+}
+
+// loader reads the settings like the Env functions do, but collects the errors instead of panicking,
+// so that all of them are reported at once
+type loader struct {
+	errs   []error
+	failed map[string]bool
+}
+
+func (ld *loader) fail(name string, err error) {
+	ld.errs = append(ld.errs, err)
+	ld.failed[name] = true
+}
+
+func (ld *loader) String(name string) string {
+	s, err := parseString(name)
+	if err != nil {
+		ld.fail(name, err)
+	}
+	return s
+}
+
+func (ld *loader) Int(name string) int64 {
+	i, err := parseInt(name)
+	if err != nil {
+		ld.fail(name, err)
+	}
+	return i
+}
+
+// OptionalInt returns the default if the variable is not set
+func (ld *loader) OptionalInt(name string, def int64) int64 {
+	if lookup(name) == "" {
+		return def
+	}
+	return ld.Int(name)
+}
+
+func (ld *loader) Bool(name string) bool {
+	b, err := parseBool(name)
+	if err != nil {
+		ld.fail(name, err)
+	}
+	return b
+}
+
+func (ld *loader) OptionalBool(name string) bool {
+	if lookup(name) == "" {
+		return false
+	}
+	return ld.Bool(name)
+}
+
+// OptionalJSONList returns an empty list if the variable is not set
+func (ld *loader) OptionalJSONList(name string) []string {
+	if lookup(name) == "" {
+		return []string{}
+	}
+	l, err := parseJSONList(name)
+	if err != nil {
+		ld.fail(name, err)
+	}
+	return l
+}
+
+// check reports the problem unless the setting already failed to load
+func (ld *loader) check(name string, ok bool, problem string) {
+	if !ok && !ld.failed[name] {
+		ld.fail(name, fmt.Errorf("invalid value of env variable '%s': %s", name, problem))
+	}
+}
+
+func (ld *loader) checkURL(name, value string) {
+	if value == "" {
+		return
+	}
+	u, err := url.Parse(value)
+	ld.check(name, err == nil && u.Scheme != "" && u.Host != "", "must be an absolute url")
+}
+
+func (ld *loader) checkPort(name string, value int64) {
+	ld.check(name, value > 0 && value <= 65535, "must be a port number")
+}
+
+func (ld *loader) checkNotNegative(name string, value int64) {
+	ld.check(name, value >= 0, "must not be negative")
+}
+
+func (ld *loader) validate(c *Config, r *Reloadable) {
+	ld.checkPort("PORT", c.Port)
+	ld.checkPort("DB_PORT", c.DBPort)
+	ld.checkPort("POSTGRES_PORT", c.PostgresPort)
+
+	ld.checkURL("HOST_BASE_URL", c.HostBaseURL)
+	ld.checkURL("OAUTH_REDIRECT_URL", c.OauthConf.RedirectURL)
+	ld.checkURL("VALIDATOR_SERVER_URL", c.ValidatorServerURL)
+	ld.checkURL("ARCHIVE_INDEXER_SERVER_URL", c.ArchiveIndexerServerURL)
+	ld.checkURL("IMAGES_CDN", r.ImagesCdn)
+	ld.checkURL("RECOMMENDATION_ENGINE_URL", r.RecommendationEngineURL)
+	ld.checkURL("TRACING_OTLP_ENDPOINT", c.TracingOTLPEndpoint)
+
+	ld.check("STORAGE_DRIVER", c.StorageDriver == "" || c.StorageDriver == "local" || c.StorageDriver == "s3", "must be local or s3")
+	if c.StorageDriver == "s3" {
+		ld.check("S3_ENDPOINT", c.S3Endpoint != "", "must be set when STORAGE_DRIVER is s3")
+		ld.check("S3_BUCKET", c.S3Bucket != "", "must be set when STORAGE_DRIVER is s3")
+	}
+
+	ld.checkNotNegative("SESSION_EXPIRATION_SECONDS", c.SessionExpirationSeconds)
+	ld.checkNotNegative("REVIEW_QUEUE_IDLE_HOURS", c.ReviewQueueIdleHours)
+	ld.checkNotNegative("SWEEPER_UNASSIGN_INACTIVE_DAYS", c.SweeperUnassignInactiveDays)
+	ld.checkNotNegative("SWEEPER_REMIND_AFTER_DAYS", c.SweeperRemindAfterDays)
+	ld.checkNotNegative("SWEEPER_REJECT_GRACE_DAYS", c.SweeperRejectGraceDays)
+	ld.checkNotNegative("SHUTDOWN_DRAIN_SECONDS", c.ShutdownDrainSeconds)
+	ld.check("SUBMISSION_PROCESSING_WORKERS", c.SubmissionProcessingWorkers >= 1, "must be at least 1")
+	ld.check("SUBMISSION_PROCESSING_ATTEMPTS", c.SubmissionProcessingAttempts >= 1, "must be at least 1")
+
+	// read outside of the config, by main and the logger
+	ld.String("GIT_COMMIT")
+	if ld.Bool("GRAYLOG_ENABLED") {
+		ld.String("GRAYLOG_HOST")
+		ld.String("GRAYLOG_ENV")
+	}
+}
+
+// Load reads and validates the config, every problem found is in the returned error
+func Load() (*Config, error) {
 	const ScopeIdentify = "identify"
 
-	return &Config{
-		Port: EnvInt("PORT"),
+	ld := &loader{failed: make(map[string]bool)}
+
+	c := &Config{
+		Port: ld.Int("PORT"),
 		OauthConf: &oauth2.Config{
-			RedirectURL:  EnvString("OAUTH_REDIRECT_URL"),
-			ClientID:     EnvString("OAUTH_CLIENT_ID"),
-			ClientSecret: EnvString("OAUTH_CLIENT_SECRET"),
+			RedirectURL:  ld.String("OAUTH_REDIRECT_URL"),
+			ClientID:     ld.String("OAUTH_CLIENT_ID"),
+			ClientSecret: ld.String("OAUTH_CLIENT_SECRET"),
 			Scopes:       []string{ScopeIdentify},
 			Endpoint: oauth2.Endpoint{
 				AuthURL:   "https://discordapp.com/api/oauth2/authorize",
@@ -122,47 +338,144 @@ func GetConfig(l *logrus.Entry) *Config {
 				AuthStyle: oauth2.AuthStyleInParams,
 			},
 		},
-		HostBaseURL:                   EnvString("HOST_BASE_URL"),
-		AuthBotToken:                  EnvString("AUTH_BOT_TOKEN"),
-		FlashpointServerID:            EnvString("FLASHPOINT_SERVER_ID"),
-		SecurecookieHashKeyPrevious:   EnvString("SECURECOOKIE_HASH_KEY_PREVIOUS"),
-		SecurecookieBlockKeyPrevious:  EnvString("SECURECOOKIE_BLOCK_KEY_PREVIOUS"),
-		SecurecookieHashKeyCurrent:    EnvString("SECURECOOKIE_HASH_KEY_CURRENT"),
-		SecurecookieBlockKeyCurrent:   EnvString("SECURECOOKIE_BLOCK_KEY_CURRENT"),
-		SessionExpirationSeconds:      EnvInt("SESSION_EXPIRATION_SECONDS"),
-		ValidatorServerURL:            EnvString("VALIDATOR_SERVER_URL"),
-		DBUser:                        EnvString("DB_USER"),
-		DBPassword:                    EnvString("DB_PASSWORD"),
-		DBIP:                          EnvString("DB_IP"),
-		DBPort:                        EnvInt("DB_PORT"),
-		DBName:                        EnvString("DB_NAME"),
-		PostgresUser:                  EnvString("POSTGRES_USER"),
-		PostgresPassword:              EnvString("POSTGRES_PASSWORD"),
-		PostgresHost:                  EnvString("POSTGRES_HOST"),
-		PostgresPort:                  EnvInt("POSTGRES_PORT"),
-		NotificationBotToken:          EnvString("NOTIFICATION_BOT_TOKEN"),
-		NotificationChannelID:         EnvString("NOTIFICATION_CHANNEL_ID"),
-		CurationFeedChannelID:         EnvString("CURATION_FEED_CHANNEL_ID"),
-		IsDev:                         EnvBool("IS_DEV"),
-		ResumableUploadDirFullPath:    EnvString("RESUMABLE_UPLOAD_DIR_FULL_PATH"),
-		FlashfreezeDirFullPath:        EnvString("FLASHFREEZE_DIR_FULL_PATH"),
-		ArchiveIndexerServerURL:       EnvString("ARCHIVE_INDEXER_SERVER_URL"),
-		FlashfreezeIngestDirFullPath:  EnvString("FLASHFREEZE_INGEST_DIR_FULL_PATH"),
-		SubmissionsDirFullPath:        EnvString("SUBMISSIONS_DIR_FULL_PATH"),
-		SubmissionImagesDirFullPath:   EnvString("SUBMISSION_IMAGES_DIR_FULL_PATH"),
-		SystemUid:                     EnvInt("SYSTEM_UID"),
-		ImagesCdn:                     EnvString("IMAGES_CDN"),
-		ImagesCdnCompressed:           EnvBool("IMAGES_CDN_COMPRESSED"),
-		ImagesCdnApiKey:               EnvString("IMAGES_CDN_API_KEY"),
-		MinLauncherVersion:            EnvString("MIN_LAUNCHER_VERSION"),
-		DataPacksDir:                  EnvString("DATA_PACKS_PATH"),
-		FrozenPacksDir:                EnvString("FROZEN_PACKS_PATH"),
-		ImagesDir:                     EnvString("IMAGES_PATH"),
-		DeletedDataPacksDir:           EnvString("DELETED_DATA_PACKS_PATH"),
-		DeletedImagesDir:              EnvString("DELETED_IMAGES_PATH"),
-		FlashpointSourceOnlyMode:      EnvBool("FLASHPOINT_SOURCE_ONLY_MODE"),
-		FlashpointSourceOnlyAdminMode: EnvBool("FLASHPOINT_SOURCE_ONLY_ADMIN_MODE"),
-		RecommendationEngineURL:       EnvString("RECOMMENDATION_ENGINE_URL"),
-		DoNotUnfreezeGameList:         EnvJSONList("DO_NOT_UNFREEZE_GAME_LIST"),
+		HostBaseURL:                   ld.String("HOST_BASE_URL"),
+		AuthBotToken:                  ld.String("AUTH_BOT_TOKEN"),
+		FlashpointServerID:            ld.String("FLASHPOINT_SERVER_ID"),
+		SecurecookieHashKeyPrevious:   ld.String("SECURECOOKIE_HASH_KEY_PREVIOUS"),
+		SecurecookieBlockKeyPrevious:  ld.String("SECURECOOKIE_BLOCK_KEY_PREVIOUS"),
+		SecurecookieHashKeyCurrent:    ld.String("SECURECOOKIE_HASH_KEY_CURRENT"),
+		SecurecookieBlockKeyCurrent:   ld.String("SECURECOOKIE_BLOCK_KEY_CURRENT"),
+		SessionExpirationSeconds:      ld.Int("SESSION_EXPIRATION_SECONDS"),
+		ValidatorServerURL:            ld.String("VALIDATOR_SERVER_URL"),
+		DBUser:                        ld.String("DB_USER"),
+		DBPassword:                    ld.String("DB_PASSWORD"),
+		DBIP:                          ld.String("DB_IP"),
+		DBPort:                        ld.Int("DB_PORT"),
+		DBName:                        ld.String("DB_NAME"),
+		PostgresUser:                  ld.String("POSTGRES_USER"),
+		PostgresPassword:              ld.String("POSTGRES_PASSWORD"),
+		PostgresHost:                  ld.String("POSTGRES_HOST"),
+		PostgresPort:                  ld.Int("POSTGRES_PORT"),
+		NotificationBotToken:          ld.String("NOTIFICATION_BOT_TOKEN"),
+		NotificationChannelID:         ld.String("NOTIFICATION_CHANNEL_ID"),
+		CurationFeedChannelID:         ld.String("CURATION_FEED_CHANNEL_ID"),
+		IsDev:                         ld.Bool("IS_DEV"),
+		ResumableUploadDirFullPath:    ld.String("RESUMABLE_UPLOAD_DIR_FULL_PATH"),
+		FlashfreezeDirFullPath:        ld.String("FLASHFREEZE_DIR_FULL_PATH"),
+		ArchiveIndexerServerURL:       EnvOptionalString("ARCHIVE_INDEXER_SERVER_URL"),
+		FlashfreezeIngestDirFullPath:  ld.String("FLASHFREEZE_INGEST_DIR_FULL_PATH"),
+		SubmissionsDirFullPath:        ld.String("SUBMISSIONS_DIR_FULL_PATH"),
+		SubmissionImagesDirFullPath:   ld.String("SUBMISSION_IMAGES_DIR_FULL_PATH"),
+		BlobStoreDirFullPath:          ld.String("BLOB_STORE_DIR_FULL_PATH"),
+		SystemUid:                     ld.Int("SYSTEM_UID"),
+		DataPacksDir:                  ld.String("DATA_PACKS_PATH"),
+		FrozenPacksDir:                ld.String("FROZEN_PACKS_PATH"),
+		ImagesDir:                     ld.String("IMAGES_PATH"),
+		DeletedDataPacksDir:           ld.String("DELETED_DATA_PACKS_PATH"),
+		DeletedImagesDir:              ld.String("DELETED_IMAGES_PATH"),
+		FlashpointSourceOnlyMode:      ld.Bool("FLASHPOINT_SOURCE_ONLY_MODE"),
+		FlashpointSourceOnlyAdminMode: ld.Bool("FLASHPOINT_SOURCE_ONLY_ADMIN_MODE"),
+		StorageDriver:                 EnvOptionalString("STORAGE_DRIVER"),
+		S3Endpoint:                    EnvOptionalString("S3_ENDPOINT"),
+		S3AccessKeyID:                 EnvOptionalString("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:             EnvOptionalString("S3_SECRET_ACCESS_KEY"),
+		S3Bucket:                      EnvOptionalString("S3_BUCKET"),
+		S3Region:                      EnvOptionalString("S3_REGION"),
+		S3UseSSL:                      ld.OptionalBool("S3_USE_SSL"),
+		S3StagingDir:                  EnvOptionalString("S3_STAGING_DIR"),
+		QuarantineDirFullPath:         EnvOptionalString("QUARANTINE_DIR_FULL_PATH"),
+		MetadataSnapshotsDirFullPath:  EnvOptionalString("METADATA_SNAPSHOTS_DIR_FULL_PATH"),
+		ReviewQueueIdleHours:          ld.OptionalInt("REVIEW_QUEUE_IDLE_HOURS", 72),
+		SweeperUnassignInactiveDays:   ld.OptionalInt("SWEEPER_UNASSIGN_INACTIVE_DAYS", 14),
+		SweeperRemindAfterDays:        ld.OptionalInt("SWEEPER_REMIND_AFTER_DAYS", 30),
+		SweeperRejectGraceDays:        ld.OptionalInt("SWEEPER_REJECT_GRACE_DAYS", 14),
+		MetricsToken:                  EnvOptionalString("METRICS_TOKEN"),
+		TracingEnabled:                ld.OptionalBool("TRACING_ENABLED"),
+		TracingOTLPEndpoint:           EnvOptionalString("TRACING_OTLP_ENDPOINT"),
+		ShutdownDrainSeconds:          ld.OptionalInt("SHUTDOWN_DRAIN_SECONDS", 120),
+		SubmissionProcessingWorkers:   ld.OptionalInt("SUBMISSION_PROCESSING_WORKERS", 2),
+		SubmissionProcessingAttempts:  ld.OptionalInt("SUBMISSION_PROCESSING_ATTEMPTS", 5),
+		DoNotUnfreezeGameList:         ld.OptionalJSONList("DO_NOT_UNFREEZE_GAME_LIST"),
+	}
+
+	r := &Reloadable{
+		MinLauncherVersion:        ld.String("MIN_LAUNCHER_VERSION"),
+		ImagesCdn:                 ld.String("IMAGES_CDN"),
+		ImagesCdnCompressed:       ld.Bool("IMAGES_CDN_COMPRESSED"),
+		ImagesCdnApiKey:           ld.String("IMAGES_CDN_API_KEY"),
+		StoragePresignedDownloads: ld.OptionalBool("STORAGE_PRESIGNED_DOWNLOADS"),
+		RecommendationEngineURL:   ld.String("RECOMMENDATION_ENGINE_URL"),
+	}
+
+	ld.validate(c, r)
+
+	if len(ld.errs) > 0 {
+		return nil, errors.Join(ld.errs...)
+	}
+
+	c.reloadable.Store(r)
+	return c, nil
+}
+
+// GetConfig loads the config and stops the process if it's invalid
+func GetConfig(l *logrus.Entry) *Config {
+	c, err := Load()
+	if err != nil {
+		if l != nil {
+			l.Fatalf("invalid config:\n%v", err)
+		}
+		panic(err)
 	}
+	return c
+}
+
+// Check validates the config and prints the problems found, it returns the exit code of the `config check` command
+func Check(w io.Writer) int {
+	_, err := Load()
+	if err != nil {
+		fmt.Fprintf(w, "invalid config:\n%v\n", err)
+		return 1
+	}
+	fmt.Fprintln(w, "config is valid")
+	return 0
+}
+
+// Reload reads the config file again and applies the reloadable settings. It returns the names of the changed
+// reloadable settings and of the changed settings which need a restart to take effect.
+// Nothing is applied when the new config is invalid.
+func (c *Config) Reload() (reloaded, ignored []string, err error) {
+	previous := snapshotFile()
+	if err := LoadFile(filePath()); err != nil {
+		return nil, nil, err
+	}
+
+	fresh, err := Load()
+	if err != nil {
+		restoreFile(previous)
+		return nil, nil, err
+	}
+
+	reloaded = changedFields(c.Reloadable(), fresh.Reloadable())
+	ignored = changedFields(c, fresh)
+	c.reloadable.Store(fresh.Reloadable())
+
+	return reloaded, ignored, nil
+}
+
+// changedFields returns the names of the exported fields which differ between the two structs of the same type
+func changedFields(a, b interface{}) []string {
+	va := reflect.ValueOf(a).Elem()
+	vb := reflect.ValueOf(b).Elem()
+
+	result := make([]string, 0)
+	for i := 0; i < va.NumField(); i++ {
+		field := va.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			result = append(result, field.Name)
+		}
+	}
+	return result
 }
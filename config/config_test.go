@@ -0,0 +1,431 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// settingNames are all the variables the config reads, they are cleared so the environment of the test run doesn't leak in
+var settingNames = []string{
+	"ARCHIVE_INDEXER_SERVER_URL", "AUTH_BOT_TOKEN", "BLOB_STORE_DIR_FULL_PATH", "CURATION_FEED_CHANNEL_ID", "DATA_PACKS_PATH",
+	"DB_IP", "DB_NAME", "DB_PASSWORD", "DB_PORT", "DB_USER", "DELETED_DATA_PACKS_PATH", "DELETED_IMAGES_PATH",
+	"DO_NOT_UNFREEZE_GAME_LIST", "FLASHFREEZE_DIR_FULL_PATH", "FLASHFREEZE_INGEST_DIR_FULL_PATH", "FLASHPOINT_SERVER_ID",
+	"FLASHPOINT_SOURCE_ONLY_ADMIN_MODE", "FLASHPOINT_SOURCE_ONLY_MODE", "FROZEN_PACKS_PATH", "GIT_COMMIT", "GRAYLOG_ENABLED",
+	"GRAYLOG_ENV", "GRAYLOG_HOST", "HOST_BASE_URL", "IMAGES_CDN", "IMAGES_CDN_API_KEY", "IMAGES_CDN_COMPRESSED", "IMAGES_PATH",
+	"IS_DEV", "METADATA_SNAPSHOTS_DIR_FULL_PATH", "METRICS_TOKEN", "MIN_LAUNCHER_VERSION", "NOTIFICATION_BOT_TOKEN",
+	"NOTIFICATION_CHANNEL_ID", "OAUTH_CLIENT_ID", "OAUTH_CLIENT_SECRET", "OAUTH_REDIRECT_URL", "PORT", "POSTGRES_HOST",
+	"POSTGRES_PASSWORD", "POSTGRES_PORT", "POSTGRES_USER", "QUARANTINE_DIR_FULL_PATH", "RECOMMENDATION_ENGINE_URL",
+	"RESUMABLE_UPLOAD_DIR_FULL_PATH", "REVIEW_QUEUE_IDLE_HOURS", "S3_ACCESS_KEY_ID", "S3_BUCKET", "S3_ENDPOINT", "S3_REGION",
+	"S3_SECRET_ACCESS_KEY", "S3_STAGING_DIR", "S3_USE_SSL", "SECURECOOKIE_BLOCK_KEY_CURRENT", "SECURECOOKIE_BLOCK_KEY_PREVIOUS",
+	"SECURECOOKIE_HASH_KEY_CURRENT", "SECURECOOKIE_HASH_KEY_PREVIOUS", "SESSION_EXPIRATION_SECONDS", "SHUTDOWN_DRAIN_SECONDS",
+	"STORAGE_DRIVER", "STORAGE_PRESIGNED_DOWNLOADS", "SUBMISSIONS_DIR_FULL_PATH", "SUBMISSION_IMAGES_DIR_FULL_PATH",
+	"SUBMISSION_PROCESSING_ATTEMPTS", "SUBMISSION_PROCESSING_WORKERS", "SWEEPER_REJECT_GRACE_DAYS", "SWEEPER_REMIND_AFTER_DAYS",
+	"SWEEPER_UNASSIGN_INACTIVE_DAYS", "SYSTEM_UID", "TRACING_ENABLED", "TRACING_OTLP_ENDPOINT", "VALIDATOR_SERVER_URL",
+}
+
+// validSettings returns the required settings with valid values
+func validSettings() map[string]string {
+	return map[string]string{
+		"PORT":                              "8730",
+		"OAUTH_REDIRECT_URL":                "http://127.0.0.1:8730/auth/callback",
+		"OAUTH_CLIENT_ID":                   "client",
+		"OAUTH_CLIENT_SECRET":               "secret",
+		"HOST_BASE_URL":                     "http://127.0.0.1:8730",
+		"AUTH_BOT_TOKEN":                    "token",
+		"FLASHPOINT_SERVER_ID":              "1",
+		"SECURECOOKIE_HASH_KEY_PREVIOUS":    "a",
+		"SECURECOOKIE_BLOCK_KEY_PREVIOUS":   "b",
+		"SECURECOOKIE_HASH_KEY_CURRENT":     "c",
+		"SECURECOOKIE_BLOCK_KEY_CURRENT":    "d",
+		"SESSION_EXPIRATION_SECONDS":        "3600",
+		"VALIDATOR_SERVER_URL":              "http://127.0.0.1:8000",
+		"DB_USER":                           "fpfss",
+		"DB_PASSWORD":                       "fpfss",
+		"DB_IP":                             "127.0.0.1",
+		"DB_PORT":                           "3306",
+		"DB_NAME":                           "fpfss",
+		"POSTGRES_USER":                     "fpfss",
+		"POSTGRES_PASSWORD":                 "fpfss",
+		"POSTGRES_HOST":                     "127.0.0.1",
+		"POSTGRES_PORT":                     "5432",
+		"NOTIFICATION_BOT_TOKEN":            "token",
+		"NOTIFICATION_CHANNEL_ID":           "2",
+		"CURATION_FEED_CHANNEL_ID":          "3",
+		"IS_DEV":                            "True",
+		"RESUMABLE_UPLOAD_DIR_FULL_PATH":    "/files/resumable",
+		"FLASHFREEZE_DIR_FULL_PATH":         "/files/flashfreeze",
+		"FLASHFREEZE_INGEST_DIR_FULL_PATH":  "/files/flashfreeze/ingest",
+		"SUBMISSIONS_DIR_FULL_PATH":         "/files/submissions",
+		"SUBMISSION_IMAGES_DIR_FULL_PATH":   "/files/submission-images",
+		"BLOB_STORE_DIR_FULL_PATH":          "/files/blobs",
+		"SYSTEM_UID":                        "123456789012345",
+		"DATA_PACKS_PATH":                   "/files/games",
+		"FROZEN_PACKS_PATH":                 "/files/frozen-games",
+		"IMAGES_PATH":                       "/files/images",
+		"DELETED_DATA_PACKS_PATH":           "/files/deleted-games",
+		"DELETED_IMAGES_PATH":               "/files/deleted-images",
+		"FLASHPOINT_SOURCE_ONLY_MODE":       "False",
+		"FLASHPOINT_SOURCE_ONLY_ADMIN_MODE": "False",
+		"MIN_LAUNCHER_VERSION":              "12.0.0",
+		"IMAGES_CDN":                        "https://infinity.unstable.life/images",
+		"IMAGES_CDN_COMPRESSED":             "False",
+		"IMAGES_CDN_API_KEY":                "key",
+		"RECOMMENDATION_ENGINE_URL":         "http://127.0.0.1:8000",
+		"GIT_COMMIT":                        "deadbeef",
+		"GRAYLOG_ENABLED":                   "False",
+	}
+}
+
+// useSettings clears the environment and sets the settings as env variables, there is no config file
+func useSettings(t *testing.T, settings map[string]string) {
+	t.Helper()
+	for _, name := range settingNames {
+		t.Setenv(name, "")
+	}
+	for name, value := range settings {
+		t.Setenv(name, value)
+	}
+	useFile(t, "")
+}
+
+// useFile loads the config file and unloads it when the test is done
+func useFile(t *testing.T, path string) {
+	t.Helper()
+	if err := LoadFile(path); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	t.Cleanup(func() { LoadFile("") })
+}
+
+// writeYAML writes the settings into a YAML config file
+func writeYAML(t *testing.T, path string, settings map[string]string) {
+	t.Helper()
+	var b strings.Builder
+	for name, value := range settings {
+		b.WriteString(name + ": " + strconv.Quote(value) + "\n")
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     map[string]string // "" unsets the setting
+		wantErrFor []string
+	}{
+		{
+			name: "valid settings",
+		},
+		{
+			name:       "missing settings are all reported",
+			change:     map[string]string{"PORT": "", "DB_USER": "", "IMAGES_CDN": ""},
+			wantErrFor: []string{"PORT", "DB_USER", "IMAGES_CDN"},
+		},
+		{
+			name:       "invalid values are all reported",
+			change:     map[string]string{"PORT": "80a", "IS_DEV": "maybe", "HOST_BASE_URL": "localhost", "REVIEW_QUEUE_IDLE_HOURS": "-1"},
+			wantErrFor: []string{"PORT", "IS_DEV", "HOST_BASE_URL", "REVIEW_QUEUE_IDLE_HOURS"},
+		},
+		{
+			name:       "port out of range",
+			change:     map[string]string{"DB_PORT": "70000"},
+			wantErrFor: []string{"DB_PORT"},
+		},
+		{
+			name:       "s3 needs its endpoint and bucket",
+			change:     map[string]string{"STORAGE_DRIVER": "s3"},
+			wantErrFor: []string{"S3_ENDPOINT", "S3_BUCKET"},
+		},
+		{
+			name:       "unknown storage driver",
+			change:     map[string]string{"STORAGE_DRIVER": "ftp"},
+			wantErrFor: []string{"STORAGE_DRIVER"},
+		},
+		{
+			name:       "processing needs a worker",
+			change:     map[string]string{"SUBMISSION_PROCESSING_WORKERS": "0"},
+			wantErrFor: []string{"SUBMISSION_PROCESSING_WORKERS"},
+		},
+		{
+			name:       "graylog needs its host when enabled",
+			change:     map[string]string{"GRAYLOG_ENABLED": "True"},
+			wantErrFor: []string{"GRAYLOG_HOST", "GRAYLOG_ENV"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := validSettings()
+			for name, value := range tt.change {
+				settings[name] = value
+			}
+			useSettings(t, settings)
+
+			c, err := Load()
+			if len(tt.wantErrFor) == 0 {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				if c.Port != 8730 || c.DBName != "fpfss" || !c.IsDev || c.Reloadable().MinLauncherVersion != "12.0.0" {
+					t.Errorf("Load() returned wrong values: %+v", c)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Load() error = nil, want errors for %v", tt.wantErrFor)
+			}
+			for _, name := range tt.wantErrFor {
+				if !strings.Contains(err.Error(), "'"+name+"'") {
+					t.Errorf("Load() error does not mention %s:\n%v", name, err)
+				}
+			}
+			if problems := len(strings.Split(err.Error(), "\n")); problems != len(tt.wantErrFor) {
+				t.Errorf("Load() reported %d problems, want %d:\n%v", problems, len(tt.wantErrFor), err)
+			}
+		})
+	}
+}
+
+func TestLoad_defaults(t *testing.T) {
+	useSettings(t, validSettings())
+
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got := []int64{c.ReviewQueueIdleHours, c.SweeperUnassignInactiveDays, c.SweeperRemindAfterDays, c.SweeperRejectGraceDays,
+		c.ShutdownDrainSeconds, c.SubmissionProcessingWorkers, c.SubmissionProcessingAttempts}
+	want := []int64{72, 14, 30, 14, 120, 2, 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() defaults = %v, want %v", got, want)
+	}
+	if c.StorageDriver != "" || c.S3UseSSL || len(c.DoNotUnfreezeGameList) != 0 {
+		t.Errorf("Load() optional settings are not empty: %+v", c)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "config.yaml")
+	tomlPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(yamlPath, []byte("port: 8080\nIS_DEV: true\nDO_NOT_UNFREEZE_GAME_LIST: [a, b]\nIMAGES_CDN: https://cdn\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tomlPath, []byte("PORT = 8080\nIS_DEV = false\nS3_REGION = \"eu\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string // written to the path when set
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "yaml values are formatted like env variables",
+			path: yamlPath,
+			want: map[string]string{"PORT": "8080", "IS_DEV": "True", "DO_NOT_UNFREEZE_GAME_LIST": `["a","b"]`, "IMAGES_CDN": "https://cdn"},
+		},
+		{
+			name: "toml",
+			path: tomlPath,
+			want: map[string]string{"PORT": "8080", "IS_DEV": "False", "S3_REGION": "eu"},
+		},
+		{
+			name: "no file",
+			path: "",
+			want: map[string]string{},
+		},
+		{
+			name:    "missing file",
+			path:    filepath.Join(dir, "missing.yaml"),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			path:    filepath.Join(dir, "config.json"),
+			content: "{}",
+			wantErr: true,
+		},
+		{
+			name:    "broken yaml",
+			path:    filepath.Join(dir, "broken.yaml"),
+			content: "PORT: [8080",
+			wantErr: true,
+		},
+		{
+			name:    "nested values are not supported",
+			path:    filepath.Join(dir, "nested.yaml"),
+			content: "DB:\n  PORT: 3306\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.content != "" {
+				if err := os.WriteFile(tt.path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Cleanup(func() { LoadFile("") })
+
+			err := LoadFile(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := snapshotFile(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadFile() values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_envOverridesFile(t *testing.T) {
+	settings := validSettings()
+	useSettings(t, map[string]string{"PORT": "9000"})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeYAML(t, path, settings)
+	useFile(t, path)
+
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.Port != 9000 {
+		t.Errorf("Load() port = %d, want the env variable 9000", c.Port)
+	}
+	if c.DBName != "fpfss" {
+		t.Errorf("Load() db name = %s, want the file value fpfss", c.DBName)
+	}
+}
+
+func TestConfig_Reload(t *testing.T) {
+	tests := []struct {
+		name         string
+		change       map[string]string
+		wantReloaded []string
+		wantIgnored  []string
+		wantErr      bool
+	}{
+		{
+			name: "nothing changed",
+		},
+		{
+			name:         "reloadable settings are applied",
+			change:       map[string]string{"MIN_LAUNCHER_VERSION": "13.0.0", "IMAGES_CDN": "https://cdn.example.com"},
+			wantReloaded: []string{"ImagesCdn", "MinLauncherVersion"},
+		},
+		{
+			name:        "other settings need a restart",
+			change:      map[string]string{"PORT": "9000", "DB_NAME": "other"},
+			wantIgnored: []string{"DBName", "Port"},
+		},
+		{
+			name:         "both kinds",
+			change:       map[string]string{"PORT": "9000", "STORAGE_PRESIGNED_DOWNLOADS": "True"},
+			wantReloaded: []string{"StoragePresignedDownloads"},
+			wantIgnored:  []string{"Port"},
+		},
+		{
+			name:    "invalid config is not applied",
+			change:  map[string]string{"MIN_LAUNCHER_VERSION": "13.0.0", "PORT": "none"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := validSettings()
+			useSettings(t, nil)
+
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			writeYAML(t, path, settings)
+			useFile(t, path)
+
+			c, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			for name, value := range tt.change {
+				settings[name] = value
+			}
+			writeYAML(t, path, settings)
+
+			reloaded, ignored, err := c.Reload()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if c.Reloadable().MinLauncherVersion != "12.0.0" {
+					t.Errorf("invalid config was applied, MinLauncherVersion = %s", c.Reloadable().MinLauncherVersion)
+				}
+				if got := fileValue("MIN_LAUNCHER_VERSION"); got != "12.0.0" {
+					t.Errorf("invalid config file was kept, MIN_LAUNCHER_VERSION = %s", got)
+				}
+				return
+			}
+
+			sort.Strings(reloaded)
+			sort.Strings(ignored)
+			if !reflect.DeepEqual(reloaded, nonNil(tt.wantReloaded)) {
+				t.Errorf("Reload() reloaded = %v, want %v", reloaded, tt.wantReloaded)
+			}
+			if !reflect.DeepEqual(ignored, nonNil(tt.wantIgnored)) {
+				t.Errorf("Reload() ignored = %v, want %v", ignored, tt.wantIgnored)
+			}
+			if got, want := c.Reloadable().MinLauncherVersion, settings["MIN_LAUNCHER_VERSION"]; got != want {
+				t.Errorf("MinLauncherVersion = %s, want %s", got, want)
+			}
+			if c.Port != 8730 {
+				t.Errorf("Port = %d, settings which need a restart must not change", c.Port)
+			}
+		})
+	}
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func Test_changedFields(t *testing.T) {
+	type settings struct {
+		Name    string
+		Count   int64
+		List    []string
+		private string
+	}
+
+	tests := []struct {
+		name string
+		a, b *settings
+		want []string
+	}{
+		{name: "equal", a: &settings{Name: "a", List: []string{"x"}}, b: &settings{Name: "a", List: []string{"x"}}, want: []string{}},
+		{name: "changed fields", a: &settings{Name: "a", Count: 1}, b: &settings{Name: "b", Count: 2}, want: []string{"Name", "Count"}},
+		{name: "changed list", a: &settings{List: []string{"x"}}, b: &settings{List: []string{"x", "y"}}, want: []string{"List"}},
+		{name: "unexported fields are ignored", a: &settings{private: "a"}, b: &settings{private: "b"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedFields(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// the values read from the config file, keyed by the name of the env variable they stand for
+var file = struct {
+	sync.RWMutex
+	path   string
+	values map[string]string
+}{}
+
+// LoadFile reads the YAML or TOML config file at the path, an empty path means there is no config file.
+// The keys of the file are the names of the env variables, the env variables take precedence over the file.
+func LoadFile(path string) error {
+	values := make(map[string]string)
+	if path != "" {
+		var err error
+		values, err = readFile(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	file.Lock()
+	defer file.Unlock()
+	file.path = path
+	file.values = values
+	return nil
+}
+
+func filePath() string {
+	file.RLock()
+	defer file.RUnlock()
+	return file.path
+}
+
+func fileValue(name string) string {
+	file.RLock()
+	defer file.RUnlock()
+	return file.values[name]
+}
+
+func snapshotFile() map[string]string {
+	file.RLock()
+	defer file.RUnlock()
+	return file.values
+}
+
+func restoreFile(values map[string]string) {
+	file.Lock()
+	defer file.Unlock()
+	file.values = values
+}
+
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format '%s', use yaml or toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		s, err := formatValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of '%s' in config file: %w", k, err)
+		}
+		values[strings.ToUpper(k)] = s
+	}
+	return values, nil
+}
+
+// formatValue formats the value the way it would be written in the env variable
+func formatValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "True", nil
+		}
+		return "False", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unsupported type %T", v)
+}
@@ -63,24 +63,32 @@ func GetActionsWithNotification() []string {
 }
 
 const (
-	ResourceKeySubmissionID          = "submission-id"
-	ResourceKeySubmissionIDs         = "submission-ids"
-	ResourceKeyFileID                = "file-id"
-	ResourceKeyFileIDs               = "file-ids"
-	ResourceKeyCommentID             = "comment-id"
-	ResourceKeyCurationImageID       = "curation-image-id"
-	ResourceKeyFlashfreezeRootFileID = "flashfreeze-root-file-id"
-	ResourceKeyUserID                = "user-id"
-	ResourceKeyTempName              = "temp-name"
-	ResourceKeyTagID                 = "tag-id"
-	ResourceKeyGameID                = "game-id"
-	ResourceKeyGameRevision          = "revision-date"
-	ResourceKeyGameDataDate          = "game-data-date"
-	ResourceKeyReason                = "reason"
-	ResourceKeyHash                  = "hash"
-	ResourceKeySessionID             = "session-id"
-	ResourceKeyClientAppID           = "client-app-id"
-	ResourceKeyRecommendationOp      = "recommendation-op"
+	ResourceKeySubmissionID            = "submission-id"
+	ResourceKeySubmissionIDs           = "submission-ids"
+	ResourceKeyFileID                  = "file-id"
+	ResourceKeyFileIDs                 = "file-ids"
+	ResourceKeyCommentID               = "comment-id"
+	ResourceKeyCurationImageID         = "curation-image-id"
+	ResourceKeyFlashfreezeRootFileID   = "flashfreeze-root-file-id"
+	ResourceKeyFlashfreezeCollectionID = "flashfreeze-collection-id"
+	ResourceKeyUserID                  = "user-id"
+	ResourceKeyTempName                = "temp-name"
+	ResourceKeyTagID                   = "tag-id"
+	ResourceKeyGameID                  = "game-id"
+	ResourceKeyGameRevision            = "revision-date"
+	ResourceKeyGameDataDate            = "game-data-date"
+	ResourceKeyReason                  = "reason"
+	ResourceKeyHash                    = "hash"
+	ResourceKeySessionID               = "session-id"
+	ResourceKeyClientAppID             = "client-app-id"
+	ResourceKeyRecommendationOp        = "recommendation-op"
+	ResourceKeyReviewRequirementID     = "review-requirement-id"
+	ResourceKeyReviewChecklistItemID   = "review-checklist-item-id"
+	ResourceKeySubmissionTemplateID    = "submission-template-id"
+	ResourceKeyScheduledJobName        = "job-name"
+	ResourceKeyScheduledJobRunID       = "run-id"
+	ResourceKeyMetadataSnapshotVersion = "snapshot-version"
+	ResourceKeyMetadataSnapshotFormat  = "snapshot-format"
 )
 
 const (
@@ -108,6 +116,25 @@ const (
 	SubmissionStatusSuccess    = "success"
 )
 
+const (
+	ScheduledJobRunRunning     = "running"
+	ScheduledJobRunSuccess     = "success"
+	ScheduledJobRunFailed      = "failed"
+	ScheduledJobRunInterrupted = "interrupted"
+)
+
+const (
+	ScheduledJobAutounfreezer             = "autounfreezer"
+	ScheduledJobRequestedChangesReminders = "requested-changes-reminders"
+	ScheduledJobRecomputeSubmissionCache  = "recompute-submission-cache"
+	ScheduledJobIngestFlashfreeze         = "flashfreeze-ingest"
+	ScheduledJobIndexUnindexedFlashfreeze = "flashfreeze-index-unindexed"
+	ScheduledJobMetadataSnapshot          = "metadata-snapshot"
+	ScheduledJobReviewQueueExpirer        = "review-queue-expirer"
+	ScheduledJobStalledSubmissionSweeper  = "stalled-submission-sweeper"
+	ScheduledJobFileBlobCollector         = "file-blob-collector"
+)
+
 func GetValidDeleteReasons() []string {
 	return []string{"Duplicate", "Owner Request", "Still On Sale", "Blacklisted Content"}
 }
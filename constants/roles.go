@@ -72,6 +72,12 @@ func AdderRoles() []string {
 	}
 }
 
+func ReviewAdminRoles() []string {
+	return []string{
+		RoleAdministrator,
+	}
+}
+
 func GodRoles() []string {
 	return []string{
 		RoleTheD,
@@ -128,6 +134,11 @@ func IsAdder(roles []string) bool {
 	return HasAnyRole(roles, AdderRoles())
 }
 
+// IsReviewAdmin allows user to configure the review requirements of submissions
+func IsReviewAdmin(roles []string) bool {
+	return HasAnyRole(roles, ReviewAdminRoles())
+}
+
 // IsGod allows user to do various things
 func IsGod(roles []string) bool {
 	return HasAnyRole(roles, GodRoles())
@@ -110,6 +110,8 @@ func (d *mysqlDAL) UpdateSubmissionCacheTable(dbs DBSession, sid int64) error {
 	return err
 }
 
+// getUserCountWithEnabledAction returns the users whose latest enabling action is newer than their latest disabling action.
+// Actions of the system count for the user they target, so that the system can e.g. unassign a tester.
 func getUserCountWithEnabledAction(dbs DBSession, enablerChunk, disablerChunk string, sid int64, onlyFromLastFileVersion bool) (*string, error) {
 
 	lastFileJoinQuery := ` `
@@ -128,7 +130,7 @@ func getUserCountWithEnabledAction(dbs DBSession, enablerChunk, disablerChunk st
 					SELECT c.*,
 						ROW_NUMBER() OVER (
 							PARTITION BY c.fk_submission_id,
-							c.fk_user_id
+							COALESCE(c.fk_target_user_id, c.fk_user_id)
 							ORDER BY created_at DESC
 						) AS rn
 					FROM comment AS c
@@ -143,7 +145,7 @@ func getUserCountWithEnabledAction(dbs DBSession, enablerChunk, disablerChunk st
 					ORDER BY created_at ASC
 				)
 				SELECT ranked_comment.fk_submission_id AS submission_id,
-					ranked_comment.fk_user_id AS author_id,
+					COALESCE(ranked_comment.fk_target_user_id, ranked_comment.fk_user_id) AS author_id,
 					ranked_comment.created_at
 				FROM ranked_comment
 					LEFT JOIN (SELECT * FROM submission_cache WHERE fk_submission_id = %d) AS submission_cache ON submission_cache.fk_submission_id = ranked_comment.fk_submission_id
@@ -156,7 +158,7 @@ func getUserCountWithEnabledAction(dbs DBSession, enablerChunk, disablerChunk st
 					SELECT c.*,
 						ROW_NUMBER() OVER (
 							PARTITION BY c.fk_submission_id,
-							c.fk_user_id
+							COALESCE(c.fk_target_user_id, c.fk_user_id)
 							ORDER BY created_at DESC
 						) AS rn
 					FROM comment AS c
@@ -170,7 +172,7 @@ func getUserCountWithEnabledAction(dbs DBSession, enablerChunk, disablerChunk st
 				    	AND c.fk_submission_id = %d
 				)
 				SELECT ranked_comment.fk_submission_id AS submission_id,
-					ranked_comment.fk_user_id AS author_id,
+					COALESCE(ranked_comment.fk_target_user_id, ranked_comment.fk_user_id) AS author_id,
 					ranked_comment.created_at
 				FROM ranked_comment
 					LEFT JOIN (SELECT * FROM submission_cache WHERE fk_submission_id = %d) AS submission_cache ON submission_cache.fk_submission_id = ranked_comment.fk_submission_id
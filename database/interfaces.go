@@ -12,6 +12,7 @@ import (
 
 type PGDAL interface {
 	Stat() *PostgresStats
+	Ping(ctx context.Context) error
 	NewSession(ctx context.Context) (PGDBSession, error)
 
 	CountSinceDate(dbs PGDBSession, modifiedAfter *string) (int, error)
@@ -43,11 +44,11 @@ type PGDAL interface {
 
 	GetMetadataStats(dbs PGDBSession) (*types.MetadataStatsPageDataBare, error)
 
-	DeleteGame(dbs PGDBSession, gameId string, uid int64, reason string, imagesPath string, gamesPath string,
-		deletedImagesPath string, deletedGamesPath string, frozenGamesPath string) error
+	DeleteGame(dbs PGDBSession, gameId string, uid int64, reason string) error
 
-	RestoreGame(dbs PGDBSession, gameId string, uid int64, reason string, imagesPath string, gamesPath string,
-		deletedImagesPath string, deletedGamesPath string, frozenGamesPath string) error
+	RestoreGame(dbs PGDBSession, gameId string, uid int64, reason string) error
+
+	GetGameDataFileRecords(dbs PGDBSession) ([]*types.GameDataFileRecord, error)
 
 	GetOrCreateTagCategory(dbs PGDBSession, categoryName string) (*types.TagCategory, error)
 	GetOrCreateTag(dbs PGDBSession, tagName string, tagCategory string, reason string, uid int64) (*types.Tag, error)
@@ -78,9 +79,32 @@ type PGDAL interface {
 	GetActivityEvents(dbs PGDBSession, filter *types.ActivityEventsFilter) ([]*activityevents.ActivityEvent, error)
 
 	GetFrozenGames(dbs PGDBSession) ([]*types.AutounfreezerGame, error)
+	GetAutounfreezerPolicy(dbs PGDBSession) (*types.AutounfreezerPolicy, error)
+	UpdateAutounfreezerPolicy(dbs PGDBSession, policy *types.AutounfreezerPolicy) error
+	ClaimAutounfreezerLegacyImport(dbs PGDBSession, at time.Time) (bool, error)
+	GetAutounfreezerExemptions(dbs PGDBSession) ([]*types.AutounfreezerExemption, error)
+	AddAutounfreezerExemption(dbs PGDBSession, exemption *types.AutounfreezerExemption) error
+	RemoveAutounfreezerExemption(dbs PGDBSession, gameID string) error
+
+	BeginMetadataSnapshot(dbs PGDBSession) (time.Time, error)
+	ExportGames(dbs PGDBSession, fn func(game *types.Game) error) error
+	ExportAdditionalApps(dbs PGDBSession, fn func(id int64, addApp *types.AdditionalApp) error) error
+	ExportGameData(dbs PGDBSession, fn func(gameData *types.GameData) error) error
+	ExportGameTagRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error
+	ExportGamePlatformRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error
+	ExportTags(dbs PGDBSession) ([]*types.LauncherDumpTagsTag, error)
+	ExportTagAliases(dbs PGDBSession) ([]*types.TagAlias, error)
+	ExportPlatforms(dbs PGDBSession) ([]*types.LauncherDumpPlatformsPlatform, error)
+	ExportPlatformAliases(dbs PGDBSession) ([]*types.PlatformAlias, error)
+	AddMetadataSnapshot(dbs PGDBSession, snapshot *types.MetadataSnapshot) (int64, error)
+	GetMetadataSnapshot(dbs PGDBSession, version int64) (*types.MetadataSnapshot, error)
+	GetLatestMetadataSnapshot(dbs PGDBSession) (*types.MetadataSnapshot, error)
+	GetMetadataSnapshots(dbs PGDBSession) ([]*types.MetadataSnapshot, error)
+	DeleteMetadataSnapshot(dbs PGDBSession, version int64) error
 }
 
 type DAL interface {
+	Ping(ctx context.Context) error
 	NewSession(ctx context.Context) (DBSession, error)
 	StoreSession(dbs DBSession, secret string, uid int64, durationSeconds int64, scope string, client string, ipAddr string) error
 	DeleteSession(dbs DBSession, secret string) error
@@ -127,6 +151,7 @@ type DAL interface {
 	GetUsersForUniversalNotification(dbs DBSession, authorID int64, action string) ([]int64, error)
 	GetOldestUnsentNotification(dbs DBSession) (*types.Notification, error)
 	MarkNotificationAsSent(dbs DBSession, nid int64) error
+	CountUnsentNotifications(dbs DBSession) (int64, error)
 
 	StoreCurationImage(dbs DBSession, c *types.CurationImage) (int64, error)
 	GetCurationImagesBySubmissionFileID(dbs DBSession, sfid int64) ([]*types.CurationImage, error)
@@ -149,6 +174,79 @@ type DAL interface {
 	GetFlashfreezeRootFile(dbs DBSession, fid int64) (*types.FlashfreezeFile, error)
 	GetAllFlashfreezeRootFiles(dbs DBSession) ([]*types.FlashfreezeFile, error)
 	GetAllUnindexedFlashfreezeRootFiles(dbs DBSession) ([]*types.FlashfreezeFile, error)
+	SoftDeleteFlashfreezeRootFile(dbs DBSession, fid int64, deleteReason string) error
+	UpdateFlashfreezeRootFileDescription(dbs DBSession, fid int64, description *string) error
+	ReplaceFlashfreezeRootFileTags(dbs DBSession, fid int64, tags []string) error
+	StoreFlashfreezeCollection(dbs DBSession, c *types.FlashfreezeCollection) (int64, error)
+	GetFlashfreezeCollections(dbs DBSession) ([]*types.FlashfreezeCollection, error)
+	GetFlashfreezeCollection(dbs DBSession, cid int64) (*types.FlashfreezeCollection, error)
+	AddFlashfreezeFilesToCollection(dbs DBSession, cid int64, fids []int64) error
+	RemoveFlashfreezeFileFromCollection(dbs DBSession, cid, fid int64) error
+	SoftDeleteFlashfreezeCollection(dbs DBSession, cid int64, deleteReason string) error
+	AcquireFileBlob(dbs DBSession, sha256sum string, size int64) error
+	GetUnreferencedFileBlobs(dbs DBSession, unreferencedBefore time.Time) ([]string, error)
+	GetAllFileBlobSHA256Sums(dbs DBSession) (map[string]struct{}, error)
+	GetDeletedFilenamesBySHA256(dbs DBSession, sha256sum string) ([]string, []string, error)
+	LockFileBlob(dbs DBSession, sha256sum string) (bool, error)
+	DeleteFileBlob(dbs DBSession, sha256sum string) (bool, error)
+	IsSubmissionFilePresent(dbs DBSession, sha256sum string) (bool, error)
+	GetStoredFileRecords(dbs DBSession) ([]*types.StoredFileRecord, error)
+	GetReviewRequirements(dbs DBSession) ([]*types.ReviewRequirement, error)
+	StoreReviewRequirement(dbs DBSession, r *types.ReviewRequirement) error
+	DeleteReviewRequirement(dbs DBSession, id int64) error
+	GetReviewQueueMembers(dbs DBSession) ([]*types.ReviewQueueMember, error)
+	StoreReviewQueueMember(dbs DBSession, m *types.ReviewQueueMember) error
+	DeleteReviewQueueMember(dbs DBSession, uid int64) error
+	LockSubmissionReviewers(dbs DBSession, sid int64) ([]int64, []int64, error)
+	StoreReviewQueueAssignment(dbs DBSession, a *types.ReviewQueueAssignment) (int64, error)
+	GetOpenReviewQueueAssignments(dbs DBSession) ([]*types.ReviewQueueAssignment, error)
+	GetFinishedReviewQueueAssignments(dbs DBSession, since time.Time) ([]*types.ReviewQueueAssignment, error)
+	FinishReviewQueueAssignment(dbs DBSession, id int64, finishedAt time.Time, outcome string) error
+	GetLatestActionTimes(dbs DBSession, sids []int64, action string) (map[int64]time.Time, error)
+	GetNewestFileTimes(dbs DBSession, sids []int64) (map[int64]time.Time, error)
+	GetLatestUserCommentTimes(dbs DBSession, sids []int64) (map[int64]map[int64]time.Time, error)
+	GetSubmissionReminders(dbs DBSession, sids []int64) (map[int64]time.Time, error)
+	StoreSubmissionReminder(dbs DBSession, sid int64, remindedAt time.Time) error
+	GetReviewChecklistItems(dbs DBSession) ([]*types.ReviewChecklistItem, error)
+	StoreReviewChecklistItem(dbs DBSession, item *types.ReviewChecklistItem) (int64, error)
+	DeleteReviewChecklistItem(dbs DBSession, id int64) error
+	StoreCommentChecklistResults(dbs DBSession, cid int64, results []*types.ChecklistResult) error
+	GetCommentChecklistResults(dbs DBSession, sid int64) (map[int64][]*types.ChecklistResult, error)
+	GetCurationLintRules(dbs DBSession) (map[string]*types.CurationLintRule, error)
+	StoreCurationLintRule(dbs DBSession, rule *types.CurationLintRule) error
+	MarkSubmissionDraft(dbs DBSession, sid int64) error
+	PublishSubmissionDraft(dbs DBSession, sid int64) (bool, error)
+	IsSubmissionDraft(dbs DBSession, sid int64) (bool, error)
+	GetSubmissionDraftUploader(dbs DBSession, sid int64) (*int64, error)
+	GetSubmissionTemplates(dbs DBSession, uid int64) ([]*types.SubmissionTemplate, error)
+	StoreSubmissionTemplate(dbs DBSession, template *types.SubmissionTemplate) (int64, error)
+	DeleteSubmissionTemplate(dbs DBSession, uid, id int64) error
+
+	StoreSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error
+	ClaimSubmissionProcessingJob(dbs DBSession, workerID string, now, claimUntil time.Time) (*types.SubmissionProcessingJob, error)
+	ExtendSubmissionProcessingJobClaim(dbs DBSession, tempName, workerID string, claimUntil time.Time) error
+	UpdateSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error
+	UpdateSubmissionProcessingJobStatus(dbs DBSession, tempName, status string, message *string, updatedAt time.Time) error
+	GetSubmissionProcessingJob(dbs DBSession, tempName string) (*types.SubmissionProcessingJob, error)
+	GetSubmissionProcessingJobs(dbs DBSession, limit int) ([]*types.SubmissionProcessingJob, error)
+	CountUnfinishedSubmissionProcessingJobs(dbs DBSession) (int64, error)
+	DeleteFinishedSubmissionProcessingJobs(dbs DBSession, updatedBefore time.Time) (int64, error)
+
+	StoreScheduledJob(dbs DBSession, job *types.ScheduledJob) error
+	GetScheduledJobs(dbs DBSession) ([]*types.ScheduledJob, error)
+	GetScheduledJob(dbs DBSession, name string) (*types.ScheduledJob, error)
+	GetDueScheduledJob(dbs DBSession, names []string, now time.Time) (*types.ScheduledJob, error)
+	UpdateScheduledJobSettings(dbs DBSession, name, schedule string, enabled bool, nextRunAt, updatedAt time.Time) error
+	RequestScheduledJobRun(dbs DBSession, name string, uid int64, updatedAt time.Time) error
+	LockScheduledJob(dbs DBSession, name, workerID string, lockedUntil, nextRunAt time.Time) error
+	ExtendScheduledJobLock(dbs DBSession, name, workerID string, lockedUntil time.Time) error
+	UnlockScheduledJob(dbs DBSession, name, workerID string) error
+	StoreScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) (int64, error)
+	UpdateScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) error
+	InterruptScheduledJobRuns(dbs DBSession, name string, finishedAt time.Time) (int64, error)
+	GetScheduledJobRuns(dbs DBSession, limit int) ([]*types.ScheduledJobRun, error)
+	GetScheduledJobRun(dbs DBSession, id int64) (*types.ScheduledJobRun, error)
+	DeleteScheduledJobRuns(dbs DBSession, startedBefore time.Time) (int64, error)
 
 	DeleteUserSessions(dbs DBSession, uid int64) (int64, error)
 
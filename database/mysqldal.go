@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +53,11 @@ type MysqlSession struct {
 	transaction *sql.Tx
 }
 
+// Ping checks that the database can be reached
+func (d *mysqlDAL) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
 // NewSession begins a transaction
 func (d *mysqlDAL) NewSession(ctx context.Context) (DBSession, error) {
 	tx, err := d.db.Begin()
@@ -425,9 +432,9 @@ func (d *mysqlDAL) StoreComment(dbs DBSession, c *types.Comment) (int64, error)
 		msg = &s
 	}
 	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
-		INSERT INTO comment (fk_user_id, fk_submission_id, message, fk_action_id, created_at) 
-        VALUES (?, ?, ?, (SELECT id FROM action WHERE name=?), ?)`,
-		c.AuthorID, c.SubmissionID, msg, c.Action, c.CreatedAt.Unix())
+		INSERT INTO comment (fk_user_id, fk_submission_id, message, fk_action_id, created_at, fk_target_user_id) 
+        VALUES (?, ?, ?, (SELECT id FROM action WHERE name=?), ?, ?)`,
+		c.AuthorID, c.SubmissionID, msg, c.Action, c.CreatedAt.Unix(), c.TargetUserID)
 	if err != nil {
 		return 0, err
 	}
@@ -524,6 +531,14 @@ func (d *mysqlDAL) SoftDeleteSubmissionFile(dbs DBSession, sfid int64, deleteRea
 	}
 
 	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE file_blob SET ref_count = GREATEST(ref_count - 1, 0)
+		WHERE sha256sum = (SELECT sha256sum FROM submission_file WHERE id = ? AND deleted_at IS NULL)`,
+		sfid)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbs.Tx().ExecContext(dbs.Ctx(), `
 		UPDATE submission_file SET deleted_at = UNIX_TIMESTAMP(), deleted_reason = ?
 		WHERE id  = ?`,
 		deleteReason, sfid)
@@ -531,6 +546,10 @@ func (d *mysqlDAL) SoftDeleteSubmissionFile(dbs DBSession, sfid int64, deleteRea
 		return err
 	}
 
+	if err := d.markUnreferencedFileBlobs(dbs); err != nil {
+		return err
+	}
+
 	err = d.UpdateSubmissionCacheTable(dbs, sid)
 	if err != nil {
 		return err
@@ -542,6 +561,16 @@ func (d *mysqlDAL) SoftDeleteSubmissionFile(dbs DBSession, sfid int64, deleteRea
 // SoftDeleteSubmission marks submission and its files as deleted
 func (d *mysqlDAL) SoftDeleteSubmission(dbs DBSession, sid int64, deleteReason string) error {
 	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE file_blob
+		JOIN submission_file ON submission_file.sha256sum = file_blob.sha256sum
+		SET file_blob.ref_count = GREATEST(file_blob.ref_count - 1, 0)
+		WHERE submission_file.fk_submission_id = ? AND submission_file.deleted_at IS NULL`,
+		sid)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbs.Tx().ExecContext(dbs.Ctx(), `
 		UPDATE submission_file SET deleted_at = UNIX_TIMESTAMP(), deleted_reason = ?
 		WHERE fk_submission_id = ?`,
 		deleteReason, sid)
@@ -549,6 +578,10 @@ func (d *mysqlDAL) SoftDeleteSubmission(dbs DBSession, sid int64, deleteReason s
 		return err
 	}
 
+	if err := d.markUnreferencedFileBlobs(dbs); err != nil {
+		return err
+	}
+
 	_, err = dbs.Tx().ExecContext(dbs.Ctx(), `
 		UPDATE comment SET deleted_at = UNIX_TIMESTAMP(), deleted_reason = ?
 		WHERE fk_submission_id = ?`,
@@ -775,6 +808,17 @@ func (d *mysqlDAL) GetOldestUnsentNotification(dbs DBSession) (*types.Notificati
 	return notification, nil
 }
 
+// CountUnsentNotifications returns the number of notifications waiting to be sent
+func (d *mysqlDAL) CountUnsentNotifications(dbs DBSession) (int64, error) {
+	var count int64
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT COUNT(*) FROM submission_notification WHERE sent_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // MarkNotificationAsSent returns oldest unsent notification
 func (d *mysqlDAL) MarkNotificationAsSent(dbs DBSession, nid int64) error {
 	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
@@ -847,7 +891,7 @@ func (d *mysqlDAL) GetNextSubmission(dbs DBSession, sid int64) (int64, error) {
 	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
 		SELECT id
 		FROM submission
-		WHERE id > ? AND deleted_at IS NULL
+		WHERE id > ? AND deleted_at IS NULL AND draft_at IS NULL
 		ORDER BY id
 		LIMIT 1`,
 		sid)
@@ -867,7 +911,7 @@ func (d *mysqlDAL) GetPreviousSubmission(dbs DBSession, sid int64) (int64, error
 	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
 		SELECT id
 		FROM submission
-		WHERE id < ? AND deleted_at IS NULL
+		WHERE id < ? AND deleted_at IS NULL AND draft_at IS NULL
 		ORDER BY id DESC
 		LIMIT 1`,
 		sid)
@@ -989,7 +1033,9 @@ func (d *mysqlDAL) UpdateFlashfreezeRootFileIndexedState(dbs DBSession, fid int6
 // GetFlashfreezeRootFile returns flashfreeze root file
 func (d *mysqlDAL) GetFlashfreezeRootFile(dbs DBSession, fid int64) (*types.FlashfreezeFile, error) {
 	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
-		SELECT fk_user_id, original_filename, current_filename, size, created_at, md5sum, sha256sum
+		SELECT fk_user_id, original_filename, current_filename, size, created_at, md5sum, sha256sum,
+			description, deleted_at, deleted_reason,
+			(SELECT GROUP_CONCAT(name ORDER BY name) FROM flashfreeze_file_tag WHERE fk_flashfreeze_file_id = flashfreeze_file.id)
 		FROM flashfreeze_file
 		WHERE id = ?`,
 		fid)
@@ -997,17 +1043,190 @@ func (d *mysqlDAL) GetFlashfreezeRootFile(dbs DBSession, fid int64) (*types.Flas
 	ff := &types.FlashfreezeFile{ID: fid}
 
 	var uploadedAt int64
+	var deletedAt *int64
+	var tags *string
 
-	err := row.Scan(&ff.UserID, &ff.OriginalFilename, &ff.CurrentFilename, &ff.Size, &uploadedAt, &ff.MD5Sum, &ff.SHA256Sum)
+	err := row.Scan(&ff.UserID, &ff.OriginalFilename, &ff.CurrentFilename, &ff.Size, &uploadedAt, &ff.MD5Sum, &ff.SHA256Sum,
+		&ff.Description, &deletedAt, &ff.DeletedReason, &tags)
 	if err != nil {
 		return nil, err
 	}
 
 	ff.UploadedAt = time.Unix(uploadedAt, 0)
+	if deletedAt != nil {
+		t := time.Unix(*deletedAt, 0)
+		ff.DeletedAt = &t
+	}
+	ff.Tags = make([]string, 0)
+	if tags != nil {
+		ff.Tags = strings.Split(*tags, ",")
+	}
 
 	return ff, nil
 }
 
+// SoftDeleteFlashfreezeRootFile marks flashfreeze root file as deleted
+func (d *mysqlDAL) SoftDeleteFlashfreezeRootFile(dbs DBSession, fid int64, deleteReason string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE file_blob SET ref_count = GREATEST(ref_count - 1, 0)
+		WHERE sha256sum = (SELECT sha256sum FROM flashfreeze_file WHERE id = ? AND deleted_at IS NULL)`,
+		fid)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE flashfreeze_file SET deleted_at = UNIX_TIMESTAMP(), deleted_reason = ?
+		WHERE id = ? AND deleted_at IS NULL`,
+		deleteReason, fid)
+	if err != nil {
+		return err
+	}
+
+	return d.markUnreferencedFileBlobs(dbs)
+}
+
+// UpdateFlashfreezeRootFileDescription sets the free-text description of a flashfreeze root file
+func (d *mysqlDAL) UpdateFlashfreezeRootFileDescription(dbs DBSession, fid int64, description *string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE flashfreeze_file SET description = ?
+		WHERE id = ?`,
+		description, fid)
+	return err
+}
+
+// ReplaceFlashfreezeRootFileTags replaces all tags of a flashfreeze root file
+func (d *mysqlDAL) ReplaceFlashfreezeRootFileTags(dbs DBSession, fid int64, tags []string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		DELETE FROM flashfreeze_file_tag WHERE fk_flashfreeze_file_id = ?`,
+		fid)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	data := make([]interface{}, 0, len(tags)*2)
+	for _, tag := range tags {
+		data = append(data, fid, tag)
+	}
+
+	const valuePlaceholder = `(?, ?)`
+	_, err = dbs.Tx().ExecContext(dbs.Ctx(),
+		`INSERT IGNORE INTO flashfreeze_file_tag (fk_flashfreeze_file_id, name) VALUES 
+		`+valuePlaceholder+strings.Repeat(`,`+valuePlaceholder, len(tags)-1),
+		data...)
+	return err
+}
+
+// StoreFlashfreezeCollection stores a new flashfreeze collection
+func (d *mysqlDAL) StoreFlashfreezeCollection(dbs DBSession, c *types.FlashfreezeCollection) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO flashfreeze_collection (fk_user_id, name, description, created_at) 
+		VALUES (?, ?, ?, ?)`,
+		c.UserID, c.Name, c.Description, c.CreatedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	cid, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return cid, nil
+}
+
+// GetFlashfreezeCollections returns all flashfreeze collections which are not deleted
+func (d *mysqlDAL) GetFlashfreezeCollections(dbs DBSession) ([]*types.FlashfreezeCollection, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT collection.id, collection.fk_user_id, discord_user.username, collection.name, collection.description, collection.created_at,
+			(SELECT COUNT(*) FROM flashfreeze_collection_file 
+				JOIN flashfreeze_file ON flashfreeze_file.id = flashfreeze_collection_file.fk_flashfreeze_file_id
+				WHERE fk_flashfreeze_collection_id = collection.id AND flashfreeze_file.deleted_at IS NULL)
+		FROM flashfreeze_collection collection
+		LEFT JOIN discord_user ON discord_user.id = collection.fk_user_id
+		WHERE collection.deleted_at IS NULL
+		ORDER BY collection.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.FlashfreezeCollection, 0)
+	for rows.Next() {
+		var createdAt int64
+		c := &types.FlashfreezeCollection{}
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Username, &c.Name, &c.Description, &createdAt, &c.FileCount); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = time.Unix(createdAt, 0)
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+// GetFlashfreezeCollection returns a flashfreeze collection which is not deleted
+func (d *mysqlDAL) GetFlashfreezeCollection(dbs DBSession, cid int64) (*types.FlashfreezeCollection, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT collection.fk_user_id, discord_user.username, collection.name, collection.description, collection.created_at,
+			(SELECT COUNT(*) FROM flashfreeze_collection_file 
+				JOIN flashfreeze_file ON flashfreeze_file.id = flashfreeze_collection_file.fk_flashfreeze_file_id
+				WHERE fk_flashfreeze_collection_id = collection.id AND flashfreeze_file.deleted_at IS NULL)
+		FROM flashfreeze_collection collection
+		LEFT JOIN discord_user ON discord_user.id = collection.fk_user_id
+		WHERE collection.id = ? AND collection.deleted_at IS NULL`,
+		cid)
+
+	var createdAt int64
+	c := &types.FlashfreezeCollection{ID: cid}
+	if err := row.Scan(&c.UserID, &c.Username, &c.Name, &c.Description, &createdAt, &c.FileCount); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = time.Unix(createdAt, 0)
+
+	return c, nil
+}
+
+// AddFlashfreezeFilesToCollection adds flashfreeze root files to a collection, files already in the collection are skipped
+func (d *mysqlDAL) AddFlashfreezeFilesToCollection(dbs DBSession, cid int64, fids []int64) error {
+	if len(fids) == 0 {
+		return nil
+	}
+
+	data := make([]interface{}, 0, len(fids)*2)
+	for _, fid := range fids {
+		data = append(data, cid, fid)
+	}
+
+	const valuePlaceholder = `(?, ?, UNIX_TIMESTAMP())`
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(),
+		`INSERT IGNORE INTO flashfreeze_collection_file (fk_flashfreeze_collection_id, fk_flashfreeze_file_id, added_at) VALUES 
+		`+valuePlaceholder+strings.Repeat(`,`+valuePlaceholder, len(fids)-1),
+		data...)
+	return err
+}
+
+// RemoveFlashfreezeFileFromCollection removes flashfreeze root file from a collection
+func (d *mysqlDAL) RemoveFlashfreezeFileFromCollection(dbs DBSession, cid, fid int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		DELETE FROM flashfreeze_collection_file 
+		WHERE fk_flashfreeze_collection_id = ? AND fk_flashfreeze_file_id = ?`,
+		cid, fid)
+	return err
+}
+
+// SoftDeleteFlashfreezeCollection marks flashfreeze collection as deleted, its files are kept
+func (d *mysqlDAL) SoftDeleteFlashfreezeCollection(dbs DBSession, cid int64, deleteReason string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE flashfreeze_collection SET deleted_at = UNIX_TIMESTAMP(), deleted_reason = ?, name = CONCAT(name, ' (deleted ', id, ')')
+		WHERE id = ? AND deleted_at IS NULL`,
+		deleteReason, cid)
+	return err
+}
+
 // GetAllFlashfreezeRootFiles returns all flashfreeze root files
 func (d *mysqlDAL) GetAllFlashfreezeRootFiles(dbs DBSession) ([]*types.FlashfreezeFile, error) {
 	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
@@ -1246,6 +1465,65 @@ func (d *mysqlDAL) UnfreezeSubmission(dbs DBSession, sid int64) error {
 	return nil
 }
 
+// MarkSubmissionDraft hides the submission from other users until it is published
+func (d *mysqlDAL) MarkSubmissionDraft(dbs DBSession, sid int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission SET draft_at = UNIX_TIMESTAMP()
+		WHERE id = ?`,
+		sid)
+	return err
+}
+
+// PublishSubmissionDraft makes the draft a regular submission, returns false if it was not a draft
+func (d *mysqlDAL) PublishSubmissionDraft(dbs DBSession, sid int64) (bool, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission SET draft_at = NULL
+		WHERE id = ? AND draft_at IS NOT NULL`,
+		sid)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// IsSubmissionDraft reports whether the submission has not been published yet
+func (d *mysqlDAL) IsSubmissionDraft(dbs DBSession, sid int64) (bool, error) {
+	var isDraft bool
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT draft_at IS NOT NULL FROM submission WHERE id = ?`,
+		sid).Scan(&isDraft)
+	return isDraft, err
+}
+
+// GetSubmissionDraftUploader returns the uploader of the first file if the submission is a draft, nil if it's not.
+// Returns sql.ErrNoRows if the submission does not exist.
+func (d *mysqlDAL) GetSubmissionDraftUploader(dbs DBSession, sid int64) (*int64, error) {
+	var isDraft bool
+	var uploaderID *int64
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT submission.draft_at IS NOT NULL, oldest_file.fk_user_id
+		FROM submission
+		LEFT JOIN submission_cache ON submission_cache.fk_submission_id = submission.id
+		LEFT JOIN submission_file AS oldest_file ON oldest_file.id = submission_cache.fk_oldest_file_id
+		WHERE submission.id = ?`,
+		sid).Scan(&isDraft, &uploaderID)
+	if err != nil {
+		return nil, err
+	}
+	if !isDraft {
+		return nil, nil
+	}
+	if uploaderID == nil {
+		// a draft without a file is visible to nobody but staff
+		uploaderID = new(int64)
+	}
+	return uploaderID, nil
+}
+
 // NukeSessionTable empties the session table
 func (d *mysqlDAL) NukeSessionTable(dbs DBSession) error {
 	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `DELETE from session`)
@@ -1264,3 +1542,1096 @@ func (d *mysqlDAL) UpdateSubmissionAutofreeze(dbs DBSession, sid int64, shouldAu
 
 	return nil
 }
+
+// AcquireFileBlob adds a reference to a stored file blob, creating the blob record if it's new
+func (d *mysqlDAL) AcquireFileBlob(dbs DBSession, sha256sum string, size int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO file_blob (sha256sum, size, ref_count, created_at) VALUES (?, ?, 1, UNIX_TIMESTAMP())
+		ON DUPLICATE KEY UPDATE ref_count = ref_count + 1, unreferenced_at = NULL`,
+		sha256sum, size)
+	return err
+}
+
+// markUnreferencedFileBlobs starts the retention period of blobs which just lost their last reference
+func (d *mysqlDAL) markUnreferencedFileBlobs(dbs DBSession) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE file_blob SET unreferenced_at = UNIX_TIMESTAMP()
+		WHERE ref_count = 0 AND unreferenced_at IS NULL`)
+	return err
+}
+
+// GetUnreferencedFileBlobs returns checksums of blobs which have no references since before given time.
+// The rows stay locked until the end of the transaction, so an upload of the same content waits for it.
+func (d *mysqlDAL) GetUnreferencedFileBlobs(dbs DBSession, unreferencedBefore time.Time) ([]string, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT sha256sum FROM file_blob
+		WHERE ref_count = 0 AND unreferenced_at < ?
+		FOR UPDATE`,
+		unreferencedBefore.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+	for rows.Next() {
+		var sha256sum string
+		if err := rows.Scan(&sha256sum); err != nil {
+			return nil, err
+		}
+		result = append(result, sha256sum)
+	}
+
+	return result, nil
+}
+
+// GetAllFileBlobSHA256Sums returns checksums of all known blobs
+func (d *mysqlDAL) GetAllFileBlobSHA256Sums(dbs DBSession) (map[string]struct{}, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `SELECT sha256sum FROM file_blob`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]struct{})
+	for rows.Next() {
+		var sha256sum string
+		if err := rows.Scan(&sha256sum); err != nil {
+			return nil, err
+		}
+		result[sha256sum] = struct{}{}
+	}
+
+	return result, nil
+}
+
+// GetDeletedFilenamesBySHA256 returns current filenames of deleted submission and flashfreeze files with given checksum
+func (d *mysqlDAL) GetDeletedFilenamesBySHA256(dbs DBSession, sha256sum string) ([]string, []string, error) {
+	query := func(q string) ([]string, error) {
+		rows, err := dbs.Tx().QueryContext(dbs.Ctx(), q, sha256sum)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		result := make([]string, 0)
+		for rows.Next() {
+			var filename string
+			if err := rows.Scan(&filename); err != nil {
+				return nil, err
+			}
+			result = append(result, filename)
+		}
+		return result, nil
+	}
+
+	submissionFilenames, err := query(`SELECT current_filename FROM submission_file WHERE sha256sum = ? AND deleted_at IS NOT NULL`)
+	if err != nil {
+		return nil, nil, err
+	}
+	flashfreezeFilenames, err := query(`SELECT current_filename FROM flashfreeze_file WHERE sha256sum = ? AND deleted_at IS NOT NULL`)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return submissionFilenames, flashfreezeFilenames, nil
+}
+
+// LockFileBlob locks the record of a blob, or the place where it would be inserted if there is none,
+// and returns whether the record exists
+func (d *mysqlDAL) LockFileBlob(dbs DBSession, sha256sum string) (bool, error) {
+	var refCount int64
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT ref_count FROM file_blob WHERE sha256sum = ? FOR UPDATE`,
+		sha256sum).Scan(&refCount)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteFileBlob removes the record of a blob if it's still unreferenced, and returns whether it did
+func (d *mysqlDAL) DeleteFileBlob(dbs DBSession, sha256sum string) (bool, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		DELETE FROM file_blob WHERE sha256sum = ? AND ref_count = 0`,
+		sha256sum)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// IsSubmissionFilePresent checks if there's a submission file with given checksum which is not deleted
+func (d *mysqlDAL) IsSubmissionFilePresent(dbs DBSession, sha256sum string) (bool, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT EXISTS(SELECT 1 FROM submission_file WHERE sha256sum = ? AND deleted_at IS NULL)`,
+		sha256sum)
+
+	var exists bool
+	if err := row.Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// GetStoredFileRecords returns all submission file, flashfreeze file and curation image rows, including deleted ones
+func (d *mysqlDAL) GetStoredFileRecords(dbs DBSession) ([]*types.StoredFileRecord, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT 'submission-file', id, current_filename, sha256sum, deleted_at IS NOT NULL
+		FROM submission_file
+		UNION ALL
+		SELECT 'flashfreeze-file', id, current_filename, sha256sum, deleted_at IS NOT NULL
+		FROM flashfreeze_file
+		UNION ALL
+		SELECT 'curation-image', curation_image.id, curation_image.filename, '', submission_file.deleted_at IS NOT NULL
+		FROM curation_image
+		JOIN submission_file ON submission_file.id = curation_image.fk_submission_file_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.StoredFileRecord, 0, 100000)
+	for rows.Next() {
+		r := &types.StoredFileRecord{}
+		if err := rows.Scan(&r.Kind, &r.ID, &r.CurrentFilename, &r.SHA256Sum, &r.Deleted); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// GetReviewRequirements returns all configured review requirements
+func (d *mysqlDAL) GetReviewRequirements(dbs DBSession) ([]*types.ReviewRequirement, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT id, submission_level, platform, required_approvals, required_verifications, required_role, fk_user_id, updated_at
+		FROM review_requirement
+		ORDER BY submission_level, platform`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ReviewRequirement, 0)
+	for rows.Next() {
+		var updatedAt int64
+		r := &types.ReviewRequirement{}
+		if err := rows.Scan(&r.ID, &r.SubmissionLevel, &r.Platform, &r.RequiredApprovals, &r.RequiredVerifications, &r.RequiredRole, &r.UpdatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		r.UpdatedAt = time.Unix(updatedAt, 0)
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
+// StoreReviewRequirement stores the requirement, replacing the one for the same level and platform if there is one
+func (d *mysqlDAL) StoreReviewRequirement(dbs DBSession, r *types.ReviewRequirement) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO review_requirement (submission_level, platform, required_approvals, required_verifications, required_role, fk_user_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE required_approvals = VALUES(required_approvals), required_verifications = VALUES(required_verifications),
+			required_role = VALUES(required_role), fk_user_id = VALUES(fk_user_id), updated_at = VALUES(updated_at)`,
+		r.SubmissionLevel, r.Platform, r.RequiredApprovals, r.RequiredVerifications, r.RequiredRole, r.UpdatedBy, r.UpdatedAt.Unix())
+	return err
+}
+
+// DeleteReviewRequirement removes the requirement, submissions it matched fall back to a less specific one
+func (d *mysqlDAL) DeleteReviewRequirement(dbs DBSession, id int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `DELETE FROM review_requirement WHERE id = ?`, id)
+	return err
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ";")
+}
+
+// GetReviewQueueMembers returns all testers in the review queue
+func (d *mysqlDAL) GetReviewQueueMembers(dbs DBSession) ([]*types.ReviewQueueMember, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT review_queue_member.fk_user_id, discord_user.username, platforms, submission_levels, max_assignments, joined_at
+		FROM review_queue_member
+		JOIN discord_user ON discord_user.id = review_queue_member.fk_user_id
+		ORDER BY joined_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ReviewQueueMember, 0)
+	for rows.Next() {
+		var platforms, levels string
+		var joinedAt int64
+		m := &types.ReviewQueueMember{}
+		if err := rows.Scan(&m.UserID, &m.Username, &platforms, &levels, &m.MaxAssignments, &joinedAt); err != nil {
+			return nil, err
+		}
+		m.Platforms = splitList(platforms)
+		m.SubmissionLevels = splitList(levels)
+		m.JoinedAt = time.Unix(joinedAt, 0)
+		result = append(result, m)
+	}
+
+	return result, rows.Err()
+}
+
+// StoreReviewQueueMember adds the tester to the review queue or updates their preferences
+func (d *mysqlDAL) StoreReviewQueueMember(dbs DBSession, m *types.ReviewQueueMember) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO review_queue_member (fk_user_id, platforms, submission_levels, max_assignments, joined_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE platforms = VALUES(platforms), submission_levels = VALUES(submission_levels),
+			max_assignments = VALUES(max_assignments)`,
+		m.UserID, strings.Join(m.Platforms, ";"), strings.Join(m.SubmissionLevels, ";"), m.MaxAssignments, m.JoinedAt.Unix())
+	return err
+}
+
+// DeleteReviewQueueMember removes the tester from the review queue, their assignments stay
+func (d *mysqlDAL) DeleteReviewQueueMember(dbs DBSession, uid int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `DELETE FROM review_queue_member WHERE fk_user_id = ?`, uid)
+	return err
+}
+
+// LockSubmissionReviewers locks the cached state of the submission until the end of the transaction and returns
+// the users assigned to test it and the users who approved it, as they are now. Returns sql.ErrNoRows
+// if another transaction holds the lock, e.g. because it's assigning somebody to the submission.
+func (d *mysqlDAL) LockSubmissionReviewers(dbs DBSession, sid int64) ([]int64, []int64, error) {
+	var testing, approved *string
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT active_assigned_testing_ids, active_approved_ids
+		FROM submission_cache
+		WHERE fk_submission_id = ?
+		FOR UPDATE SKIP LOCKED`,
+		sid).Scan(&testing, &approved)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	testingIDs, err := parseUserIDs(testing)
+	if err != nil {
+		return nil, nil, err
+	}
+	approvedIDs, err := parseUserIDs(approved)
+	if err != nil {
+		return nil, nil, err
+	}
+	return testingIDs, approvedIDs, nil
+}
+
+// parseUserIDs parses a comma separated list of user ids as stored in the submission cache
+func parseUserIDs(ids *string) ([]int64, error) {
+	result := []int64{}
+	if ids == nil || len(*ids) == 0 {
+		return result, nil
+	}
+	for _, id := range strings.Split(*ids, ",") {
+		uid, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, uid)
+	}
+	return result, nil
+}
+
+// StoreReviewQueueAssignment stores a new assignment made by the review queue
+func (d *mysqlDAL) StoreReviewQueueAssignment(dbs DBSession, a *types.ReviewQueueAssignment) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO review_queue_assignment (fk_submission_id, fk_user_id, assigned_at)
+		VALUES (?, ?, ?)`,
+		a.SubmissionID, a.UserID, a.AssignedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (d *mysqlDAL) getReviewQueueAssignments(dbs DBSession, where string, args ...interface{}) ([]*types.ReviewQueueAssignment, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT a.id, a.fk_submission_id, a.fk_user_id, discord_user.username, a.assigned_at,
+			(SELECT MAX(comment.created_at) FROM comment
+			 WHERE comment.fk_submission_id = a.fk_submission_id AND comment.fk_user_id = a.fk_user_id
+			 AND comment.deleted_at IS NULL),
+			a.finished_at, a.outcome
+		FROM review_queue_assignment AS a
+		JOIN discord_user ON discord_user.id = a.fk_user_id
+		WHERE `+where+`
+		ORDER BY a.assigned_at`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ReviewQueueAssignment, 0)
+	for rows.Next() {
+		var assignedAt int64
+		var lastActivityAt, finishedAt *int64
+		a := &types.ReviewQueueAssignment{}
+		if err := rows.Scan(&a.ID, &a.SubmissionID, &a.UserID, &a.Username, &assignedAt, &lastActivityAt, &finishedAt, &a.Outcome); err != nil {
+			return nil, err
+		}
+		a.AssignedAt = time.Unix(assignedAt, 0)
+		a.LastActivityAt = a.AssignedAt
+		if lastActivityAt != nil && *lastActivityAt > assignedAt {
+			a.LastActivityAt = time.Unix(*lastActivityAt, 0)
+		}
+		if finishedAt != nil {
+			t := time.Unix(*finishedAt, 0)
+			a.FinishedAt = &t
+		}
+		result = append(result, a)
+	}
+
+	return result, rows.Err()
+}
+
+// GetOpenReviewQueueAssignments returns the assignments of the review queue which are not finished yet
+func (d *mysqlDAL) GetOpenReviewQueueAssignments(dbs DBSession) ([]*types.ReviewQueueAssignment, error) {
+	return d.getReviewQueueAssignments(dbs, "a.finished_at IS NULL")
+}
+
+// GetFinishedReviewQueueAssignments returns the assignments of the review queue finished after given time
+func (d *mysqlDAL) GetFinishedReviewQueueAssignments(dbs DBSession, since time.Time) ([]*types.ReviewQueueAssignment, error) {
+	return d.getReviewQueueAssignments(dbs, "a.finished_at >= ?", since.Unix())
+}
+
+// FinishReviewQueueAssignment closes the assignment with given outcome
+func (d *mysqlDAL) FinishReviewQueueAssignment(dbs DBSession, id int64, finishedAt time.Time, outcome string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE review_queue_assignment SET finished_at = ?, outcome = ? WHERE id = ?`,
+		finishedAt.Unix(), outcome, id)
+	return err
+}
+
+// GetLatestActionTimes returns when the action was last commented on each of the submissions
+func (d *mysqlDAL) GetLatestActionTimes(dbs DBSession, sids []int64, action string) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(sids))
+	if len(sids) == 0 {
+		return result, nil
+	}
+
+	data := []interface{}{action}
+	for _, sid := range sids {
+		data = append(data, sid)
+	}
+
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT comment.fk_submission_id, MAX(comment.created_at)
+		FROM comment
+		WHERE comment.fk_action_id = (SELECT id FROM action WHERE name = ?)
+		AND comment.fk_submission_id IN(?`+strings.Repeat(",?", len(sids)-1)+`)
+		AND comment.deleted_at IS NULL
+		GROUP BY comment.fk_submission_id`, data...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid, createdAt int64
+		if err := rows.Scan(&sid, &createdAt); err != nil {
+			return nil, err
+		}
+		result[sid] = time.Unix(createdAt, 0)
+	}
+
+	return result, rows.Err()
+}
+
+// GetNewestFileTimes returns when the newest file of each of the submissions was uploaded
+func (d *mysqlDAL) GetNewestFileTimes(dbs DBSession, sids []int64) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(sids))
+	if len(sids) == 0 {
+		return result, nil
+	}
+
+	data := make([]interface{}, 0, len(sids))
+	for _, sid := range sids {
+		data = append(data, sid)
+	}
+
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT fk_submission_id, MAX(created_at)
+		FROM submission_file
+		WHERE fk_submission_id IN(?`+strings.Repeat(",?", len(sids)-1)+`)
+		AND deleted_at IS NULL
+		GROUP BY fk_submission_id`, data...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid, createdAt int64
+		if err := rows.Scan(&sid, &createdAt); err != nil {
+			return nil, err
+		}
+		result[sid] = time.Unix(createdAt, 0)
+	}
+
+	return result, rows.Err()
+}
+
+// GetLatestUserCommentTimes returns when each user last commented on each of the submissions, by submission and user
+func (d *mysqlDAL) GetLatestUserCommentTimes(dbs DBSession, sids []int64) (map[int64]map[int64]time.Time, error) {
+	result := make(map[int64]map[int64]time.Time, len(sids))
+	if len(sids) == 0 {
+		return result, nil
+	}
+
+	data := make([]interface{}, 0, len(sids))
+	for _, sid := range sids {
+		data = append(data, sid)
+	}
+
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT comment.fk_submission_id, comment.fk_user_id, MAX(comment.created_at)
+		FROM comment
+		WHERE comment.fk_submission_id IN(?`+strings.Repeat(",?", len(sids)-1)+`)
+		AND comment.deleted_at IS NULL
+		GROUP BY comment.fk_submission_id, comment.fk_user_id`, data...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid, uid, createdAt int64
+		if err := rows.Scan(&sid, &uid, &createdAt); err != nil {
+			return nil, err
+		}
+		if _, ok := result[sid]; !ok {
+			result[sid] = make(map[int64]time.Time)
+		}
+		result[sid][uid] = time.Unix(createdAt, 0)
+	}
+
+	return result, rows.Err()
+}
+
+// GetSubmissionReminders returns when the submitters of the submissions were last reminded about them
+func (d *mysqlDAL) GetSubmissionReminders(dbs DBSession, sids []int64) (map[int64]time.Time, error) {
+	result := make(map[int64]time.Time, len(sids))
+	if len(sids) == 0 {
+		return result, nil
+	}
+
+	data := make([]interface{}, 0, len(sids))
+	for _, sid := range sids {
+		data = append(data, sid)
+	}
+
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT fk_submission_id, reminded_at
+		FROM submission_reminder
+		WHERE fk_submission_id IN(?`+strings.Repeat(",?", len(sids)-1)+`)`, data...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sid, remindedAt int64
+		if err := rows.Scan(&sid, &remindedAt); err != nil {
+			return nil, err
+		}
+		result[sid] = time.Unix(remindedAt, 0)
+	}
+
+	return result, rows.Err()
+}
+
+// StoreSubmissionReminder records that the submitter was reminded about the submission
+func (d *mysqlDAL) StoreSubmissionReminder(dbs DBSession, sid int64, remindedAt time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO submission_reminder (fk_submission_id, reminded_at)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE reminded_at = VALUES(reminded_at)`,
+		sid, remindedAt.Unix())
+	return err
+}
+
+// GetReviewChecklistItems returns all checks which are not deleted, in the order they are shown to reviewers
+func (d *mysqlDAL) GetReviewChecklistItems(dbs DBSession) ([]*types.ReviewChecklistItem, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT id, platform, label, position, fk_user_id, updated_at
+		FROM review_checklist_item
+		WHERE deleted_at IS NULL
+		ORDER BY platform, position, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ReviewChecklistItem, 0)
+	for rows.Next() {
+		var updatedAt int64
+		item := &types.ReviewChecklistItem{}
+		if err := rows.Scan(&item.ID, &item.Platform, &item.Label, &item.Position, &item.UpdatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		item.UpdatedAt = time.Unix(updatedAt, 0)
+		result = append(result, item)
+	}
+
+	return result, rows.Err()
+}
+
+// StoreReviewChecklistItem creates the check, or updates it if it has an id
+func (d *mysqlDAL) StoreReviewChecklistItem(dbs DBSession, item *types.ReviewChecklistItem) (int64, error) {
+	if item.ID != 0 {
+		_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+			UPDATE review_checklist_item SET platform = ?, label = ?, position = ?, fk_user_id = ?, updated_at = ?
+			WHERE id = ? AND deleted_at IS NULL`,
+			item.Platform, item.Label, item.Position, item.UpdatedBy, item.UpdatedAt.Unix(), item.ID)
+		return item.ID, err
+	}
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO review_checklist_item (platform, label, position, fk_user_id, updated_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		item.Platform, item.Label, item.Position, item.UpdatedBy, item.UpdatedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeleteReviewChecklistItem soft deletes the check, results stored with comments keep their label
+func (d *mysqlDAL) DeleteReviewChecklistItem(dbs DBSession, id int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE review_checklist_item SET deleted_at = UNIX_TIMESTAMP() WHERE id = ?`, id)
+	return err
+}
+
+// GetCurationLintRules returns the stored settings of the lint rules by rule name
+func (d *mysqlDAL) GetCurationLintRules(dbs DBSession) (map[string]*types.CurationLintRule, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT rule, enabled, severity, parameter, fk_user_id, updated_at
+		FROM curation_lint_rule`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]*types.CurationLintRule)
+	for rows.Next() {
+		var updatedBy, updatedAt int64
+		rule := &types.CurationLintRule{}
+		if err := rows.Scan(&rule.Rule, &rule.Enabled, &rule.Severity, &rule.Parameter, &updatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+		t := time.Unix(updatedAt, 0)
+		rule.UpdatedBy = &updatedBy
+		rule.UpdatedAt = &t
+		result[rule.Rule] = rule
+	}
+
+	return result, rows.Err()
+}
+
+// StoreCurationLintRule stores the settings of the lint rule
+func (d *mysqlDAL) StoreCurationLintRule(dbs DBSession, rule *types.CurationLintRule) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO curation_lint_rule (rule, enabled, severity, parameter, fk_user_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), severity = VALUES(severity), parameter = VALUES(parameter),
+			fk_user_id = VALUES(fk_user_id), updated_at = VALUES(updated_at)`,
+		rule.Rule, rule.Enabled, rule.Severity, rule.Parameter, *rule.UpdatedBy, rule.UpdatedAt.Unix())
+	return err
+}
+
+// GetSubmissionTemplates returns the templates of the user sorted by name
+func (d *mysqlDAL) GetSubmissionTemplates(dbs DBSession, uid int64) ([]*types.SubmissionTemplate, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT id, fk_user_id, name, fields, updated_at
+		FROM submission_template
+		WHERE fk_user_id = ? AND deleted_at IS NULL
+		ORDER BY name, id`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.SubmissionTemplate, 0)
+	for rows.Next() {
+		var fields string
+		var updatedAt int64
+		template := &types.SubmissionTemplate{}
+		if err := rows.Scan(&template.ID, &template.UserID, &template.Name, &fields, &updatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(fields), &template.Fields); err != nil {
+			return nil, err
+		}
+		template.UpdatedAt = time.Unix(updatedAt, 0)
+		result = append(result, template)
+	}
+
+	return result, rows.Err()
+}
+
+// StoreSubmissionTemplate creates the template, or updates it if it has an id and belongs to the user
+func (d *mysqlDAL) StoreSubmissionTemplate(dbs DBSession, template *types.SubmissionTemplate) (int64, error) {
+	fields, err := json.Marshal(template.Fields)
+	if err != nil {
+		return 0, err
+	}
+	if template.ID != 0 {
+		_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+			UPDATE submission_template SET name = ?, fields = ?, updated_at = ?
+			WHERE id = ? AND fk_user_id = ? AND deleted_at IS NULL`,
+			template.Name, string(fields), template.UpdatedAt.Unix(), template.ID, template.UserID)
+		return template.ID, err
+	}
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO submission_template (fk_user_id, name, fields, updated_at)
+		VALUES (?, ?, ?, ?)`,
+		template.UserID, template.Name, string(fields), template.UpdatedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// DeleteSubmissionTemplate soft deletes the template of the user
+func (d *mysqlDAL) DeleteSubmissionTemplate(dbs DBSession, uid, id int64) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission_template SET deleted_at = UNIX_TIMESTAMP() WHERE id = ? AND fk_user_id = ?`, id, uid)
+	return err
+}
+
+// StoreSubmissionProcessingJob adds the received upload to the processing queue
+func (d *mysqlDAL) StoreSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error {
+	params, err := json.Marshal(job.ResumableParams)
+	if err != nil {
+		return err
+	}
+	var changes *string
+	if len(job.CurationMetaChanges) > 0 {
+		b, err := json.Marshal(job.CurationMetaChanges)
+		if err != nil {
+			return err
+		}
+		changes = utils.StrPtr(string(b))
+	}
+	_, err = dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO submission_processing_job (temp_name, fk_user_id, fk_submission_id, resumable_params, curation_meta_changes, status, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.TempName, job.UserID, job.SubmissionID, string(params), changes, job.Status, job.NextAttemptAt.Unix(), job.CreatedAt.Unix(), job.UpdatedAt.Unix())
+	return err
+}
+
+const submissionProcessingJobColumns = `temp_name, fk_user_id, fk_submission_id, resumable_params, curation_meta_changes, status, message, result_submission_id,
+	attempts, next_attempt_at, claimed_by, claimed_until, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubmissionProcessingJob(row rowScanner) (*types.SubmissionProcessingJob, error) {
+	job := &types.SubmissionProcessingJob{ResumableParams: &types.ResumableParams{}}
+	var params string
+	var changes *string
+	var nextAttemptAt, createdAt, updatedAt int64
+	var claimedUntil *int64
+	if err := row.Scan(&job.TempName, &job.UserID, &job.SubmissionID, &params, &changes, &job.Status, &job.Message, &job.ResultSubmissionID,
+		&job.Attempts, &nextAttemptAt, &job.ClaimedBy, &claimedUntil, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(params), job.ResumableParams); err != nil {
+		return nil, err
+	}
+	if changes != nil {
+		if err := json.Unmarshal([]byte(*changes), &job.CurationMetaChanges); err != nil {
+			return nil, err
+		}
+	}
+	job.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+	if claimedUntil != nil {
+		t := time.Unix(*claimedUntil, 0)
+		job.ClaimedUntil = &t
+	}
+	job.CreatedAt = time.Unix(createdAt, 0)
+	job.UpdatedAt = time.Unix(updatedAt, 0)
+	return job, nil
+}
+
+// ClaimSubmissionProcessingJob claims the oldest unfinished job which is due and not claimed by a live worker,
+// and counts the attempt. Jobs of workers whose claim ran out, e.g. because they crashed, are claimed again.
+// Returns sql.ErrNoRows when there is nothing to claim.
+func (d *mysqlDAL) ClaimSubmissionProcessingJob(dbs DBSession, workerID string, now, claimUntil time.Time) (*types.SubmissionProcessingJob, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT `+submissionProcessingJobColumns+`
+		FROM submission_processing_job
+		WHERE status NOT IN (?, ?) AND next_attempt_at <= ? AND (claimed_until IS NULL OR claimed_until < ?)
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`,
+		constants.SubmissionStatusSuccess, constants.SubmissionStatusFailed, now.Unix(), now.Unix())
+	job, err := scanSubmissionProcessingJob(row)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Attempts++
+	job.ClaimedBy = &workerID
+	job.ClaimedUntil = &claimUntil
+	job.UpdatedAt = now
+
+	if err := d.UpdateSubmissionProcessingJob(dbs, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ExtendSubmissionProcessingJobClaim keeps the job claimed by the worker while it's being processed
+func (d *mysqlDAL) ExtendSubmissionProcessingJobClaim(dbs DBSession, tempName, workerID string, claimUntil time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission_processing_job SET claimed_until = ? WHERE temp_name = ? AND claimed_by = ?`,
+		claimUntil.Unix(), tempName, workerID)
+	return err
+}
+
+// UpdateSubmissionProcessingJob stores the state of the job, including its claim
+func (d *mysqlDAL) UpdateSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error {
+	var claimedUntil *int64
+	if job.ClaimedUntil != nil {
+		t := job.ClaimedUntil.Unix()
+		claimedUntil = &t
+	}
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission_processing_job
+		SET status = ?, message = ?, result_submission_id = ?, attempts = ?, next_attempt_at = ?, claimed_by = ?, claimed_until = ?, updated_at = ?
+		WHERE temp_name = ?`,
+		job.Status, job.Message, job.ResultSubmissionID, job.Attempts, job.NextAttemptAt.Unix(), job.ClaimedBy, claimedUntil, job.UpdatedAt.Unix(),
+		job.TempName)
+	return err
+}
+
+// UpdateSubmissionProcessingJobStatus stores the progress of the job being processed
+func (d *mysqlDAL) UpdateSubmissionProcessingJobStatus(dbs DBSession, tempName, status string, message *string, updatedAt time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE submission_processing_job SET status = ?, message = ?, updated_at = ? WHERE temp_name = ?`,
+		status, message, updatedAt.Unix(), tempName)
+	return err
+}
+
+// GetSubmissionProcessingJob returns sql.ErrNoRows if the job does not exist
+func (d *mysqlDAL) GetSubmissionProcessingJob(dbs DBSession, tempName string) (*types.SubmissionProcessingJob, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT `+submissionProcessingJobColumns+`
+		FROM submission_processing_job
+		WHERE temp_name = ?`, tempName)
+	return scanSubmissionProcessingJob(row)
+}
+
+// GetSubmissionProcessingJobs returns the unfinished jobs oldest first, followed by the most recently finished ones
+func (d *mysqlDAL) GetSubmissionProcessingJobs(dbs DBSession, limit int) ([]*types.SubmissionProcessingJob, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT `+submissionProcessingJobColumns+`
+		FROM submission_processing_job
+		ORDER BY status IN (?, ?), IF(status IN (?, ?), -updated_at, created_at)
+		LIMIT ?`,
+		constants.SubmissionStatusSuccess, constants.SubmissionStatusFailed,
+		constants.SubmissionStatusSuccess, constants.SubmissionStatusFailed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.SubmissionProcessingJob, 0)
+	for rows.Next() {
+		job, err := scanSubmissionProcessingJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, job)
+	}
+
+	return result, rows.Err()
+}
+
+// CountUnfinishedSubmissionProcessingJobs counts the jobs waiting for or being processed
+func (d *mysqlDAL) CountUnfinishedSubmissionProcessingJobs(dbs DBSession) (int64, error) {
+	var count int64
+	err := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT COUNT(*) FROM submission_processing_job WHERE status NOT IN (?, ?)`,
+		constants.SubmissionStatusSuccess, constants.SubmissionStatusFailed).Scan(&count)
+	return count, err
+}
+
+// DeleteFinishedSubmissionProcessingJobs removes the jobs which finished before the given time
+func (d *mysqlDAL) DeleteFinishedSubmissionProcessingJobs(dbs DBSession, updatedBefore time.Time) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		DELETE FROM submission_processing_job WHERE status IN (?, ?) AND updated_at < ?`,
+		constants.SubmissionStatusSuccess, constants.SubmissionStatusFailed, updatedBefore.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+const scheduledJobColumns = `name, schedule, enabled, next_run_at, run_requested_by, locked_by, locked_until, updated_at`
+
+func scanScheduledJob(row rowScanner) (*types.ScheduledJob, error) {
+	job := &types.ScheduledJob{}
+	var nextRunAt, updatedAt int64
+	var lockedUntil *int64
+	if err := row.Scan(&job.Name, &job.Schedule, &job.Enabled, &nextRunAt, &job.RunRequestedBy, &job.LockedBy, &lockedUntil, &updatedAt); err != nil {
+		return nil, err
+	}
+	job.NextRunAt = time.Unix(nextRunAt, 0)
+	if lockedUntil != nil {
+		t := time.Unix(*lockedUntil, 0)
+		job.LockedUntil = &t
+	}
+	job.UpdatedAt = time.Unix(updatedAt, 0)
+	return job, nil
+}
+
+// StoreScheduledJob stores the job unless it already exists, so that the settings changed by the admins are kept
+func (d *mysqlDAL) StoreScheduledJob(dbs DBSession, job *types.ScheduledJob) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT IGNORE INTO scheduled_job (name, schedule, enabled, next_run_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		job.Name, job.Schedule, job.Enabled, job.NextRunAt.Unix(), job.UpdatedAt.Unix())
+	return err
+}
+
+func (d *mysqlDAL) GetScheduledJobs(dbs DBSession) ([]*types.ScheduledJob, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `SELECT `+scheduledJobColumns+` FROM scheduled_job ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ScheduledJob, 0)
+	for rows.Next() {
+		job, err := scanScheduledJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, job)
+	}
+	return result, rows.Err()
+}
+
+// GetScheduledJob returns sql.ErrNoRows if the job does not exist
+func (d *mysqlDAL) GetScheduledJob(dbs DBSession, name string) (*types.ScheduledJob, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `SELECT `+scheduledJobColumns+` FROM scheduled_job WHERE name = ?`, name)
+	return scanScheduledJob(row)
+}
+
+// GetDueScheduledJob returns a job which is due or was triggered by an admin, and is not locked by a live worker.
+// Only the named jobs are considered, so an instance doesn't claim jobs it doesn't know how to run.
+// The row stays locked until the end of the session. Returns sql.ErrNoRows when there is no such job.
+func (d *mysqlDAL) GetDueScheduledJob(dbs DBSession, names []string, now time.Time) (*types.ScheduledJob, error) {
+	if len(names) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	args := make([]interface{}, 0, len(names)+2)
+	for _, name := range names {
+		args = append(args, name)
+	}
+	args = append(args, now.Unix(), now.Unix())
+
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT `+scheduledJobColumns+`
+		FROM scheduled_job
+		WHERE name IN(?`+strings.Repeat(",?", len(names)-1)+`)
+		AND ((enabled AND next_run_at <= ?) OR run_requested_by IS NOT NULL) AND (locked_until IS NULL OR locked_until < ?)
+		ORDER BY next_run_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED`,
+		args...)
+	return scanScheduledJob(row)
+}
+
+func (d *mysqlDAL) UpdateScheduledJobSettings(dbs DBSession, name, schedule string, enabled bool, nextRunAt, updatedAt time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job SET schedule = ?, enabled = ?, next_run_at = ?, updated_at = ? WHERE name = ?`,
+		schedule, enabled, nextRunAt.Unix(), updatedAt.Unix(), name)
+	return err
+}
+
+func (d *mysqlDAL) RequestScheduledJobRun(dbs DBSession, name string, uid int64, updatedAt time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job SET run_requested_by = ?, updated_at = ? WHERE name = ?`,
+		uid, updatedAt.Unix(), name)
+	return err
+}
+
+// LockScheduledJob locks the job for the worker and clears the request to run it
+func (d *mysqlDAL) LockScheduledJob(dbs DBSession, name, workerID string, lockedUntil, nextRunAt time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job SET locked_by = ?, locked_until = ?, next_run_at = ?, run_requested_by = NULL WHERE name = ?`,
+		workerID, lockedUntil.Unix(), nextRunAt.Unix(), name)
+	return err
+}
+
+// ExtendScheduledJobLock keeps the job locked by the worker while it's running
+func (d *mysqlDAL) ExtendScheduledJobLock(dbs DBSession, name, workerID string, lockedUntil time.Time) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job SET locked_until = ? WHERE name = ? AND locked_by = ?`,
+		lockedUntil.Unix(), name, workerID)
+	return err
+}
+
+func (d *mysqlDAL) UnlockScheduledJob(dbs DBSession, name, workerID string) error {
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job SET locked_by = NULL, locked_until = NULL WHERE name = ? AND locked_by = ?`,
+		name, workerID)
+	return err
+}
+
+func (d *mysqlDAL) StoreScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO scheduled_job_run (job_name, triggered_by, worker_id, started_at, status) VALUES (?, ?, ?, ?, ?)`,
+		run.JobName, run.TriggeredBy, run.WorkerID, run.StartedAt.Unix(), run.Status)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// UpdateScheduledJobRun stores the outcome of the run
+func (d *mysqlDAL) UpdateScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) error {
+	var finishedAt *int64
+	if run.FinishedAt != nil {
+		t := run.FinishedAt.Unix()
+		finishedAt = &t
+	}
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job_run SET finished_at = ?, status = ?, message = ?, log = ? WHERE id = ?`,
+		finishedAt, run.Status, run.Message, run.Log, run.ID)
+	return err
+}
+
+// InterruptScheduledJobRuns marks the runs of the job which are still running as interrupted,
+// it's called when the job is locked, so these runs belong to workers which are gone
+func (d *mysqlDAL) InterruptScheduledJobRuns(dbs DBSession, name string, finishedAt time.Time) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		UPDATE scheduled_job_run SET status = ?, finished_at = ? WHERE job_name = ? AND status = ?`,
+		constants.ScheduledJobRunInterrupted, finishedAt.Unix(), name, constants.ScheduledJobRunRunning)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func scanScheduledJobRun(row rowScanner, withLog bool) (*types.ScheduledJobRun, error) {
+	run := &types.ScheduledJobRun{}
+	var startedAt int64
+	var finishedAt *int64
+	dest := []interface{}{&run.ID, &run.JobName, &run.TriggeredBy, &run.WorkerID, &startedAt, &finishedAt, &run.Status, &run.Message}
+	if withLog {
+		dest = append(dest, &run.Log)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	run.StartedAt = time.Unix(startedAt, 0)
+	if finishedAt != nil {
+		t := time.Unix(*finishedAt, 0)
+		run.FinishedAt = &t
+	}
+	return run, nil
+}
+
+// GetScheduledJobRuns returns the most recent runs without their logs
+func (d *mysqlDAL) GetScheduledJobRuns(dbs DBSession, limit int) ([]*types.ScheduledJobRun, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT id, job_name, triggered_by, worker_id, started_at, finished_at, status, message
+		FROM scheduled_job_run
+		ORDER BY id DESC
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.ScheduledJobRun, 0)
+	for rows.Next() {
+		run, err := scanScheduledJobRun(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, run)
+	}
+	return result, rows.Err()
+}
+
+// GetScheduledJobRun returns the run with its log, or sql.ErrNoRows if it does not exist
+func (d *mysqlDAL) GetScheduledJobRun(dbs DBSession, id int64) (*types.ScheduledJobRun, error) {
+	row := dbs.Tx().QueryRowContext(dbs.Ctx(), `
+		SELECT id, job_name, triggered_by, worker_id, started_at, finished_at, status, message, log
+		FROM scheduled_job_run
+		WHERE id = ?`, id)
+	return scanScheduledJobRun(row, true)
+}
+
+func (d *mysqlDAL) DeleteScheduledJobRuns(dbs DBSession, startedBefore time.Time) (int64, error) {
+	res, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		DELETE FROM scheduled_job_run WHERE status != ? AND started_at < ?`,
+		constants.ScheduledJobRunRunning, startedBefore.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// StoreCommentChecklistResults stores the checklist filled in by the author of the comment
+func (d *mysqlDAL) StoreCommentChecklistResults(dbs DBSession, cid int64, results []*types.ChecklistResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	data := make([]interface{}, 0, len(results)*3)
+	for _, r := range results {
+		data = append(data, cid, r.ItemID, r.Result)
+	}
+	_, err := dbs.Tx().ExecContext(dbs.Ctx(), `
+		INSERT INTO comment_checklist_result (fk_comment_id, fk_review_checklist_item_id, result)
+		VALUES (?, ?, ?)`+strings.Repeat(", (?, ?, ?)", len(results)-1), data...)
+	return err
+}
+
+// GetCommentChecklistResults returns the checklists filled in on the submission's comments, by comment id
+func (d *mysqlDAL) GetCommentChecklistResults(dbs DBSession, sid int64) (map[int64][]*types.ChecklistResult, error) {
+	rows, err := dbs.Tx().QueryContext(dbs.Ctx(), `
+		SELECT comment_checklist_result.fk_comment_id, review_checklist_item.id, review_checklist_item.label, comment_checklist_result.result
+		FROM comment_checklist_result
+		JOIN comment ON comment.id = comment_checklist_result.fk_comment_id
+		JOIN review_checklist_item ON review_checklist_item.id = comment_checklist_result.fk_review_checklist_item_id
+		WHERE comment.fk_submission_id = ?
+		ORDER BY review_checklist_item.platform, review_checklist_item.position, review_checklist_item.id`, sid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64][]*types.ChecklistResult)
+	for rows.Next() {
+		var cid int64
+		r := &types.ChecklistResult{}
+		if err := rows.Scan(&cid, &r.ItemID, &r.Label, &r.Result); err != nil {
+			return nil, err
+		}
+		result[cid] = append(result[cid], r)
+	}
+
+	return result, rows.Err()
+}
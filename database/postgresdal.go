@@ -8,7 +8,7 @@ import (
 	"io"
 	"log"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -83,6 +83,11 @@ func (d *postgresDAL) Stat() *PostgresStats {
 	}
 }
 
+// Ping checks that the database can be reached
+func (d *postgresDAL) Ping(ctx context.Context) error {
+	return d.db.Ping(ctx)
+}
+
 // NewSession begins a transaction
 func (d *postgresDAL) NewSession(ctx context.Context) (PGDBSession, error) {
 	tx, err := d.db.BeginTx(ctx, pgx.TxOptions{})
@@ -1538,6 +1543,30 @@ func (d *postgresDAL) GetGameRedirects(dbs PGDBSession) ([]*types.GameRedirect,
 	return redirects, nil
 }
 
+// GetGameDataFileRecords returns all game data rows together with the state of their games
+func (d *postgresDAL) GetGameDataFileRecords(dbs PGDBSession) ([]*types.GameDataFileRecord, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT game_data.game_id, game_data.date_added, game_data.sha256, game_data.crc32,
+		game.deleted, game.archive_state
+		FROM game_data
+		JOIN game ON game.id = game_data.game_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.GameDataFileRecord, 0)
+	for rows.Next() {
+		r := &types.GameDataFileRecord{}
+		err = rows.Scan(&r.GameID, &r.DateAdded, &r.SHA256, &r.CRC32, &r.GameDeleted, &r.ArchiveState)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+
+	return result, rows.Err()
+}
+
 func (d *postgresDAL) AddGameRedirect(dbs PGDBSession, srcId string, destId string) error {
 	// Validate source id is not a live game
 	srcGame, err := d.GetGame(dbs, srcId)
@@ -1929,164 +1958,16 @@ func (d *postgresDAL) GetTagRevisionInfo(dbs PGDBSession, tagId int64) ([]*types
 	return revisions, nil
 }
 
-func (d *postgresDAL) DeleteGame(dbs PGDBSession, gameId string, uid int64, reason string, imagesPath string,
-	gamesPath string, deletedImagesPath string, deletedGamesPath string, frozenGamesPath string) error {
-	// Get Game Data
-	game, err := d.GetGame(dbs, gameId)
-	if err != nil {
-		return err
-	}
-
-	// Disable Database Entry
-	_, err = dbs.Tx().Exec(dbs.Ctx(), `UPDATE game SET action = 'delete', deleted = TRUE, user_id = $1, reason = $2 WHERE id = $3`,
+func (d *postgresDAL) DeleteGame(dbs PGDBSession, gameId string, uid int64, reason string) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `UPDATE game SET action = 'delete', deleted = TRUE, user_id = $1, reason = $2 WHERE id = $3`,
 		uid, reason, gameId)
-	if err != nil {
-		return err
-	}
-
-	// Disable Game Files if not frozen already
-	if game.ArchiveState == types.Available {
-		for _, data := range game.Data {
-			base := fmt.Sprintf("%s-%d%s", game.ID, data.DateAdded.UnixMilli(), ".zip")
-			existingFileName := filepath.Join(gamesPath, base)
-			if game.ArchiveState == types.Archived {
-				existingFileName = filepath.Join(frozenGamesPath, base)
-			}
-			if _, err := os.Stat(existingFileName); err == nil {
-				newFilename := filepath.Join(deletedGamesPath, base)
-				err = os.MkdirAll(filepath.Dir(newFilename), 0755)
-				if err != nil {
-					return err
-				}
-				err = utils.CopyFile(existingFileName, newFilename)
-				if err != nil {
-					return err
-				}
-				err = os.Remove(existingFileName)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	// Disable Image Files
-	logoPath := fmt.Sprintf("%s/Logos/%s/%s/%s.png", imagesPath, gameId[:2], gameId[2:4], gameId)
-	ssPath := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png", imagesPath, gameId[:2], gameId[2:4], gameId)
-
-	if _, err := os.Stat(logoPath); err == nil {
-		newLogoPath := fmt.Sprintf("%s/Logos/%s/%s/%s.png", deletedImagesPath, gameId[:2], gameId[2:4], gameId)
-		err = os.MkdirAll(filepath.Dir(newLogoPath), 0755)
-		if err != nil {
-			return err
-		}
-		err = utils.CopyFile(logoPath, newLogoPath)
-		if err != nil {
-			return err
-		}
-		err = os.Remove(logoPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	if _, err := os.Stat(ssPath); err == nil {
-		newSSPath := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png", deletedImagesPath, gameId[:2], gameId[2:4], gameId)
-		err = os.MkdirAll(filepath.Dir(newSSPath), 0755)
-		if err != nil {
-			return err
-		}
-		err = utils.CopyFile(ssPath, newSSPath)
-		if err != nil {
-			return err
-		}
-		err = os.Remove(newSSPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return err
 }
 
-func (d *postgresDAL) RestoreGame(dbs PGDBSession, gameId string, uid int64, reason string, imagesPath string,
-	gamesPath string, deletedImagesPath string, deletedGamesPath string, frozenGamesPath string) error {
-	// Get Game Data
-	game, err := d.GetGame(dbs, gameId)
-	if err != nil {
-		return err
-	}
-
-	_, err = dbs.Tx().Exec(dbs.Ctx(), `UPDATE game SET action = 'restore', deleted = FALSE, user_id = $1, reason = $2 WHERE id = $3`,
+func (d *postgresDAL) RestoreGame(dbs PGDBSession, gameId string, uid int64, reason string) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `UPDATE game SET action = 'restore', deleted = FALSE, user_id = $1, reason = $2 WHERE id = $3`,
 		uid, reason, gameId)
-	if err != nil {
-		return err
-	}
-
-	// Enable Game Files if not frozen already
-	if game.ArchiveState == types.Available {
-		for _, data := range game.Data {
-			base := fmt.Sprintf("%s-%d%s", game.ID, data.DateAdded.UnixMilli(), ".zip")
-			existingFileName := filepath.Join(deletedGamesPath, base)
-
-			if _, err := os.Stat(existingFileName); err == nil {
-				newFilename := filepath.Join(gamesPath, base)
-				if game.ArchiveState == types.Archived {
-					newFilename = filepath.Join(frozenGamesPath, base)
-				}
-				err = os.MkdirAll(filepath.Dir(newFilename), 0755)
-				if err != nil {
-					return err
-				}
-				err = utils.CopyFile(existingFileName, newFilename)
-				if err != nil {
-					return err
-				}
-				err = os.Remove(existingFileName)
-				if err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	// Enable Image Files
-	logoPath := fmt.Sprintf("%s/Logos/%s/%s/%s.png", deletedImagesPath, gameId[:2], gameId[2:4], gameId)
-	ssPath := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png", deletedImagesPath, gameId[:2], gameId[2:4], gameId)
-
-	if _, err := os.Stat(logoPath); err == nil {
-		newLogoPath := fmt.Sprintf("%s/Logos/%s/%s/%s.png", imagesPath, gameId[:2], gameId[2:4], gameId)
-		err = os.MkdirAll(filepath.Dir(newLogoPath), 0755)
-		if err != nil {
-			return err
-		}
-		err = utils.CopyFile(logoPath, newLogoPath)
-		if err != nil {
-			return err
-		}
-		err = os.Remove(logoPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	if _, err := os.Stat(ssPath); err == nil {
-		newSSPath := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png", imagesPath, gameId[:2], gameId[2:4], gameId)
-		err = os.MkdirAll(filepath.Dir(newSSPath), 0755)
-		if err != nil {
-			return err
-		}
-		err = utils.CopyFile(ssPath, newSSPath)
-		if err != nil {
-			return err
-		}
-		err = os.Remove(ssPath)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return err
 }
 
 func (d *postgresDAL) UpdateTagsFromTagsList(dbs PGDBSession, tagsList []types.Tag) error {
@@ -2261,7 +2142,7 @@ func (d *postgresDAL) GetFrozenGames(dbs PGDBSession) ([]*types.AutounfreezerGam
 	games := make([]*types.AutounfreezerGame, 0)
 
 	rows, err := dbs.Tx().Query(dbs.Ctx(), `
-		SELECT id, release_date FROM public.game
+		SELECT id, title, release_date FROM public.game
 		WHERE release_date != '' 
 		AND archive_state = 1`)
 	if err != nil {
@@ -2272,7 +2153,7 @@ func (d *postgresDAL) GetFrozenGames(dbs PGDBSession) ([]*types.AutounfreezerGam
 	}
 	for rows.Next() {
 		g := &types.AutounfreezerGame{}
-		err = rows.Scan(&g.GameID, &g.ReleaseDate)
+		err = rows.Scan(&g.GameID, &g.Title, &g.ReleaseDate)
 		if err != nil {
 			return nil, err
 		}
@@ -2281,3 +2162,376 @@ func (d *postgresDAL) GetFrozenGames(dbs PGDBSession) ([]*types.AutounfreezerGam
 
 	return games, nil
 }
+
+// GetAutounfreezerPolicy returns the policy of the autounfreezer
+func (d *postgresDAL) GetAutounfreezerPolicy(dbs PGDBSession) (*types.AutounfreezerPolicy, error) {
+	p := &types.AutounfreezerPolicy{}
+	err := dbs.Tx().QueryRow(dbs.Ctx(), `SELECT age_threshold_days, updated_by, updated_at FROM autounfreezer_policy WHERE id = 1`).
+		Scan(&p.AgeThresholdDays, &p.UpdatedBy, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (d *postgresDAL) UpdateAutounfreezerPolicy(dbs PGDBSession, policy *types.AutounfreezerPolicy) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `UPDATE autounfreezer_policy
+		SET age_threshold_days = $1, updated_by = $2, updated_at = $3
+		WHERE id = 1`,
+		policy.AgeThresholdDays, policy.UpdatedBy, policy.UpdatedAt)
+	return err
+}
+
+// ClaimAutounfreezerLegacyImport marks the legacy do-not-unfreeze list as imported, it returns false if it already was
+func (d *postgresDAL) ClaimAutounfreezerLegacyImport(dbs PGDBSession, at time.Time) (bool, error) {
+	tag, err := dbs.Tx().Exec(dbs.Ctx(), `UPDATE autounfreezer_policy
+		SET legacy_list_imported_at = $1
+		WHERE id = 1 AND legacy_list_imported_at IS NULL`, at)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (d *postgresDAL) GetAutounfreezerExemptions(dbs PGDBSession) ([]*types.AutounfreezerExemption, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT e.game_id, game.title, e.reason, e.created_by, e.created_at
+		FROM autounfreezer_exemption e
+		LEFT JOIN game ON game.id = e.game_id
+		ORDER BY e.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.AutounfreezerExemption, 0)
+	for rows.Next() {
+		e := &types.AutounfreezerExemption{}
+		err = rows.Scan(&e.GameID, &e.Title, &e.Reason, &e.CreatedBy, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
+
+// AddAutounfreezerExemption adds the exemption, or updates the reason of an existing one
+func (d *postgresDAL) AddAutounfreezerExemption(dbs PGDBSession, exemption *types.AutounfreezerExemption) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `INSERT INTO autounfreezer_exemption (game_id, reason, created_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (game_id) DO UPDATE SET reason = EXCLUDED.reason`,
+		exemption.GameID, exemption.Reason, exemption.CreatedBy, exemption.CreatedAt)
+	return err
+}
+
+func (d *postgresDAL) RemoveAutounfreezerExemption(dbs PGDBSession, gameID string) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `DELETE FROM autounfreezer_exemption WHERE game_id = $1`, gameID)
+	return err
+}
+
+// BeginMetadataSnapshot makes all the following reads of the session see the same snapshot of the database, and returns
+// the time the changes missing from it can be synced from. Writes still in progress are not in the snapshot, yet their
+// date_modified can be older, so it is the start of the oldest writing transaction when there is one.
+func (d *postgresDAL) BeginMetadataSnapshot(dbs PGDBSession) (time.Time, error) {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var t time.Time
+	err = dbs.Tx().QueryRow(dbs.Ctx(), `SELECT least(localtimestamp,
+		(SELECT min(xact_start)::timestamp FROM pg_stat_activity WHERE backend_xid IS NOT NULL))`).Scan(&t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t, nil
+}
+
+// ExportGames calls fn with every game which is not deleted, ordered by the bytes of their ids
+func (d *postgresDAL) ExportGames(dbs PGDBSession, fn func(game *types.Game) error) error {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT game.id, game.parent_game_id, game.title, game.alternate_titles, game.series,
+       		game.developer, game.publisher, game.date_added, game.date_modified, game.play_mode, game.status, game.notes, game.source,
+       		game.application_path, game.launch_command, game.release_date, game.version, game.original_description, game.language,
+       		game.library, game.active_data_id, game.tags_str, game.platforms_str, game.platform_name, game.archive_state, game.ruffle_support
+		FROM game
+		WHERE game.deleted = FALSE
+		ORDER BY game.id COLLATE "C"`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		game := &types.Game{}
+		if err := rows.Scan(&game.ID, &game.ParentGameID, &game.Title, &game.AlternateTitles, &game.Series, &game.Developer,
+			&game.Publisher, &game.DateAdded, &game.DateModified, &game.PlayMode, &game.Status, &game.Notes, &game.Source,
+			&game.ApplicationPath, &game.LaunchCommand, &game.ReleaseDate, &game.Version, &game.OriginalDesc, &game.Language,
+			&game.Library, &game.ActiveDataID, &game.TagsStr, &game.PlatformsStr, &game.PrimaryPlatform, &game.ArchiveState, &game.RuffleSupport); err != nil {
+			return err
+		}
+		if err := fn(game); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportAdditionalApps calls fn with the add apps of the games which are not deleted, ordered by id
+func (d *postgresDAL) ExportAdditionalApps(dbs PGDBSession, fn func(id int64, addApp *types.AdditionalApp) error) error {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT aa.id, aa.name, aa.application_path, aa.launch_command,
+			aa.wait_for_exit, aa.auto_run_before, aa.parent_game_id
+		FROM additional_app aa
+		JOIN game ON game.id = aa.parent_game_id
+		WHERE game.deleted = FALSE
+		ORDER BY aa.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		addApp := &types.AdditionalApp{}
+		if err := rows.Scan(&id, &addApp.Name, &addApp.ApplicationPath, &addApp.LaunchCommand,
+			&addApp.WaitForExit, &addApp.AutoRunBefore, &addApp.ParentGameID); err != nil {
+			return err
+		}
+		addApp.ID = strconv.FormatInt(id, 10)
+		if err := fn(id, addApp); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportGameData calls fn with the game data of the games which are not deleted, ordered by id
+func (d *postgresDAL) ExportGameData(dbs PGDBSession, fn func(gameData *types.GameData) error) error {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT gd.id, gd.game_id, gd.title, gd.date_added, gd.sha256,
+			gd.crc32, gd.size, gd.parameters, gd.application_path, gd.launch_command
+		FROM game_data gd
+		JOIN game ON game.id = gd.game_id
+		WHERE game.deleted = FALSE
+		ORDER BY gd.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		gameData := &types.GameData{}
+		if err := rows.Scan(&gameData.ID, &gameData.GameID, &gameData.Title, &gameData.DateAdded, &gameData.SHA256,
+			&gameData.CRC32, &gameData.Size, &gameData.Parameters, &gameData.ApplicationPath, &gameData.LaunchCommand); err != nil {
+			return err
+		}
+		if err := fn(gameData); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportGameTagRelations calls fn with the tags of the games, leaving out deleted games and tags.
+// The relations are ordered by the bytes of the game ids, then by tag id.
+func (d *postgresDAL) ExportGameTagRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	return d.exportGameRelations(dbs, `SELECT gtt.game_id, gtt.tag_id
+		FROM game_tags_tag gtt
+		JOIN game ON game.id = gtt.game_id
+		JOIN tag ON tag.id = gtt.tag_id
+		WHERE game.deleted = FALSE AND tag.deleted = FALSE
+		ORDER BY gtt.game_id COLLATE "C", gtt.tag_id`, fn)
+}
+
+// ExportGamePlatformRelations calls fn with the platforms of the games, leaving out deleted games and platforms.
+// The relations are ordered by the bytes of the game ids, then by platform id.
+func (d *postgresDAL) ExportGamePlatformRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	return d.exportGameRelations(dbs, `SELECT gpp.game_id, gpp.platform_id
+		FROM game_platforms_platform gpp
+		JOIN game ON game.id = gpp.game_id
+		JOIN platform ON platform.id = gpp.platform_id
+		WHERE game.deleted = FALSE AND platform.deleted = FALSE
+		ORDER BY gpp.game_id COLLATE "C", gpp.platform_id`, fn)
+}
+
+func (d *postgresDAL) exportGameRelations(dbs PGDBSession, query string, fn func(relation *types.LauncherDumpRelation) error) error {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		relation := &types.LauncherDumpRelation{}
+		if err := rows.Scan(&relation.GameID, &relation.Value); err != nil {
+			return err
+		}
+		if err := fn(relation); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ExportTags returns the tags which are not deleted, ordered by id
+func (d *postgresDAL) ExportTags(dbs PGDBSession) ([]*types.LauncherDumpTagsTag, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT id, category_id, coalesce(description, ''), primary_alias, date_modified
+		FROM tag
+		WHERE deleted = FALSE
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.LauncherDumpTagsTag, 0)
+	for rows.Next() {
+		tag := &types.LauncherDumpTagsTag{}
+		if err := rows.Scan(&tag.ID, &tag.CategoryID, &tag.Description, &tag.PrimaryAlias, &tag.DateModified); err != nil {
+			return nil, err
+		}
+		result = append(result, tag)
+	}
+
+	return result, rows.Err()
+}
+
+// ExportTagAliases returns the aliases of the tags which are not deleted
+func (d *postgresDAL) ExportTagAliases(dbs PGDBSession) ([]*types.TagAlias, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT tag_alias.tag_id, tag_alias.name
+		FROM tag_alias
+		JOIN tag ON tag.id = tag_alias.tag_id
+		WHERE tag.deleted = FALSE
+		ORDER BY tag_alias.tag_id, tag_alias.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.TagAlias, 0)
+	for rows.Next() {
+		alias := &types.TagAlias{}
+		if err := rows.Scan(&alias.TagID, &alias.Name); err != nil {
+			return nil, err
+		}
+		result = append(result, alias)
+	}
+
+	return result, rows.Err()
+}
+
+// ExportPlatforms returns the platforms which are not deleted, ordered by id
+func (d *postgresDAL) ExportPlatforms(dbs PGDBSession) ([]*types.LauncherDumpPlatformsPlatform, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT id, coalesce(description, ''), primary_alias, date_modified
+		FROM platform
+		WHERE deleted = FALSE
+		ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.LauncherDumpPlatformsPlatform, 0)
+	for rows.Next() {
+		platform := &types.LauncherDumpPlatformsPlatform{}
+		if err := rows.Scan(&platform.ID, &platform.Description, &platform.PrimaryAlias, &platform.DateModified); err != nil {
+			return nil, err
+		}
+		result = append(result, platform)
+	}
+
+	return result, rows.Err()
+}
+
+// ExportPlatformAliases returns the aliases of the platforms which are not deleted
+func (d *postgresDAL) ExportPlatformAliases(dbs PGDBSession) ([]*types.PlatformAlias, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT platform_alias.platform_id, platform_alias.name
+		FROM platform_alias
+		JOIN platform ON platform.id = platform_alias.platform_id
+		WHERE platform.deleted = FALSE
+		ORDER BY platform_alias.platform_id, platform_alias.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.PlatformAlias, 0)
+	for rows.Next() {
+		alias := &types.PlatformAlias{}
+		if err := rows.Scan(&alias.PlatformID, &alias.Name); err != nil {
+			return nil, err
+		}
+		result = append(result, alias)
+	}
+
+	return result, rows.Err()
+}
+
+const metadataSnapshotColumns = `version, format_version, snapshot_time, created_at, game_count,
+	sqlite_key, sqlite_size, sqlite_sha256, json_key, json_size, json_sha256`
+
+func scanMetadataSnapshot(row pgx.Row) (*types.MetadataSnapshot, error) {
+	s := &types.MetadataSnapshot{SQLite: &types.MetadataSnapshotFile{}, JSON: &types.MetadataSnapshotFile{}}
+	err := row.Scan(&s.Version, &s.FormatVersion, &s.Timestamp, &s.CreatedAt, &s.GameCount,
+		&s.SQLite.Key, &s.SQLite.Size, &s.SQLite.SHA256, &s.JSON.Key, &s.JSON.Size, &s.JSON.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// AddMetadataSnapshot stores the snapshot and returns its version
+func (d *postgresDAL) AddMetadataSnapshot(dbs PGDBSession, snapshot *types.MetadataSnapshot) (int64, error) {
+	var version int64
+	err := dbs.Tx().QueryRow(dbs.Ctx(), `INSERT INTO metadata_snapshot (format_version, snapshot_time, created_at, game_count,
+			sqlite_key, sqlite_size, sqlite_sha256, json_key, json_size, json_sha256)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING version`,
+		snapshot.FormatVersion, snapshot.Timestamp, snapshot.CreatedAt, snapshot.GameCount,
+		snapshot.SQLite.Key, snapshot.SQLite.Size, snapshot.SQLite.SHA256,
+		snapshot.JSON.Key, snapshot.JSON.Size, snapshot.JSON.SHA256).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+func (d *postgresDAL) GetMetadataSnapshot(dbs PGDBSession, version int64) (*types.MetadataSnapshot, error) {
+	return scanMetadataSnapshot(dbs.Tx().QueryRow(dbs.Ctx(), `SELECT `+metadataSnapshotColumns+`
+		FROM metadata_snapshot WHERE version = $1`, version))
+}
+
+// GetLatestMetadataSnapshot returns the newest snapshot, pgx.ErrNoRows if there is none
+func (d *postgresDAL) GetLatestMetadataSnapshot(dbs PGDBSession) (*types.MetadataSnapshot, error) {
+	return scanMetadataSnapshot(dbs.Tx().QueryRow(dbs.Ctx(), `SELECT `+metadataSnapshotColumns+`
+		FROM metadata_snapshot ORDER BY version DESC LIMIT 1`))
+}
+
+// GetMetadataSnapshots returns all snapshots, the newest first
+func (d *postgresDAL) GetMetadataSnapshots(dbs PGDBSession) ([]*types.MetadataSnapshot, error) {
+	rows, err := dbs.Tx().Query(dbs.Ctx(), `SELECT `+metadataSnapshotColumns+`
+		FROM metadata_snapshot ORDER BY version DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*types.MetadataSnapshot, 0)
+	for rows.Next() {
+		s, err := scanMetadataSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+
+	return result, rows.Err()
+}
+
+func (d *postgresDAL) DeleteMetadataSnapshot(dbs PGDBSession, version int64) error {
+	_, err := dbs.Tx().Exec(dbs.Ctx(), `DELETE FROM metadata_snapshot WHERE version = $1`, version)
+	return err
+}
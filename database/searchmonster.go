@@ -316,6 +316,18 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 			}
 
 		}
+		if len(filter.ChecklistFailed) != 0 {
+			filters = append(filters, `(submission.id IN (
+				SELECT comment.fk_submission_id FROM comment
+				JOIN comment_checklist_result ON comment_checklist_result.fk_comment_id = comment.id
+				WHERE comment.deleted_at IS NULL AND comment_checklist_result.result = ?
+				AND comment_checklist_result.fk_review_checklist_item_id IN(?`+strings.Repeat(",?", len(filter.ChecklistFailed)-1)+`)))`)
+			data = append(data, types.ChecklistFail)
+			for _, id := range filter.ChecklistFailed {
+				data = append(data, id)
+			}
+			masterFilters = append(masterFilters, "(1 = 0)") // exclude legacy results
+		}
 		if filter.IsFrozen != nil {
 			if *filter.IsFrozen == "no" {
 				filters = append(filters, "(submission.frozen_at IS NULL)")
@@ -326,6 +338,12 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 		}
 	}
 
+	// drafts are only listed to their submitters, the callers asking for them by id check them with requireVisibleSubmission
+	if filter == nil || len(filter.SubmissionIDs) == 0 {
+		filters = append(filters, "(submission.draft_at IS NULL OR uploader.id = ?)")
+		data = append(data, uid)
+	}
+
 	and := ""
 	if len(filters) > 0 {
 		and = " AND "
@@ -372,6 +390,7 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 		submission_cache.distinct_actions AS distinct_actions,
 		meta.game_exists AS meta_game_exists,
 		submission.frozen_at as frozen_at,
+		submission.draft_at as draft_at,
 		submission.should_autofreeze as should_autofreeze
 		FROM submission
 		LEFT JOIN submission_cache ON submission_cache.fk_submission_id = submission.id
@@ -423,6 +442,7 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 			(SELECT "mark-added") AS distinct_actions,
 			(SELECT TRUE) as meta_game_exists,
 			(SELECT NULL) as frozen_at,
+			(SELECT NULL) as draft_at,
 			(SELECT FALSE) as should_autofreeze
 			FROM masterdb_game
 			WHERE (SELECT 1) ` + masterAnd + strings.Join(masterFilters, " AND ") + `
@@ -468,6 +488,7 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 	var verifiedUserIDs *string
 	var distinctActions *string
 	var frozenAt *int64
+	var draftAt *int64
 
 	for rows.Next() {
 		s := &types.ExtendedSubmission{}
@@ -482,7 +503,7 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 			&s.BotAction,
 			&s.FileCount,
 			&assignedTestingUserIDs, &assignedVerificationUserIDs, &requestedChangesUserIDs, &approvedUserIDs, &verifiedUserIDs,
-			&distinctActions, &s.GameExists, &frozenAt, &s.ShouldAutofreeze); err != nil {
+			&distinctActions, &s.GameExists, &frozenAt, &draftAt, &s.ShouldAutofreeze); err != nil {
 			return nil, 0, err
 		}
 		s.SubmitterAvatarURL = utils.FormatAvatarURL(s.SubmitterID, submitterAvatar)
@@ -492,6 +513,9 @@ func (d *mysqlDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFil
 		if frozenAt != nil {
 			s.IsFrozen = true
 		}
+		if draftAt != nil {
+			s.IsDraft = true
+		}
 
 		s.AssignedTestingUserIDs = []int64{}
 		if assignedTestingUserIDs != nil && len(*assignedTestingUserIDs) > 0 {
@@ -691,8 +715,8 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 			entryData = append(entryData, utils.FormatLike(*filter.NamePrefix))
 		}
 		if filter.DescriptionPrefix != nil {
-			filters = append(filters, "(1 = 0)") // exclude root files
-
+			filters = append(filters, "(description LIKE ? || '%')")
+			data = append(data, utils.FormatLike(*filter.DescriptionPrefix))
 			entryFilters = append(entryFilters, "(description LIKE ? || '%')")
 			entryData = append(entryData, utils.FormatLike(*filter.DescriptionPrefix))
 		}
@@ -705,8 +729,8 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 			entryDataFulltext = append(entryDataFulltext, utils.FormatLike(*filter.NameFulltext))
 		}
 		if filter.DescriptionFulltext != nil {
-			filters = append(filters, "(1 = 0)") // exclude root files
-
+			filtersFulltext = append(filtersFulltext, "(file.description LIKE ?)")
+			dataFulltext = append(dataFulltext, utils.FormatLike(*filter.DescriptionFulltext))
 			entryFiltersFulltext = append(entryFiltersFulltext, "(MATCH(entry.description) AGAINST(? IN BOOLEAN MODE))")
 			entryDataFulltext = append(entryDataFulltext, utils.FormatLike(*filter.DescriptionFulltext))
 		}
@@ -723,6 +747,18 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 			entryFilters = append(entryFilters, "(size <= ?)")
 			entryData = append(entryData, *filter.SizeMax)
 		}
+		for _, tag := range filter.Tags {
+			filters = append(filters, "(file_id IN (SELECT fk_flashfreeze_file_id FROM flashfreeze_file_tag WHERE name = ?))")
+			data = append(data, tag)
+			entryFilters = append(entryFilters, "(file_id IN (SELECT fk_flashfreeze_file_id FROM flashfreeze_file_tag WHERE name = ?))")
+			entryData = append(entryData, tag)
+		}
+		if filter.CollectionID != nil {
+			filters = append(filters, "(file_id IN (SELECT fk_flashfreeze_file_id FROM flashfreeze_collection_file WHERE fk_flashfreeze_collection_id = ?))")
+			data = append(data, *filter.CollectionID)
+			entryFilters = append(entryFilters, "(file_id IN (SELECT fk_flashfreeze_file_id FROM flashfreeze_collection_file WHERE fk_flashfreeze_collection_id = ?))")
+			entryData = append(entryData, *filter.CollectionID)
+		}
 		if filter.SearchFiles != nil || filter.SearchFilesRecursively != nil {
 			if !(filter.SearchFiles != nil && filter.SearchFilesRecursively != nil) {
 				searchRoot := false
@@ -754,6 +790,14 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 		}
 	}
 
+	if filter != nil && filter.IsDeleted != nil && *filter.IsDeleted {
+		filters = append(filters, "(deleted_at IS NOT NULL)")
+		entryFilters = append(entryFilters, "(deleted_at IS NOT NULL)")
+	} else {
+		filters = append(filters, "(deleted_at IS NULL)")
+		entryFilters = append(entryFilters, "(deleted_at IS NULL)")
+	}
+
 	finalData := make([]interface{}, 0)
 	finalQuery := `
 		SELECT 
@@ -770,7 +814,10 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 		    is_deep_file,
 		    indexing_time_seconds,
 		    file_count,
-			indexing_errors
+			indexing_errors,
+			tags,
+			deleted_at,
+			deleted_reason
 		FROM (
 		SELECT 
        		file.id AS file_id,
@@ -781,16 +828,19 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 			file.sha256sum AS sha256sum,
 			file.size AS size,
 			file.created_at AS uploaded_at,
-			NULL AS description,
+			file.description AS description,
 			True AS is_root_file,
 			False AS is_deep_file,
 		    (CASE WHEN file.indexed_at IS NOT NULL THEN (file.indexed_at - file.created_at) END) AS indexing_time_seconds,
 			(SELECT COUNT(*) FROM flashfreeze_file_contents WHERE fk_flashfreeze_file_id = file.id) AS file_count,
-		    file.indexing_errors as indexing_errors
+		    file.indexing_errors as indexing_errors,
+			(SELECT GROUP_CONCAT(tag.name ORDER BY tag.name) FROM flashfreeze_file_tag tag WHERE tag.fk_flashfreeze_file_id = file.id) AS tags,
+			file.deleted_at AS deleted_at,
+			file.deleted_reason AS deleted_reason
 		FROM flashfreeze_file file
 			LEFT JOIN discord_user AS uploader ON uploader.id = file.fk_user_id `
 
-	fulltextQuery := `WHERE file.deleted_at IS NULL ` + magicAnd(filtersFulltext) + strings.Join(filtersFulltext, " AND ") + ` ORDER BY uploaded_at DESC) AS root`
+	fulltextQuery := `WHERE 1=1 ` + magicAnd(filtersFulltext) + strings.Join(filtersFulltext, " AND ") + ` ORDER BY uploaded_at DESC) AS root`
 	finalQuery += fulltextQuery
 	finalData = append(finalData, dataFulltext...)
 
@@ -813,7 +863,10 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 				is_deep_file,
 				indexing_time_seconds,
 				file_count,
-				indexing_errors
+				indexing_errors,
+				tags,
+				deleted_at,
+				deleted_reason
 			FROM (
 			SELECT
 			entry.fk_flashfreeze_file_id AS file_id,
@@ -829,8 +882,12 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 				True as is_deep_file,
 				NULL AS indexing_time_seconds,
 				NULL AS file_count,
-				NULL AS indexing_errors
-			FROM flashfreeze_file_contents entry `
+				NULL AS indexing_errors,
+				NULL AS tags,
+				parent.deleted_at AS deleted_at,
+				parent.deleted_reason AS deleted_reason
+			FROM flashfreeze_file_contents entry
+				LEFT JOIN flashfreeze_file parent ON parent.id = entry.fk_flashfreeze_file_id `
 	finalQuery += entryQuery
 
 	entryFulltextQuery := ` WHERE 1=1 ` + magicAnd(entryFiltersFulltext) + strings.Join(entryFiltersFulltext, " AND ") + `) AS deep `
@@ -874,15 +931,26 @@ func (d *mysqlDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.Flashfree
 
 	var uploadedAt *int64
 	var indexingTime *int64
+	var tags *string
+	var deletedAt *int64
 
 	for rows.Next() {
 		f := &types.ExtendedFlashfreezeItem{}
 		if err := rows.Scan(&f.FileID, &f.SubmitterID, &f.SubmitterUsername,
 			&f.OriginalFilename, &f.MD5Sum, &f.SHA256Sum, &f.Size,
-			&uploadedAt, &f.Description, &f.IsRootFile, &f.IsDeepFile, &indexingTime, &f.FileCount, &f.IndexingErrors); err != nil {
+			&uploadedAt, &f.Description, &f.IsRootFile, &f.IsDeepFile, &indexingTime, &f.FileCount, &f.IndexingErrors,
+			&tags, &deletedAt, &f.DeletedReason); err != nil {
 			return nil, 0, err
 		}
 
+		if tags != nil {
+			f.Tags = strings.Split(*tags, ",")
+		}
+		if deletedAt != nil {
+			t := time.Unix(*deletedAt, 0)
+			f.DeletedAt = &t
+		}
+
 		if uploadedAt != nil {
 			t := time.Unix(*uploadedAt, 0)
 			f.UploadedAt = &t
@@ -0,0 +1,1512 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/activityevents"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// endSpan ends the span, missing rows are expected by the callers and are not recorded as errors
+func endSpan(span trace.Span, err error) {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
+// tracedDAL starts a span for every call to the wrapped DAL, as a child of the span in the session context
+type tracedDAL struct {
+	DAL
+}
+
+// NewTracedDAL wraps the DAL so that the database calls show up in the traces
+func NewTracedDAL(dal DAL) DAL {
+	return &tracedDAL{DAL: dal}
+}
+
+// tracedPGDAL starts a span for every call to the wrapped PGDAL, as a child of the span in the session context
+type tracedPGDAL struct {
+	PGDAL
+}
+
+// NewTracedPGDAL wraps the PGDAL so that the database calls show up in the traces
+func NewTracedPGDAL(pgdal PGDAL) PGDAL {
+	return &tracedPGDAL{PGDAL: pgdal}
+}
+
+func (d *tracedDAL) Ping(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "DAL.Ping")
+	err := d.DAL.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) NewSession(ctx context.Context) (DBSession, error) {
+	_, span := tracing.Start(ctx, "DAL.NewSession")
+	r0, err := d.DAL.NewSession(ctx)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreSession(dbs DBSession, secret string, uid int64, durationSeconds int64, scope string, client string, ipAddr string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSession")
+	err := d.DAL.StoreSession(dbs, secret, uid, durationSeconds, scope, client, ipAddr)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) DeleteSession(dbs DBSession, secret string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteSession")
+	err := d.DAL.DeleteSession(dbs, secret)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetSessions(dbs DBSession, uid int64) ([]*types.SessionInfo, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSessions")
+	r0, err := d.DAL.GetSessions(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSessionAuthInfo(dbs DBSession, secret string) (*types.SessionInfo, bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSessionAuthInfo")
+	r0, r1, err := d.DAL.GetSessionAuthInfo(dbs, secret)
+	endSpan(span, err)
+	return r0, r1, err
+}
+
+func (d *tracedDAL) RevokeSession(dbs DBSession, uid int64, sessionID int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.RevokeSession")
+	err := d.DAL.RevokeSession(dbs, uid, sessionID)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) SetClientSecret(dbs DBSession, clientID string, clientSecret string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SetClientSecret")
+	err := d.DAL.SetClientSecret(dbs, clientID, clientSecret)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetClientSecret(dbs DBSession, clientID string) (string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetClientSecret")
+	r0, err := d.DAL.GetClientSecret(dbs, clientID)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreDiscordUser(dbs DBSession, discordUser *types.DiscordUser) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreDiscordUser")
+	err := d.DAL.StoreDiscordUser(dbs, discordUser)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetDiscordUser(dbs DBSession, uid int64) (*types.DiscordUser, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetDiscordUser")
+	r0, err := d.DAL.GetDiscordUser(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreDiscordServerRoles(dbs DBSession, roles []types.DiscordRole) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreDiscordServerRoles")
+	err := d.DAL.StoreDiscordServerRoles(dbs, roles)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreDiscordUserRoles(dbs DBSession, uid int64, roles []int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreDiscordUserRoles")
+	err := d.DAL.StoreDiscordUserRoles(dbs, uid, roles)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetDiscordUserRoles(dbs DBSession, uid int64) ([]string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetDiscordUserRoles")
+	r0, err := d.DAL.GetDiscordUserRoles(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreSubmission(dbs DBSession, submissionLevel string) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSubmission")
+	r0, err := d.DAL.StoreSubmission(dbs, submissionLevel)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreSubmissionFile(dbs DBSession, s *types.SubmissionFile) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSubmissionFile")
+	r0, err := d.DAL.StoreSubmissionFile(dbs, s)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSubmissionFiles(dbs DBSession, sfids []int64) ([]*types.SubmissionFile, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionFiles")
+	r0, err := d.DAL.GetSubmissionFiles(dbs, sfids)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetExtendedSubmissionFilesBySubmissionID(dbs DBSession, sid int64) ([]*types.ExtendedSubmissionFile, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetExtendedSubmissionFilesBySubmissionID")
+	r0, err := d.DAL.GetExtendedSubmissionFilesBySubmissionID(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) SearchSubmissions(dbs DBSession, filter *types.SubmissionsFilter) ([]*types.ExtendedSubmission, int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SearchSubmissions")
+	r0, r1, err := d.DAL.SearchSubmissions(dbs, filter)
+	endSpan(span, err)
+	return r0, r1, err
+}
+
+func (d *tracedDAL) StoreCurationMeta(dbs DBSession, cm *types.CurationMeta) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreCurationMeta")
+	err := d.DAL.StoreCurationMeta(dbs, cm)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetCurationMetaBySubmissionFileID(dbs DBSession, sfid int64) (*types.CurationMeta, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCurationMetaBySubmissionFileID")
+	r0, err := d.DAL.GetCurationMetaBySubmissionFileID(dbs, sfid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreComment(dbs DBSession, c *types.Comment) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreComment")
+	r0, err := d.DAL.StoreComment(dbs, c)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetExtendedCommentsBySubmissionID(dbs DBSession, sid int64) ([]*types.ExtendedComment, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetExtendedCommentsBySubmissionID")
+	r0, err := d.DAL.GetExtendedCommentsBySubmissionID(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetCommentByID(dbs DBSession, cid int64) (*types.Comment, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCommentByID")
+	r0, err := d.DAL.GetCommentByID(dbs, cid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) SoftDeleteSubmissionFile(dbs DBSession, sfid int64, deleteReason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SoftDeleteSubmissionFile")
+	err := d.DAL.SoftDeleteSubmissionFile(dbs, sfid, deleteReason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) SoftDeleteSubmission(dbs DBSession, sid int64, deleteReason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SoftDeleteSubmission")
+	err := d.DAL.SoftDeleteSubmission(dbs, sid, deleteReason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) SoftDeleteComment(dbs DBSession, cid int64, deleteReason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SoftDeleteComment")
+	err := d.DAL.SoftDeleteComment(dbs, cid, deleteReason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreNotificationSettings(dbs DBSession, uid int64, actions []string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreNotificationSettings")
+	err := d.DAL.StoreNotificationSettings(dbs, uid, actions)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetNotificationSettingsByUserID(dbs DBSession, uid int64) ([]string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetNotificationSettingsByUserID")
+	r0, err := d.DAL.GetNotificationSettingsByUserID(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) SubscribeUserToSubmission(dbs DBSession, uid, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SubscribeUserToSubmission")
+	err := d.DAL.SubscribeUserToSubmission(dbs, uid, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UnsubscribeUserFromSubmission(dbs DBSession, uid, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UnsubscribeUserFromSubmission")
+	err := d.DAL.UnsubscribeUserFromSubmission(dbs, uid, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) IsUserSubscribedToSubmission(dbs DBSession, uid, sid int64) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.IsUserSubscribedToSubmission")
+	r0, err := d.DAL.IsUserSubscribedToSubmission(dbs, uid, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreNotification(dbs DBSession, msg, notificationType string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreNotification")
+	err := d.DAL.StoreNotification(dbs, msg, notificationType)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetUsersForNotification(dbs DBSession, authorID, sid int64, action string) ([]int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetUsersForNotification")
+	r0, err := d.DAL.GetUsersForNotification(dbs, authorID, sid, action)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetUsersForUniversalNotification(dbs DBSession, authorID int64, action string) ([]int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetUsersForUniversalNotification")
+	r0, err := d.DAL.GetUsersForUniversalNotification(dbs, authorID, action)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetOldestUnsentNotification(dbs DBSession) (*types.Notification, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetOldestUnsentNotification")
+	r0, err := d.DAL.GetOldestUnsentNotification(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) MarkNotificationAsSent(dbs DBSession, nid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.MarkNotificationAsSent")
+	err := d.DAL.MarkNotificationAsSent(dbs, nid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) CountUnsentNotifications(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.CountUnsentNotifications")
+	r0, err := d.DAL.CountUnsentNotifications(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreCurationImage(dbs DBSession, c *types.CurationImage) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreCurationImage")
+	r0, err := d.DAL.StoreCurationImage(dbs, c)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetCurationImagesBySubmissionFileID(dbs DBSession, sfid int64) ([]*types.CurationImage, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCurationImagesBySubmissionFileID")
+	r0, err := d.DAL.GetCurationImagesBySubmissionFileID(dbs, sfid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetCurationImage(dbs DBSession, ciid int64) (*types.CurationImage, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCurationImage")
+	r0, err := d.DAL.GetCurationImage(dbs, ciid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetNextSubmission(dbs DBSession, sid int64) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetNextSubmission")
+	r0, err := d.DAL.GetNextSubmission(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetPreviousSubmission(dbs DBSession, sid int64) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetPreviousSubmission")
+	r0, err := d.DAL.GetPreviousSubmission(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) UpdateSubmissionCacheTable(dbs DBSession, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateSubmissionCacheTable")
+	err := d.DAL.UpdateSubmissionCacheTable(dbs, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) ClearMasterDBGames(dbs DBSession) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.ClearMasterDBGames")
+	err := d.DAL.ClearMasterDBGames(dbs)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreMasterDBGames(dbs DBSession, games []*types.MasterDatabaseGame) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreMasterDBGames")
+	err := d.DAL.StoreMasterDBGames(dbs, games)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetAllSimilarityAttributes(dbs DBSession) ([]*types.SimilarityAttributes, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetAllSimilarityAttributes")
+	r0, err := d.DAL.GetAllSimilarityAttributes(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreFlashfreezeRootFile(dbs DBSession, s *types.FlashfreezeFile) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreFlashfreezeRootFile")
+	r0, err := d.DAL.StoreFlashfreezeRootFile(dbs, s)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreFlashfreezeDeepFile(dbs DBSession, fid int64, entries []*types.IndexedFileEntry) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreFlashfreezeDeepFile")
+	err := d.DAL.StoreFlashfreezeDeepFile(dbs, fid, entries)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) SearchFlashfreezeFiles(dbs DBSession, filter *types.FlashfreezeFilter) ([]*types.ExtendedFlashfreezeItem, int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SearchFlashfreezeFiles")
+	r0, r1, err := d.DAL.SearchFlashfreezeFiles(dbs, filter)
+	endSpan(span, err)
+	return r0, r1, err
+}
+
+func (d *tracedDAL) UpdateFlashfreezeRootFileIndexedState(dbs DBSession, fid int64, indexedAt *time.Time, indexingErrors uint64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateFlashfreezeRootFileIndexedState")
+	err := d.DAL.UpdateFlashfreezeRootFileIndexedState(dbs, fid, indexedAt, indexingErrors)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetFlashfreezeRootFile(dbs DBSession, fid int64) (*types.FlashfreezeFile, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetFlashfreezeRootFile")
+	r0, err := d.DAL.GetFlashfreezeRootFile(dbs, fid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetAllFlashfreezeRootFiles(dbs DBSession) ([]*types.FlashfreezeFile, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetAllFlashfreezeRootFiles")
+	r0, err := d.DAL.GetAllFlashfreezeRootFiles(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetAllUnindexedFlashfreezeRootFiles(dbs DBSession) ([]*types.FlashfreezeFile, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetAllUnindexedFlashfreezeRootFiles")
+	r0, err := d.DAL.GetAllUnindexedFlashfreezeRootFiles(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) SoftDeleteFlashfreezeRootFile(dbs DBSession, fid int64, deleteReason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SoftDeleteFlashfreezeRootFile")
+	err := d.DAL.SoftDeleteFlashfreezeRootFile(dbs, fid, deleteReason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UpdateFlashfreezeRootFileDescription(dbs DBSession, fid int64, description *string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateFlashfreezeRootFileDescription")
+	err := d.DAL.UpdateFlashfreezeRootFileDescription(dbs, fid, description)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) ReplaceFlashfreezeRootFileTags(dbs DBSession, fid int64, tags []string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.ReplaceFlashfreezeRootFileTags")
+	err := d.DAL.ReplaceFlashfreezeRootFileTags(dbs, fid, tags)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreFlashfreezeCollection(dbs DBSession, c *types.FlashfreezeCollection) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreFlashfreezeCollection")
+	r0, err := d.DAL.StoreFlashfreezeCollection(dbs, c)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetFlashfreezeCollections(dbs DBSession) ([]*types.FlashfreezeCollection, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetFlashfreezeCollections")
+	r0, err := d.DAL.GetFlashfreezeCollections(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetFlashfreezeCollection(dbs DBSession, cid int64) (*types.FlashfreezeCollection, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetFlashfreezeCollection")
+	r0, err := d.DAL.GetFlashfreezeCollection(dbs, cid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) AddFlashfreezeFilesToCollection(dbs DBSession, cid int64, fids []int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.AddFlashfreezeFilesToCollection")
+	err := d.DAL.AddFlashfreezeFilesToCollection(dbs, cid, fids)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) RemoveFlashfreezeFileFromCollection(dbs DBSession, cid, fid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.RemoveFlashfreezeFileFromCollection")
+	err := d.DAL.RemoveFlashfreezeFileFromCollection(dbs, cid, fid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) SoftDeleteFlashfreezeCollection(dbs DBSession, cid int64, deleteReason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.SoftDeleteFlashfreezeCollection")
+	err := d.DAL.SoftDeleteFlashfreezeCollection(dbs, cid, deleteReason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) AcquireFileBlob(dbs DBSession, sha256sum string, size int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.AcquireFileBlob")
+	err := d.DAL.AcquireFileBlob(dbs, sha256sum, size)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetUnreferencedFileBlobs(dbs DBSession, unreferencedBefore time.Time) ([]string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetUnreferencedFileBlobs")
+	r0, err := d.DAL.GetUnreferencedFileBlobs(dbs, unreferencedBefore)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetAllFileBlobSHA256Sums(dbs DBSession) (map[string]struct{}, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetAllFileBlobSHA256Sums")
+	r0, err := d.DAL.GetAllFileBlobSHA256Sums(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetDeletedFilenamesBySHA256(dbs DBSession, sha256sum string) ([]string, []string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetDeletedFilenamesBySHA256")
+	r0, r1, err := d.DAL.GetDeletedFilenamesBySHA256(dbs, sha256sum)
+	endSpan(span, err)
+	return r0, r1, err
+}
+
+func (d *tracedDAL) LockFileBlob(dbs DBSession, sha256sum string) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.LockFileBlob")
+	r0, err := d.DAL.LockFileBlob(dbs, sha256sum)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteFileBlob(dbs DBSession, sha256sum string) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteFileBlob")
+	r0, err := d.DAL.DeleteFileBlob(dbs, sha256sum)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) IsSubmissionFilePresent(dbs DBSession, sha256sum string) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.IsSubmissionFilePresent")
+	r0, err := d.DAL.IsSubmissionFilePresent(dbs, sha256sum)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetStoredFileRecords(dbs DBSession) ([]*types.StoredFileRecord, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetStoredFileRecords")
+	r0, err := d.DAL.GetStoredFileRecords(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetReviewRequirements(dbs DBSession) ([]*types.ReviewRequirement, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetReviewRequirements")
+	r0, err := d.DAL.GetReviewRequirements(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreReviewRequirement(dbs DBSession, r *types.ReviewRequirement) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreReviewRequirement")
+	err := d.DAL.StoreReviewRequirement(dbs, r)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) DeleteReviewRequirement(dbs DBSession, id int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteReviewRequirement")
+	err := d.DAL.DeleteReviewRequirement(dbs, id)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetReviewQueueMembers(dbs DBSession) ([]*types.ReviewQueueMember, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetReviewQueueMembers")
+	r0, err := d.DAL.GetReviewQueueMembers(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreReviewQueueMember(dbs DBSession, m *types.ReviewQueueMember) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreReviewQueueMember")
+	err := d.DAL.StoreReviewQueueMember(dbs, m)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) DeleteReviewQueueMember(dbs DBSession, uid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteReviewQueueMember")
+	err := d.DAL.DeleteReviewQueueMember(dbs, uid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) LockSubmissionReviewers(dbs DBSession, sid int64) ([]int64, []int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.LockSubmissionReviewers")
+	r0, r1, err := d.DAL.LockSubmissionReviewers(dbs, sid)
+	endSpan(span, err)
+	return r0, r1, err
+}
+
+func (d *tracedDAL) StoreReviewQueueAssignment(dbs DBSession, a *types.ReviewQueueAssignment) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreReviewQueueAssignment")
+	r0, err := d.DAL.StoreReviewQueueAssignment(dbs, a)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetOpenReviewQueueAssignments(dbs DBSession) ([]*types.ReviewQueueAssignment, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetOpenReviewQueueAssignments")
+	r0, err := d.DAL.GetOpenReviewQueueAssignments(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetFinishedReviewQueueAssignments(dbs DBSession, since time.Time) ([]*types.ReviewQueueAssignment, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetFinishedReviewQueueAssignments")
+	r0, err := d.DAL.GetFinishedReviewQueueAssignments(dbs, since)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) FinishReviewQueueAssignment(dbs DBSession, id int64, finishedAt time.Time, outcome string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.FinishReviewQueueAssignment")
+	err := d.DAL.FinishReviewQueueAssignment(dbs, id, finishedAt, outcome)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetNewestFileTimes(dbs DBSession, sids []int64) (map[int64]time.Time, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetNewestFileTimes")
+	r0, err := d.DAL.GetNewestFileTimes(dbs, sids)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetLatestActionTimes(dbs DBSession, sids []int64, action string) (map[int64]time.Time, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetLatestActionTimes")
+	r0, err := d.DAL.GetLatestActionTimes(dbs, sids, action)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetLatestUserCommentTimes(dbs DBSession, sids []int64) (map[int64]map[int64]time.Time, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetLatestUserCommentTimes")
+	r0, err := d.DAL.GetLatestUserCommentTimes(dbs, sids)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSubmissionReminders(dbs DBSession, sids []int64) (map[int64]time.Time, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionReminders")
+	r0, err := d.DAL.GetSubmissionReminders(dbs, sids)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreSubmissionReminder(dbs DBSession, sid int64, remindedAt time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSubmissionReminder")
+	err := d.DAL.StoreSubmissionReminder(dbs, sid, remindedAt)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetReviewChecklistItems(dbs DBSession) ([]*types.ReviewChecklistItem, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetReviewChecklistItems")
+	r0, err := d.DAL.GetReviewChecklistItems(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreReviewChecklistItem(dbs DBSession, item *types.ReviewChecklistItem) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreReviewChecklistItem")
+	r0, err := d.DAL.StoreReviewChecklistItem(dbs, item)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteReviewChecklistItem(dbs DBSession, id int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteReviewChecklistItem")
+	err := d.DAL.DeleteReviewChecklistItem(dbs, id)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreCommentChecklistResults(dbs DBSession, cid int64, results []*types.ChecklistResult) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreCommentChecklistResults")
+	err := d.DAL.StoreCommentChecklistResults(dbs, cid, results)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetCommentChecklistResults(dbs DBSession, sid int64) (map[int64][]*types.ChecklistResult, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCommentChecklistResults")
+	r0, err := d.DAL.GetCommentChecklistResults(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetCurationLintRules(dbs DBSession) (map[string]*types.CurationLintRule, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCurationLintRules")
+	r0, err := d.DAL.GetCurationLintRules(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreCurationLintRule(dbs DBSession, rule *types.CurationLintRule) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreCurationLintRule")
+	err := d.DAL.StoreCurationLintRule(dbs, rule)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) MarkSubmissionDraft(dbs DBSession, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.MarkSubmissionDraft")
+	err := d.DAL.MarkSubmissionDraft(dbs, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) PublishSubmissionDraft(dbs DBSession, sid int64) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.PublishSubmissionDraft")
+	r0, err := d.DAL.PublishSubmissionDraft(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) IsSubmissionDraft(dbs DBSession, sid int64) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.IsSubmissionDraft")
+	r0, err := d.DAL.IsSubmissionDraft(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSubmissionDraftUploader(dbs DBSession, sid int64) (*int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionDraftUploader")
+	r0, err := d.DAL.GetSubmissionDraftUploader(dbs, sid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSubmissionTemplates(dbs DBSession, uid int64) ([]*types.SubmissionTemplate, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionTemplates")
+	r0, err := d.DAL.GetSubmissionTemplates(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreSubmissionTemplate(dbs DBSession, template *types.SubmissionTemplate) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSubmissionTemplate")
+	r0, err := d.DAL.StoreSubmissionTemplate(dbs, template)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteSubmissionTemplate(dbs DBSession, uid, id int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteSubmissionTemplate")
+	err := d.DAL.DeleteSubmissionTemplate(dbs, uid, id)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreSubmissionProcessingJob")
+	err := d.DAL.StoreSubmissionProcessingJob(dbs, job)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) ClaimSubmissionProcessingJob(dbs DBSession, workerID string, now, claimUntil time.Time) (*types.SubmissionProcessingJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.ClaimSubmissionProcessingJob")
+	r0, err := d.DAL.ClaimSubmissionProcessingJob(dbs, workerID, now, claimUntil)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) ExtendSubmissionProcessingJobClaim(dbs DBSession, tempName, workerID string, claimUntil time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.ExtendSubmissionProcessingJobClaim")
+	err := d.DAL.ExtendSubmissionProcessingJobClaim(dbs, tempName, workerID, claimUntil)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UpdateSubmissionProcessingJob(dbs DBSession, job *types.SubmissionProcessingJob) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateSubmissionProcessingJob")
+	err := d.DAL.UpdateSubmissionProcessingJob(dbs, job)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UpdateSubmissionProcessingJobStatus(dbs DBSession, tempName, status string, message *string, updatedAt time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateSubmissionProcessingJobStatus")
+	err := d.DAL.UpdateSubmissionProcessingJobStatus(dbs, tempName, status, message, updatedAt)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetSubmissionProcessingJob(dbs DBSession, tempName string) (*types.SubmissionProcessingJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionProcessingJob")
+	r0, err := d.DAL.GetSubmissionProcessingJob(dbs, tempName)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetSubmissionProcessingJobs(dbs DBSession, limit int) ([]*types.SubmissionProcessingJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetSubmissionProcessingJobs")
+	r0, err := d.DAL.GetSubmissionProcessingJobs(dbs, limit)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) CountUnfinishedSubmissionProcessingJobs(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.CountUnfinishedSubmissionProcessingJobs")
+	r0, err := d.DAL.CountUnfinishedSubmissionProcessingJobs(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteFinishedSubmissionProcessingJobs(dbs DBSession, updatedBefore time.Time) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteFinishedSubmissionProcessingJobs")
+	r0, err := d.DAL.DeleteFinishedSubmissionProcessingJobs(dbs, updatedBefore)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) StoreScheduledJob(dbs DBSession, job *types.ScheduledJob) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreScheduledJob")
+	err := d.DAL.StoreScheduledJob(dbs, job)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) GetScheduledJobs(dbs DBSession) ([]*types.ScheduledJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetScheduledJobs")
+	r0, err := d.DAL.GetScheduledJobs(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetScheduledJob(dbs DBSession, name string) (*types.ScheduledJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetScheduledJob")
+	r0, err := d.DAL.GetScheduledJob(dbs, name)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetDueScheduledJob(dbs DBSession, names []string, now time.Time) (*types.ScheduledJob, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetDueScheduledJob")
+	r0, err := d.DAL.GetDueScheduledJob(dbs, names, now)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) UpdateScheduledJobSettings(dbs DBSession, name, schedule string, enabled bool, nextRunAt, updatedAt time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateScheduledJobSettings")
+	err := d.DAL.UpdateScheduledJobSettings(dbs, name, schedule, enabled, nextRunAt, updatedAt)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) RequestScheduledJobRun(dbs DBSession, name string, uid int64, updatedAt time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.RequestScheduledJobRun")
+	err := d.DAL.RequestScheduledJobRun(dbs, name, uid, updatedAt)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) LockScheduledJob(dbs DBSession, name, workerID string, lockedUntil, nextRunAt time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.LockScheduledJob")
+	err := d.DAL.LockScheduledJob(dbs, name, workerID, lockedUntil, nextRunAt)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) ExtendScheduledJobLock(dbs DBSession, name, workerID string, lockedUntil time.Time) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.ExtendScheduledJobLock")
+	err := d.DAL.ExtendScheduledJobLock(dbs, name, workerID, lockedUntil)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UnlockScheduledJob(dbs DBSession, name, workerID string) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UnlockScheduledJob")
+	err := d.DAL.UnlockScheduledJob(dbs, name, workerID)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) StoreScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.StoreScheduledJobRun")
+	r0, err := d.DAL.StoreScheduledJobRun(dbs, run)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) UpdateScheduledJobRun(dbs DBSession, run *types.ScheduledJobRun) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateScheduledJobRun")
+	err := d.DAL.UpdateScheduledJobRun(dbs, run)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) InterruptScheduledJobRuns(dbs DBSession, name string, finishedAt time.Time) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.InterruptScheduledJobRuns")
+	r0, err := d.DAL.InterruptScheduledJobRuns(dbs, name, finishedAt)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetScheduledJobRuns(dbs DBSession, limit int) ([]*types.ScheduledJobRun, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetScheduledJobRuns")
+	r0, err := d.DAL.GetScheduledJobRuns(dbs, limit)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetScheduledJobRun(dbs DBSession, id int64) (*types.ScheduledJobRun, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetScheduledJobRun")
+	r0, err := d.DAL.GetScheduledJobRun(dbs, id)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteScheduledJobRuns(dbs DBSession, startedBefore time.Time) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteScheduledJobRuns")
+	r0, err := d.DAL.DeleteScheduledJobRuns(dbs, startedBefore)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) DeleteUserSessions(dbs DBSession, uid int64) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.DeleteUserSessions")
+	r0, err := d.DAL.DeleteUserSessions(dbs, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalCommentsCount(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalCommentsCount")
+	r0, err := d.DAL.GetTotalCommentsCount(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalUserCount(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalUserCount")
+	r0, err := d.DAL.GetTotalUserCount(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalFlashfreezeCount(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalFlashfreezeCount")
+	r0, err := d.DAL.GetTotalFlashfreezeCount(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalFlashfreezeFileCount(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalFlashfreezeFileCount")
+	r0, err := d.DAL.GetTotalFlashfreezeFileCount(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalSubmissionFilesize(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalSubmissionFilesize")
+	r0, err := d.DAL.GetTotalSubmissionFilesize(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetTotalFlashfreezeFilesize(dbs DBSession) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetTotalFlashfreezeFilesize")
+	r0, err := d.DAL.GetTotalFlashfreezeFilesize(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetUsers(dbs DBSession) ([]*types.User, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetUsers")
+	r0, err := d.DAL.GetUsers(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) GetCommentsByUserIDAndAction(dbs DBSession, uid int64, action string) ([]*types.Comment, error) {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.GetCommentsByUserIDAndAction")
+	r0, err := d.DAL.GetCommentsByUserIDAndAction(dbs, uid, action)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedDAL) PopulateRevisionInfo(dbs DBSession, revisions []*types.RevisionInfo) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.PopulateRevisionInfo")
+	err := d.DAL.PopulateRevisionInfo(dbs, revisions)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) FreezeSubmission(dbs DBSession, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.FreezeSubmission")
+	err := d.DAL.FreezeSubmission(dbs, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UnfreezeSubmission(dbs DBSession, sid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UnfreezeSubmission")
+	err := d.DAL.UnfreezeSubmission(dbs, sid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) NukeSessionTable(dbs DBSession) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.NukeSessionTable")
+	err := d.DAL.NukeSessionTable(dbs)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedDAL) UpdateSubmissionAutofreeze(dbs DBSession, sid int64, shouldAutofreeze bool) error {
+	_, span := tracing.Start(dbs.Ctx(), "DAL.UpdateSubmissionAutofreeze")
+	err := d.DAL.UpdateSubmissionAutofreeze(dbs, sid, shouldAutofreeze)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) Ping(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "PGDAL.Ping")
+	err := d.PGDAL.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) NewSession(ctx context.Context) (PGDBSession, error) {
+	_, span := tracing.Start(ctx, "PGDAL.NewSession")
+	r0, err := d.PGDAL.NewSession(ctx)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) CountSinceDate(dbs PGDBSession, modifiedAfter *string) (int, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.CountSinceDate")
+	r0, err := d.PGDAL.CountSinceDate(dbs, modifiedAfter)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) SearchTags(dbs PGDBSession, modifiedAfter *string) ([]*types.Tag, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SearchTags")
+	r0, err := d.PGDAL.SearchTags(dbs, modifiedAfter)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) SearchPlatforms(dbs PGDBSession, modifiedAfter *string) ([]*types.Platform, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SearchPlatforms")
+	r0, err := d.PGDAL.SearchPlatforms(dbs, modifiedAfter)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) SearchGames(dbs PGDBSession, modifiedAfter *string, modifiedBefore *string, broad bool, afterId *string) ([]*types.Game, []*types.AdditionalApp, []*types.GameData, [][]string, [][]string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SearchGames")
+	r0, r1, r2, r3, r4, err := d.PGDAL.SearchGames(dbs, modifiedAfter, modifiedBefore, broad, afterId)
+	endSpan(span, err)
+	return r0, r1, r2, r3, r4, err
+}
+
+func (d *tracedPGDAL) SearchDeletedGames(dbs PGDBSession, modifiedAfter *string) ([]*types.DeletedGame, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SearchDeletedGames")
+	r0, err := d.PGDAL.SearchDeletedGames(dbs, modifiedAfter)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetTagCategories(dbs PGDBSession) ([]*types.TagCategory, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetTagCategories")
+	r0, err := d.PGDAL.GetTagCategories(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGamesUsingTagTotal(dbs PGDBSession, tagId int64) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGamesUsingTagTotal")
+	r0, err := d.PGDAL.GetGamesUsingTagTotal(dbs, tagId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) SaveGame(dbs PGDBSession, game *types.Game, uid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SaveGame")
+	err := d.PGDAL.SaveGame(dbs, game, uid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) SaveGameData(dbs PGDBSession, gameId string, date int64, gameData *types.GameData) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SaveGameData")
+	err := d.PGDAL.SaveGameData(dbs, gameId, date, gameData)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) SaveTag(dbs PGDBSession, tag *types.Tag, uid int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.SaveTag")
+	err := d.PGDAL.SaveTag(dbs, tag, uid)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) DeveloperImportDatabaseJson(dbs PGDBSession, data *types.LauncherDump) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.DeveloperImportDatabaseJson")
+	err := d.PGDAL.DeveloperImportDatabaseJson(dbs, data)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) GetTagCategory(dbs PGDBSession, categoryId int64) (*types.TagCategory, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetTagCategory")
+	r0, err := d.PGDAL.GetTagCategory(dbs, categoryId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetTag(dbs PGDBSession, tagId int64) (*types.Tag, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetTag")
+	r0, err := d.PGDAL.GetTag(dbs, tagId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetTagByName(dbs PGDBSession, tagName string) (*types.Tag, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetTagByName")
+	r0, err := d.PGDAL.GetTagByName(dbs, tagName)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetPlatform(dbs PGDBSession, platformId int64) (*types.Platform, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetPlatform")
+	r0, err := d.PGDAL.GetPlatform(dbs, platformId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetPlatformByName(dbs PGDBSession, platformName string) (*types.Platform, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetPlatformByName")
+	r0, err := d.PGDAL.GetPlatformByName(dbs, platformName)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGamesSlimInfo(dbs PGDBSession, gameIds []string) ([]*types.GameSlimInfo, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGamesSlimInfo")
+	r0, err := d.PGDAL.GetGamesSlimInfo(dbs, gameIds)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGames(dbs PGDBSession, gameIds []string) ([]*types.Game, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGames")
+	r0, err := d.PGDAL.GetGames(dbs, gameIds)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGame(dbs PGDBSession, gameId string) (*types.Game, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGame")
+	r0, err := d.PGDAL.GetGame(dbs, gameId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGameData(dbs PGDBSession, gameId string, date int64) (*types.GameData, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameData")
+	r0, err := d.PGDAL.GetGameData(dbs, gameId, date)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGameDataIndex(dbs PGDBSession, gameId string, date int64) (*types.GameDataIndex, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameDataIndex")
+	r0, err := d.PGDAL.GetGameDataIndex(dbs, gameId, date)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGameRevisionInfo(dbs PGDBSession, gameId string) ([]*types.RevisionInfo, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameRevisionInfo")
+	r0, err := d.PGDAL.GetGameRevisionInfo(dbs, gameId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetTagRevisionInfo(dbs PGDBSession, tagId int64) ([]*types.RevisionInfo, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetTagRevisionInfo")
+	r0, err := d.PGDAL.GetTagRevisionInfo(dbs, tagId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetMetadataStats(dbs PGDBSession) (*types.MetadataStatsPageDataBare, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetMetadataStats")
+	r0, err := d.PGDAL.GetMetadataStats(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) DeleteGame(dbs PGDBSession, gameId string, uid int64, reason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.DeleteGame")
+	err := d.PGDAL.DeleteGame(dbs, gameId, uid, reason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) RestoreGame(dbs PGDBSession, gameId string, uid int64, reason string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.RestoreGame")
+	err := d.PGDAL.RestoreGame(dbs, gameId, uid, reason)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) GetGameDataFileRecords(dbs PGDBSession) ([]*types.GameDataFileRecord, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameDataFileRecords")
+	r0, err := d.PGDAL.GetGameDataFileRecords(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetOrCreateTagCategory(dbs PGDBSession, categoryName string) (*types.TagCategory, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetOrCreateTagCategory")
+	r0, err := d.PGDAL.GetOrCreateTagCategory(dbs, categoryName)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetOrCreateTag(dbs PGDBSession, tagName string, tagCategory string, reason string, uid int64) (*types.Tag, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetOrCreateTag")
+	r0, err := d.PGDAL.GetOrCreateTag(dbs, tagName, tagCategory, reason, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetOrCreatePlatform(dbs PGDBSession, platformName string, reason string, uid int64) (*types.Platform, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetOrCreatePlatform")
+	r0, err := d.PGDAL.GetOrCreatePlatform(dbs, platformName, reason, uid)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) AddSubmissionFromValidator(dbs PGDBSession, uid int64, vr *types.ValidatorRepackResponse, frozen bool) (*types.Game, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.AddSubmissionFromValidator")
+	r0, err := d.PGDAL.AddSubmissionFromValidator(dbs, uid, vr, frozen)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) AddGameData(dbs PGDBSession, uid int64, gameId string, vr *types.ValidatorRepackResponse) (*types.GameData, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.AddGameData")
+	r0, err := d.PGDAL.AddGameData(dbs, uid, gameId, vr)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) IndexerGetNext(ctx context.Context) (*types.GameData, error) {
+	ctx, span := tracing.Start(ctx, "PGDAL.IndexerGetNext")
+	r0, err := d.PGDAL.IndexerGetNext(ctx)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) IndexerInsert(ctx context.Context, crc32sum []byte, md5sum []byte, sha256sum []byte, sha1sum []byte, size uint64, path string, gameId string, zipDate time.Time) error {
+	ctx, span := tracing.Start(ctx, "PGDAL.IndexerInsert")
+	err := d.PGDAL.IndexerInsert(ctx, crc32sum, md5sum, sha256sum, sha1sum, size, path, gameId, zipDate)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) IndexerMarkFailure(ctx context.Context, gameId string, zipDate time.Time) error {
+	ctx, span := tracing.Start(ctx, "PGDAL.IndexerMarkFailure")
+	err := d.PGDAL.IndexerMarkFailure(ctx, gameId, zipDate)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) GetIndexMatchesHash(dbs PGDBSession, hashType string, hashStr string) ([]*types.IndexMatchData, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetIndexMatchesHash")
+	r0, err := d.PGDAL.GetIndexMatchesHash(dbs, hashType, hashStr)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetIndexMatchesPath(dbs PGDBSession, paths []string) ([]*types.IndexMatchData, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetIndexMatchesPath")
+	r0, err := d.PGDAL.GetIndexMatchesPath(dbs, paths)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) UpdateTagsFromTagsList(dbs PGDBSession, tagsList []types.Tag) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.UpdateTagsFromTagsList")
+	err := d.PGDAL.UpdateTagsFromTagsList(dbs, tagsList)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ApplyGamePatch(dbs PGDBSession, uid int64, game *types.Game, patch *types.GameContentPatch, addApps []*types.CurationAdditionalApp) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ApplyGamePatch")
+	err := d.PGDAL.ApplyGamePatch(dbs, uid, game, patch, addApps)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) GetGameRedirectTo(dbs PGDBSession, gameId string) (string, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameRedirectTo")
+	r0, err := d.PGDAL.GetGameRedirectTo(dbs, gameId)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetGameRedirects(dbs PGDBSession) ([]*types.GameRedirect, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetGameRedirects")
+	r0, err := d.PGDAL.GetGameRedirects(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) AddGameRedirect(dbs PGDBSession, srcId string, destId string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.AddGameRedirect")
+	err := d.PGDAL.AddGameRedirect(dbs, srcId, destId)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) RemoveGameRedirectsTo(dbs PGDBSession, srcId string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.RemoveGameRedirectsTo")
+	err := d.PGDAL.RemoveGameRedirectsTo(dbs, srcId)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) RemoveGameRedirectsFrom(dbs PGDBSession, srcId string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.RemoveGameRedirectsFrom")
+	err := d.PGDAL.RemoveGameRedirectsFrom(dbs, srcId)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) UpdateGameRedirects(dbs PGDBSession, srcId string, destId string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.UpdateGameRedirects")
+	err := d.PGDAL.UpdateGameRedirects(dbs, srcId, destId)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) CreateActivityEvent(dbs PGDBSession, event *activityevents.ActivityEvent) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.CreateActivityEvent")
+	err := d.PGDAL.CreateActivityEvent(dbs, event)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) GetActivityEvents(dbs PGDBSession, filter *types.ActivityEventsFilter) ([]*activityevents.ActivityEvent, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetActivityEvents")
+	r0, err := d.PGDAL.GetActivityEvents(dbs, filter)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetFrozenGames(dbs PGDBSession) ([]*types.AutounfreezerGame, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetFrozenGames")
+	r0, err := d.PGDAL.GetFrozenGames(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetAutounfreezerPolicy(dbs PGDBSession) (*types.AutounfreezerPolicy, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetAutounfreezerPolicy")
+	r0, err := d.PGDAL.GetAutounfreezerPolicy(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) UpdateAutounfreezerPolicy(dbs PGDBSession, policy *types.AutounfreezerPolicy) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.UpdateAutounfreezerPolicy")
+	err := d.PGDAL.UpdateAutounfreezerPolicy(dbs, policy)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ClaimAutounfreezerLegacyImport(dbs PGDBSession, at time.Time) (bool, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ClaimAutounfreezerLegacyImport")
+	r0, err := d.PGDAL.ClaimAutounfreezerLegacyImport(dbs, at)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetAutounfreezerExemptions(dbs PGDBSession) ([]*types.AutounfreezerExemption, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetAutounfreezerExemptions")
+	r0, err := d.PGDAL.GetAutounfreezerExemptions(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) AddAutounfreezerExemption(dbs PGDBSession, exemption *types.AutounfreezerExemption) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.AddAutounfreezerExemption")
+	err := d.PGDAL.AddAutounfreezerExemption(dbs, exemption)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) RemoveAutounfreezerExemption(dbs PGDBSession, gameID string) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.RemoveAutounfreezerExemption")
+	err := d.PGDAL.RemoveAutounfreezerExemption(dbs, gameID)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) BeginMetadataSnapshot(dbs PGDBSession) (time.Time, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.BeginMetadataSnapshot")
+	r0, err := d.PGDAL.BeginMetadataSnapshot(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) ExportGames(dbs PGDBSession, fn func(game *types.Game) error) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportGames")
+	err := d.PGDAL.ExportGames(dbs, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ExportAdditionalApps(dbs PGDBSession, fn func(id int64, addApp *types.AdditionalApp) error) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportAdditionalApps")
+	err := d.PGDAL.ExportAdditionalApps(dbs, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ExportGameData(dbs PGDBSession, fn func(gameData *types.GameData) error) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportGameData")
+	err := d.PGDAL.ExportGameData(dbs, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ExportGameTagRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportGameTagRelations")
+	err := d.PGDAL.ExportGameTagRelations(dbs, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ExportGamePlatformRelations(dbs PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportGamePlatformRelations")
+	err := d.PGDAL.ExportGamePlatformRelations(dbs, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (d *tracedPGDAL) ExportTags(dbs PGDBSession) ([]*types.LauncherDumpTagsTag, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportTags")
+	r0, err := d.PGDAL.ExportTags(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) ExportTagAliases(dbs PGDBSession) ([]*types.TagAlias, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportTagAliases")
+	r0, err := d.PGDAL.ExportTagAliases(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) ExportPlatforms(dbs PGDBSession) ([]*types.LauncherDumpPlatformsPlatform, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportPlatforms")
+	r0, err := d.PGDAL.ExportPlatforms(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) ExportPlatformAliases(dbs PGDBSession) ([]*types.PlatformAlias, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.ExportPlatformAliases")
+	r0, err := d.PGDAL.ExportPlatformAliases(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) AddMetadataSnapshot(dbs PGDBSession, snapshot *types.MetadataSnapshot) (int64, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.AddMetadataSnapshot")
+	r0, err := d.PGDAL.AddMetadataSnapshot(dbs, snapshot)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetMetadataSnapshot(dbs PGDBSession, version int64) (*types.MetadataSnapshot, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetMetadataSnapshot")
+	r0, err := d.PGDAL.GetMetadataSnapshot(dbs, version)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetLatestMetadataSnapshot(dbs PGDBSession) (*types.MetadataSnapshot, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetLatestMetadataSnapshot")
+	r0, err := d.PGDAL.GetLatestMetadataSnapshot(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) GetMetadataSnapshots(dbs PGDBSession) ([]*types.MetadataSnapshot, error) {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.GetMetadataSnapshots")
+	r0, err := d.PGDAL.GetMetadataSnapshots(dbs)
+	endSpan(span, err)
+	return r0, err
+}
+
+func (d *tracedPGDAL) DeleteMetadataSnapshot(dbs PGDBSession, version int64) error {
+	_, span := tracing.Start(dbs.Ctx(), "PGDAL.DeleteMetadataSnapshot")
+	err := d.PGDAL.DeleteMetadataSnapshot(dbs, version)
+	endSpan(span, err)
+	return err
+}
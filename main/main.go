@@ -19,6 +19,7 @@
 package main
 
 import (
+	"flag"
 	"os"
 
 	"github.com/bwmarrin/discordgo"
@@ -46,7 +47,24 @@ func main() {
 			panic(err)
 		}
 	}
+	if err := config.LoadFile(os.Getenv("CONFIG_FILE")); err != nil {
+		panic(err)
+	}
+
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(config.Check(os.Stdout))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "admin" && len(os.Args) < 4 {
+		transport.PrintAdminUsage(os.Stderr)
+		os.Exit(2)
+	}
+
 	log := logging.InitLogger()
+	if len(os.Args) > 1 && (os.Args[1] == "check-files" || os.Args[1] == "admin") {
+		// the commands print JSON to stdout
+		log.SetOutput(os.Stderr)
+	}
 	l := log.WithField("commit", config.EnvString("GIT_COMMIT")).WithField("runID", utils.NewRealRandomStringProvider().RandomString(8))
 	l.Infoln("hi")
 
@@ -60,6 +78,25 @@ func main() {
 	pgdb := database.OpenPostgresDB(l, conf)
 	defer pgdb.Close()
 
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		code := transport.RunAdminCommand(l, conf, db, pgdb, os.Args[2:])
+		db.Close()
+		pgdb.Close()
+		os.Exit(code)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "check-files" {
+		fs := flag.NewFlagSet("check-files", flag.ExitOnError)
+		verifyHashes := fs.Bool("verify-hashes", false, "verify the checksums of data packs and blobs, reads every file")
+		quarantine := fs.Bool("quarantine", false, "move orphaned files into the quarantine storage")
+		fs.Parse(os.Args[2:])
+
+		code := transport.RunFileConsistencyCheck(l, conf, db, pgdb, *verifyHashes, *quarantine)
+		db.Close()
+		pgdb.Close()
+		os.Exit(code)
+	}
+
 	var authBot *discordgo.Session
 	var notificationBot *discordgo.Session
 	var rsu *resumableuploadservice.ResumableUploadService
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "fpfss"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by route template, method and status code.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"route", "method", "code"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Runs of background jobs by job and result.",
+	}, []string{"job", "result"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of background job runs.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 4, 10),
+	}, []string{"job"})
+
+	jobLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "Time of the last successful run of background jobs.",
+	}, []string{"job"})
+
+	// ZipIndexerIndexed counts data packs processed by the zip indexer by result
+	ZipIndexerIndexed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "zip_indexer_data_packs_total",
+		Help:      "Data packs processed by the zip indexer by result.",
+	}, []string{"result"})
+
+	// ZipIndexerFiles counts files indexed inside data packs
+	ZipIndexerFiles = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "zip_indexer_files_total",
+		Help:      "Files indexed inside data packs.",
+	})
+
+	// ZipIndexerRunning is 1 while the zip indexer is running
+	ZipIndexerRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "zip_indexer_running",
+		Help:      "Whether the zip indexer is running.",
+	})
+
+	// NotificationsSent counts notifications delivered to discord by result
+	NotificationsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_sent_total",
+		Help:      "Notification delivery attempts by result.",
+	}, []string{"result"})
+
+	// UploadChunks counts received resumable upload chunks by kind of upload
+	UploadChunks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upload_chunks_total",
+		Help:      "Received resumable upload chunks by upload kind.",
+	}, []string{"kind"})
+
+	// UploadsCompleted counts resumable uploads whose last chunk was received, by kind of upload
+	UploadsCompleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_completed_total",
+		Help:      "Resumable uploads with all chunks received by upload kind.",
+	}, []string{"kind"})
+
+	// SubmissionProcessingJobs counts attempts of the submission processing queue by outcome
+	SubmissionProcessingJobs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "submission_processing_jobs_total",
+		Help:      "Submission processing attempts by outcome, one of success, failed, retry or interrupted.",
+	}, []string{"result"})
+
+	validatorCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "validator_call_duration_seconds",
+		Help:      "Duration of validator calls by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 3, 10),
+	}, []string{"operation", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		jobRuns, jobDuration, jobLastSuccess,
+		ZipIndexerIndexed, ZipIndexerFiles, ZipIndexerRunning,
+		NotificationsSent,
+		UploadChunks, UploadsCompleted,
+		SubmissionProcessingJobs,
+		validatorCallDuration,
+	)
+}
+
+// Handler serves the metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterGaugeFunc adds a gauge which calls f on every scrape, e.g. to read a value from the database
+func RegisterGaugeFunc(name, help string, f func() float64) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, f))
+}
+
+// RegisterDatabases exports the connection pool stats of both databases
+func RegisterDatabases(db *sql.DB, pgdb *pgxpool.Pool) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, "mysql"), newPgxPoolCollector(pgdb))
+}
+
+// InstrumentHandler records the duration of requests by the route they matched.
+// Matching is done before the request is served, so unmatched requests are counted under one label.
+func InstrumentHandler(router *mux.Router, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if template, err := match.Route.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		m := httpsnoop.CaptureMetrics(h, w, r)
+		httpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(m.Code)).Observe(m.Duration.Seconds())
+	})
+}
+
+// ObserveJob runs one iteration of a background job and records its duration and result
+func ObserveJob(job string, f func() error) error {
+	start := time.Now()
+	err := f()
+	jobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	jobRuns.WithLabelValues(job, Result(err)).Inc()
+	if err == nil {
+		jobLastSuccess.WithLabelValues(job).SetToCurrentTime()
+	}
+	return err
+}
+
+// ObserveValidatorCall records the duration and result of a call to the validator
+func ObserveValidatorCall(operation string, start time.Time, err error) {
+	validatorCallDuration.WithLabelValues(operation, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+// Result is the label value of an operation which may have failed
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
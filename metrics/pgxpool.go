@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pgxPoolCollector reads the pool stats on every scrape, like the DBStatsCollector does for database/sql
+type pgxPoolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns        *prometheus.Desc
+	totalConns      *prometheus.Desc
+	idleConns       *prometheus.Desc
+	acquiredConns   *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+}
+
+func newPgxPoolCollector(pool *pgxpool.Pool) *pgxPoolCollector {
+	labels := prometheus.Labels{"db_name": "postgres"}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("go", "sql", name), help, nil, labels)
+	}
+	return &pgxPoolCollector{
+		pool:            pool,
+		maxConns:        desc("max_open_connections", "Maximum number of open connections to the database."),
+		totalConns:      desc("open_connections", "The number of established connections both in use and idle."),
+		idleConns:       desc("idle_connections", "The number of idle connections."),
+		acquiredConns:   desc("in_use_connections", "The number of connections currently in use."),
+		acquireCount:    desc("acquire_total", "The total number of successful connection acquires."),
+		acquireDuration: desc("acquire_duration_seconds_total", "The total time spent acquiring connections."),
+		emptyAcquire:    desc("wait_count_total", "The total number of acquires which waited for a connection because the pool was empty."),
+		canceledAcquire: desc("acquire_canceled_total", "The total number of acquires canceled by their context."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *pgxPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.acquiredConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+}
+
+// Collect implements prometheus.Collector
+func (c *pgxPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
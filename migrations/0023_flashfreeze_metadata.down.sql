@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS flashfreeze_collection_file;
+DROP TABLE IF EXISTS flashfreeze_collection;
+DROP TABLE IF EXISTS flashfreeze_file_tag;
+
+ALTER TABLE `flashfreeze_file`
+    DROP COLUMN `description`;
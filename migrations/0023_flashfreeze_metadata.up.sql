@@ -0,0 +1,34 @@
+ALTER TABLE `flashfreeze_file`
+    ADD `description` TEXT DEFAULT NULL;
+
+CREATE TABLE IF NOT EXISTS flashfreeze_file_tag
+(
+    fk_flashfreeze_file_id BIGINT      NOT NULL,
+    name                   VARCHAR(64) NOT NULL,
+    PRIMARY KEY (fk_flashfreeze_file_id, name),
+    FOREIGN KEY (fk_flashfreeze_file_id) REFERENCES flashfreeze_file (id)
+);
+CREATE INDEX idx_flashfreeze_file_tag_name ON flashfreeze_file_tag (name);
+
+CREATE TABLE IF NOT EXISTS flashfreeze_collection
+(
+    id             BIGINT PRIMARY KEY AUTO_INCREMENT,
+    fk_user_id     BIGINT              NOT NULL,
+    name           VARCHAR(255) UNIQUE NOT NULL,
+    description    TEXT                NOT NULL,
+    created_at     BIGINT              NOT NULL,
+    deleted_at     BIGINT       DEFAULT NULL,
+    deleted_reason VARCHAR(255) DEFAULT NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id)
+);
+
+CREATE TABLE IF NOT EXISTS flashfreeze_collection_file
+(
+    fk_flashfreeze_collection_id BIGINT NOT NULL,
+    fk_flashfreeze_file_id       BIGINT NOT NULL,
+    added_at                     BIGINT NOT NULL,
+    PRIMARY KEY (fk_flashfreeze_collection_id, fk_flashfreeze_file_id),
+    FOREIGN KEY (fk_flashfreeze_collection_id) REFERENCES flashfreeze_collection (id),
+    FOREIGN KEY (fk_flashfreeze_file_id) REFERENCES flashfreeze_file (id)
+);
+CREATE INDEX idx_flashfreeze_collection_file_file_id ON flashfreeze_collection_file (fk_flashfreeze_file_id);
@@ -0,0 +1,20 @@
+DROP INDEX idx_submission_file_live_sha256sum ON submission_file;
+DROP INDEX idx_submission_file_sha256sum ON submission_file;
+DROP INDEX idx_submission_file_md5sum ON submission_file;
+ALTER TABLE submission_file
+    DROP COLUMN live_sha256sum;
+
+-- deleted files which were uploaded again have the checksums of the new copy, which the unique indexes don't allow,
+-- so the deleted rows get placeholder checksums
+UPDATE submission_file
+    JOIN (SELECT sha256sum FROM submission_file GROUP BY sha256sum HAVING COUNT(*) > 1) AS duplicate
+    ON duplicate.sha256sum = submission_file.sha256sum
+SET submission_file.md5sum    = MD5(CONCAT('deleted duplicate ', submission_file.id)),
+    submission_file.sha256sum = SHA2(CONCAT('deleted duplicate ', submission_file.id), 256)
+WHERE submission_file.deleted_at IS NOT NULL;
+
+ALTER TABLE submission_file
+    ADD UNIQUE (md5sum),
+    ADD UNIQUE (sha256sum);
+
+DROP TABLE IF EXISTS file_blob;
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS file_blob
+(
+    sha256sum       CHAR(64) PRIMARY KEY,
+    size            BIGINT NOT NULL,
+    ref_count       BIGINT NOT NULL,
+    created_at      BIGINT NOT NULL,
+    unreferenced_at BIGINT DEFAULT NULL
+);
+CREATE INDEX idx_file_blob_unreferenced_at ON file_blob (unreferenced_at);
+
+INSERT INTO file_blob (sha256sum, size, ref_count, created_at, unreferenced_at)
+SELECT sha256sum,
+       MAX(size),
+       SUM(deleted_at IS NULL),
+       MIN(created_at),
+       IF(SUM(deleted_at IS NULL) = 0, MAX(deleted_at), NULL)
+FROM (SELECT sha256sum, size, created_at, deleted_at
+      FROM submission_file
+      UNION ALL
+      SELECT sha256sum, size, created_at, deleted_at
+      FROM flashfreeze_file) AS files
+GROUP BY sha256sum;
+
+-- identical files share a blob, so a file deleted earlier can be uploaded again, but only one live copy is allowed
+ALTER TABLE submission_file
+    DROP INDEX md5sum,
+    DROP INDEX sha256sum,
+    ADD COLUMN live_sha256sum CHAR(64) AS (IF(deleted_at IS NULL, sha256sum, NULL)) STORED;
+CREATE INDEX idx_submission_file_md5sum ON submission_file (md5sum);
+CREATE INDEX idx_submission_file_sha256sum ON submission_file (sha256sum);
+CREATE UNIQUE INDEX idx_submission_file_live_sha256sum ON submission_file (live_sha256sum);
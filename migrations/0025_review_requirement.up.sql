@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS review_requirement
+(
+    id                     BIGINT PRIMARY KEY AUTO_INCREMENT,
+    submission_level       VARCHAR(32)  NOT NULL DEFAULT '',
+    platform               VARCHAR(255) NOT NULL DEFAULT '',
+    required_approvals     INT          NOT NULL,
+    required_verifications INT          NOT NULL,
+    required_role          VARCHAR(64)  NOT NULL DEFAULT '',
+    fk_user_id             BIGINT       NOT NULL,
+    updated_at             BIGINT       NOT NULL,
+    UNIQUE (submission_level, platform),
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id)
+);
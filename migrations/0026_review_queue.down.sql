@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_queue_assignment;
+DROP TABLE IF EXISTS review_queue_member;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS review_queue_member
+(
+    fk_user_id        BIGINT PRIMARY KEY,
+    platforms         VARCHAR(1024) NOT NULL DEFAULT '',
+    submission_levels VARCHAR(255)  NOT NULL DEFAULT '',
+    max_assignments   INT           NOT NULL,
+    joined_at         BIGINT        NOT NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id)
+);
+CREATE TABLE IF NOT EXISTS review_queue_assignment
+(
+    id               BIGINT PRIMARY KEY AUTO_INCREMENT,
+    fk_submission_id BIGINT      NOT NULL,
+    fk_user_id       BIGINT      NOT NULL,
+    assigned_at      BIGINT      NOT NULL,
+    finished_at      BIGINT      NULL,
+    outcome          VARCHAR(32) NULL,
+    FOREIGN KEY (fk_submission_id) REFERENCES submission (id),
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id),
+    INDEX (finished_at)
+);
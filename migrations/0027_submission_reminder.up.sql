@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS submission_reminder
+(
+    fk_submission_id BIGINT PRIMARY KEY,
+    reminded_at      BIGINT NOT NULL,
+    FOREIGN KEY (fk_submission_id) REFERENCES submission (id)
+);
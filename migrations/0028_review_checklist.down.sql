@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS comment_checklist_result;
+DROP TABLE IF EXISTS review_checklist_item;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS review_checklist_item
+(
+    id         BIGINT PRIMARY KEY AUTO_INCREMENT,
+    platform   VARCHAR(255) NOT NULL DEFAULT '',
+    label      VARCHAR(255) NOT NULL,
+    position   INT          NOT NULL DEFAULT 0,
+    fk_user_id BIGINT       NOT NULL,
+    updated_at BIGINT       NOT NULL,
+    deleted_at BIGINT       NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id)
+);
+CREATE TABLE IF NOT EXISTS comment_checklist_result
+(
+    fk_comment_id               BIGINT      NOT NULL,
+    fk_review_checklist_item_id BIGINT      NOT NULL,
+    result                      VARCHAR(16) NOT NULL,
+    PRIMARY KEY (fk_comment_id, fk_review_checklist_item_id),
+    FOREIGN KEY (fk_comment_id) REFERENCES comment (id),
+    FOREIGN KEY (fk_review_checklist_item_id) REFERENCES review_checklist_item (id),
+    INDEX (fk_review_checklist_item_id, result)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS curation_lint_rule;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS curation_lint_rule
+(
+    rule       VARCHAR(64) PRIMARY KEY,
+    enabled    BOOLEAN     NOT NULL DEFAULT TRUE,
+    severity   VARCHAR(16) NOT NULL,
+    parameter  TEXT        NOT NULL,
+    fk_user_id BIGINT      NOT NULL,
+    updated_at BIGINT      NOT NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS submission_template;
+ALTER TABLE submission DROP COLUMN draft_at;
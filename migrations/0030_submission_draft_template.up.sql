@@ -0,0 +1,12 @@
+ALTER TABLE submission ADD draft_at BIGINT DEFAULT NULL;
+CREATE TABLE IF NOT EXISTS submission_template
+(
+    id         BIGINT PRIMARY KEY AUTO_INCREMENT,
+    fk_user_id BIGINT       NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    fields     TEXT         NOT NULL,
+    updated_at BIGINT       NOT NULL,
+    deleted_at BIGINT       NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id),
+    INDEX (fk_user_id)
+);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS pending_submission_upload
+(
+    temp_name        VARCHAR(64) PRIMARY KEY,
+    fk_user_id       BIGINT NOT NULL,
+    fk_submission_id BIGINT NULL,
+    resumable_params TEXT   NOT NULL,
+    created_at       BIGINT NOT NULL,
+    FOREIGN KEY (fk_user_id) REFERENCES discord_user (id),
+    FOREIGN KEY (fk_submission_id) REFERENCES submission (id)
+);
@@ -0,0 +1,13 @@
+DELETE FROM submission_processing_job WHERE status IN ('success', 'failed');
+ALTER TABLE submission_processing_job
+    DROP FOREIGN KEY fk_submission_processing_job_result,
+    DROP INDEX idx_submission_processing_job_status,
+    DROP COLUMN status,
+    DROP COLUMN message,
+    DROP COLUMN result_submission_id,
+    DROP COLUMN attempts,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN claimed_by,
+    DROP COLUMN claimed_until,
+    DROP COLUMN updated_at;
+RENAME TABLE submission_processing_job TO pending_submission_upload;
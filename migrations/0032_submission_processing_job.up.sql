@@ -0,0 +1,13 @@
+RENAME TABLE pending_submission_upload TO submission_processing_job;
+ALTER TABLE submission_processing_job
+    ADD status               VARCHAR(16) NOT NULL DEFAULT 'received',
+    ADD message              TEXT        NULL,
+    ADD result_submission_id BIGINT      NULL,
+    ADD attempts             INT         NOT NULL DEFAULT 0,
+    ADD next_attempt_at      BIGINT      NOT NULL DEFAULT 0,
+    ADD claimed_by           VARCHAR(64) NULL,
+    ADD claimed_until        BIGINT      NULL,
+    ADD updated_at           BIGINT      NOT NULL DEFAULT 0,
+    ADD CONSTRAINT fk_submission_processing_job_result FOREIGN KEY (result_submission_id) REFERENCES submission (id),
+    ADD INDEX idx_submission_processing_job_status (status, next_attempt_at);
+UPDATE submission_processing_job SET updated_at = created_at;
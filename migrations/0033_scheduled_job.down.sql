@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS scheduled_job_run;
+DROP TABLE IF EXISTS scheduled_job;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS scheduled_job
+(
+    name             VARCHAR(64) PRIMARY KEY,
+    schedule         VARCHAR(128) NOT NULL,
+    enabled          BOOL         NOT NULL DEFAULT TRUE,
+    next_run_at      BIGINT       NOT NULL DEFAULT 0,
+    run_requested_by BIGINT       NULL,
+    locked_by        VARCHAR(64)  NULL,
+    locked_until     BIGINT       NULL,
+    updated_at       BIGINT       NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS scheduled_job_run
+(
+    id           BIGINT PRIMARY KEY AUTO_INCREMENT,
+    job_name     VARCHAR(64) NOT NULL,
+    triggered_by BIGINT      NULL,
+    worker_id    VARCHAR(64) NOT NULL,
+    started_at   BIGINT      NOT NULL,
+    finished_at  BIGINT      NULL,
+    status       VARCHAR(16) NOT NULL,
+    message      TEXT        NULL,
+    log          MEDIUMTEXT  NULL,
+    FOREIGN KEY (job_name) REFERENCES scheduled_job (name) ON DELETE CASCADE,
+    INDEX idx_scheduled_job_run_started_at (job_name, started_at)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE comment
+    DROP FOREIGN KEY fk_comment_target_user,
+    DROP COLUMN fk_target_user_id;
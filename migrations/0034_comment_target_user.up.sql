@@ -0,0 +1,3 @@
+ALTER TABLE comment
+    ADD COLUMN fk_target_user_id BIGINT DEFAULT NULL,
+    ADD CONSTRAINT fk_comment_target_user FOREIGN KEY (fk_target_user_id) REFERENCES discord_user (id);
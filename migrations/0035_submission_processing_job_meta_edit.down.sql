@@ -0,0 +1,2 @@
+ALTER TABLE submission_processing_job
+    DROP COLUMN curation_meta_changes;
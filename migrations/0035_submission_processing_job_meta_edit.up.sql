@@ -0,0 +1,2 @@
+ALTER TABLE submission_processing_job
+    ADD COLUMN curation_meta_changes TEXT DEFAULT NULL;
@@ -38,6 +38,15 @@ func ConnectBot(l *logrus.Entry, token string) *discordgo.Session {
 	return dg
 }
 
+// Ping checks that discord accepts the bot token, notifications are not sent in dev mode so there is nothing to check
+func (b *bot) Ping() error {
+	if b.isDev {
+		return nil
+	}
+	_, err := b.session.User("@me")
+	return err
+}
+
 // SendNotification sends a message
 func (b *bot) SendNotification(msg, notificationType string) error {
 	if b.isDev {
@@ -2,4 +2,5 @@ package notificationbot
 
 type DiscordNotificationSender interface {
 	SendNotification(msg, notificationType string) error
+	Ping() error
 }
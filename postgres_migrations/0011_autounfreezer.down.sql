@@ -0,0 +1,2 @@
+DROP TABLE "autounfreezer_exemption";
+DROP TABLE "autounfreezer_policy";
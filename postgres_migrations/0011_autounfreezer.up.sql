@@ -0,0 +1,20 @@
+-- the autounfreezer is run and enabled by the scheduled job framework, the policy only holds its settings.
+-- legacy_list_imported_at is set once the legacy DO_NOT_UNFREEZE_GAME_LIST was imported, so exemptions removed later are not imported again
+CREATE TABLE "autounfreezer_policy"
+(
+    "id"                      integer PRIMARY KEY DEFAULT 1 CHECK ("id" = 1),
+    "age_threshold_days"      integer   NOT NULL DEFAULT 1095,
+    "legacy_list_imported_at" timestamp NULL,
+    "updated_by"              bigint    NULL,
+    "updated_at"              timestamp NOT NULL DEFAULT now()
+);
+
+INSERT INTO "autounfreezer_policy" ("id") VALUES (1);
+
+CREATE TABLE "autounfreezer_exemption"
+(
+    "game_id"    varchar(36) PRIMARY KEY,
+    "reason"     text      NOT NULL,
+    "created_by" bigint    NOT NULL,
+    "created_at" timestamp NOT NULL
+);
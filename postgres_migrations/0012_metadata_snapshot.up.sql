@@ -0,0 +1,14 @@
+CREATE TABLE "metadata_snapshot"
+(
+    "version"        bigserial PRIMARY KEY,
+    "format_version" integer     NOT NULL,
+    "snapshot_time"  timestamptz NOT NULL,
+    "created_at"     timestamptz NOT NULL,
+    "game_count"     bigint      NOT NULL,
+    "sqlite_key"     text        NOT NULL,
+    "sqlite_size"    bigint      NOT NULL,
+    "sqlite_sha256"  varchar(64) NOT NULL,
+    "json_key"       text        NOT NULL,
+    "json_size"      bigint      NOT NULL,
+    "json_sha256"    varchar(64) NOT NULL
+);
@@ -34,6 +34,18 @@ func New(path string) (*ResumableUploadService, error) {
 	return rsu, nil
 }
 
+// CheckWritable checks that chunks can be stored
+func (rsu *ResumableUploadService) CheckWritable() error {
+	f, err := os.CreateTemp(rsu.path, "readyz-*")
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(f.Name())
+}
+
 // Close stops what needs to be stopped
 func (rsu *ResumableUploadService) Close() {
 }
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/bodgit/sevenzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// maxArchiveNestingDepth limits how deep nested archives are unpacked, to protect against archive bombs
+const maxArchiveNestingDepth = 8
+
+// fileTypeSniffLength is how many leading bytes of a file are used to detect its type
+const fileTypeSniffLength = 512
+
+type remoteArchiveIndexer struct {
+	archiveIndexerServerURL string
+}
+
+// NewRemoteArchiveIndexer returns an indexer which delegates to the external recursive-archive-indexer service
+func NewRemoteArchiveIndexer(archiveIndexerServerURL string) *remoteArchiveIndexer {
+	return &remoteArchiveIndexer{
+		archiveIndexerServerURL: archiveIndexerServerURL,
+	}
+}
+
+func (r *remoteArchiveIndexer) IndexArchive(ctx context.Context, filePath string) ([]*types.IndexedFileEntry, uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/provide-path?path=%s", r.archiveIndexerServerURL, url.QueryEscape(filePath)), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	client := http.Client{Transport: tracing.NewTransport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	bytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Check the response
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("provide to remote error: %s", string(bytes))
+	}
+
+	var ir types.IndexerResp
+	err = json.Unmarshal(bytes, &ir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return ir.Files, ir.IndexingErrors, nil
+}
+
+// Ping checks that the indexer service responds
+func (r *remoteArchiveIndexer) Ping(ctx context.Context) error {
+	return pingURL(ctx, r.archiveIndexerServerURL)
+}
+
+type localArchiveIndexer struct {
+	tempDir string
+}
+
+// NewLocalArchiveIndexer returns an indexer which walks archives in-process, spooling nested archives into tempDir.
+// Empty tempDir means the system default.
+func NewLocalArchiveIndexer(tempDir string) *localArchiveIndexer {
+	return &localArchiveIndexer{
+		tempDir: tempDir,
+	}
+}
+
+// IndexArchive lists and hashes every regular file in the archive, descending into nested archives.
+// Broken nested archives and unreadable entries are counted as indexing errors instead of failing the whole run.
+func (l *localArchiveIndexer) IndexArchive(ctx context.Context, filePath string) (_ []*types.IndexedFileEntry, _ uint64, err error) {
+	_, span := tracing.Start(ctx, "localArchiveIndexer.IndexArchive")
+	defer func() { tracing.End(span, err) }()
+
+	if archiveFormat(filePath) == "" {
+		return nil, 0, fmt.Errorf("unsupported archive format: %s", filepath.Base(filePath))
+	}
+
+	files := make([]*types.IndexedFileEntry, 0)
+	var indexingErrors uint64
+
+	err = l.indexFile(filePath, "", 0, &files, &indexingErrors)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return files, indexingErrors, nil
+}
+
+func (l *localArchiveIndexer) indexFile(filePath, prefix string, depth int, files *[]*types.IndexedFileEntry, indexingErrors *uint64) error {
+	return walkArchiveFile(filePath, func(entry *archiveEntry, r io.Reader) error {
+		name := prefix + entry.Name
+		indexed, spooledPath, err := l.indexEntry(name, entry, r, depth)
+		if spooledPath != "" {
+			defer os.Remove(spooledPath)
+		}
+		if err != nil {
+			*indexingErrors++
+			return nil
+		}
+		*files = append(*files, indexed)
+
+		if spooledPath != "" {
+			if err := l.indexFile(spooledPath, name+"/", depth+1, files, indexingErrors); err != nil {
+				*indexingErrors++
+			}
+		}
+		return nil
+	})
+}
+
+// indexEntry hashes a single entry. Nested archives are also copied to a temporary file, whose path is returned.
+func (l *localArchiveIndexer) indexEntry(name string, entry *archiveEntry, r io.Reader, depth int) (*types.IndexedFileEntry, string, error) {
+	sha256sum := sha256.New()
+	md5sum := md5.New()
+	head := &headBuffer{limit: fileTypeSniffLength}
+	writers := []io.Writer{sha256sum, md5sum, head}
+
+	spooledPath := ""
+	if archiveFormat(name) != "" && depth < maxArchiveNestingDepth {
+		tmp, err := os.CreateTemp(l.tempDir, "fpfss-index-*"+archiveExtension(name))
+		if err != nil {
+			return nil, "", err
+		}
+		defer tmp.Close()
+		spooledPath = tmp.Name()
+		writers = append(writers, tmp)
+	}
+
+	size, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, spooledPath, err
+	}
+
+	sizeCompressed := entry.SizeCompressed
+	if sizeCompressed < 0 {
+		sizeCompressed = size
+	}
+
+	return &types.IndexedFileEntry{
+		Name:             name,
+		SizeCompressed:   sizeCompressed,
+		SizeUncompressed: size,
+		FileUtilOutput:   describeFileType(name, head.Bytes()),
+		SHA256:           hex.EncodeToString(sha256sum.Sum(nil)),
+		MD5:              hex.EncodeToString(md5sum.Sum(nil)),
+	}, spooledPath, nil
+}
+
+// headBuffer keeps only the first limit bytes written to it
+type headBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if remaining := h.limit - h.Len(); remaining > 0 {
+		if len(p) > remaining {
+			h.Buffer.Write(p[:remaining])
+		} else {
+			h.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// archiveEntry is a regular file found while walking an archive.
+// SizeCompressed is -1 when the format does not expose it per entry.
+type archiveEntry struct {
+	Name             string
+	SizeCompressed   int64
+	SizeUncompressed int64
+	Modified         time.Time
+	CRC32            string // hex, empty if the format doesn't store checksums
+}
+
+type archiveWalkFunc func(entry *archiveEntry, r io.Reader) error
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.zstd", ".tar", ".zip", ".7z"}
+
+// archiveExtension returns the archive extension of the filename (including compound ones like .tar.gz), or empty string
+func archiveExtension(filename string) string {
+	lower := strings.ToLower(filename)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// archiveFormat returns the container format the walker uses for the filename, or empty string if it's not supported
+func archiveFormat(filename string) string {
+	switch archiveExtension(filename) {
+	case ".zip":
+		return "zip"
+	case ".7z":
+		return "7z"
+	case ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar.zst", ".tar.zstd":
+		return "tar"
+	}
+	return ""
+}
+
+// walkArchiveFile calls fn for every regular file in the archive, in archive order.
+// The reader passed to fn is only valid until fn returns.
+func walkArchiveFile(filePath string, fn archiveWalkFunc) error {
+	if archiveFormat(filePath) == "" {
+		return fmt.Errorf("unsupported archive format: %s", filepath.Base(filePath))
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	return visitArchive(f, fi.Size(), filePath, func(entry *archiveEntry, open func() (io.ReadCloser, error)) error {
+		return walkOpenedEntry(entry, open, fn)
+	})
+}
+
+// archiveSource is an archive which doesn't have to be a local file, e.g. an opened storage object.
+// Zip and 7z entries are read at their offsets, tar archives are read sequentially.
+type archiveSource interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// archiveVisitFunc is called for every regular file in the archive. Entries are only read if open is called,
+// and the opened entry is only valid until the function returns.
+type archiveVisitFunc func(entry *archiveEntry, open func() (io.ReadCloser, error)) error
+
+// visitArchive calls fn for every regular file in the archive, in archive order. The format is detected from the filename.
+func visitArchive(r archiveSource, size int64, filename string, fn archiveVisitFunc) error {
+	switch archiveFormat(filename) {
+	case "zip":
+		return visitZip(r, size, fn)
+	case "7z":
+		return visit7z(r, size, fn)
+	case "tar":
+		return visitTar(r, archiveExtension(filename), fn)
+	}
+	return fmt.Errorf("unsupported archive format: %s", filepath.Base(filename))
+}
+
+func visitZip(r io.ReaderAt, size int64, fn archiveVisitFunc) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entry := &archiveEntry{
+			Name:             forceUTF8Compliant(f.Name),
+			SizeCompressed:   int64(f.CompressedSize64),
+			SizeUncompressed: int64(f.UncompressedSize64),
+			Modified:         f.Modified,
+			CRC32:            fmt.Sprintf("%08x", f.CRC32),
+		}
+		if err := fn(entry, f.Open); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func visit7z(r io.ReaderAt, size int64, fn archiveVisitFunc) error {
+	zr, err := sevenzip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		entry := &archiveEntry{
+			Name:             forceUTF8Compliant(f.Name),
+			SizeCompressed:   -1,
+			SizeUncompressed: int64(f.UncompressedSize),
+			Modified:         f.Modified,
+			CRC32:            fmt.Sprintf("%08x", f.CRC32),
+		}
+		if err := fn(entry, f.Open); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walkOpenedEntry opens a random-access entry and passes it to fn, reporting open failures to fn as an empty failing reader
+func walkOpenedEntry(entry *archiveEntry, open func() (io.ReadCloser, error), fn archiveWalkFunc) error {
+	rc, err := open()
+	if err != nil {
+		return fn(entry, &failingReader{err: err})
+	}
+	defer rc.Close()
+	return fn(entry, rc)
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read([]byte) (int, error) {
+	return 0, f.err
+}
+
+func visitTar(f io.Reader, ext string, fn archiveVisitFunc) error {
+	r := f
+	switch ext {
+	case ".tar.gz", ".tgz":
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	case ".tar.bz2":
+		r = bzip2.NewReader(f)
+	case ".tar.xz":
+		xr, err := xz.NewReader(f)
+		if err != nil {
+			return err
+		}
+		r = xr
+	case ".tar.zst", ".tar.zstd":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	open := func() (io.ReadCloser, error) {
+		return io.NopCloser(tr), nil
+	}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		entry := &archiveEntry{
+			Name:             forceUTF8Compliant(hdr.Name),
+			SizeCompressed:   hdr.Size,
+			SizeUncompressed: hdr.Size,
+			Modified:         hdr.ModTime,
+		}
+		if err := fn(entry, open); err != nil {
+			return err
+		}
+	}
+}
+
+// describeFileType gives a short human-readable description of a file, similar to what file(1) prints
+func describeFileType(name string, head []byte) string {
+	switch {
+	case len(head) >= 4 && (bytes.HasPrefix(head, []byte("FWS")) || bytes.HasPrefix(head, []byte("CWS")) || bytes.HasPrefix(head, []byte("ZWS"))):
+		compression := map[byte]string{'F': "uncompressed", 'C': "zlib compressed", 'Z': "LZMA compressed"}[head[0]]
+		return fmt.Sprintf("Macromedia Flash data (%s), version %d", compression, head[3])
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "PNG image data"
+	case bytes.HasPrefix(head, []byte("GIF87a")) || bytes.HasPrefix(head, []byte("GIF89a")):
+		return "GIF image data"
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return "JPEG image data"
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")) || bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return "Zip archive data"
+	case bytes.HasPrefix(head, []byte("7z\xbc\xaf\x27\x1c")):
+		return "7-zip archive data"
+	case bytes.HasPrefix(head, []byte("Rar!\x1a\x07")):
+		return "RAR archive data"
+	case bytes.HasPrefix(head, []byte("\x1f\x8b")):
+		return "gzip compressed data"
+	case bytes.HasPrefix(head, []byte("BZh")):
+		return "bzip2 compressed data"
+	case bytes.HasPrefix(head, []byte("\xfd7zXZ\x00")):
+		return "XZ compressed data"
+	case bytes.HasPrefix(head, []byte("\x28\xb5\x2f\xfd")):
+		return "Zstandard compressed data"
+	case len(head) > 262 && bytes.Equal(head[257:262], []byte("ustar")):
+		return "POSIX tar archive"
+	case bytes.HasPrefix(head, []byte("MZ")):
+		return "PE32 executable (MS-DOS header)"
+	case bytes.HasPrefix(head, []byte("\x7fELF")):
+		return "ELF executable"
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "PDF document"
+	case bytes.HasPrefix(head, []byte("FLV\x01")):
+		return "Macromedia Flash Video"
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "Audio file with ID3 tag"
+	case bytes.HasPrefix(head, []byte("IXAD")) || bytes.HasPrefix(head, []byte("XFIR")) || bytes.HasPrefix(head, []byte("RIFX")):
+		return "Macromedia Director data"
+	case len(head) == 0:
+		return "empty"
+	}
+
+	contentType := http.DetectContentType(head)
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return "HTML document"
+	case strings.HasPrefix(contentType, "text/xml"):
+		return "XML document"
+	case strings.HasPrefix(contentType, "text/plain"):
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".js":
+			return "JavaScript source, ASCII text"
+		case ".css":
+			return "CSS source, ASCII text"
+		case ".json":
+			return "JSON data"
+		}
+		return "ASCII text"
+	case contentType == "application/octet-stream":
+		return "data"
+	}
+	return contentType
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_archiveFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string
+	}{
+		{name: "zip", filename: "game.zip", want: "zip"},
+		{name: "7z", filename: "game.7z", want: "7z"},
+		{name: "plain tar", filename: "game.tar", want: "tar"},
+		{name: "gzipped tar", filename: "game.tar.gz", want: "tar"},
+		{name: "short gzipped tar", filename: "game.tgz", want: "tar"},
+		{name: "zstd tar", filename: "game.tar.zstd", want: "tar"},
+		{name: "extension is case insensitive", filename: "GAME.ZIP", want: "zip"},
+		{name: "swf is not an archive", filename: "game.swf", want: ""},
+		{name: "rar is not supported", filename: "game.rar", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archiveFormat(tt.filename); got != tt.want {
+				t.Errorf("archiveFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_describeFileType(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		head []byte
+		want string
+	}{
+		{name: "uncompressed swf", file: "a.swf", head: []byte("FWS\x0a...."), want: "Macromedia Flash data (uncompressed), version 10"},
+		{name: "zlib swf", file: "a.swf", head: []byte("CWS\x09...."), want: "Macromedia Flash data (zlib compressed), version 9"},
+		{name: "png", file: "a.png", head: []byte("\x89PNG\r\n\x1a\n...."), want: "PNG image data"},
+		{name: "zip", file: "a.zip", head: []byte("PK\x03\x04...."), want: "Zip archive data"},
+		{name: "empty", file: "a.txt", head: []byte{}, want: "empty"},
+		{name: "javascript by extension", file: "a.js", head: []byte("var a = 1;"), want: "JavaScript source, ASCII text"},
+		{name: "plain text", file: "readme", head: []byte("hello"), want: "ASCII text"},
+		{name: "html", file: "index.htm", head: []byte("<html><body></body></html>"), want: "HTML document"},
+		{name: "binary", file: "a.bin", head: []byte{0x00, 0x01, 0x02, 0x03}, want: "data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeFileType(tt.file, tt.head); got != tt.want {
+				t.Errorf("describeFileType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testArchiveFile struct {
+	name    string
+	content []byte
+}
+
+func writeTestZip(t *testing.T, files []testArchiveFile) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func writeTestTarGz(t *testing.T, files []testArchiveFile) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gw := gzip.NewWriter(&b)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func Test_localArchiveIndexer_IndexArchive(t *testing.T) {
+	swf := []byte("FWS\x0a some flash data")
+	text := []byte("hello")
+	nested := writeTestTarGz(t, []testArchiveFile{{name: "inner/readme.txt", content: text}})
+
+	type wantFile struct {
+		name     string
+		size     int64
+		content  []byte // hashed when set
+		fileType string
+	}
+
+	tests := []struct {
+		name               string
+		archive            string
+		files              []testArchiveFile
+		wantFiles          []wantFile
+		wantIndexingErrors uint64
+		wantErr            bool
+	}{
+		{
+			name:    "files are listed and hashed",
+			archive: "game.zip",
+			files:   []testArchiveFile{{name: "content/game.swf", content: swf}, {name: "readme.txt", content: text}},
+			wantFiles: []wantFile{
+				{name: "content/game.swf", size: int64(len(swf)), content: swf, fileType: "Macromedia Flash data (uncompressed), version 10"},
+				{name: "readme.txt", size: int64(len(text)), content: text, fileType: "ASCII text"},
+			},
+		},
+		{
+			name:    "nested archives are listed with their contents",
+			archive: "game.zip",
+			files:   []testArchiveFile{{name: "extras.tar.gz", content: nested}},
+			wantFiles: []wantFile{
+				{name: "extras.tar.gz", size: int64(len(nested)), content: nested, fileType: "gzip compressed data"},
+				{name: "extras.tar.gz/inner/readme.txt", size: int64(len(text)), content: text, fileType: "ASCII text"},
+			},
+		},
+		{
+			name:    "broken nested archive is an indexing error",
+			archive: "game.zip",
+			files:   []testArchiveFile{{name: "broken.zip", content: []byte("not a zip")}, {name: "readme.txt", content: text}},
+			wantFiles: []wantFile{
+				{name: "broken.zip", size: 9},
+				{name: "readme.txt", size: int64(len(text)), content: text},
+			},
+			wantIndexingErrors: 1,
+		},
+		{
+			name:    "unsupported format",
+			archive: "game.rar",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, tt.archive)
+			if err := os.WriteFile(archivePath, writeTestZip(t, tt.files), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			files, indexingErrors, err := NewLocalArchiveIndexer(dir).IndexArchive(context.Background(), archivePath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IndexArchive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if indexingErrors != tt.wantIndexingErrors {
+				t.Errorf("IndexArchive() indexingErrors = %d, want %d", indexingErrors, tt.wantIndexingErrors)
+			}
+			if len(files) != len(tt.wantFiles) {
+				t.Fatalf("IndexArchive() returned %d files, want %d", len(files), len(tt.wantFiles))
+			}
+			for i, want := range tt.wantFiles {
+				got := files[i]
+				if got.Name != want.name || got.SizeUncompressed != want.size {
+					t.Errorf("file %d = %s (%d bytes), want %s (%d bytes)", i, got.Name, got.SizeUncompressed, want.name, want.size)
+				}
+				if want.content != nil {
+					sha := sha256.Sum256(want.content)
+					md := md5.Sum(want.content)
+					if got.SHA256 != hex.EncodeToString(sha[:]) || got.MD5 != hex.EncodeToString(md[:]) {
+						t.Errorf("file %s has wrong checksums", got.Name)
+					}
+				}
+				if want.fileType != "" && got.FileUtilOutput != want.fileType {
+					t.Errorf("file %s type = %s, want %s", got.Name, got.FileUtilOutput, want.fileType)
+				}
+			}
+
+			// the spooled nested archives are removed
+			if leftovers, _ := filepath.Glob(filepath.Join(dir, "fpfss-index-*")); len(leftovers) > 0 {
+				t.Errorf("temporary files left behind: %v", leftovers)
+			}
+		})
+	}
+}
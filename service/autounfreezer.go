@@ -2,92 +2,334 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/activityevents"
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
-	"github.com/sirupsen/logrus"
-	"golang.org/x/exp/slices"
-	"sync"
-	"time"
+	"github.com/jackc/pgx/v5"
 )
 
-func (s *SiteService) RunAutounfreezer(logger *logrus.Entry, ctx context.Context, wg *sync.WaitGroup, dataPacksPath, frozenPacksPath, deletedPacksPath string, doNotUnfreezeGameList []string) {
-	defer wg.Done()
-	l := logger.WithField("serviceName", "autounfreezer")
-	defer l.Info("autounfreezer stopped")
-
-	ticker := time.NewTicker(time.Hour * 12)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			l.Info("context cancelled, stopping autounfreezer")
-			return
-		case <-ticker.C:
-
-			loop := func() {
-				ctx := context.WithValue(ctx, utils.CtxKeys.Log, l)
-
-				dbs, err := s.pgdal.NewSession(ctx)
-				if err != nil {
-					l.Error(err)
-					return
-				}
-				defer func() {
-					err = dbs.Rollback()
-					if err != nil {
-						l.Error(err)
-					}
-				}()
-
-				games, err := s.pgdal.GetFrozenGames(dbs)
-				if err != nil {
-					l.Error(err)
-					return
-				}
-
-				ageThreshold := time.Now().Add(-time.Hour * 24 * 365 * 3)
-
-				for _, game := range games {
-					if slices.Contains(doNotUnfreezeGameList, game.GameID) {
-						l.Infof("game %s with release date '%s' is on the do-not-unfreeze list and will be skipped", game.GameID, game.ReleaseDate)
-						continue
-					}
-
-					releaseTime, err := parseDate(game.ReleaseDate)
-					if err != nil {
-						l.Errorf("game %s has unexpected release date format '%s' and will be skipped", game.GameID, game.ReleaseDate)
-						continue
-					}
-
-					if releaseTime.Before(ageThreshold) {
-						l.Infof("game %s with release date '%s' will be unfrozen", game.GameID, game.ReleaseDate)
-						err = s.UnfreezeGame(ctx, game.GameID, constants.SystemID, dataPacksPath, frozenPacksPath, deletedPacksPath)
-						if err != nil {
-							l.Error(err)
-							return
-						}
-					}
-				}
-			}
+// ImportAutounfreezerExemptions adds the games from the legacy do-not-unfreeze list as exemptions, the existing exemptions are kept.
+// The list is imported only once, so the exemptions removed on /web/internal afterwards stay removed.
+func (s *SiteService) ImportAutounfreezerExemptions(ctx context.Context, gameIDs []string) error {
+	if len(gameIDs) == 0 {
+		return nil
+	}
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	claimed, err := s.pgdal.ClaimAutounfreezerLegacyImport(dbs, s.clock.Now())
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if !claimed {
+		return nil
+	}
+
+	existing, err := s.pgdal.GetAutounfreezerExemptions(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	exempted := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		exempted[e.GameID] = true
+	}
+
+	imported := 0
+	for _, gameID := range gameIDs {
+		if exempted[gameID] {
+			continue
+		}
+		exemption := &types.AutounfreezerExemption{
+			GameID:    gameID,
+			Reason:    "imported from DO_NOT_UNFREEZE_GAME_LIST",
+			CreatedBy: constants.SystemID,
+			CreatedAt: s.clock.Now(),
+		}
+		if err := s.pgdal.AddAutounfreezerExemption(dbs, exemption); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return dberr(err)
+		}
+		exempted[gameID] = true
+		imported++
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if imported > 0 {
+		utils.LogCtx(ctx).WithField("count", imported).Info("imported the do-not-unfreeze list into the autounfreezer exemptions")
+	}
+
+	return nil
+}
+
+// planAutounfreeze sorts the frozen games into the ones the policy unfreezes, the exempted ones and the ones with an unknown release date
+func (s *SiteService) planAutounfreeze(dbs database.PGDBSession, policy *types.AutounfreezerPolicy) (*types.AutounfreezeReport, error) {
+	games, err := s.pgdal.GetFrozenGames(dbs)
+	if err != nil {
+		return nil, err
+	}
 
-			loop()
+	exemptions, err := s.pgdal.GetAutounfreezerExemptions(dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	exempted := make(map[string]bool, len(exemptions))
+	for _, e := range exemptions {
+		exempted[e.GameID] = true
+	}
+
+	now := s.clock.Now()
+	report := &types.AutounfreezeReport{
+		StartedAt:      now,
+		ReleasedBefore: now.AddDate(0, 0, -int(policy.AgeThresholdDays)),
+		Unfrozen:       make([]*types.AutounfreezerGame, 0),
+		Exempted:       make([]*types.AutounfreezerGame, 0),
+		InvalidDate:    make([]*types.AutounfreezerGame, 0),
+		Failed:         make([]*types.AutounfreezerFailure, 0),
+	}
+
+	for _, game := range games {
+		releaseTime, err := parseDate(game.ReleaseDate)
+		if err != nil {
+			report.InvalidDate = append(report.InvalidDate, game)
+			continue
+		}
+
+		if !releaseTime.Before(report.ReleasedBefore) {
+			continue
+		}
+
+		if exempted[game.GameID] {
+			report.Exempted = append(report.Exempted, game)
+			continue
 		}
+
+		report.Unfrozen = append(report.Unfrozen, game)
 	}
+
+	return report, nil
+}
+
+// PreviewAutounfreeze reports what the next run of the autounfreezer would do, without unfreezing anything
+func (s *SiteService) PreviewAutounfreeze(ctx context.Context) (*types.AutounfreezeReport, error) {
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	policy, err := s.pgdal.GetAutounfreezerPolicy(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	report, err := s.planAutounfreeze(dbs, policy)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	report.DryRun = true
+
+	return report, nil
+}
+
+// Autounfreeze unfreezes the games the policy allows and records the run in the activity log.
+// Games which fail to unfreeze are listed in the report and tried again on the next run.
+// It's run by the autounfreezer job, which keeps it from running more than once at a time.
+func (s *SiteService) Autounfreeze(ctx context.Context) (*types.AutounfreezeReport, error) {
+	report, err := s.PreviewAutounfreeze(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = false
+
+	for _, game := range report.InvalidDate {
+		utils.LogCtx(ctx).Warnf("game %s has unexpected release date format '%s' and will be skipped", game.GameID, game.ReleaseDate)
+	}
+
+	unfrozen := make([]*types.AutounfreezerGame, 0, len(report.Unfrozen))
+	for _, game := range report.Unfrozen {
+		if ctx.Err() != nil {
+			break
+		}
+		utils.LogCtx(ctx).Infof("game %s with release date '%s' will be unfrozen", game.GameID, game.ReleaseDate)
+		if err := s.UnfreezeGame(ctx, game.GameID, constants.SystemID); err != nil {
+			utils.LogCtx(ctx).WithField("gameID", game.GameID).Error(err)
+			report.Failed = append(report.Failed, &types.AutounfreezerFailure{GameID: game.GameID, Error: err.Error()})
+			continue
+		}
+		unfrozen = append(unfrozen, game)
+	}
+	report.Unfrozen = unfrozen
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	event := activityevents.BuildAutounfreezeEvent(constants.SystemID, report.ReleasedBefore,
+		autounfreezerGameIDs(report.Unfrozen), autounfreezerGameIDs(report.Exempted), autounfreezerFailureIDs(report.Failed))
+	if err := s.pgdal.CreateActivityEvent(dbs, event); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	utils.LogCtx(ctx).
+		WithField("unfrozen", len(report.Unfrozen)).
+		WithField("exempted", len(report.Exempted)).
+		WithField("failed", len(report.Failed)).
+		Info("autounfreezer finished")
+
+	return report, nil
+}
+
+func autounfreezerGameIDs(games []*types.AutounfreezerGame) []string {
+	result := make([]string, 0, len(games))
+	for _, g := range games {
+		result = append(result, g.GameID)
+	}
+	return result
+}
+
+func autounfreezerFailureIDs(failures []*types.AutounfreezerFailure) []string {
+	result := make([]string, 0, len(failures))
+	for _, f := range failures {
+		result = append(result, f.GameID)
+	}
+	return result
+}
+
+// UpdateAutounfreezerPolicy updates the policy, it applies from the next run of the autounfreezer
+func (s *SiteService) UpdateAutounfreezerPolicy(ctx context.Context, ageThresholdDays int64) error {
+	if ageThresholdDays < 0 {
+		return perr("age threshold must not be negative", http.StatusBadRequest)
+	}
+
+	uid := utils.UserID(ctx)
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	policy := &types.AutounfreezerPolicy{
+		AgeThresholdDays: ageThresholdDays,
+		UpdatedBy:        &uid,
+		UpdatedAt:        s.clock.Now(),
+	}
+	if err := s.pgdal.UpdateAutounfreezerPolicy(dbs, policy); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("ageThresholdDays", ageThresholdDays).Info("autounfreezer policy updated")
+
+	return nil
+}
+
+// AddAutounfreezerExemption exempts the game from the autounfreezer, or updates the reason if it's already exempted
+func (s *SiteService) AddAutounfreezerExemption(ctx context.Context, gameID, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return perr("reason is required", http.StatusBadRequest)
+	}
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if _, err := s.pgdal.GetGame(dbs, gameID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return perr("game not found", http.StatusNotFound)
+		}
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	exemption := &types.AutounfreezerExemption{
+		GameID:    gameID,
+		Reason:    reason,
+		CreatedBy: utils.UserID(ctx),
+		CreatedAt: s.clock.Now(),
+	}
+	if err := s.pgdal.AddAutounfreezerExemption(dbs, exemption); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+func (s *SiteService) RemoveAutounfreezerExemption(ctx context.Context, gameID string) error {
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.pgdal.RemoveAutounfreezerExemption(dbs, gameID); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
 }
 
 // parseDate parses date string to up to day resolution.
 // If the day is missing, then it is assumed it is the last day of the month.
 // If the month is also missing, then it is assumed it is the last day of the year.
 func parseDate(dateStr string) (time.Time, error) {
-	var layouts = []string{
-		"2006",       // yyyy
-		"2006-01",    // yyyy-mm
-		"2006-01-02", // yyyy-mm-dd
-	}
-
-	for _, layout := range layouts {
+	for _, layout := range releaseDateFormats {
 		if t, err := time.Parse(layout, dateStr); err == nil {
 			switch layout {
 			case "2006": // Move to the last day of the year
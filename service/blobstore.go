@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+)
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// BlobStore keeps files addressed by their SHA256 checksum, so identical uploads are stored only once.
+// Blobs live under <first two characters of the checksum>/<checksum><original extension>,
+// the extension is kept because the validator and the archive indexer detect formats by it.
+type BlobStore struct {
+	st storage.Storage
+}
+
+func NewBlobStore(st storage.Storage) *BlobStore {
+	return &BlobStore{
+		st: st,
+	}
+}
+
+// Storage returns the storage the blobs are kept in
+func (b *BlobStore) Storage() storage.Storage {
+	return b.st
+}
+
+// Find returns the key of the blob with given checksum, if it exists
+func (b *BlobStore) Find(ctx context.Context, sha256sum string) (string, bool, error) {
+	if !sha256Regexp.MatchString(sha256sum) {
+		return "", false, nil
+	}
+	var key string
+	err := b.st.Walk(ctx, path.Join(sha256sum[:2], sha256sum), func(info *storage.ObjectInfo) error {
+		if key == "" {
+			key = info.Key
+		}
+		return nil
+	})
+	if err != nil {
+		return "", false, err
+	}
+	return key, key != "", nil
+}
+
+// Ingest moves the local file into the store and returns the blob key.
+// If the blob already exists, the file is removed instead and the existing blob key is returned.
+func (b *BlobStore) Ingest(ctx context.Context, filePath, sha256sum string) (string, error) {
+	if !sha256Regexp.MatchString(sha256sum) {
+		return "", fmt.Errorf("invalid sha256 checksum '%s'", sha256sum)
+	}
+
+	existing, ok, err := b.Find(ctx, sha256sum)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		if err := os.Remove(filePath); err != nil {
+			return "", err
+		}
+		return existing, nil
+	}
+
+	ext := archiveExtension(filePath)
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(filePath))
+	}
+
+	key := path.Join(sha256sum[:2], sha256sum+ext)
+	if err := b.st.Import(ctx, key, filePath); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// Remove deletes the blob with given checksum, it is not an error if the blob doesn't exist
+func (b *BlobStore) Remove(ctx context.Context, sha256sum string) error {
+	key, ok, err := b.Find(ctx, sha256sum)
+	if err != nil || !ok {
+		return err
+	}
+	return b.st.Remove(ctx, key)
+}
+
+// Walk calls fn for every blob in the store
+func (b *BlobStore) Walk(ctx context.Context, fn func(sha256sum, key string, modTime time.Time) error) error {
+	return b.st.Walk(ctx, "", func(info *storage.ObjectInfo) error {
+		name := path.Base(info.Key)
+		if len(name) < 64 || !sha256Regexp.MatchString(name[:64]) {
+			return nil
+		}
+		return fn(name[:64], info.Key, info.ModTime)
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/workflow"
 )
 
 func Test_isActionValidForSubmission(t *testing.T) {
@@ -304,7 +305,7 @@ func Test_isActionValidForSubmission(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := isActionValidForSubmission(tt.args.uid, tt.args.formAction, tt.args.submission); (err != nil) != tt.wantErr {
+			if err := isActionValidForSubmission(workflow.Default(), tt.args.uid, tt.args.formAction, tt.args.submission); (err != nil) != tt.wantErr {
 				t.Errorf("isActionValidForSubmission() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -12,8 +12,18 @@ import (
 type Validator interface {
 	Validate(ctx context.Context, file io.Reader, filename string) (*types.ValidatorResponse, error)
 	GetTags(ctx context.Context) ([]types.Tag, error)
-	ProvideArchiveForValidation(filePath string) (*types.ValidatorResponse, error)
-	ProvideArchiveForRepacking(filePath string) (*types.ValidatorRepackResponse, error)
+	ProvideArchiveForValidation(ctx context.Context, filePath string) (*types.ValidatorResponse, error)
+	ProvideArchiveForRepacking(ctx context.Context, filePath string) (*types.ValidatorRepackResponse, error)
+}
+
+// ArchiveIndexer lists every file inside an archive, including files inside nested archives
+type ArchiveIndexer interface {
+	IndexArchive(ctx context.Context, filePath string) ([]*types.IndexedFileEntry, uint64, error)
+}
+
+// Pinger is implemented by the remote services which can be checked for readiness
+type Pinger interface {
+	Ping(ctx context.Context) error
 }
 
 type MultipartFileProvider interface {
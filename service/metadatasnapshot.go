@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// metadataSnapshotsKept is how many snapshots are kept, so that a launcher can still download the one it just
+// looked up while a newer one is exported. The older snapshots are deleted with their files.
+const metadataSnapshotsKept = 3
+
+var errMetadataSnapshotsDisabled = perr("metadata snapshots are not configured", http.StatusNotFound)
+
+// ExportMetadataSnapshot exports all the metadata into a new snapshot and deletes the old snapshots
+func (s *SiteService) ExportMetadataSnapshot(ctx context.Context) (*types.MetadataSnapshot, error) {
+	st := s.volumes.MetadataSnapshots
+	if st == nil {
+		return nil, errMetadataSnapshotsDisabled
+	}
+
+	dir, err := os.MkdirTemp("", "metadata-snapshot-")
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	createdAt := s.clock.Now().UTC()
+	name := fmt.Sprintf("flashpoint-metadata-%s", createdAt.Format("20060102-150405"))
+	sqlitePath := filepath.Join(dir, name+".sqlite")
+	jsonPath := filepath.Join(dir, name+".json.gz")
+
+	timestamp, gameCount, err := s.exportMetadataSnapshotFiles(ctx, sqlitePath, jsonPath)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &types.MetadataSnapshot{
+		FormatVersion: metadataSnapshotFormatVersion,
+		Timestamp:     timestamp,
+		CreatedAt:     createdAt,
+		GameCount:     gameCount,
+	}
+	snapshot.SQLite, err = storeMetadataSnapshotFile(ctx, st, sqlitePath)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, err
+	}
+	snapshot.JSON, err = storeMetadataSnapshotFile(ctx, st, jsonPath)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		removeMetadataSnapshotFiles(ctx, st, snapshot)
+		return nil, err
+	}
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		removeMetadataSnapshotFiles(ctx, st, snapshot)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	snapshot.Version, err = s.pgdal.AddMetadataSnapshot(dbs, snapshot)
+	if err == nil {
+		err = dbs.Commit()
+	}
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		removeMetadataSnapshotFiles(ctx, st, snapshot)
+		return nil, dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("version", snapshot.Version).WithField("games", gameCount).
+		WithField("sqliteSize", snapshot.SQLite.Size).WithField("jsonSize", snapshot.JSON.Size).
+		Info("metadata snapshot exported")
+
+	if err := s.deleteOldMetadataSnapshots(ctx); err != nil {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+// exportMetadataSnapshotFiles writes the snapshot files from one consistent read of the database,
+// and returns the time they are up to date with and the count of games
+func (s *SiteService) exportMetadataSnapshotFiles(ctx context.Context, sqlitePath, jsonPath string) (time.Time, int64, error) {
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return time.Time{}, 0, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	timestamp, err := s.pgdal.BeginMetadataSnapshot(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return time.Time{}, 0, dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("timestamp", timestamp).Info("exporting the metadata snapshot")
+	gameCount, err := exportMetadataSnapshot(s.pgdal, dbs, timestamp, sqlitePath, jsonPath)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return time.Time{}, 0, err
+	}
+	return timestamp, gameCount, nil
+}
+
+// storeMetadataSnapshotFile moves the exported file into the storage, its name is the key
+func storeMetadataSnapshotFile(ctx context.Context, st storage.Storage, filePath string) (*types.MetadataSnapshotFile, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &types.MetadataSnapshotFile{
+		Key:    filepath.Base(filePath),
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	}
+	if err := st.Import(ctx, file.Key, filePath); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func removeMetadataSnapshotFiles(ctx context.Context, st storage.Storage, snapshot *types.MetadataSnapshot) {
+	for _, file := range []*types.MetadataSnapshotFile{snapshot.SQLite, snapshot.JSON} {
+		if file == nil {
+			continue
+		}
+		if err := st.Remove(ctx, file.Key); err != nil {
+			utils.LogCtx(ctx).WithField("key", file.Key).Error(err)
+		}
+	}
+}
+
+func (s *SiteService) deleteOldMetadataSnapshots(ctx context.Context) error {
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	snapshots, err := s.pgdal.GetMetadataSnapshots(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if len(snapshots) <= metadataSnapshotsKept {
+		return nil
+	}
+
+	for _, snapshot := range snapshots[metadataSnapshotsKept:] {
+		if err := s.pgdal.DeleteMetadataSnapshot(dbs, snapshot.Version); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return dberr(err)
+		}
+	}
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	// the files go after the rows, so a listed snapshot always has its files
+	for _, snapshot := range snapshots[metadataSnapshotsKept:] {
+		utils.LogCtx(ctx).WithField("version", snapshot.Version).Info("deleting old metadata snapshot")
+		removeMetadataSnapshotFiles(ctx, s.volumes.MetadataSnapshots, snapshot)
+	}
+	return nil
+}
+
+// GetMetadataSnapshot returns the snapshot of the version, or the latest one if version is nil
+func (s *SiteService) GetMetadataSnapshot(ctx context.Context, version *int64) (*types.MetadataSnapshot, error) {
+	if s.volumes.MetadataSnapshots == nil {
+		return nil, errMetadataSnapshotsDisabled
+	}
+
+	dbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	var snapshot *types.MetadataSnapshot
+	if version == nil {
+		snapshot, err = s.pgdal.GetLatestMetadataSnapshot(dbs)
+	} else {
+		snapshot, err = s.pgdal.GetMetadataSnapshot(dbs, *version)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, perr("metadata snapshot not found", http.StatusNotFound)
+		}
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/sqlitefile"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+// metadataSnapshotFormatVersion is the user_version of the SQLite file and the format_version of the JSON,
+// it has to change with the schema of either
+const metadataSnapshotFormatVersion = 1
+
+// metadataSnapshotTimeFormat is how the SQLite file stores dates, the way the API returns them
+const metadataSnapshotTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// The schema of the SQLite file, the tables and columns are named like the launcher's. Ids which are integers
+// in Postgres are INTEGER PRIMARY KEYs, the ids of the aliases are assigned by the export.
+const (
+	snapshotSchemaGame = `CREATE TABLE "game" ("id" varchar PRIMARY KEY NOT NULL, "parentGameId" varchar, "title" varchar NOT NULL, ` +
+		`"alternateTitles" varchar NOT NULL, "series" varchar NOT NULL, "developer" varchar NOT NULL, "publisher" varchar NOT NULL, ` +
+		`"platformName" varchar NOT NULL, "platformsStr" varchar NOT NULL, "dateAdded" datetime NOT NULL, "dateModified" datetime NOT NULL, ` +
+		`"playMode" varchar NOT NULL, "status" varchar NOT NULL, "notes" varchar NOT NULL, "tagsStr" varchar NOT NULL, ` +
+		`"source" varchar NOT NULL, "applicationPath" varchar NOT NULL, "launchCommand" varchar NOT NULL, "releaseDate" varchar NOT NULL, ` +
+		`"version" varchar NOT NULL, "originalDescription" varchar NOT NULL, "language" varchar NOT NULL, "library" varchar NOT NULL, ` +
+		`"activeDataId" integer, "archiveState" integer NOT NULL, "ruffleSupport" varchar NOT NULL)`
+	snapshotSchemaAdditionalApp = `CREATE TABLE "additional_app" ("id" integer PRIMARY KEY NOT NULL, "applicationPath" varchar NOT NULL, ` +
+		`"autoRunBefore" boolean NOT NULL, "launchCommand" varchar NOT NULL, "name" varchar NOT NULL, "waitForExit" boolean NOT NULL, ` +
+		`"parentGameId" varchar NOT NULL)`
+	snapshotSchemaAdditionalAppIndex = `CREATE INDEX "IDX_additional_app_parentGameId" ON "additional_app" ("parentGameId")`
+	snapshotSchemaGameData           = `CREATE TABLE "game_data" ("id" integer PRIMARY KEY NOT NULL, "gameId" varchar NOT NULL, "title" varchar NOT NULL, ` +
+		`"dateAdded" datetime NOT NULL, "sha256" varchar NOT NULL, "crc32" integer NOT NULL, "size" integer NOT NULL, ` +
+		`"parameters" varchar, "applicationPath" varchar NOT NULL, "launchCommand" varchar NOT NULL)`
+	snapshotSchemaGameDataIndex = `CREATE INDEX "IDX_game_data_gameId" ON "game_data" ("gameId")`
+	snapshotSchemaTagCategory   = `CREATE TABLE "tag_category" ("id" integer PRIMARY KEY NOT NULL, "name" varchar NOT NULL, ` +
+		`"color" varchar NOT NULL, "description" varchar NOT NULL)`
+	snapshotSchemaTag = `CREATE TABLE "tag" ("id" integer PRIMARY KEY NOT NULL, "dateModified" datetime NOT NULL, "primaryAliasId" integer, ` +
+		`"categoryId" integer NOT NULL, "description" varchar NOT NULL)`
+	snapshotSchemaTagAlias      = `CREATE TABLE "tag_alias" ("id" integer PRIMARY KEY NOT NULL, "tagId" integer NOT NULL, "name" varchar NOT NULL UNIQUE)`
+	snapshotSchemaTagAliasIndex = `CREATE INDEX "IDX_tag_alias_tagId" ON "tag_alias" ("tagId")`
+	snapshotSchemaPlatform      = `CREATE TABLE "platform" ("id" integer PRIMARY KEY NOT NULL, "dateModified" datetime NOT NULL, ` +
+		`"primaryAliasId" integer, "description" varchar NOT NULL)`
+	snapshotSchemaPlatformAlias      = `CREATE TABLE "platform_alias" ("id" integer PRIMARY KEY NOT NULL, "platformId" integer NOT NULL, "name" varchar NOT NULL UNIQUE)`
+	snapshotSchemaPlatformAliasIndex = `CREATE INDEX "IDX_platform_alias_platformId" ON "platform_alias" ("platformId")`
+	snapshotSchemaGameTags           = `CREATE TABLE "game_tags_tag" ("gameId" varchar NOT NULL, "tagId" integer NOT NULL, PRIMARY KEY ("gameId", "tagId"))`
+	snapshotSchemaGameTagsIndex      = `CREATE INDEX "IDX_game_tags_tag_tagId" ON "game_tags_tag" ("tagId")`
+	snapshotSchemaGamePlatforms      = `CREATE TABLE "game_platforms_platform" ("gameId" varchar NOT NULL, "platformId" integer NOT NULL, PRIMARY KEY ("gameId", "platformId"))`
+	snapshotSchemaGamePlatformsIndex = `CREATE INDEX "IDX_game_platforms_platform_platformId" ON "game_platforms_platform" ("platformId")`
+	snapshotSchemaGameRedirect       = `CREATE TABLE "game_redirect" ("sourceId" varchar NOT NULL, "id" varchar NOT NULL, "dateAdded" datetime NOT NULL, PRIMARY KEY ("sourceId", "id"))`
+	snapshotSchemaSnapshot           = `CREATE TABLE "metadata_snapshot" ("formatVersion" integer NOT NULL, "timestamp" datetime NOT NULL)`
+)
+
+func formatSnapshotTime(t time.Time) string {
+	return t.UTC().Format(metadataSnapshotTimeFormat)
+}
+
+// snapshotTextKey and snapshotIntKey are index entries collected during the export, for the indexes
+// whose order differs from the order of the rows
+type snapshotTextKey struct {
+	value string
+	rowid int64
+}
+
+type snapshotIntKey struct {
+	value int64
+	rowid int64
+}
+
+func insertSnapshotTextKeys(index *sqlitefile.Index, keys []snapshotTextKey) error {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].value != keys[j].value {
+			return keys[i].value < keys[j].value
+		}
+		return keys[i].rowid < keys[j].rowid
+	})
+	for _, k := range keys {
+		if err := index.Insert(k.value, k.rowid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertSnapshotIntKeys(index *sqlitefile.Index, keys []snapshotIntKey) error {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].value != keys[j].value {
+			return keys[i].value < keys[j].value
+		}
+		return keys[i].rowid < keys[j].rowid
+	})
+	for _, k := range keys {
+		if err := index.Insert(k.value, k.rowid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotJSON writes the gzipped JSON, in the format of the launcher dumps imported by DeveloperImportDatabaseJson
+type snapshotJSON struct {
+	f  *os.File
+	gz *gzip.Writer
+	w  *bufio.Writer
+	// elements is the count of elements written into the open array
+	elements int
+	err      error
+	closed   bool
+}
+
+func createSnapshotJSON(path string) (*snapshotJSON, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	return &snapshotJSON{f: f, gz: gz, w: bufio.NewWriterSize(gz, 1<<16)}, nil
+}
+
+func (j *snapshotJSON) write(s string) {
+	if j.err == nil {
+		_, j.err = j.w.WriteString(s)
+	}
+}
+
+// openArray writes what precedes the next array, which ends with the opening bracket
+func (j *snapshotJSON) openArray(s string) {
+	j.write(s)
+	j.elements = 0
+}
+
+func (j *snapshotJSON) element(v interface{}) error {
+	if j.err != nil {
+		return j.err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		j.err = err
+		return err
+	}
+	if j.elements > 0 {
+		j.write(",")
+	}
+	j.write(string(b))
+	j.elements++
+	return j.err
+}
+
+func (j *snapshotJSON) close() error {
+	if j.closed {
+		return j.err
+	}
+	j.closed = true
+	if j.err == nil {
+		j.err = j.w.Flush()
+	}
+	if err := j.gz.Close(); j.err == nil {
+		j.err = err
+	}
+	if err := j.f.Close(); j.err == nil {
+		j.err = err
+	}
+	return j.err
+}
+
+// metadataExport writes the snapshot files from one read of the database
+type metadataExport struct {
+	pgdal     database.PGDAL
+	dbs       database.PGDBSession
+	timestamp time.Time
+	db        *sqlitefile.Writer
+	json      *snapshotJSON
+	gameCount int64
+}
+
+// exportMetadataSnapshot writes the SQLite file and the gzipped JSON, the session has to be in a snapshot
+// started by BeginMetadataSnapshot. Returns the count of exported games.
+func exportMetadataSnapshot(pgdal database.PGDAL, dbs database.PGDBSession, timestamp time.Time, sqlitePath, jsonPath string) (int64, error) {
+	db, err := sqlitefile.Create(sqlitePath)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Abort()
+
+	j, err := createSnapshotJSON(jsonPath)
+	if err != nil {
+		return 0, err
+	}
+	defer j.close()
+
+	e := &metadataExport{pgdal: pgdal, dbs: dbs, timestamp: timestamp, db: db, json: j}
+	if err := e.run(); err != nil {
+		return 0, err
+	}
+	if err := db.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish the sqlite file: %w", err)
+	}
+	if err := j.close(); err != nil {
+		return 0, fmt.Errorf("failed to finish the json file: %w", err)
+	}
+	return e.gameCount, nil
+}
+
+func (e *metadataExport) run() error {
+	// the small tables are read first, they are needed to resolve the primary aliases
+	categories, err := e.pgdal.GetTagCategories(e.dbs)
+	if err != nil {
+		return err
+	}
+	tags, err := e.pgdal.ExportTags(e.dbs)
+	if err != nil {
+		return err
+	}
+	tagAliases, err := e.pgdal.ExportTagAliases(e.dbs)
+	if err != nil {
+		return err
+	}
+	platforms, err := e.pgdal.ExportPlatforms(e.dbs)
+	if err != nil {
+		return err
+	}
+	platformAliases, err := e.pgdal.ExportPlatformAliases(e.dbs)
+	if err != nil {
+		return err
+	}
+	redirects, err := e.pgdal.GetGameRedirects(e.dbs)
+	if err != nil {
+		return err
+	}
+
+	e.db.SetUserVersion(metadataSnapshotFormatVersion)
+	timestamp, err := json.Marshal(e.timestamp)
+	if err != nil {
+		return err
+	}
+	e.json.write(fmt.Sprintf(`{"format_version":%d,"timestamp":%s,`, metadataSnapshotFormatVersion, timestamp))
+
+	if err := e.games(); err != nil {
+		return err
+	}
+	if err := e.tags(categories, tags, tagAliases); err != nil {
+		return err
+	}
+	if err := e.platforms(platforms, platformAliases); err != nil {
+		return err
+	}
+	if err := e.relations(); err != nil {
+		return err
+	}
+	if err := e.redirects(redirects); err != nil {
+		return err
+	}
+	e.json.write("}")
+
+	snapshot := e.db.CreateTable("metadata_snapshot", snapshotSchemaSnapshot)
+	return snapshot.Insert(1, int64(metadataSnapshotFormatVersion), formatSnapshotTime(e.timestamp))
+}
+
+func (e *metadataExport) games() error {
+	gameTable := e.db.CreateTable("game", snapshotSchemaGame)
+	gameIndex := e.db.CreateIndex("sqlite_autoindex_game_1", "game", "")
+
+	var rowid int64
+	e.json.openArray(`"games":{"games":[`)
+	err := e.pgdal.ExportGames(e.dbs, func(g *types.Game) error {
+		rowid++
+		var activeDataID interface{}
+		if g.ActiveDataID != nil {
+			activeDataID = *g.ActiveDataID
+		}
+		err := gameTable.Insert(rowid, g.ID, g.ParentGameID, g.Title, g.AlternateTitles, g.Series, g.Developer, g.Publisher,
+			g.PrimaryPlatform, g.PlatformsStr, formatSnapshotTime(g.DateAdded), formatSnapshotTime(g.DateModified),
+			g.PlayMode, g.Status, g.Notes, g.TagsStr, g.Source, g.ApplicationPath, g.LaunchCommand, g.ReleaseDate,
+			g.Version, g.OriginalDesc, g.Language, g.Library, activeDataID, int64(g.ArchiveState), g.RuffleSupport)
+		if err != nil {
+			return err
+		}
+		if err := gameIndex.Insert(g.ID, rowid); err != nil {
+			return err
+		}
+		return e.json.element(&types.GameDump{
+			ID:              g.ID,
+			ParentGameID:    g.ParentGameID,
+			Title:           g.Title,
+			AlternateTitles: g.AlternateTitles,
+			Series:          g.Series,
+			Developer:       g.Developer,
+			Publisher:       g.Publisher,
+			PrimaryPlatform: g.PrimaryPlatform,
+			PlatformsStr:    g.PlatformsStr,
+			DateAdded:       g.DateAdded,
+			DateModified:    g.DateModified,
+			PlayMode:        g.PlayMode,
+			Status:          g.Status,
+			Notes:           g.Notes,
+			TagsStr:         g.TagsStr,
+			Source:          g.Source,
+			ApplicationPath: g.ApplicationPath,
+			LaunchCommand:   g.LaunchCommand,
+			ReleaseDate:     g.ReleaseDate,
+			Version:         g.Version,
+			OriginalDesc:    g.OriginalDesc,
+			Language:        g.Language,
+			Library:         g.Library,
+			ActiveDataID:    g.ActiveDataID,
+			ArchiveState:    g.ArchiveState,
+			RuffleSupport:   g.RuffleSupport,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export games: %w", err)
+	}
+	e.gameCount = rowid
+
+	addAppTable := e.db.CreateTable("additional_app", snapshotSchemaAdditionalApp)
+	addAppIndex := e.db.CreateIndex("IDX_additional_app_parentGameId", "additional_app", snapshotSchemaAdditionalAppIndex)
+	addAppKeys := make([]snapshotTextKey, 0)
+	e.json.openArray(`],"add_apps":[`)
+	err = e.pgdal.ExportAdditionalApps(e.dbs, func(id int64, aa *types.AdditionalApp) error {
+		err := addAppTable.Insert(id, nil, aa.ApplicationPath, aa.AutoRunBefore, aa.LaunchCommand, aa.Name, aa.WaitForExit, aa.ParentGameID)
+		if err != nil {
+			return err
+		}
+		addAppKeys = append(addAppKeys, snapshotTextKey{value: aa.ParentGameID, rowid: id})
+		return e.json.element(aa)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export additional apps: %w", err)
+	}
+	if err := insertSnapshotTextKeys(addAppIndex, addAppKeys); err != nil {
+		return err
+	}
+
+	gameDataTable := e.db.CreateTable("game_data", snapshotSchemaGameData)
+	gameDataIndex := e.db.CreateIndex("IDX_game_data_gameId", "game_data", snapshotSchemaGameDataIndex)
+	gameDataKeys := make([]snapshotTextKey, 0)
+	e.json.openArray(`],"game_data":[`)
+	err = e.pgdal.ExportGameData(e.dbs, func(gd *types.GameData) error {
+		id := int64(gd.ID)
+		err := gameDataTable.Insert(id, nil, gd.GameID, gd.Title, formatSnapshotTime(gd.DateAdded), gd.SHA256, gd.CRC32,
+			gd.Size, gd.Parameters, gd.ApplicationPath, gd.LaunchCommand)
+		if err != nil {
+			return err
+		}
+		gameDataKeys = append(gameDataKeys, snapshotTextKey{value: gd.GameID, rowid: id})
+		return e.json.element(gd)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export game data: %w", err)
+	}
+	e.json.write("]}")
+	return insertSnapshotTextKeys(gameDataIndex, gameDataKeys)
+}
+
+// aliasKey finds the alias named as the primary alias, the names are case insensitive in Postgres
+type aliasKey struct {
+	ownerID int64
+	name    string
+}
+
+func (e *metadataExport) tags(categories []*types.TagCategory, tags []*types.LauncherDumpTagsTag, aliases []*types.TagAlias) error {
+
+	categoryTable := e.db.CreateTable("tag_category", snapshotSchemaTagCategory)
+	e.json.openArray(`,"tags":{"categories":[`)
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	for _, c := range categories {
+		if err := categoryTable.Insert(c.ID, nil, c.Name, c.Color, c.Description); err != nil {
+			return err
+		}
+		if err := e.json.element(c); err != nil {
+			return err
+		}
+	}
+
+	aliasTable := e.db.CreateTable("tag_alias", snapshotSchemaTagAlias)
+	aliasNameIndex := e.db.CreateIndex("sqlite_autoindex_tag_alias_1", "tag_alias", "")
+	aliasTagIndex := e.db.CreateIndex("IDX_tag_alias_tagId", "tag_alias", snapshotSchemaTagAliasIndex)
+	aliasIDs := make(map[aliasKey]int64, len(aliases))
+	nameKeys := make([]snapshotTextKey, 0, len(aliases))
+	e.json.openArray(`],"aliases":[`)
+	// the aliases are ordered by tag, so the index on the tag is filled along with the table
+	for i, a := range aliases {
+		id := int64(i + 1)
+		if err := aliasTable.Insert(id, nil, a.TagID, a.Name); err != nil {
+			return err
+		}
+		if err := aliasTagIndex.Insert(a.TagID, id); err != nil {
+			return err
+		}
+		aliasIDs[aliasKey{ownerID: a.TagID, name: strings.ToLower(a.Name)}] = id
+		nameKeys = append(nameKeys, snapshotTextKey{value: a.Name, rowid: id})
+		if err := e.json.element(a); err != nil {
+			return err
+		}
+	}
+	if err := insertSnapshotTextKeys(aliasNameIndex, nameKeys); err != nil {
+		return err
+	}
+
+	tagTable := e.db.CreateTable("tag", snapshotSchemaTag)
+	e.json.openArray(`],"tags":[`)
+	for _, t := range tags {
+		var primaryAliasID interface{}
+		if id, ok := aliasIDs[aliasKey{ownerID: t.ID, name: strings.ToLower(t.PrimaryAlias)}]; ok {
+			primaryAliasID = id
+		}
+		if err := tagTable.Insert(t.ID, nil, formatSnapshotTime(t.DateModified), primaryAliasID, t.CategoryID, t.Description); err != nil {
+			return err
+		}
+		if err := e.json.element(t); err != nil {
+			return err
+		}
+	}
+	e.json.write("]}")
+	return nil
+}
+
+func (e *metadataExport) platforms(platforms []*types.LauncherDumpPlatformsPlatform, aliases []*types.PlatformAlias) error {
+
+	aliasTable := e.db.CreateTable("platform_alias", snapshotSchemaPlatformAlias)
+	aliasNameIndex := e.db.CreateIndex("sqlite_autoindex_platform_alias_1", "platform_alias", "")
+	aliasPlatformIndex := e.db.CreateIndex("IDX_platform_alias_platformId", "platform_alias", snapshotSchemaPlatformAliasIndex)
+	aliasIDs := make(map[aliasKey]int64, len(aliases))
+	nameKeys := make([]snapshotTextKey, 0, len(aliases))
+	e.json.openArray(`,"platforms":{"aliases":[`)
+	for i, a := range aliases {
+		id := int64(i + 1)
+		if err := aliasTable.Insert(id, nil, a.PlatformID, a.Name); err != nil {
+			return err
+		}
+		if err := aliasPlatformIndex.Insert(a.PlatformID, id); err != nil {
+			return err
+		}
+		aliasIDs[aliasKey{ownerID: a.PlatformID, name: strings.ToLower(a.Name)}] = id
+		nameKeys = append(nameKeys, snapshotTextKey{value: a.Name, rowid: id})
+		if err := e.json.element(a); err != nil {
+			return err
+		}
+	}
+	if err := insertSnapshotTextKeys(aliasNameIndex, nameKeys); err != nil {
+		return err
+	}
+
+	platformTable := e.db.CreateTable("platform", snapshotSchemaPlatform)
+	e.json.openArray(`],"platforms":[`)
+	for _, p := range platforms {
+		var primaryAliasID interface{}
+		if id, ok := aliasIDs[aliasKey{ownerID: p.ID, name: strings.ToLower(p.PrimaryAlias)}]; ok {
+			primaryAliasID = id
+		}
+		if err := platformTable.Insert(p.ID, nil, formatSnapshotTime(p.DateModified), primaryAliasID, p.Description); err != nil {
+			return err
+		}
+		if err := e.json.element(p); err != nil {
+			return err
+		}
+	}
+	e.json.write("]}")
+	return nil
+}
+
+func (e *metadataExport) relations() error {
+
+	export := []struct {
+		table, sql, autoindex, index, indexSQL, jsonKey string
+		read                                            func(database.PGDBSession, func(*types.LauncherDumpRelation) error) error
+	}{
+		{"game_tags_tag", snapshotSchemaGameTags, "sqlite_autoindex_game_tags_tag_1", "IDX_game_tags_tag_tagId", snapshotSchemaGameTagsIndex,
+			"tag_relations", e.pgdal.ExportGameTagRelations},
+		{"game_platforms_platform", snapshotSchemaGamePlatforms, "sqlite_autoindex_game_platforms_platform_1", "IDX_game_platforms_platform_platformId", snapshotSchemaGamePlatformsIndex,
+			"platform_relations", e.pgdal.ExportGamePlatformRelations},
+	}
+
+	for _, x := range export {
+		table := e.db.CreateTable(x.table, x.sql)
+		autoindex := e.db.CreateIndex(x.autoindex, x.table, "")
+		index := e.db.CreateIndex(x.index, x.table, x.indexSQL)
+		keys := make([]snapshotIntKey, 0)
+
+		var rowid int64
+		e.json.openArray(fmt.Sprintf(`,"%s":[`, x.jsonKey))
+		err := x.read(e.dbs, func(r *types.LauncherDumpRelation) error {
+			rowid++
+			if err := table.Insert(rowid, r.GameID, r.Value); err != nil {
+				return err
+			}
+			if err := autoindex.Insert(r.GameID, r.Value, rowid); err != nil {
+				return err
+			}
+			keys = append(keys, snapshotIntKey{value: r.Value, rowid: rowid})
+			return e.json.element(r)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", x.table, err)
+		}
+		e.json.write("]")
+		if err := insertSnapshotIntKeys(index, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *metadataExport) redirects(redirects []*types.GameRedirect) error {
+	table := e.db.CreateTable("game_redirect", snapshotSchemaGameRedirect)
+	autoindex := e.db.CreateIndex("sqlite_autoindex_game_redirect_1", "game_redirect", "")
+
+	sort.Slice(redirects, func(i, j int) bool {
+		if redirects[i].SourceId != redirects[j].SourceId {
+			return redirects[i].SourceId < redirects[j].SourceId
+		}
+		return redirects[i].DestId < redirects[j].DestId
+	})
+	e.json.openArray(`,"redirects":[`)
+	for i, r := range redirects {
+		rowid := int64(i + 1)
+		if err := table.Insert(rowid, r.SourceId, r.DestId, formatSnapshotTime(r.DateAdded)); err != nil {
+			return err
+		}
+		if err := autoindex.Insert(r.SourceId, r.DestId, rowid); err != nil {
+			return err
+		}
+		if err := e.json.element(r); err != nil {
+			return err
+		}
+	}
+	e.json.write("]")
+	return nil
+}
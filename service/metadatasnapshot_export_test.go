@@ -0,0 +1,320 @@
+package service
+
+import (
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	_ "modernc.org/sqlite"
+)
+
+// fakeSnapshotPGDAL serves the reads of the metadata export, the other methods of the DAL are not implemented
+type fakeSnapshotPGDAL struct {
+	database.PGDAL
+	games             []*types.Game
+	addApps           []*types.AdditionalApp
+	gameData          []*types.GameData
+	categories        []*types.TagCategory
+	tags              []*types.LauncherDumpTagsTag
+	tagAliases        []*types.TagAlias
+	platforms         []*types.LauncherDumpPlatformsPlatform
+	platformAliases   []*types.PlatformAlias
+	tagRelations      []*types.LauncherDumpRelation
+	platformRelations []*types.LauncherDumpRelation
+	redirects         []*types.GameRedirect
+	gamesErr          error
+}
+
+func (d *fakeSnapshotPGDAL) GetTagCategories(_ database.PGDBSession) ([]*types.TagCategory, error) {
+	return d.categories, nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportTags(_ database.PGDBSession) ([]*types.LauncherDumpTagsTag, error) {
+	return d.tags, nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportTagAliases(_ database.PGDBSession) ([]*types.TagAlias, error) {
+	return d.tagAliases, nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportPlatforms(_ database.PGDBSession) ([]*types.LauncherDumpPlatformsPlatform, error) {
+	return d.platforms, nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportPlatformAliases(_ database.PGDBSession) ([]*types.PlatformAlias, error) {
+	return d.platformAliases, nil
+}
+
+func (d *fakeSnapshotPGDAL) GetGameRedirects(_ database.PGDBSession) ([]*types.GameRedirect, error) {
+	return d.redirects, nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportGames(_ database.PGDBSession, fn func(game *types.Game) error) error {
+	for _, g := range d.games {
+		if err := fn(g); err != nil {
+			return err
+		}
+	}
+	return d.gamesErr
+}
+
+func (d *fakeSnapshotPGDAL) ExportAdditionalApps(_ database.PGDBSession, fn func(id int64, addApp *types.AdditionalApp) error) error {
+	for i, aa := range d.addApps {
+		if err := fn(int64(i+1), aa); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportGameData(_ database.PGDBSession, fn func(gameData *types.GameData) error) error {
+	for _, gd := range d.gameData {
+		if err := fn(gd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportGameTagRelations(_ database.PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	for _, r := range d.tagRelations {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *fakeSnapshotPGDAL) ExportGamePlatformRelations(_ database.PGDBSession, fn func(relation *types.LauncherDumpRelation) error) error {
+	for _, r := range d.platformRelations {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newFakeSnapshotPGDAL() *fakeSnapshotPGDAL {
+	added := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	modified := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	parent := "aaaaaaaa-0000-0000-0000-000000000001"
+	dataID := 10
+	params := "-extract"
+
+	return &fakeSnapshotPGDAL{
+		games: []*types.Game{
+			{ID: parent, Title: "Alien Hominid", PrimaryPlatform: "Flash", PlatformsStr: "Flash", TagsStr: "Action; Arcade",
+				DateAdded: added, DateModified: modified, Library: "arcade", ActiveDataID: &dataID, ArchiveState: 2},
+			{ID: "bbbbbbbb-0000-0000-0000-000000000002", ParentGameID: &parent, Title: "Alien Hominid Demo", PrimaryPlatform: "Flash",
+				PlatformsStr: "Flash", DateAdded: added, DateModified: modified, Library: "arcade"},
+			{ID: "cccccccc-0000-0000-0000-000000000003", Title: strings.Repeat("Long title ", 1000), PrimaryPlatform: "HTML5",
+				PlatformsStr: "HTML5; Flash", DateAdded: added, DateModified: modified, Library: "theatre"},
+		},
+		addApps: []*types.AdditionalApp{
+			{ApplicationPath: ":message:", Name: "Readme", ParentGameID: "cccccccc-0000-0000-0000-000000000003"},
+			{ApplicationPath: "FPSoftware\\Flash.exe", Name: "Bonus", AutoRunBefore: true, ParentGameID: parent},
+		},
+		gameData: []*types.GameData{
+			{ID: dataID, GameID: parent, Title: "Alien Hominid", DateAdded: added, SHA256: strings.Repeat("a", 64), CRC32: 12345,
+				Size: 1 << 20, Parameters: &params, LaunchCommand: "http://example.com/game.swf"},
+		},
+		categories: []*types.TagCategory{
+			{ID: 2, Name: "genre", Color: "#ff0000"},
+			{ID: 1, Name: "default", Color: "#ffffff"},
+		},
+		tags: []*types.LauncherDumpTagsTag{
+			{ID: 1, CategoryID: 2, PrimaryAlias: "action", DateModified: modified},
+			{ID: 2, CategoryID: 2, PrimaryAlias: "Arcade", DateModified: modified},
+		},
+		tagAliases: []*types.TagAlias{
+			{TagID: 1, Name: "Action"},
+			{TagID: 1, Name: "Action Game"},
+			{TagID: 2, Name: "Arcade"},
+		},
+		platforms: []*types.LauncherDumpPlatformsPlatform{
+			{ID: 1, PrimaryAlias: "Flash", DateModified: modified},
+			{ID: 2, PrimaryAlias: "HTML5", DateModified: modified},
+		},
+		platformAliases: []*types.PlatformAlias{
+			{PlatformID: 1, Name: "Flash"},
+			{PlatformID: 2, Name: "HTML5"},
+		},
+		tagRelations: []*types.LauncherDumpRelation{
+			{GameID: parent, Value: 1},
+			{GameID: parent, Value: 2},
+		},
+		platformRelations: []*types.LauncherDumpRelation{
+			{GameID: parent, Value: 1},
+			{GameID: "bbbbbbbb-0000-0000-0000-000000000002", Value: 1},
+			{GameID: "cccccccc-0000-0000-0000-000000000003", Value: 1},
+			{GameID: "cccccccc-0000-0000-0000-000000000003", Value: 2},
+		},
+		redirects: []*types.GameRedirect{
+			{SourceId: "dddddddd-0000-0000-0000-000000000004", DestId: parent, DateAdded: added},
+			{SourceId: "00000000-0000-0000-0000-000000000005", DestId: parent, DateAdded: added},
+		},
+	}
+}
+
+// querySQLite runs the queries with SQLite itself, the rows are formatted like the sqlite3 shell does.
+// The driver parses the dates in datetime columns, they are cast to text to check them as they are stored.
+func querySQLite(t *testing.T, path string, queries ...string) []string {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	result := make([]string, 0, len(queries))
+	for _, q := range queries {
+		rows, err := db.Query(q)
+		if err != nil {
+			t.Fatalf("query %q failed: %v", q, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := make([]string, 0)
+		for rows.Next() {
+			values := make([]sql.NullString, len(columns))
+			dest := make([]interface{}, len(columns))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatalf("query %q failed: %v", q, err)
+			}
+			fields := make([]string, len(values))
+			for i, v := range values {
+				fields[i] = v.String
+			}
+			lines = append(lines, strings.Join(fields, "|"))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("query %q failed: %v", q, err)
+		}
+		rows.Close()
+		result = append(result, strings.Join(lines, "\n"))
+	}
+	return result
+}
+
+func Test_exportMetadataSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	sqlitePath := filepath.Join(dir, "metadata.sqlite")
+	jsonPath := filepath.Join(dir, "metadata.json.gz")
+	timestamp := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+	pgdal := newFakeSnapshotPGDAL()
+
+	count, err := exportMetadataSnapshot(pgdal, nil, timestamp, sqlitePath, jsonPath)
+	if err != nil {
+		t.Fatalf("exportMetadataSnapshot() error = %v", err)
+	}
+	if count != 3 {
+		t.Errorf("exportMetadataSnapshot() count = %d, want 3", count)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		f, err := os.Open(jsonPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatalf("the JSON is not gzipped: %v", err)
+		}
+
+		var dump struct {
+			types.LauncherDump
+			FormatVersion int64                `json:"format_version"`
+			Timestamp     time.Time            `json:"timestamp"`
+			Redirects     []types.GameRedirect `json:"redirects"`
+		}
+		decoder := json.NewDecoder(gz)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&dump); err != nil {
+			t.Fatalf("the JSON is invalid: %v", err)
+		}
+
+		if dump.FormatVersion != metadataSnapshotFormatVersion || !dump.Timestamp.Equal(timestamp) {
+			t.Errorf("format version %d and timestamp %v, want %d and %v", dump.FormatVersion, dump.Timestamp, metadataSnapshotFormatVersion, timestamp)
+		}
+		got := []int{len(dump.Games.Games), len(dump.Games.AddApps), len(dump.Games.GameData), len(dump.Tags.Categories), len(dump.Tags.Aliases),
+			len(dump.Tags.Tags), len(dump.Platforms.Aliases), len(dump.Platforms.Platforms), len(dump.TagRelations), len(dump.PlatformRelations), len(dump.Redirects)}
+		want := []int{3, 2, 1, 2, 3, 2, 2, 2, 2, 4, 2}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("JSON has %v elements, want %v", got, want)
+		}
+		if g := dump.Games.Games[1]; g.ParentGameID == nil || *g.ParentGameID != pgdal.games[0].ID || g.Title != "Alien Hominid Demo" {
+			t.Errorf("JSON game = %+v", g)
+		}
+		if gd := dump.Games.GameData[0]; gd.Parameters == nil || *gd.Parameters != "-extract" || !gd.DateAdded.Equal(pgdal.gameData[0].DateAdded) {
+			t.Errorf("JSON game data = %+v", gd)
+		}
+		if dump.Tags.Categories[0].ID != 1 {
+			t.Errorf("JSON categories are not ordered by id: %+v", dump.Tags.Categories)
+		}
+		if dump.Redirects[0].SourceId != "00000000-0000-0000-0000-000000000005" {
+			t.Errorf("JSON redirects are not ordered by source: %+v", dump.Redirects)
+		}
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		got := querySQLite(t, sqlitePath,
+			"PRAGMA integrity_check;",
+			"PRAGMA user_version;",
+			"SELECT formatVersion, CAST(timestamp AS TEXT) FROM metadata_snapshot;",
+			"SELECT id, parentGameId, activeDataId, archiveState, CAST(dateAdded AS TEXT) FROM game WHERE id LIKE 'b%' OR id LIKE 'a%' ORDER BY id;",
+			"SELECT length(title) FROM game WHERE id = 'cccccccc-0000-0000-0000-000000000003';",
+			"SELECT aa.name, aa.autoRunBefore FROM additional_app aa JOIN game_data d ON d.gameId = aa.parentGameId;",
+			"SELECT t.id, a.name FROM tag t JOIN tag_alias a ON a.id = t.primaryAliasId ORDER BY t.id;",
+			"SELECT p.id, a.name FROM platform p JOIN platform_alias a ON a.id = p.primaryAliasId ORDER BY p.id;",
+			"SELECT count(*) FROM game_platforms_platform WHERE platformId = 1;",
+			"SELECT group_concat(tagId) FROM game_tags_tag WHERE gameId = 'aaaaaaaa-0000-0000-0000-000000000001';",
+			"SELECT sourceId FROM game_redirect ORDER BY sourceId LIMIT 1;",
+			"SELECT name FROM tag_category WHERE id = 2;",
+		)
+		want := []string{
+			"ok",
+			"1",
+			"1|2024-06-02T00:00:00.000Z",
+			"aaaaaaaa-0000-0000-0000-000000000001||10|2|2020-01-02T03:04:05.600Z\nbbbbbbbb-0000-0000-0000-000000000002|aaaaaaaa-0000-0000-0000-000000000001||0|2020-01-02T03:04:05.600Z",
+			"11000",
+			"Bonus|1",
+			"1|Action\n2|Arcade",
+			"1|Flash\n2|HTML5",
+			"3",
+			"1,2",
+			"00000000-0000-0000-0000-000000000005",
+			"genre",
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("query %d = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+}
+
+func Test_exportMetadataSnapshot_readError(t *testing.T) {
+	dir := t.TempDir()
+	pgdal := newFakeSnapshotPGDAL()
+	pgdal.gamesErr = errors.New("connection lost")
+
+	_, err := exportMetadataSnapshot(pgdal, nil, time.Now(), filepath.Join(dir, "metadata.sqlite"), filepath.Join(dir, "metadata.json.gz"))
+	if err == nil || !strings.Contains(err.Error(), "connection lost") {
+		t.Errorf("exportMetadataSnapshot() error = %v, want the read error", err)
+	}
+}
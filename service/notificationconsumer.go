@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -38,15 +40,27 @@ func (s *SiteService) RunNotificationConsumer(logger *logrus.Entry, ctx context.
 			// TODO yea, like this is fetching notifications one by one, which is lovely and simple,
 			// but also has some room for optimizing database access
 
+			// errorSleep backs off after a failure, but doesn't hold up the shutdown
+			errorSleep := func() {
+				l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
+				select {
+				case <-ctx.Done():
+				case <-time.After(errorSleepTime):
+				}
+			}
+
 			loopWrap := func() {
+				// a notification being sent when the shutdown begins is still marked as sent, so it's not sent twice
+				ctx, span := tracing.Start(context.WithoutCancel(ctx), "NotificationConsumer.sendNotification")
+				defer span.End()
+
 				dbs, err := s.dal.NewSession(ctx)
 				if err != nil {
 					if err == context.Canceled {
 						return
 					}
 					l.Error(err)
-					l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
-					time.Sleep(errorSleepTime)
+					errorSleep()
 					return
 				}
 				defer dbs.Rollback()
@@ -61,16 +75,17 @@ func (s *SiteService) RunNotificationConsumer(logger *logrus.Entry, ctx context.
 						return
 					}
 					l.Error(err)
-					l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
-					time.Sleep(errorSleepTime)
+					errorSleep()
 					return
 				}
 				s.announceNotification()
 
-				if err := s.notificationBot.SendNotification(notification.Message, notification.Type); err != nil {
+				err = s.notificationBot.SendNotification(notification.Message, notification.Type)
+				metrics.NotificationsSent.WithLabelValues(metrics.Result(err)).Inc()
+				if err != nil {
+					tracing.Fail(span, err)
 					l.Error(err)
-					l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
-					time.Sleep(errorSleepTime)
+					errorSleep()
 					return
 				}
 
@@ -79,8 +94,7 @@ func (s *SiteService) RunNotificationConsumer(logger *logrus.Entry, ctx context.
 						return
 					}
 					l.Error(err)
-					l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
-					time.Sleep(errorSleepTime)
+					errorSleep()
 					return
 				}
 
@@ -89,8 +103,7 @@ func (s *SiteService) RunNotificationConsumer(logger *logrus.Entry, ctx context.
 						return
 					}
 					l.Error(err)
-					l.Debugf("sleeping for %f seconds", errorSleepTime.Seconds())
-					time.Sleep(errorSleepTime)
+					errorSleep()
 					return
 				}
 			}
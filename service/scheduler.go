@@ -0,0 +1,721 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	scheduledJobPollInterval = 30 * time.Second
+	// scheduledJobLockDuration is how long a lock lives without a heartbeat, a crashed instance holds the job this long
+	scheduledJobLockDuration = 5 * time.Minute
+	scheduledJobHeartbeat    = time.Minute
+	scheduledJobRunRetention = 30 * 24 * time.Hour
+	scheduledJobRunsShown    = 50
+	// scheduledJobLogLimit bounds the log stored with a run, the rest is only in the log of the instance
+	scheduledJobLogLimit = 64 * 1024
+)
+
+// scheduledJobDefinition is a job the scheduler knows how to run. The schedule and the enabled flag are the defaults
+// stored when the job is first seen, the admins change them on /web/internal afterwards.
+type scheduledJobDefinition struct {
+	name        string
+	description string
+	schedule    string
+	enabled     bool
+	// run returns a short summary of what the run did
+	run func(ctx context.Context) (string, error)
+}
+
+func (s *SiteService) scheduledJobDefinitions() []*scheduledJobDefinition {
+	return []*scheduledJobDefinition{
+		{
+			name:        constants.ScheduledJobAutounfreezer,
+			description: "Unfreezes the frozen games allowed by the autounfreezer policy",
+			schedule:    "0 */12 * * *",
+			enabled:     true,
+			run: func(ctx context.Context) (string, error) {
+				report, err := s.Autounfreeze(ctx)
+				if err != nil {
+					return "", err
+				}
+				msg := fmt.Sprintf("%d games unfrozen, %d exempted, %d with an invalid release date",
+					len(report.Unfrozen), len(report.Exempted), len(report.InvalidDate))
+				if len(report.Failed) > 0 {
+					return msg, fmt.Errorf("%d games failed to unfreeze", len(report.Failed))
+				}
+				return msg, nil
+			},
+		},
+		{
+			name:        constants.ScheduledJobRequestedChangesReminders,
+			description: "Reminds the submitters of submissions waiting for changes more than a month",
+			schedule:    "0 12 * * 1",
+			enabled:     false,
+			run: func(ctx context.Context) (string, error) {
+				count, err := s.ProduceRemindersAboutRequestedChanges(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d notifications added to the queue", count), nil
+			},
+		},
+		{
+			name:        constants.ScheduledJobRecomputeSubmissionCache,
+			description: "Recomputes the cached state of all submissions",
+			schedule:    "0 4 * * *",
+			enabled:     false,
+			run: func(ctx context.Context) (string, error) {
+				count, err := s.RecomputeSubmissionCacheAll(ctx)
+				return fmt.Sprintf("%d submissions recomputed", count), err
+			},
+		},
+		{
+			name:        constants.ScheduledJobIngestFlashfreeze,
+			description: "Ingests the flashfreeze directory",
+			schedule:    "*/30 * * * *",
+			enabled:     false,
+			run: func(ctx context.Context) (string, error) {
+				count, err := s.IngestFlashfreezeItems(ctx)
+				return fmt.Sprintf("%d files ingested", count), err
+			},
+		},
+		{
+			name:        constants.ScheduledJobIndexUnindexedFlashfreeze,
+			description: "Indexes the contents of the flashfreeze files which are not indexed yet",
+			schedule:    "0 * * * *",
+			enabled:     false,
+			run: func(ctx context.Context) (string, error) {
+				count, err := s.IndexUnindexedFlashfreezeItems(ctx)
+				return fmt.Sprintf("%d files indexed", count), err
+			},
+		},
+		{
+			name:        constants.ScheduledJobMetadataSnapshot,
+			description: "Exports the metadata into the SQLite and JSON snapshots downloaded by the launchers",
+			schedule:    "0 3 * * *",
+			enabled:     true,
+			run: func(ctx context.Context) (string, error) {
+				if s.volumes.MetadataSnapshots == nil {
+					return "metadata snapshots are not configured", nil
+				}
+				snapshot, err := s.ExportMetadataSnapshot(ctx)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("version %d with %d games, sqlite %s, json %s", snapshot.Version, snapshot.GameCount,
+					utils.SizeToString(snapshot.SQLite.Size), utils.SizeToString(snapshot.JSON.Size)), nil
+			},
+		},
+		{
+			name:        constants.ScheduledJobReviewQueueExpirer,
+			description: "Closes the finished review queue assignments and unassigns the testers idle for longer than REVIEW_QUEUE_IDLE_HOURS",
+			schedule:    "0 * * * *",
+			enabled:     true,
+			run: func(ctx context.Context) (string, error) {
+				expired, err := s.ExpireReviewQueueAssignments(ctx, s.reviewQueueIdleTimeout)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d assignments expired", expired), nil
+			},
+		},
+		{
+			name:        constants.ScheduledJobStalledSubmissionSweeper,
+			description: "Unassigns inactive testers and verifiers, reminds and rejects submissions without a requested new version, following the SWEEPER_* rules",
+			schedule:    "0 6 * * *",
+			enabled:     true,
+			run: func(ctx context.Context) (string, error) {
+				report, err := s.SweepStalledSubmissions(ctx, s.sweeperRules)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%d unassigned, %d reminded, %d rejected",
+					len(report.Unassigned), len(report.Reminded), len(report.Rejected)), nil
+			},
+		},
+		{
+			name:        constants.ScheduledJobFileBlobCollector,
+			description: "Removes the file blobs which are unreferenced for longer than the retention period and the orphaned ones",
+			schedule:    "0 2 * * *",
+			enabled:     true,
+			run: func(ctx context.Context) (string, error) {
+				removed, err := s.CollectFileBlobGarbage(ctx)
+				return fmt.Sprintf("%d blobs removed", removed), err
+			},
+		},
+	}
+}
+
+func (s *SiteService) scheduledJobDefinition(name string) *scheduledJobDefinition {
+	for _, def := range s.scheduledJobDefinitions() {
+		if def.name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+func (s *SiteService) announceScheduledJob() {
+	select {
+	// non-blocking announce that a job was triggered
+	case s.scheduledJobTriggered <- true:
+	default:
+	}
+}
+
+// RunScheduler runs the scheduled jobs when they are due or triggered by an admin. The jobs are locked in the database,
+// so any number of instances can run the scheduler and each run happens on one of them.
+func (s *SiteService) RunScheduler(logger *logrus.Entry, ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	l := logger.WithField("serviceName", "scheduler").WithField("workerID", s.workerID)
+	defer l.Info("scheduler stopped")
+
+	if err := s.registerScheduledJobs(context.WithValue(ctx, utils.CtxKeys.Log, l)); err != nil {
+		l.Error(err)
+	}
+
+	defs := s.scheduledJobDefinitions()
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.name)
+	}
+
+	pollTicker := time.NewTicker(scheduledJobPollInterval)
+	defer pollTicker.Stop()
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	var runs sync.WaitGroup
+	defer runs.Wait()
+
+	s.announceScheduledJob()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("context cancelled, stopping scheduler")
+			return
+		case <-cleanupTicker.C:
+			ctx := context.WithValue(ctx, utils.CtxKeys.Log, l)
+			err := metrics.ObserveJob("scheduled_job_run_cleanup", func() error {
+				return s.deleteOldScheduledJobRuns(ctx)
+			})
+			if err != nil {
+				l.Error(err)
+			}
+			continue
+		case <-pollTicker.C:
+		case <-s.scheduledJobTriggered:
+		}
+
+		for {
+			job, run, err := s.claimScheduledJob(context.WithValue(ctx, utils.CtxKeys.Log, l), names)
+			if err != nil || job == nil {
+				break
+			}
+
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				s.runScheduledJob(ctx, l, s.scheduledJobDefinition(job.Name), run)
+			}()
+		}
+	}
+}
+
+// registerScheduledJobs stores the jobs which are not in the database yet, the existing ones keep their settings
+func (s *SiteService) registerScheduledJobs(ctx context.Context) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	now := s.clock.Now()
+	for _, def := range s.scheduledJobDefinitions() {
+		schedule, err := utils.ParseCron(def.schedule)
+		if err != nil {
+			return fmt.Errorf("default schedule of job %s: %w", def.name, err)
+		}
+		job := &types.ScheduledJob{
+			Name:      def.name,
+			Schedule:  def.schedule,
+			Enabled:   def.enabled,
+			NextRunAt: schedule.Next(now),
+			UpdatedAt: now,
+		}
+		if err := s.dal.StoreScheduledJob(dbs, job); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return dberr(err)
+		}
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// claimScheduledJob locks a due job for this instance and stores its run, returns nil if there is no job to run
+func (s *SiteService) claimScheduledJob(ctx context.Context, names []string) (*types.ScheduledJob, *types.ScheduledJobRun, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	now := s.clock.Now()
+	job, err := s.dal.GetDueScheduledJob(dbs, names, now)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+
+	nextRunAt := now.Add(24 * time.Hour)
+	if schedule, err := utils.ParseCron(job.Schedule); err != nil {
+		utils.LogCtx(ctx).WithField("jobName", job.Name).Errorf("invalid schedule '%s', trying again in a day: %s", job.Schedule, err)
+	} else {
+		nextRunAt = schedule.Next(now)
+	}
+
+	// the lock expired, so the runs still marked as running belong to an instance which is gone
+	interrupted, err := s.dal.InterruptScheduledJobRuns(dbs, job.Name, now)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+	if interrupted > 0 {
+		utils.LogCtx(ctx).WithField("jobName", job.Name).Warnf("%d runs were left running by a lost instance", interrupted)
+	}
+
+	if err := s.dal.LockScheduledJob(dbs, job.Name, s.workerID, now.Add(scheduledJobLockDuration), nextRunAt); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+
+	run := &types.ScheduledJobRun{
+		JobName:     job.Name,
+		TriggeredBy: job.RunRequestedBy,
+		WorkerID:    s.workerID,
+		StartedAt:   now,
+		Status:      constants.ScheduledJobRunRunning,
+	}
+	run.ID, err = s.dal.StoreScheduledJobRun(dbs, run)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, nil, dberr(err)
+	}
+
+	return job, run, nil
+}
+
+// runScheduledJob runs the claimed job while keeping its lock alive, and records the outcome and the log of the run.
+// When ctx is cancelled, the run is recorded as interrupted without waiting for the job.
+func (s *SiteService) runScheduledJob(ctx context.Context, logger *logrus.Entry, def *scheduledJobDefinition, run *types.ScheduledJobRun) {
+	l := logger.WithField("jobName", run.JobName).WithField("runID", run.ID)
+	runLog := newScheduledJobLog(l)
+
+	type result struct {
+		msg string
+		err error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		runCtx := context.WithValue(ctx, utils.CtxKeys.Log, runLog.entry)
+		var msg string
+		err := metrics.ObserveJob(run.JobName, func() error {
+			var err error
+			msg, err = def.run(runCtx)
+			return err
+		})
+		done <- result{msg, err}
+	}()
+
+	l.Info("scheduled job started")
+
+	heartbeat := time.NewTicker(scheduledJobHeartbeat)
+	defer heartbeat.Stop()
+
+	var res result
+	status := constants.ScheduledJobRunSuccess
+wait:
+	for {
+		select {
+		case res = <-done:
+			if res.err != nil {
+				// goes to the log of the instance as well
+				runLog.entry.Error(res.err)
+				status = constants.ScheduledJobRunFailed
+			} else if errors := runLog.errorCount(); errors > 0 {
+				status = constants.ScheduledJobRunFailed
+				res.err = fmt.Errorf("%d errors logged", errors)
+			}
+			break wait
+		case <-ctx.Done():
+			status = constants.ScheduledJobRunInterrupted
+			res.err = fmt.Errorf("the instance is shutting down")
+			break wait
+		case <-heartbeat.C:
+			if err := s.extendScheduledJobLock(context.WithValue(ctx, utils.CtxKeys.Log, l), run.JobName); err != nil {
+				l.Error(err)
+			}
+		}
+	}
+
+	finishedAt := s.clock.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = status
+	if res.msg != "" && res.err != nil {
+		res.msg = fmt.Sprintf("%s: %s", res.msg, res.err)
+	} else if res.err != nil {
+		res.msg = res.err.Error()
+	}
+	if res.msg != "" {
+		run.Message = &res.msg
+	}
+	log := runLog.String()
+	run.Log = &log
+
+	// record the run even when the instance is shutting down
+	if err := s.finishScheduledJobRun(context.WithValue(context.WithoutCancel(ctx), utils.CtxKeys.Log, l), run); err != nil {
+		l.Error(err)
+		return
+	}
+
+	l.WithField("status", status).WithField("duration", run.Duration()).Info("scheduled job finished")
+}
+
+func (s *SiteService) extendScheduledJobLock(ctx context.Context, name string) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.ExtendScheduledJobLock(dbs, name, s.workerID, s.clock.Now().Add(scheduledJobLockDuration)); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// finishScheduledJobRun stores the outcome of the run and releases the lock of its job
+func (s *SiteService) finishScheduledJobRun(ctx context.Context, run *types.ScheduledJobRun) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.UpdateScheduledJobRun(dbs, run); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := s.dal.UnlockScheduledJob(dbs, run.JobName, s.workerID); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	// the job may have been triggered while it was running
+	s.announceScheduledJob()
+
+	return nil
+}
+
+func (s *SiteService) deleteOldScheduledJobRuns(ctx context.Context) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	deleted, err := s.dal.DeleteScheduledJobRuns(dbs, s.clock.Now().Add(-scheduledJobRunRetention))
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if deleted > 0 {
+		utils.LogCtx(ctx).Infof("deleted %d old scheduled job runs", deleted)
+	}
+
+	return nil
+}
+
+// scheduledJobLog captures the log of a run, up to scheduledJobLogLimit, and passes every entry on to the log of the instance
+type scheduledJobLog struct {
+	mu        sync.Mutex
+	b         strings.Builder
+	truncated bool
+	errors    int
+	formatter logrus.Formatter
+	forward   *logrus.Entry
+	entry     *logrus.Entry
+}
+
+func newScheduledJobLog(forward *logrus.Entry) *scheduledJobLog {
+	h := &scheduledJobLog{
+		formatter: &logrus.TextFormatter{DisableColors: true, FullTimestamp: true},
+		forward:   forward,
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.SetLevel(forward.Logger.GetLevel())
+	logger.AddHook(h)
+	h.entry = logrus.NewEntry(logger)
+
+	return h
+}
+
+func (h *scheduledJobLog) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *scheduledJobLog) Fire(e *logrus.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if e.Level <= logrus.ErrorLevel {
+		h.errors++
+	}
+
+	if line, err := h.formatter.Format(e); err == nil {
+		if h.b.Len()+len(line) <= scheduledJobLogLimit {
+			h.b.Write(line)
+		} else {
+			h.truncated = true
+		}
+	}
+
+	h.forward.WithFields(e.Data).WithTime(e.Time).Log(e.Level, e.Message)
+	return nil
+}
+
+func (h *scheduledJobLog) errorCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.errors
+}
+
+func (h *scheduledJobLog) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.truncated {
+		return h.b.String() + "... the log was truncated\n"
+	}
+	return h.b.String()
+}
+
+// TriggerScheduledJob asks the scheduler to run the job as soon as it's not running, even if it's disabled
+func (s *SiteService) TriggerScheduledJob(ctx context.Context, name string) error {
+	if s.scheduledJobDefinition(name) == nil {
+		return perr("job not found", http.StatusNotFound)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if _, err := s.dal.GetScheduledJob(dbs, name); err != nil {
+		if err == sql.ErrNoRows {
+			return perr("job not found", http.StatusNotFound)
+		}
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := s.dal.RequestScheduledJobRun(dbs, name, utils.UserID(ctx), s.clock.Now()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("jobName", name).Info("scheduled job triggered")
+	s.announceScheduledJob()
+
+	return nil
+}
+
+// UpdateScheduledJob changes the schedule of the job and enables or disables it, the next run follows the new schedule
+func (s *SiteService) UpdateScheduledJob(ctx context.Context, name, schedule string, enabled bool) error {
+	if s.scheduledJobDefinition(name) == nil {
+		return perr("job not found", http.StatusNotFound)
+	}
+
+	schedule = strings.TrimSpace(schedule)
+	cron, err := utils.ParseCron(schedule)
+	if err != nil {
+		return perr(fmt.Sprintf("invalid schedule: %s", err), http.StatusBadRequest)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if _, err := s.dal.GetScheduledJob(dbs, name); err != nil {
+		if err == sql.ErrNoRows {
+			return perr("job not found", http.StatusNotFound)
+		}
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	now := s.clock.Now()
+	if err := s.dal.UpdateScheduledJobSettings(dbs, name, schedule, enabled, cron.Next(now), now); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("jobName", name).WithField("schedule", schedule).WithField("enabled", enabled).Info("scheduled job updated")
+
+	return nil
+}
+
+// GetScheduledJobRun returns the run including its log
+func (s *SiteService) GetScheduledJobRun(ctx context.Context, id int64) (*types.ScheduledJobRun, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	run, err := s.dal.GetScheduledJobRun(dbs, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, perr("run not found", http.StatusNotFound)
+		}
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	return run, nil
+}
+
+func (s *SiteService) GetInternalPageData(ctx context.Context) (*types.InternalPageData, error) {
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	policy, err := s.pgdal.GetAutounfreezerPolicy(pgdbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	exemptions, err := s.pgdal.GetAutounfreezerExemptions(pgdbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	jobs, err := s.dal.GetScheduledJobs(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	runs, err := s.dal.GetScheduledJobRuns(dbs, scheduledJobRunsShown)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	for _, job := range jobs {
+		if def := s.scheduledJobDefinition(job.Name); def != nil {
+			job.Description = def.description
+		}
+		// the runs are sorted from the newest, jobs which didn't run recently show no last run
+		for _, run := range runs {
+			if run.JobName == job.Name {
+				job.LastRun = run
+				break
+			}
+		}
+	}
+
+	return &types.InternalPageData{
+		BasePageData:            *bpd,
+		AutounfreezerPolicy:     policy,
+		AutounfreezerExemptions: exemptions,
+		ScheduledJobs:           jobs,
+		ScheduledJobRuns:        runs,
+	}, nil
+}
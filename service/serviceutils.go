@@ -37,6 +37,8 @@ func resumableLog(ctx context.Context, resumableParams *types.ResumableParams) *
 
 type SubmissionStatusKeeper struct {
 	m map[string]*types.SubmissionStatus
+	// watchers are told when an upload moves to the next stage, the processing queue stores the progress this way
+	watchers map[string]func(status types.SubmissionStatus)
 	sync.Mutex
 }
 
@@ -47,24 +49,31 @@ func (s *SubmissionStatusKeeper) SetReceived(tempName string) {
 }
 
 func (s *SubmissionStatusKeeper) SetCopying(tempName string, message string) {
-	s.Lock()
-	defer s.Unlock()
-	s.m[tempName].Status = constants.SubmissionStatusCopying
-	s.m[tempName].Message = &message
+	s.setStage(tempName, constants.SubmissionStatusCopying, &message)
 }
 
 func (s *SubmissionStatusKeeper) SetValidating(tempName string) {
-	s.Lock()
-	defer s.Unlock()
-	s.m[tempName].Status = constants.SubmissionStatusValidating
-	s.m[tempName].Message = nil
+	s.setStage(tempName, constants.SubmissionStatusValidating, nil)
 }
 
 func (s *SubmissionStatusKeeper) SetFinalizing(tempName string) {
+	s.setStage(tempName, constants.SubmissionStatusFinalizing, nil)
+}
+
+// setStage updates the status and tells the watcher of the upload if the stage changed, the message alone changes too often
+func (s *SubmissionStatusKeeper) setStage(tempName, stage string, message *string) {
 	s.Lock()
-	defer s.Unlock()
-	s.m[tempName].Status = constants.SubmissionStatusFinalizing
-	s.m[tempName].Message = nil
+	ss := s.m[tempName]
+	changed := ss.Status != stage
+	ss.Status = stage
+	ss.Message = message
+	status := *ss
+	watcher := s.watchers[tempName]
+	s.Unlock()
+
+	if changed && watcher != nil {
+		watcher(status)
+	}
 }
 
 func (s *SubmissionStatusKeeper) SetFailed(tempName, message string) {
@@ -82,6 +91,7 @@ func (s *SubmissionStatusKeeper) SetSuccess(tempName string, sid int64) {
 	s.m[tempName].SubmissionID = &sid
 }
 
+// Get returns a copy of the status, or nil if the upload is not known to this instance
 func (s *SubmissionStatusKeeper) Get(tempName string) *types.SubmissionStatus {
 	s.Lock()
 	defer s.Unlock()
@@ -89,5 +99,19 @@ func (s *SubmissionStatusKeeper) Get(tempName string) *types.SubmissionStatus {
 	if !ok {
 		return nil
 	}
-	return ss
+	status := *ss
+	return &status
+}
+
+// watch registers the function called when the upload moves to the next stage
+func (s *SubmissionStatusKeeper) watch(tempName string, watcher func(status types.SubmissionStatus)) {
+	s.Lock()
+	defer s.Unlock()
+	s.watchers[tempName] = watcher
+}
+
+func (s *SubmissionStatusKeeper) unwatch(tempName string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.watchers, tempName)
 }
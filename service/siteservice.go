@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"crypto/sha256"
@@ -12,18 +13,16 @@ import (
 	"io"
 	"io/fs"
 	"io/ioutil"
-	"log"
 	"math"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/FlashpointProject/flashpoint-submission-system/activityevents"
@@ -41,13 +40,16 @@ import (
 	"github.com/FlashpointProject/flashpoint-submission-system/authbot"
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
 	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
 	"github.com/FlashpointProject/flashpoint-submission-system/notificationbot"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/agnivade/levenshtein"
 	"github.com/bwmarrin/discordgo"
 	"github.com/gofrs/uuid"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type MultipartFileWrapper struct {
@@ -132,60 +134,91 @@ func MapAuthToken(token *AuthToken) map[string]string {
 }
 
 type SiteService struct {
-	authBot                   authbot.DiscordRoleReader
-	notificationBot           notificationbot.DiscordNotificationSender
-	dal                       database.DAL
-	pgdal                     database.PGDAL
-	validator                 Validator
-	clock                     Clock
-	randomStringProvider      utils.RandomStringer
-	authTokenProvider         AuthTokenizer
-	sessionExpirationSeconds  int64
-	submissionsDir            string
-	submissionImagesDir       string
-	flashfreezeDir            string
-	notificationQueueNotEmpty chan bool
-	isDev                     bool
-	submissionReceiverMutex   sync.Mutex
-	discordRoleCache          *memoize.Memoizer
-	metadataStatsCache        *memoize.Memoizer
-	resumableUploadService    *resumableuploadservice.ResumableUploadService
-	archiveIndexerServerURL   string
-	flashfreezeIngestDir      string
-	SSK                       SubmissionStatusKeeper
-	DataPacksIndexer          ZipIndexer
+	authBot                    authbot.DiscordRoleReader
+	notificationBot            notificationbot.DiscordNotificationSender
+	dal                        database.DAL
+	pgdal                      database.PGDAL
+	validator                  Validator
+	clock                      Clock
+	randomStringProvider       utils.RandomStringer
+	authTokenProvider          AuthTokenizer
+	sessionExpirationSeconds   int64
+	submissionsDir             string
+	flashfreezeDir             string
+	notificationQueueNotEmpty  chan bool
+	submissionJobQueued        chan bool
+	scheduledJobTriggered      chan bool
+	workerID                   string // identifies this instance in the claims of the submission processing queue and the locks of the scheduled jobs
+	isDev                      bool
+	submissionReceiverMutex    sync.Mutex
+	discordRoleCache           *memoize.Memoizer
+	metadataStatsCache         *memoize.Memoizer
+	archiveDigestCache         *memoize.Memoizer
+	resumableUploadService     *resumableuploadservice.ResumableUploadService
+	archiveIndexer             ArchiveIndexer
+	flashfreezeIngestDir       string
+	blobs                      *BlobStore
+	volumes                    *StorageVolumes
+	fileConsistencyReport      *types.FileConsistencyReport
+	fileConsistencyReportMutex sync.Mutex
+	processingMutex            sync.Mutex      // guards shuttingDown and additions to processingWG
+	shuttingDown               bool            // new uploads are refused once the shutdown began
+	processingWG               sync.WaitGroup  // background processing of received uploads
+	processingCtx              context.Context // cancelled when the shutdown doesn't wait for the processing anymore
+	cancelProcessing           context.CancelFunc
+	reviewQueueIdleTimeout     time.Duration // testers of the review queue are unassigned after this long without a comment, zero never
+	sweeperRules               SweeperRules
+	SSK                        SubmissionStatusKeeper
+	DataPacksIndexer           ZipIndexer
 }
 
 func New(l *logrus.Entry, db *sql.DB, pgdb *pgxpool.Pool, authBotSession, notificationBotSession *discordgo.Session,
 	flashpointServerID, notificationChannelID, curationFeedChannelID, validatorServerURL string,
-	sessionExpirationSeconds int64, submissionsDir, submissionImagesDir, flashfreezeDir string, isDev bool,
-	rsu *resumableuploadservice.ResumableUploadService, archiveIndexerServerURL, flashfreezeIngestDir,
-	dataPacksDir string) *SiteService {
+	sessionExpirationSeconds int64, submissionsDir, flashfreezeDir string, isDev bool,
+	rsu *resumableuploadservice.ResumableUploadService, archiveIndexerServerURL, flashfreezeIngestDir string,
+	volumes *StorageVolumes, reviewQueueIdleTimeout time.Duration, sweeperRules SweeperRules) *SiteService {
+
+	var archiveIndexer ArchiveIndexer = NewLocalArchiveIndexer("")
+	if archiveIndexerServerURL != "" {
+		archiveIndexer = NewRemoteArchiveIndexer(archiveIndexerServerURL)
+	}
+
+	processingCtx, cancelProcessing := context.WithCancel(context.Background())
 
 	return &SiteService{
 		authBot:                   authbot.NewBot(authBotSession, flashpointServerID, l.WithField("botName", "authBot"), isDev),
 		notificationBot:           notificationbot.NewBot(notificationBotSession, flashpointServerID, notificationChannelID, curationFeedChannelID, l.WithField("botName", "notificationBot"), isDev),
-		dal:                       database.NewMysqlDAL(db),
-		pgdal:                     database.NewPostgresDAL(pgdb),
-		validator:                 NewValidator(validatorServerURL),
+		dal:                       database.NewTracedDAL(database.NewMysqlDAL(db)),
+		pgdal:                     database.NewTracedPGDAL(database.NewPostgresDAL(pgdb)),
+		validator:                 &instrumentedValidator{validator: NewValidator(validatorServerURL)},
 		clock:                     &RealClock{},
 		randomStringProvider:      utils.NewRealRandomStringProvider(),
 		authTokenProvider:         NewAuthTokenProvider(),
 		sessionExpirationSeconds:  sessionExpirationSeconds,
 		submissionsDir:            submissionsDir,
-		submissionImagesDir:       submissionImagesDir,
 		flashfreezeDir:            flashfreezeDir,
 		notificationQueueNotEmpty: make(chan bool, 1),
+		submissionJobQueued:       make(chan bool, 1),
+		scheduledJobTriggered:     make(chan bool, 1),
+		workerID:                  newWorkerID(),
 		isDev:                     isDev,
 		discordRoleCache:          memoize.NewMemoizer(2*time.Minute, 60*time.Minute),
 		metadataStatsCache:        memoize.NewMemoizer(1*time.Minute, cache2.NoExpiration),
+		archiveDigestCache:        memoize.NewMemoizer(24*time.Hour, time.Hour),
 		resumableUploadService:    rsu,
-		archiveIndexerServerURL:   archiveIndexerServerURL,
+		archiveIndexer:            archiveIndexer,
 		flashfreezeIngestDir:      flashfreezeIngestDir,
+		blobs:                     NewBlobStore(volumes.Blobs),
+		volumes:                   volumes,
+		processingCtx:             processingCtx,
+		cancelProcessing:          cancelProcessing,
+		reviewQueueIdleTimeout:    reviewQueueIdleTimeout,
+		sweeperRules:              sweeperRules,
 		SSK: SubmissionStatusKeeper{
-			m: make(map[string]*types.SubmissionStatus),
+			m:        make(map[string]*types.SubmissionStatus),
+			watchers: make(map[string]func(status types.SubmissionStatus)),
 		},
-		DataPacksIndexer: NewZipIndexer(pgdb, dataPacksDir, l.WithField("botName", "dataPackIndexer")),
+		DataPacksIndexer: NewZipIndexer(pgdb, volumes.DataPacks, l.WithField("botName", "dataPackIndexer")),
 	}
 }
 
@@ -226,8 +259,7 @@ func (s *SiteService) GetBasePageData(ctx context.Context) (*types.BasePageData,
 	return bpd, nil
 }
 
-func (s *SiteService) DeleteGame(ctx context.Context, gameId string, reason string, destId string, imagesPath string, gamesPath string,
-	deletedImagesPath string, deletedGamesPath string, frozenPacksPath string) error {
+func (s *SiteService) DeleteGame(ctx context.Context, gameId string, reason string, destId string) error {
 	// Lock the database for sequential write
 	utils.MetadataMutex.Lock()
 	defer utils.MetadataMutex.Unlock()
@@ -241,14 +273,33 @@ func (s *SiteService) DeleteGame(ctx context.Context, gameId string, reason stri
 
 	uid := utils.UserID(ctx)
 
-	// Soft delete database entry + move files
-	err = s.pgdal.DeleteGame(dbs, gameId, uid, reason, imagesPath, gamesPath,
-		deletedImagesPath, deletedGamesPath, frozenPacksPath)
+	game, err := s.pgdal.GetGame(dbs, gameId)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+
+	// Soft delete database entry
+	err = s.pgdal.DeleteGame(dbs, gameId, uid, reason)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		return err
 	}
 
+	// Disable game files if not frozen already
+	if game.ArchiveState == types.Available {
+		if err := s.moveGameDataPacks(ctx, game, s.volumes.DataPacks, s.volumes.DeletedDataPacks); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return err
+		}
+	}
+
+	// Disable image files
+	if err := s.moveGameImages(ctx, gameId, s.volumes.Images, s.volumes.DeletedImages); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+
 	// Add redirect if given
 	if len(destId) > 0 {
 		err = s.pgdal.AddGameRedirect(dbs, gameId, destId)
@@ -287,8 +338,7 @@ func (s *SiteService) DeleteGame(ctx context.Context, gameId string, reason stri
 	return nil
 }
 
-func (s *SiteService) RestoreGame(ctx context.Context, gameId string, reason string, imagesPath string, gamesPath string,
-	deletedImagesPath string, deletedGamesPath string, frozenPacksPath string) error {
+func (s *SiteService) RestoreGame(ctx context.Context, gameId string, reason string) error {
 	// Lock the database for sequential write
 	utils.MetadataMutex.Lock()
 	defer utils.MetadataMutex.Unlock()
@@ -302,14 +352,33 @@ func (s *SiteService) RestoreGame(ctx context.Context, gameId string, reason str
 
 	uid := utils.UserID(ctx)
 
+	game, err := s.pgdal.GetGame(dbs, gameId)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+
 	// Restore database entry
-	err = s.pgdal.RestoreGame(dbs, gameId, uid, reason, imagesPath, gamesPath, deletedImagesPath,
-		deletedGamesPath, frozenPacksPath)
+	err = s.pgdal.RestoreGame(dbs, gameId, uid, reason)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		return err
 	}
 
+	// Enable game files if not frozen already
+	if game.ArchiveState == types.Available {
+		if err := s.moveGameDataPacks(ctx, game, s.volumes.DeletedDataPacks, s.volumes.DataPacks); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return err
+		}
+	}
+
+	// Enable image files
+	if err := s.moveGameImages(ctx, gameId, s.volumes.DeletedImages, s.volumes.Images); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+
 	// Remove existing redirects
 	err = s.pgdal.RemoveGameRedirectsFrom(dbs, gameId)
 	if err != nil {
@@ -789,6 +858,10 @@ func (s *SiteService) GetApplyContentPatchPageData(ctx context.Context, sid int6
 	}
 
 	submission := submissions[0]
+	if err := s.fillReviewProgress(dbs, bpd.UserID, bpd.UserRoles, submissions); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
 
 	meta, err := s.dal.GetCurationMetaBySubmissionFileID(dbs, submission.FileID)
 	if err != nil && err != sql.ErrNoRows {
@@ -823,6 +896,10 @@ func (s *SiteService) GetViewSubmissionPageData(ctx context.Context, uid, sid in
 	}
 	defer dbs.Rollback()
 
+	if err := s.requireVisibleSubmission(dbs, sid, uid); err != nil {
+		return nil, err
+	}
+
 	pgdbs, err := s.pgdal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -863,6 +940,27 @@ func (s *SiteService) GetViewSubmissionPageData(ctx context.Context, uid, sid in
 		return nil, dberr(err)
 	}
 
+	checklistResults, err := s.dal.GetCommentChecklistResults(dbs, sid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	for _, comment := range comments {
+		comment.ChecklistResults = checklistResults[comment.CommentID]
+	}
+
+	checklistItems, err := s.dal.GetReviewChecklistItems(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	templates, err := s.dal.GetSubmissionTemplates(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
 	isUserSubscribed, err := s.dal.IsUserSubscribedToSubmission(dbs, uid, sid)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -921,6 +1019,9 @@ func (s *SiteService) GetViewSubmissionPageData(ctx context.Context, uid, sid in
 		NextSubmissionID:     nextSID,
 		PreviousSubmissionID: prevSID,
 		TagList:              tagList,
+		ReviewChecklist:      checklistFor(checklistItems, submission),
+		EditableMetaFields:   editableCurationMeta(meta),
+		SubmissionTemplates:  templates,
 	}
 
 	return pageData, nil
@@ -934,6 +1035,10 @@ func (s *SiteService) GetSubmissionsFilesPageData(ctx context.Context, sid int64
 	}
 	defer dbs.Rollback()
 
+	if err := s.requireVisibleSubmission(dbs, sid, utils.UserID(ctx)); err != nil {
+		return nil, err
+	}
+
 	bpd, err := s.GetBasePageData(ctx)
 	if err != nil {
 		return nil, err
@@ -1027,11 +1132,23 @@ func (s *SiteService) GetSubmissionsPageData(ctx context.Context, filter *types.
 		return nil, dberr(err)
 	}
 
+	if err := s.fillReviewProgress(dbs, bpd.UserID, bpd.UserRoles, submissions); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	checklistItems, err := s.dal.GetReviewChecklistItems(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
 	pageData := &types.SubmissionsPageData{
-		BasePageData: *bpd,
-		TotalCount:   count,
-		Submissions:  submissions,
-		Filter:       *filter,
+		BasePageData:         *bpd,
+		TotalCount:           count,
+		Submissions:          submissions,
+		Filter:               *filter,
+		ReviewChecklistItems: checklistItems,
 	}
 
 	return pageData, nil
@@ -1670,6 +1787,19 @@ func (s *SiteService) GetCurationImage(ctx context.Context, ciid int64) (*types.
 		utils.LogCtx(ctx).Error(err)
 		return nil, dberr(err)
 	}
+
+	sfs, err := s.dal.GetSubmissionFiles(dbs, []int64{ci.SubmissionFileID})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	if len(sfs) == 0 {
+		return nil, perr("curation image not found", http.StatusNotFound)
+	}
+	if err := s.requireVisibleSubmission(dbs, sfs[0].SubmissionID, utils.UserID(ctx)); err != nil {
+		return nil, err
+	}
+
 	return ci, nil
 }
 
@@ -1899,7 +2029,7 @@ func isFlasfhreezeExtensionValid(filename string) (bool, string) {
 	return true, ext
 }
 
-func (s *SiteService) processReceivedFlashfreezeItem(ctx context.Context, dbs database.DBSession, uid int64, fileReadCloserProvider resumableuploadservice.ReadCloserInformerProvider, filename string, filesize int64) (*string, *int64, error) {
+func (s *SiteService) processReceivedFlashfreezeItem(ctx context.Context, dbs database.DBSession, uid int64, fileReadCloserProvider resumableuploadservice.ReadCloserInformerProvider, filename string, filesize int64) (*string, *types.FlashfreezeFile, error) {
 	utils.LogCtx(ctx).Debugf("received a file '%s' - %d bytes", filename, filesize)
 
 	if err := os.MkdirAll(s.flashfreezeDir, os.ModeDir); err != nil {
@@ -1977,7 +2107,22 @@ func (s *SiteService) processReceivedFlashfreezeItem(ctx context.Context, dbs da
 		return &destinationFilePath, nil, dberr(err)
 	}
 
-	return &destinationFilePath, &fid, nil
+	if err := s.dal.AcquireFileBlob(dbs, sf.SHA256Sum, sf.Size); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return &destinationFilePath, nil, dberr(err)
+	}
+
+	sf.ID = fid
+
+	if err := destination.Close(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return &destinationFilePath, nil, err
+	}
+	if err := s.moveToBlobStore(ctx, destinationFilePath, sf.SHA256Sum); err != nil {
+		return &destinationFilePath, nil, err
+	}
+
+	return &destinationFilePath, sf, nil
 }
 
 func (s *SiteService) GetSearchFlashfreezeData(ctx context.Context, filter *types.FlashfreezeFilter) (*types.SearchFlashfreezePageData, error) {
@@ -1999,11 +2144,18 @@ func (s *SiteService) GetSearchFlashfreezeData(ctx context.Context, filter *type
 		return nil, dberr(err)
 	}
 
+	collections, err := s.dal.GetFlashfreezeCollections(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
 	pageData := &types.SearchFlashfreezePageData{
 		BasePageData:     *bpd,
 		FlashfreezeFiles: flashfreezeFiles,
 		TotalCount:       count,
 		Filter:           *filter,
+		Collections:      collections,
 	}
 
 	return pageData, nil
@@ -2019,15 +2171,23 @@ func (s *SiteService) GetFlashfreezeRootFile(ctx context.Context, fid int64) (*t
 
 	ci, err := s.dal.GetFlashfreezeRootFile(dbs, fid)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, perr("flashfreeze file not found", http.StatusNotFound)
+		}
 		utils.LogCtx(ctx).Error(err)
 		return nil, dberr(err)
 	}
+	if ci.DeletedAt != nil {
+		return nil, perr("flashfreeze file has been deleted", http.StatusNotFound)
+	}
 	return ci, nil
 }
 
-func (s *SiteService) processReceivedResumableSubmission(ctx context.Context, uid int64, sid *int64, resumableParams *types.ResumableParams, tempName string) error {
+// processReceivedResumableSubmission processes the fully received upload. The changes are set for the versions made in the curation meta editor.
+func (s *SiteService) processReceivedResumableSubmission(ctx context.Context, uid int64, sid *int64, resumableParams *types.ResumableParams,
+	metaChanges []*types.CurationMetaChange, tempName string) error {
 	var destinationFilename *string
-	imageFilePaths := make([]string, 0)
+	imageKeys := make([]string, 0)
 
 	cleanup := func() {
 		if destinationFilename != nil {
@@ -2036,9 +2196,9 @@ func (s *SiteService) processReceivedResumableSubmission(ctx context.Context, ui
 				utils.LogCtx(ctx).Error(err)
 			}
 		}
-		for _, fp := range imageFilePaths {
-			utils.LogCtx(ctx).Debugf("cleaning up image file '%s'...", fp)
-			if err := os.Remove(fp); err != nil {
+		for _, key := range imageKeys {
+			utils.LogCtx(ctx).Debugf("cleaning up image file '%s'...", key)
+			if err := s.volumes.SubmissionImages.Remove(ctx, key); err != nil {
 				utils.LogCtx(ctx).Error(err)
 			}
 		}
@@ -2084,15 +2244,24 @@ func (s *SiteService) processReceivedResumableSubmission(ctx context.Context, ui
 	}
 
 	ru := newResumableUpload(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks, s.resumableUploadService)
-	destinationFilename, ifp, submissionID, err := s.processReceivedSubmission(ctx, dbs, pgdbs, ru, resumableParams.ResumableFilename, resumableParams.ResumableTotalSize, sid, submissionLevel, tempName)
+	destinationFilename, iks, submissionID, err := s.processReceivedSubmission(ctx, dbs, pgdbs, ru, resumableParams.ResumableFilename, resumableParams.ResumableTotalSize, sid, submissionLevel, resumableParams.Draft, tempName)
 
-	imageFilePaths = append(imageFilePaths, ifp...)
+	imageKeys = append(imageKeys, iks...)
 
 	if err != nil {
 		cleanup()
 		return err
 	}
 
+	if len(metaChanges) > 0 {
+		if err := s.storeCurationMetaEditComment(dbs, pgdbs, submissionID, metaChanges); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			s.SSK.SetFailed(tempName, "internal error")
+			cleanup()
+			return dberr(err)
+		}
+	}
+
 	if err := pgdbs.Commit(); err != nil {
 		utils.LogCtx(ctx).Error(err)
 		s.SSK.SetFailed(tempName, "internal error")
@@ -2134,8 +2303,9 @@ func (s *SiteService) processReceivedResumableFlashfreeze(ctx context.Context, u
 	defer dbs.Rollback()
 
 	ru := newResumableUpload(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks, s.resumableUploadService)
-	destinationFilePath, fid, err := s.processReceivedFlashfreezeItem(ctx, dbs, uid, ru, resumableParams.ResumableFilename, resumableParams.ResumableTotalSize)
+	destinationFilename, ff, err := s.processReceivedFlashfreezeItem(ctx, dbs, uid, ru, resumableParams.ResumableFilename, resumableParams.ResumableTotalSize)
 	if err != nil {
+		cleanup()
 		return nil, err
 	}
 
@@ -2147,26 +2317,52 @@ func (s *SiteService) processReceivedResumableFlashfreeze(ctx context.Context, u
 
 	utils.LogCtx(ctx).WithField("amount", 1).Debug("flashfreeze items received")
 
-	l := utils.LogCtx(ctx).WithFields(logrus.Fields{"flashfreezeFileID": *fid, "destinationFilePath": *destinationFilePath})
-	go s.indexReceivedFlashfreezeFile(l, *fid, *destinationFilePath)
+	l := utils.LogCtx(ctx).WithFields(logrus.Fields{"flashfreezeFileID": ff.ID, "sha256sum": ff.SHA256Sum})
+	if s.startProcessing() {
+		go func() {
+			defer s.processingWG.Done()
+			_ = s.indexReceivedFlashfreezeFile(ctx, l, ff)
+		}()
+	} else {
+		l.Info("server is shutting down, the flashfreeze file is left unindexed")
+	}
 
-	return fid, nil
+	return &ff.ID, nil
 }
 
-func (s *SiteService) indexReceivedFlashfreezeFile(l *logrus.Entry, fid int64, filePath string) {
-	ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, l)
+// indexReceivedFlashfreezeFile indexes the file in the background, it outlives the request but stays in its trace.
+// The errors are logged before they are returned.
+func (s *SiteService) indexReceivedFlashfreezeFile(parent context.Context, l *logrus.Entry, ff *types.FlashfreezeFile) error {
+	ctx := context.WithValue(utils.ValueOnlyContext{Context: parent}, utils.CtxKeys.Log, l)
+	ctx, span := tracing.Start(ctx, "indexReceivedFlashfreezeFile", attribute.Int64("flashfreeze.file.id", ff.ID))
+	defer span.End()
+
 	utils.LogCtx(ctx).Debug("indexing flashfreeze file")
 
-	files, indexingErrors, err := provideArchiveForIndexing(filePath, s.archiveIndexerServerURL)
+	fid := ff.ID
+
+	st, key, err := s.FlashfreezeFileLocation(ctx, ff)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return err
+	}
+	filePath, release, err := st.LocalPath(ctx, key)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+	defer release()
+
+	files, indexingErrors, err := s.archiveIndexer.IndexArchive(ctx, filePath)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
 	}
 
 	dbs, err := s.dal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return err
 	}
 	defer dbs.Rollback()
 
@@ -2180,7 +2376,7 @@ func (s *SiteService) indexReceivedFlashfreezeFile(l *logrus.Entry, fid int64, f
 			err = s.dal.StoreFlashfreezeDeepFile(dbs, fid, batch)
 			if err != nil {
 				utils.LogCtx(ctx).Error(err)
-				return
+				return err
 			}
 			batch = make([]*types.IndexedFileEntry, 0, 1000)
 		}
@@ -2190,159 +2386,165 @@ func (s *SiteService) indexReceivedFlashfreezeFile(l *logrus.Entry, fid int64, f
 	err = s.dal.UpdateFlashfreezeRootFileIndexedState(dbs, fid, &t, indexingErrors)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return err
 	}
 
 	if err := dbs.Commit(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return err
 	}
 
 	utils.LogCtx(ctx).Debug("flashfreeze file indexed")
+	return nil
 }
 
-func provideArchiveForIndexing(filePath string, baseUrl string) ([]*types.IndexedFileEntry, uint64, error) {
-	client := http.Client{}
-	resp, err := client.Post(fmt.Sprintf("%s/provide-path?path=%s", baseUrl, url.QueryEscape(filePath)), "application/json;charset=utf-8", nil)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer resp.Body.Close()
-
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	// Check the response
-	if resp.StatusCode != http.StatusOK {
-		return nil, 0, fmt.Errorf("provide to remote error: %s", string(bytes))
-	}
-
-	var ir types.IndexerResp
-	err = json.Unmarshal(bytes, &ir)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return ir.Files, ir.IndexingErrors, nil
-}
-
-func (s *SiteService) ingestGivenFlashfreezeItems(l *logrus.Entry, files []fs.FileInfo, rootDir string) {
+// ingestGivenFlashfreezeItems ingests and indexes the files, it returns how many were ingested and an error if some failed
+func (s *SiteService) ingestGivenFlashfreezeItems(parent context.Context, files []fs.FileInfo, rootDir string) (int, error) {
 	guard := make(chan struct{}, 3)
 
 	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	var ingested, failed atomic.Int64
 
 	for _, fileInfo := range files {
+		if fileInfo.IsDir() {
+			continue
+		}
 		guard <- struct{}{}
-		fileInfo := fileInfo
+		wg.Add(1)
 		go func() {
+			defer wg.Done()
 			defer func() { <-guard }()
-			if fileInfo.IsDir() {
-				return
+			ctx := context.WithValue(parent, utils.CtxKeys.Log, utils.LogCtx(parent).WithField("filename", fileInfo.Name()))
+			ok, err := s.ingestFlashfreezeItem(ctx, &mutex, fileInfo, rootDir)
+			if ok {
+				ingested.Add(1)
 			}
-			ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, l.WithField("filename", fileInfo.Name()))
-			fullFilepath := rootDir + "/" + fileInfo.Name()
-
-			ok, ext := isFlasfhreezeExtensionValid(fileInfo.Name())
-			if !ok {
-				utils.LogCtx(ctx).Warn("unsupported file extension")
-				return
+			if err != nil {
+				failed.Add(1)
 			}
+		}()
+	}
 
-			var destinationFilename string
-			var destinationFilePath string
+	wg.Wait()
 
-			for {
-				destinationFilename = s.randomStringProvider.RandomString(64) + ext
-				destinationFilePath = fmt.Sprintf("%s/%s", s.flashfreezeDir, destinationFilename)
-				if !utils.FileExists(destinationFilePath) {
-					break
-				}
-			}
+	if failed.Load() > 0 {
+		return int(ingested.Load()), fmt.Errorf("%d flashfreeze files failed to ingest", failed.Load())
+	}
+	return int(ingested.Load()), nil
+}
 
-			md5sum := md5.New()
-			sha256sum := sha256.New()
-			multiWriter := io.MultiWriter(sha256sum, md5sum)
+// ingestFlashfreezeItem moves the file into the blob store, stores it and indexes it. The mutex serializes the stores.
+// It returns whether the file was stored, the errors are logged before they are returned.
+func (s *SiteService) ingestFlashfreezeItem(ctx context.Context, mutex *sync.Mutex, fileInfo fs.FileInfo, rootDir string) (bool, error) {
+	fullFilepath := rootDir + "/" + fileInfo.Name()
 
-			f, err := os.Open(fullFilepath)
-			if err != nil {
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
+	ok, ext := isFlasfhreezeExtensionValid(fileInfo.Name())
+	if !ok {
+		utils.LogCtx(ctx).Warn("unsupported file extension")
+		return false, nil
+	}
 
-			utils.LogCtx(ctx).Debug("computing checksums...")
-			nBytes, err := io.Copy(multiWriter, f)
-			if err != nil {
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
-			if nBytes != fileInfo.Size() {
-				err := fmt.Errorf("incorrect number of bytes copied to destination")
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
+	var destinationFilename string
+	var destinationFilePath string
 
-			mutex.Lock()
-			defer mutex.Unlock()
-			dbs, err := s.dal.NewSession(ctx)
-			if err != nil {
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
-			defer dbs.Rollback()
+	for {
+		destinationFilename = s.randomStringProvider.RandomString(64) + ext
+		destinationFilePath = fmt.Sprintf("%s/%s", s.flashfreezeDir, destinationFilename)
+		if !utils.FileExists(destinationFilePath) {
+			break
+		}
+	}
 
-			sf := &types.FlashfreezeFile{
-				UserID:           constants.SystemID,
-				OriginalFilename: fileInfo.Name(),
-				CurrentFilename:  destinationFilename,
-				Size:             fileInfo.Size(),
-				UploadedAt:       s.clock.Now(),
-				MD5Sum:           hex.EncodeToString(md5sum.Sum(nil)),
-				SHA256Sum:        hex.EncodeToString(sha256sum.Sum(nil)),
-			}
+	md5sum := md5.New()
+	sha256sum := sha256.New()
+	multiWriter := io.MultiWriter(sha256sum, md5sum)
 
-			fid, err := s.dal.StoreFlashfreezeRootFile(dbs, sf)
-			if err != nil {
-				me, ok := err.(*mysql.MySQLError)
-				if ok {
-					if me.Number == 1062 {
-						err := fmt.Errorf("file '%s' with checksums md5:%s sha256:%s already present in the DB", fileInfo.Name(), sf.MD5Sum, sf.SHA256Sum)
-						utils.LogCtx(ctx).Error(err)
-						return
-					}
-				}
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
+	f, err := os.Open(fullFilepath)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
+	defer f.Close()
 
-			if err := os.Rename(fullFilepath, destinationFilePath); err != nil {
-				utils.LogCtx(ctx).Error(err)
-				return
-			}
+	utils.LogCtx(ctx).Debug("computing checksums...")
+	nBytes, err := io.Copy(multiWriter, f)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
+	if nBytes != fileInfo.Size() {
+		err := fmt.Errorf("incorrect number of bytes copied to destination")
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
 
-			if err := dbs.Commit(); err != nil {
+	mutex.Lock()
+	defer mutex.Unlock()
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
+	defer dbs.Rollback()
+
+	sf := &types.FlashfreezeFile{
+		UserID:           constants.SystemID,
+		OriginalFilename: fileInfo.Name(),
+		CurrentFilename:  destinationFilename,
+		Size:             fileInfo.Size(),
+		UploadedAt:       s.clock.Now(),
+		MD5Sum:           hex.EncodeToString(md5sum.Sum(nil)),
+		SHA256Sum:        hex.EncodeToString(sha256sum.Sum(nil)),
+	}
+
+	fid, err := s.dal.StoreFlashfreezeRootFile(dbs, sf)
+	if err != nil {
+		me, ok := err.(*mysql.MySQLError)
+		if ok {
+			if me.Number == 1062 {
+				err := fmt.Errorf("file '%s' with checksums md5:%s sha256:%s already present in the DB", fileInfo.Name(), sf.MD5Sum, sf.SHA256Sum)
 				utils.LogCtx(ctx).Error(err)
-				return
+				return false, err
 			}
+		}
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
 
-			utils.LogCtx(ctx).WithField("amount", 1).Debug("flashfreeze items received")
-			l := utils.LogCtx(ctx).WithFields(logrus.Fields{"flashfreezeFileID": fid, "destinationFilepath": destinationFilePath})
-			s.indexReceivedFlashfreezeFile(l, fid, destinationFilePath)
-		}()
+	sf.ID = fid
+
+	if err := s.dal.AcquireFileBlob(dbs, sf.SHA256Sum, sf.Size); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
+
+	if err := s.moveToBlobStore(ctx, fullFilepath, sf.SHA256Sum); err != nil {
+		return false, err
 	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return false, err
+	}
+
+	utils.LogCtx(ctx).WithField("amount", 1).Debug("flashfreeze items received")
+	l := utils.LogCtx(ctx).WithFields(logrus.Fields{"flashfreezeFileID": fid, "sha256sum": sf.SHA256Sum})
+	// the file stays unindexed if this fails, for the job indexing the unindexed files
+	return true, s.indexReceivedFlashfreezeFile(ctx, l, sf)
 }
 
-func (s *SiteService) IngestFlashfreezeItems(l *logrus.Entry) {
-	l.WithField("directory", s.flashfreezeIngestDir).Debug("listing directory")
+// IngestFlashfreezeItems ingests the files of the flashfreeze ingest directory, it returns how many were ingested
+func (s *SiteService) IngestFlashfreezeItems(ctx context.Context) (int, error) {
+	utils.LogCtx(ctx).WithField("directory", s.flashfreezeIngestDir).Debug("listing directory")
 
 	files, err := ioutil.ReadDir(s.flashfreezeIngestDir)
 	if err != nil {
-		l.Error(err)
-		return
+		utils.LogCtx(ctx).Error(err)
+		return 0, err
 	}
 
-	s.ingestGivenFlashfreezeItems(l, files, s.flashfreezeIngestDir)
+	return s.ingestGivenFlashfreezeItems(ctx, files, s.flashfreezeIngestDir)
 }
 
 func (s *SiteService) IngestUnknownFlashfreezeItems(l *logrus.Entry) {
@@ -2394,14 +2596,18 @@ func (s *SiteService) IngestUnknownFlashfreezeItems(l *logrus.Entry) {
 	}
 
 	utils.LogCtx(ctx).WithField("unknownFlashfreezeItems", len(files)).Debug("found some unknown flashfreeze items")
-	s.ingestGivenFlashfreezeItems(l, files, s.flashfreezeDir)
+	if _, err := s.ingestGivenFlashfreezeItems(ctx, files, s.flashfreezeDir); err != nil {
+		utils.LogCtx(ctx).Error(err)
+	}
 }
 
-func (s *SiteService) RecomputeSubmissionCacheAll(ctx context.Context) {
+// RecomputeSubmissionCacheAll recomputes the cached state of the submissions, it returns how many were recomputed
+func (s *SiteService) RecomputeSubmissionCacheAll(ctx context.Context) (int64, error) {
 
 	var perPage int64 = 10000
 	var count int64 = 1
 	var recomputedCount int64 = 0
+	var recomputed int64 = 0
 
 	for recomputedCount < count {
 		var submissions []*types.ExtendedSubmission
@@ -2409,64 +2615,84 @@ func (s *SiteService) RecomputeSubmissionCacheAll(ctx context.Context) {
 		submissions, count, err = s.SearchSubmissions(ctx, &types.SubmissionsFilter{ResultsPerPage: &perPage, ExcludeLegacy: true})
 		if err != nil {
 			utils.LogCtx(ctx).Error(err)
-			return
+			return recomputed, err
 		}
 		utils.LogCtx(ctx).WithField("perPage", perPage).WithField("recomputedCount", recomputedCount).WithField("totalSubmissions", count).Debug("processing a page of submissions")
 
 		for _, submission := range submissions {
-			func() {
-				utils.LogCtx(ctx).WithField("submissionID", submission.SubmissionID).Debug("recomputing cache for submission")
-
-				dbs, err := s.dal.NewSession(ctx)
-				if err != nil {
-					utils.LogCtx(ctx).Error(err)
-					return
-				}
-				defer dbs.Rollback()
-
-				err = s.dal.UpdateSubmissionCacheTable(dbs, submission.SubmissionID)
-				if err != nil {
-					utils.LogCtx(ctx).Error(err)
-					return
-				}
-
-				if err := dbs.Commit(); err != nil {
-					utils.LogCtx(ctx).Error(err)
-					return
-				}
-			}()
+			if err := s.recomputeSubmissionCache(ctx, submission.SubmissionID); err != nil {
+				return recomputed, err
+			}
+			recomputed++
 		}
 
 		recomputedCount += count
 	}
+
+	return recomputed, nil
 }
 
-func (s *SiteService) IndexUnindexedFlashfreezeItems(l *logrus.Entry) {
-	ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, l)
+func (s *SiteService) recomputeSubmissionCache(ctx context.Context, sid int64) error {
+	utils.LogCtx(ctx).WithField("submissionID", sid).Debug("recomputing cache for submission")
 
 	dbs, err := s.dal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// IndexUnindexedFlashfreezeItems indexes the flashfreeze files which are not indexed yet, it returns how many were indexed
+func (s *SiteService) IndexUnindexedFlashfreezeItems(ctx context.Context) (int, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
 	}
 	defer dbs.Rollback()
 
 	unindexedFiles, err := s.dal.GetAllUnindexedFlashfreezeRootFiles(dbs)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		return
+		return 0, dberr(err)
 	}
 
 	if len(unindexedFiles) == 0 {
 		utils.LogCtx(ctx).Debug("found no unindexed flashfreeze files")
+		return 0, nil
 	}
 
 	utils.LogCtx(ctx).WithField("unindexedFlashfreezeItems", len(unindexedFiles)).Debug("found some unindexed flashfreeze files")
 
+	indexed := 0
 	for _, unindexedFile := range unindexedFiles {
-		destinationFilePath := s.flashfreezeDir + "/" + unindexedFile.CurrentFilename
-		s.indexReceivedFlashfreezeFile(l, unindexedFile.ID, destinationFilePath)
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+		l := utils.LogCtx(ctx).WithFields(logrus.Fields{"flashfreezeFileID": unindexedFile.ID, "sha256sum": unindexedFile.SHA256Sum})
+		if err := s.indexReceivedFlashfreezeFile(ctx, l, unindexedFile); err != nil {
+			continue
+		}
+		indexed++
+	}
+
+	if failed := len(unindexedFiles) - indexed; failed > 0 {
+		return indexed, fmt.Errorf("%d flashfreeze files failed to index", failed)
 	}
+	return indexed, nil
 }
 
 func (s *SiteService) DeleteUserSessions(ctx context.Context, targetID int64) (int64, error) {
@@ -3336,8 +3562,8 @@ func (s *SiteService) GetGamesPageData(ctx context.Context, modifierAfter *strin
 	return games, addApps, gameData, tagRelations, platformRelations, nil
 }
 
-func (s *SiteService) AddSubmissionToFlashpoint(ctx context.Context, submission *types.ExtendedSubmission, subDirFullPath string,
-	dataPacksDir string, frozenPacksDir string, imagesDir string, r *http.Request) (*string, error) {
+func (s *SiteService) AddSubmissionToFlashpoint(ctx context.Context, submission *types.ExtendedSubmission,
+	r *http.Request) (*string, error) {
 	// Lock the database for sequential write
 	utils.MetadataMutex.Lock()
 	defer utils.MetadataMutex.Unlock()
@@ -3356,8 +3582,18 @@ func (s *SiteService) AddSubmissionToFlashpoint(ctx context.Context, submission
 	sf := sfs[0]
 
 	// Repack the curation via the validator and get fresh metadata
-	originalPath := fmt.Sprintf("%s/%s", subDirFullPath, sf.CurrentFilename)
-	vr, err := s.validator.ProvideArchiveForRepacking(originalPath)
+	st, key, err := s.SubmissionFileLocation(ctx, sf)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, err
+	}
+	originalPath, release, err := st.LocalPath(ctx, key)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, err
+	}
+	defer release()
+	vr, err := s.validator.ProvideArchiveForRepacking(ctx, originalPath)
 	if err != nil {
 		return nil, err
 	}
@@ -3414,48 +3650,27 @@ func (s *SiteService) AddSubmissionToFlashpoint(ctx context.Context, submission
 		gameData = game.Data[0]
 	}
 
-	msTime := gameData.DateAdded.UnixMilli()
-
 	utils.LogCtx(ctx).Debug("Adding sub from validator")
 	// Add game into metadata
 
-	// Get the base name of the data pack file
-	base := filepath.Base(*vr.FilePath)
-
-	// Add date added into filename
-	newBase := fmt.Sprintf("%s-%d%s", game.ID, msTime, filepath.Ext(base))
-	newFileName := filepath.Join(dataPacksDir, newBase)
+	// Copy the data pack into the data packs, with date added in the filename
+	dataPacks := s.volumes.DataPacks
 	if submission.IsFrozen {
-		newFileName = filepath.Join(frozenPacksDir, newBase)
-	}
-	err = os.MkdirAll(dataPacksDir, 0777)
-	if err != nil {
-		return nil, err
-	}
-	err = os.MkdirAll(frozenPacksDir, 0777)
-	if err != nil {
-		return nil, err
+		dataPacks = s.volumes.FrozenPacks
 	}
 
-	// Copy renamed data pack file to data packs folder
 	srcFile, err := os.Open(*vr.FilePath)
 	if err != nil {
 		return nil, err
 	}
 	defer srcFile.Close()
 
-	destFile, err := os.Create(newFileName) // creates if file doesn't exist
-	if err != nil {
-		return nil, err
-	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, srcFile) // check first var for number of bytes copied
+	srcInfo, err := srcFile.Stat()
 	if err != nil {
 		return nil, err
 	}
 
-	err = destFile.Sync()
+	err = dataPacks.Put(ctx, DataPackKey(game.ID, gameData.DateAdded), srcFile, srcInfo.Size())
 	if err != nil {
 		return nil, err
 	}
@@ -3467,34 +3682,24 @@ func (s *SiteService) AddSubmissionToFlashpoint(ctx context.Context, submission
 		}
 
 		logo := vr.Images[0]
-		logoFilePath := fmt.Sprintf(`%s/Logos/%s/%s/%s.png`, imagesDir, game.ID[0:2], game.ID[2:4], game.ID)
 		decodedLogo, err := base64.StdEncoding.DecodeString(logo.Data)
 		if err != nil {
 			return nil, err
 		}
-		err = os.MkdirAll(filepath.Dir(logoFilePath), 0777)
+		err = s.volumes.Images.Put(ctx, LogoKey(game.ID), bytes.NewReader(decodedLogo), int64(len(decodedLogo)))
 		if err != nil {
 			return nil, err
 		}
-		err = os.WriteFile(logoFilePath, decodedLogo, 0644)
-		if err != nil {
-			log.Fatal(err)
-		}
 
 		ss := vr.Images[1]
-		ssFilePath := fmt.Sprintf(`%s/Screenshots/%s/%s/%s.png`, imagesDir, game.ID[0:2], game.ID[2:4], game.ID)
 		decodedScreenshot, err := base64.StdEncoding.DecodeString(ss.Data)
 		if err != nil {
 			return nil, err
 		}
-		err = os.MkdirAll(filepath.Dir(ssFilePath), 0777)
+		err = s.volumes.Images.Put(ctx, ScreenshotKey(game.ID), bytes.NewReader(decodedScreenshot), int64(len(decodedScreenshot)))
 		if err != nil {
 			return nil, err
 		}
-		err = os.WriteFile(ssFilePath, decodedScreenshot, 0644)
-		if err != nil {
-			log.Fatal(err)
-		}
 	}
 
 	err = dbs.Commit()
@@ -3621,8 +3826,7 @@ func (s *SiteService) UnfreezeSubmission(ctx context.Context, sid int64) error {
 	return nil
 }
 
-func (s *SiteService) FreezeGame(ctx context.Context, gameId string, uid int64, dataPacksPath string,
-	frozenPacksPath string, deletedPacksPath string) error {
+func (s *SiteService) FreezeGame(ctx context.Context, gameId string, uid int64) error {
 	dbs, err := s.pgdal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -3650,41 +3854,19 @@ func (s *SiteService) FreezeGame(ctx context.Context, gameId string, uid int64,
 	}
 
 	// Move game zip to freezer
-	for _, data := range game.Data {
-		fileName := fmt.Sprintf("%s-%d%s", game.ID, data.DateAdded.UnixMilli(), ".zip")
-		src := path.Join(dataPacksPath, fileName)
-		if game.Deleted == true {
-			src = path.Join(deletedPacksPath, fileName)
-		}
-		dest := path.Join(frozenPacksPath, fileName)
-		// Only copy if the file exists
-		if _, err := os.Stat(src); err == nil {
-			err = os.MkdirAll(filepath.Base(src), 0777)
-			if err != nil {
-				return err
-			}
-			err = os.MkdirAll(frozenPacksPath, 0777)
-			if err != nil {
-				return err
-			}
-
-			err = utils.CopyFile(src, dest)
-			if err != nil {
-				return err
-			}
-
-			err = os.Remove(src)
-			if err != nil {
-				return err
-			}
-		}
+	src := s.volumes.DataPacks
+	if game.Deleted == true {
+		src = s.volumes.DeletedDataPacks
+	}
+	if err := s.moveGameDataPacks(ctx, game, src, s.volumes.FrozenPacks); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
 	}
 
 	return err
 }
 
-func (s *SiteService) UnfreezeGame(ctx context.Context, gameId string, uid int64, dataPacksPath string,
-	frozenPacksPath string, deletedPacksPath string) error {
+func (s *SiteService) UnfreezeGame(ctx context.Context, gameId string, uid int64) error {
 	dbs, err := s.pgdal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -3712,34 +3894,13 @@ func (s *SiteService) UnfreezeGame(ctx context.Context, gameId string, uid int64
 	}
 
 	// Move game zip out of freezer
-	for _, data := range game.Data {
-		fileName := fmt.Sprintf("%s-%d%s", game.ID, data.DateAdded.UnixMilli(), ".zip")
-		src := path.Join(frozenPacksPath, fileName)
-		dest := path.Join(dataPacksPath, fileName)
-		if game.Deleted == true {
-			dest = path.Join(deletedPacksPath, fileName)
-		}
-		// Only copy if the file exists
-		if _, err := os.Stat(src); err == nil {
-			err = os.MkdirAll(frozenPacksPath, 0777)
-			if err != nil {
-				return err
-			}
-			err = os.MkdirAll(filepath.Base(dest), 0777)
-			if err != nil {
-				return err
-			}
-
-			err = utils.CopyFile(src, dest)
-			if err != nil {
-				return err
-			}
-
-			err = os.Remove(src)
-			if err != nil {
-				return err
-			}
-		}
+	dest := s.volumes.DataPacks
+	if game.Deleted == true {
+		dest = s.volumes.DeletedDataPacks
+	}
+	if err := s.moveGameDataPacks(ctx, game, s.volumes.FrozenPacks, dest); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
 	}
 
 	return err
@@ -3829,6 +3990,49 @@ func (s *SiteService) Stat() *database.PostgresStats {
 	return s.pgdal.Stat()
 }
 
+// RegisterMetrics exports the metrics which are read from the database on every scrape
+func (s *SiteService) RegisterMetrics(l *logrus.Entry) {
+	l = l.WithField("serviceName", "metrics")
+
+	metrics.RegisterGaugeFunc("notification_queue_depth", "Notifications waiting to be sent.", func() float64 {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), utils.CtxKeys.Log, l), 5*time.Second)
+		defer cancel()
+
+		dbs, err := s.dal.NewSession(ctx)
+		if err != nil {
+			l.Error(err)
+			return math.NaN()
+		}
+		defer dbs.Rollback()
+
+		count, err := s.dal.CountUnsentNotifications(dbs)
+		if err != nil {
+			l.Error(err)
+			return math.NaN()
+		}
+		return float64(count)
+	})
+
+	metrics.RegisterGaugeFunc("submission_processing_queue_depth", "Received uploads waiting for or being processed.", func() float64 {
+		ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), utils.CtxKeys.Log, l), 5*time.Second)
+		defer cancel()
+
+		dbs, err := s.dal.NewSession(ctx)
+		if err != nil {
+			l.Error(err)
+			return math.NaN()
+		}
+		defer dbs.Rollback()
+
+		count, err := s.dal.CountUnfinishedSubmissionProcessingJobs(dbs)
+		if err != nil {
+			l.Error(err)
+			return math.NaN()
+		}
+		return float64(count)
+	})
+}
+
 func (s *SiteService) SetClientAppSecret(ctx context.Context, clientID string, clientSecret string) error {
 	uid := utils.UserID(ctx)
 	dbs, err := s.dal.NewSession(ctx)
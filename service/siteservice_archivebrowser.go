@@ -0,0 +1,424 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// maxEntryPreviewSize limits how much of a text or html entry is shown inline
+const maxEntryPreviewSize = 256 * 1024
+
+// errArchiveEntryFound stops visiting the archive once the wanted entry was read
+var errArchiveEntryFound = errors.New("archive entry found")
+
+// storedArchive is an opened submission or flashfreeze file. Entries are read from it with range requests,
+// nothing gets unpacked to disk.
+type storedArchive struct {
+	obj  storage.Object
+	size int64
+	name string // used to detect the format
+	url  string // path of the file, below /data
+	// originalFilename is what the archive was uploaded as
+	originalFilename string
+}
+
+func (a *storedArchive) Close() error {
+	return a.obj.Close()
+}
+
+func (s *SiteService) openStoredArchive(ctx context.Context, st storage.Storage, key, name string) (storage.Object, int64, error) {
+	if archiveFormat(name) == "" {
+		return nil, 0, perr("the archive format of this file is not supported", http.StatusBadRequest)
+	}
+	obj, info, err := st.Open(ctx, key)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, 0, perr("failed to read file", http.StatusInternalServerError)
+	}
+	return obj, info.Size, nil
+}
+
+// openSubmissionArchive opens the submission file, which must belong to the submission
+func (s *SiteService) openSubmissionArchive(ctx context.Context, sid, sfid int64) (*storedArchive, error) {
+	if err := s.RequireVisibleSubmissions(ctx, []int64{sid}); err != nil {
+		return nil, err
+	}
+
+	sfs, err := s.GetSubmissionFiles(ctx, []int64{sfid})
+	if err != nil {
+		return nil, err
+	}
+	if len(sfs) == 0 || sfs[0].SubmissionID != sid {
+		return nil, perr("submission file not found", http.StatusNotFound)
+	}
+	sf := sfs[0]
+
+	st, key, err := s.SubmissionFileLocation(ctx, sf)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, perr("failed to read file", http.StatusInternalServerError)
+	}
+	obj, size, err := s.openStoredArchive(ctx, st, key, sf.CurrentFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedArchive{
+		obj:              obj,
+		size:             size,
+		name:             sf.CurrentFilename,
+		url:              fmt.Sprintf("/submission/%d/file/%d", sid, sfid),
+		originalFilename: sf.OriginalFilename,
+	}, nil
+}
+
+// openFlashfreezeArchive opens the flashfreeze root file
+func (s *SiteService) openFlashfreezeArchive(ctx context.Context, fid int64) (*storedArchive, error) {
+	ff, err := s.GetFlashfreezeRootFile(ctx, fid)
+	if err != nil {
+		return nil, err
+	}
+
+	st, key, err := s.FlashfreezeFileLocation(ctx, ff)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, perr("failed to read file", http.StatusInternalServerError)
+	}
+	obj, size, err := s.openStoredArchive(ctx, st, key, ff.CurrentFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storedArchive{
+		obj:              obj,
+		size:             size,
+		name:             ff.CurrentFilename,
+		url:              fmt.Sprintf("/flashfreeze/file/%d", fid),
+		originalFilename: ff.OriginalFilename,
+	}, nil
+}
+
+func toArchiveBrowserEntry(entry *archiveEntry) *types.ArchiveBrowserEntry {
+	result := &types.ArchiveBrowserEntry{
+		Name:     entry.Name,
+		Size:     entry.SizeUncompressed,
+		Modified: entry.Modified,
+	}
+	if entry.SizeCompressed >= 0 {
+		sizeCompressed := entry.SizeCompressed
+		result.SizeCompressed = &sizeCompressed
+	}
+	if entry.CRC32 != "" {
+		crc32 := entry.CRC32
+		result.CRC32 = &crc32
+	}
+	return result
+}
+
+// listArchive lists the entries of the archive without reading their content.
+// Tar archives have to be read sequentially, so listing them reads the whole archive.
+func (s *SiteService) listArchive(ctx context.Context, archive *storedArchive) (*types.ArchiveBrowserPageData, error) {
+	defer archive.Close()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*types.ArchiveBrowserEntry, 0)
+	err = visitArchive(archive.obj, archive.size, archive.name, func(entry *archiveEntry, _ func() (io.ReadCloser, error)) error {
+		entries = append(entries, toArchiveBrowserEntry(entry))
+		return nil
+	})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, perr("failed to read archive", http.StatusInternalServerError)
+	}
+
+	pageData := &types.ArchiveBrowserPageData{
+		BasePageData: *bpd,
+		ArchiveName:  archive.originalFilename,
+		FileURL:      archive.url,
+		Entries:      entries,
+	}
+
+	return pageData, nil
+}
+
+// readArchiveEntry calls fn with the content of the entry, the reader is only valid until fn returns
+func readArchiveEntry(ctx context.Context, archive *storedArchive, name string, fn func(entry *types.ArchiveBrowserEntry, r io.Reader) error) error {
+	err := visitArchive(archive.obj, archive.size, archive.name, func(entry *archiveEntry, open func() (io.ReadCloser, error)) error {
+		if entry.Name != name {
+			return nil
+		}
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		if err := fn(toArchiveBrowserEntry(entry), rc); err != nil {
+			return err
+		}
+		return errArchiveEntryFound
+	})
+	if errors.Is(err, errArchiveEntryFound) {
+		return nil
+	}
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return perr("failed to read archive", http.StatusInternalServerError)
+	}
+	return perr(fmt.Sprintf("archive entry '%s' not found", name), http.StatusNotFound)
+}
+
+// describeArchiveEntry reads the entry, hashes it and prepares the inline preview
+func describeArchiveEntry(entry *types.ArchiveBrowserEntry, r io.Reader) (*types.ArchiveEntryDetails, error) {
+	sha256sum := sha256.New()
+	md5sum := md5.New()
+	head := &headBuffer{limit: maxEntryPreviewSize}
+
+	size, err := io.Copy(io.MultiWriter(sha256sum, md5sum, head), r)
+	if err != nil {
+		return nil, err
+	}
+
+	content := head.Bytes()
+	sniff := content
+	if len(sniff) > fileTypeSniffLength {
+		sniff = sniff[:fileTypeSniffLength]
+	}
+
+	details := &types.ArchiveEntryDetails{
+		ArchiveBrowserEntry: *entry,
+		SHA256:              hex.EncodeToString(sha256sum.Sum(nil)),
+		MD5:                 hex.EncodeToString(md5sum.Sum(nil)),
+		FileType:            describeFileType(entry.Name, sniff),
+		PreviewKind:         types.ArchivePreviewNone,
+	}
+	details.Size = size
+
+	contentType := http.DetectContentType(sniff)
+	switch {
+	case bytes.HasPrefix(content, []byte("FWS")) || bytes.HasPrefix(content, []byte("CWS")) || bytes.HasPrefix(content, []byte("ZWS")):
+		swf, err := parseSWFHeader(content)
+		if err == nil {
+			details.PreviewKind = types.ArchivePreviewSWF
+			details.SWFHeader = swf
+		}
+	case IsInlineImage(contentType):
+		details.PreviewKind = types.ArchivePreviewImage
+	case strings.HasPrefix(contentType, "text/") && utf8.Valid(trimIncompleteRune(content)):
+		details.PreviewKind = types.ArchivePreviewText
+		if strings.HasPrefix(contentType, "text/html") {
+			details.PreviewKind = types.ArchivePreviewHTML
+		}
+		text := string(trimIncompleteRune(content))
+		details.PreviewText = &text
+		details.TextTruncated = size > int64(len(content))
+	}
+
+	return details, nil
+}
+
+// IsInlineImage reports whether the image can be served inline, svg is excluded because it can carry scripts
+func IsInlineImage(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/gif", "image/jpeg", "image/webp", "image/bmp":
+		return true
+	}
+	return false
+}
+
+// trimIncompleteRune drops a multibyte character cut in half by the preview size limit
+func trimIncompleteRune(b []byte) []byte {
+	for i := 0; i < utf8.UTFMax && i < len(b); i++ {
+		if utf8.RuneStart(b[len(b)-1-i]) {
+			if !utf8.FullRune(b[len(b)-1-i:]) {
+				return b[:len(b)-1-i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// parseSWFHeader reads the SWF header, decompressing just enough of the movie to get to the frame count
+func parseSWFHeader(b []byte) (*types.SWFHeader, error) {
+	if len(b) < 8 {
+		return nil, errors.New("swf header is too short")
+	}
+
+	header := &types.SWFHeader{
+		Version:    int(b[3]),
+		FileLength: binary.LittleEndian.Uint32(b[4:8]),
+	}
+
+	var body io.Reader
+	switch b[0] {
+	case 'F':
+		header.Compression = "none"
+		body = bytes.NewReader(b[8:])
+	case 'C':
+		header.Compression = "zlib"
+		zr, err := zlib.NewReader(bytes.NewReader(b[8:]))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		body = zr
+	case 'Z':
+		header.Compression = "lzma"
+		// 4 bytes of compressed length and 5 bytes of properties, the classic lzma header also wants the uncompressed size
+		if len(b) < 17 {
+			return nil, errors.New("swf header is too short")
+		}
+		lzmaHeader := make([]byte, 13)
+		copy(lzmaHeader, b[12:17])
+		binary.LittleEndian.PutUint64(lzmaHeader[5:], uint64(header.FileLength-8))
+		lr, err := lzma.NewReader(io.MultiReader(bytes.NewReader(lzmaHeader), bytes.NewReader(b[17:])))
+		if err != nil {
+			return nil, err
+		}
+		body = lr
+	default:
+		return nil, errors.New("not a swf file")
+	}
+
+	// the rect takes at most 17 bytes, followed by the frame rate and frame count
+	buf := make([]byte, 21)
+	n, err := io.ReadFull(body, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+	if len(buf) < 1 {
+		return nil, errors.New("swf header is too short")
+	}
+
+	nbits := int(buf[0] >> 3)
+	rectBytes := (5 + 4*nbits + 7) / 8
+	if len(buf) < rectBytes+4 {
+		return nil, errors.New("swf header is too short")
+	}
+
+	bit := 5
+	readSigned := func() int64 {
+		var v int64
+		for i := 0; i < nbits; i++ {
+			v = v<<1 | int64(buf[bit/8]>>(7-bit%8)&1)
+			bit++
+		}
+		if nbits > 0 && v&(1<<(nbits-1)) != 0 {
+			v -= 1 << nbits
+		}
+		return v
+	}
+	xMin, xMax, yMin, yMax := readSigned(), readSigned(), readSigned(), readSigned()
+
+	header.Width = float64(xMax-xMin) / 20
+	header.Height = float64(yMax-yMin) / 20
+	header.FrameRate = float64(buf[rectBytes+1]) + float64(buf[rectBytes])/256
+	header.FrameCount = binary.LittleEndian.Uint16(buf[rectBytes+2 : rectBytes+4])
+
+	return header, nil
+}
+
+func (s *SiteService) archiveEntryPageData(ctx context.Context, archive *storedArchive, name string) (*types.ArchiveEntryPageData, error) {
+	defer archive.Close()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var details *types.ArchiveEntryDetails
+	err = readArchiveEntry(ctx, archive, name, func(entry *types.ArchiveBrowserEntry, r io.Reader) error {
+		var err error
+		details, err = describeArchiveEntry(entry, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pageData := &types.ArchiveEntryPageData{
+		BasePageData: *bpd,
+		ArchiveName:  archive.originalFilename,
+		FileURL:      archive.url,
+		Entry:        details,
+	}
+
+	return pageData, nil
+}
+
+// GetSubmissionArchiveBrowserPageData lists the entries of the submission file
+func (s *SiteService) GetSubmissionArchiveBrowserPageData(ctx context.Context, sid, sfid int64) (*types.ArchiveBrowserPageData, error) {
+	archive, err := s.openSubmissionArchive(ctx, sid, sfid)
+	if err != nil {
+		return nil, err
+	}
+	return s.listArchive(ctx, archive)
+}
+
+// GetFlashfreezeArchiveBrowserPageData lists the entries of the flashfreeze root file
+func (s *SiteService) GetFlashfreezeArchiveBrowserPageData(ctx context.Context, fid int64) (*types.ArchiveBrowserPageData, error) {
+	archive, err := s.openFlashfreezeArchive(ctx, fid)
+	if err != nil {
+		return nil, err
+	}
+	return s.listArchive(ctx, archive)
+}
+
+// GetSubmissionArchiveEntryPageData reads a single entry of the submission file
+func (s *SiteService) GetSubmissionArchiveEntryPageData(ctx context.Context, sid, sfid int64, name string) (*types.ArchiveEntryPageData, error) {
+	archive, err := s.openSubmissionArchive(ctx, sid, sfid)
+	if err != nil {
+		return nil, err
+	}
+	return s.archiveEntryPageData(ctx, archive, name)
+}
+
+// GetFlashfreezeArchiveEntryPageData reads a single entry of the flashfreeze root file
+func (s *SiteService) GetFlashfreezeArchiveEntryPageData(ctx context.Context, fid int64, name string) (*types.ArchiveEntryPageData, error) {
+	archive, err := s.openFlashfreezeArchive(ctx, fid)
+	if err != nil {
+		return nil, err
+	}
+	return s.archiveEntryPageData(ctx, archive, name)
+}
+
+// ReadSubmissionArchiveEntry calls fn with the content of a single entry of the submission file
+func (s *SiteService) ReadSubmissionArchiveEntry(ctx context.Context, sid, sfid int64, name string, fn func(entry *types.ArchiveBrowserEntry, r io.Reader) error) error {
+	archive, err := s.openSubmissionArchive(ctx, sid, sfid)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return readArchiveEntry(ctx, archive, name, fn)
+}
+
+// ReadFlashfreezeArchiveEntry calls fn with the content of a single entry of the flashfreeze root file
+func (s *SiteService) ReadFlashfreezeArchiveEntry(ctx context.Context, fid int64, name string, fn func(entry *types.ArchiveBrowserEntry, r io.Reader) error) error {
+	archive, err := s.openFlashfreezeArchive(ctx, fid)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return readArchiveEntry(ctx, archive, name, fn)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// fileBlobRetentionPeriod is how long blobs are kept after their last submission or flashfreeze file got deleted
+const fileBlobRetentionPeriod = 30 * 24 * time.Hour
+
+// moveToBlobStore moves a received file into the blob store
+func (s *SiteService) moveToBlobStore(ctx context.Context, filePath, sha256sum string) error {
+	key, err := s.blobs.Ingest(ctx, filePath, sha256sum)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return err
+	}
+	utils.LogCtx(ctx).WithField("blobKey", key).Debug("file moved to blob store")
+	return nil
+}
+
+// CollectFileBlobGarbage removes blobs which were unreferenced for longer than the retention period,
+// together with their pre-blob-store copies. Stored blobs without any record (left behind by failed uploads) are removed too.
+//
+// Uploads acquire the record of their blob before looking for it in the store, so the blobs are removed while their
+// records are locked. An upload of the same content waits until the blob is gone and then stores its own copy.
+// If the transaction fails after that, the record stays unreferenced and the next upload stores its copy the same way.
+func (s *SiteService) CollectFileBlobGarbage(ctx context.Context) (int, error) {
+	threshold := s.clock.Now().Add(-fileBlobRetentionPeriod)
+
+	removed, err := s.removeUnreferencedFileBlobs(ctx, threshold)
+	if err != nil {
+		return removed, err
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return removed, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	known, err := s.dal.GetAllFileBlobSHA256Sums(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return removed, dberr(err)
+	}
+
+	err = s.blobs.Walk(ctx, func(sha256sum, key string, modTime time.Time) error {
+		if _, ok := known[sha256sum]; ok || modTime.After(threshold) {
+			return nil
+		}
+		ok, err := s.removeOrphanedFileBlob(ctx, sha256sum, key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return removed, err
+	}
+
+	return removed, nil
+}
+
+func (s *SiteService) removeUnreferencedFileBlobs(ctx context.Context, threshold time.Time) (int, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	garbage, err := s.dal.GetUnreferencedFileBlobs(dbs, threshold)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	removed := 0
+
+	for _, sha256sum := range garbage {
+		deleted, err := s.dal.DeleteFileBlob(dbs, sha256sum)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+		if !deleted {
+			continue
+		}
+
+		submissionFilenames, flashfreezeFilenames, err := s.dal.GetDeletedFilenamesBySHA256(dbs, sha256sum)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+
+		for _, filename := range submissionFilenames {
+			if err := s.volumes.Submissions.Remove(ctx, filename); err != nil {
+				utils.LogCtx(ctx).Error(err)
+				return 0, err
+			}
+		}
+		for _, filename := range flashfreezeFilenames {
+			if err := s.volumes.Flashfreeze.Remove(ctx, filename); err != nil {
+				utils.LogCtx(ctx).Error(err)
+				return 0, err
+			}
+		}
+
+		if err := s.blobs.Remove(ctx, sha256sum); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, err
+		}
+
+		utils.LogCtx(ctx).WithField("sha256sum", sha256sum).Info("removed unreferenced blob")
+		removed++
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	return removed, nil
+}
+
+// removeOrphanedFileBlob removes a stored blob which has no record, unless an upload created the record in the meantime
+func (s *SiteService) removeOrphanedFileBlob(ctx context.Context, sha256sum, key string) (bool, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		return false, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	exists, err := s.dal.LockFileBlob(dbs, sha256sum)
+	if err != nil {
+		return false, dberr(err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := s.volumes.Blobs.Remove(ctx, key); err != nil {
+		return false, err
+	}
+	utils.LogCtx(ctx).WithField("blobKey", key).Info("removed orphaned blob")
+
+	if err := dbs.Commit(); err != nil {
+		return true, dberr(err)
+	}
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// maxChecklistLabelLength matches the database column
+const maxChecklistLabelLength = 255
+
+// checklistFor returns the checks which apply to the submission
+func checklistFor(items []*types.ReviewChecklistItem, submission *types.ExtendedSubmission) []*types.ReviewChecklistItem {
+	result := make([]*types.ReviewChecklistItem, 0, len(items))
+	for _, item := range items {
+		if item.Platform == "" || platformsMatch([]string{item.Platform}, submission) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// checklistResults validates the checklist (result by item id) filled in for the submission
+func checklistResults(items []*types.ReviewChecklistItem, submission *types.ExtendedSubmission, action string, checklist map[int64]string) ([]*types.ChecklistResult, error) {
+	applicable := checklistFor(items, submission)
+	results := make([]*types.ChecklistResult, 0, len(checklist))
+
+	for _, item := range applicable {
+		result, ok := checklist[item.ID]
+		if !ok {
+			continue
+		}
+		if result != types.ChecklistPass && result != types.ChecklistFail && result != types.ChecklistNotApplicable {
+			return nil, perr(fmt.Sprintf("invalid result '%s' of check '%s'", result, item.Label), http.StatusBadRequest)
+		}
+		if result == types.ChecklistFail && action == constants.ActionApprove {
+			return nil, perr(fmt.Sprintf("cannot approve submission %d which failed the check '%s', request changes instead", submission.SubmissionID, item.Label), http.StatusBadRequest)
+		}
+		results = append(results, &types.ChecklistResult{ItemID: item.ID, Label: item.Label, Result: result})
+	}
+
+	if len(results) != len(checklist) {
+		return nil, perr(fmt.Sprintf("checklist contains checks which do not apply to submission %d", submission.SubmissionID), http.StatusBadRequest)
+	}
+
+	return results, nil
+}
+
+func (s *SiteService) GetReviewChecklistsPageData(ctx context.Context) (*types.ReviewChecklistsPageData, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.dal.GetReviewChecklistItems(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.ReviewChecklistsPageData{
+		BasePageData:         *bpd,
+		ReviewChecklistItems: items,
+	}
+
+	return pageData, nil
+}
+
+// SaveReviewChecklistItem creates the check, or updates it if it has an id
+func (s *SiteService) SaveReviewChecklistItem(ctx context.Context, item *types.ReviewChecklistItem) error {
+	item.Platform = strings.TrimSpace(item.Platform)
+	item.Label = strings.TrimSpace(item.Label)
+
+	if item.Label == "" || len(item.Label) > maxChecklistLabelLength {
+		return perr(fmt.Sprintf("label must be between 1 and %d characters long", maxChecklistLabelLength), http.StatusBadRequest)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	item.UpdatedBy = utils.UserID(ctx)
+	item.UpdatedAt = s.clock.Now()
+
+	if _, err := s.dal.StoreReviewChecklistItem(dbs, item); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// DeleteReviewChecklistItem removes the check from the checklists, results already stored with comments stay
+func (s *SiteService) DeleteReviewChecklistItem(ctx context.Context, id int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.DeleteReviewChecklistItem(dbs, id); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+func Test_checklistResults(t *testing.T) {
+	flash := "Flash"
+	html5 := "HTML5; Flash"
+	items := []*types.ReviewChecklistItem{
+		{ID: 1, Label: "Game launches"},
+		{ID: 2, Platform: "Flash", Label: "SWF is not a loader"},
+		{ID: 3, Platform: "Shockwave", Label: "Xtras are included"},
+	}
+
+	type args struct {
+		submission *types.ExtendedSubmission
+		action     string
+		checklist  map[int64]string
+	}
+
+	tests := []struct {
+		name        string
+		args        args
+		wantResults []string
+		wantErr     bool
+	}{
+		{
+			name: "empty checklist is allowed",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{},
+			},
+			wantResults: []string{},
+		},
+		{
+			name: "passed checks approve",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{1: types.ChecklistPass, 2: types.ChecklistNotApplicable},
+			},
+			wantResults: []string{"Game launches=pass", "SWF is not a loader=n/a"},
+		},
+		{
+			name: "platform check applies to one of several platforms",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &html5},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{2: types.ChecklistPass},
+			},
+			wantResults: []string{"SWF is not a loader=pass"},
+		},
+		{
+			name: "failed check cannot approve",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{1: types.ChecklistPass, 2: types.ChecklistFail},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failed check requests changes",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionRequestChanges,
+				checklist:  map[int64]string{2: types.ChecklistFail},
+			},
+			wantResults: []string{"SWF is not a loader=fail"},
+		},
+		{
+			name: "unknown result",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{1: "maybe"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "check of another platform",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{3: types.ChecklistPass},
+			},
+			wantErr: true,
+		},
+		{
+			name: "platform check without a platform",
+			args: args{
+				submission: &types.ExtendedSubmission{},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{2: types.ChecklistPass},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown check",
+			args: args{
+				submission: &types.ExtendedSubmission{CurationPlatform: &flash},
+				action:     constants.ActionApprove,
+				checklist:  map[int64]string{1: types.ChecklistPass, 42: types.ChecklistPass},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := checklistResults(items, tt.args.submission, tt.args.action, tt.args.checklist)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checklistResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := make([]string, 0, len(results))
+			for _, r := range results {
+				got = append(got, r.Label+"="+r.Result)
+			}
+			if len(got) != len(tt.wantResults) {
+				t.Fatalf("checklistResults() = %v, want %v", got, tt.wantResults)
+			}
+			for i := range got {
+				if got[i] != tt.wantResults[i] {
+					t.Errorf("checklistResults() = %v, want %v", got, tt.wantResults)
+				}
+			}
+		})
+	}
+}
@@ -11,8 +11,10 @@ import (
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 )
 
+// ReceiveComments stores the comment on all the submissions. The checklist (result by check id) can only be filled in
+// when approving or requesting changes on a single submission.
 func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int64, formAction, formMessage,
-	formIgnoreDupeActions, subDirFullPath, dataPacksDir, frozenPacksDir, imagesDir string, r *http.Request) error {
+	formIgnoreDupeActions string, checklist map[int64]string, r *http.Request) error {
 	dbs, err := s.dal.NewSession(ctx)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -27,6 +29,12 @@ func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int
 	}
 	defer pgdbs.Rollback()
 
+	for _, sid := range sids {
+		if err := s.requireVisibleSubmission(dbs, sid, uid); err != nil {
+			return err
+		}
+	}
+
 	var message *string
 	if formMessage != "" {
 		message = &formMessage
@@ -71,6 +79,21 @@ func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int
 		return perr("cannot reject multiple submissions at once", http.StatusBadRequest)
 	}
 
+	var checklistItems []*types.ReviewChecklistItem
+	if len(checklist) > 0 {
+		if formAction != constants.ActionApprove && formAction != constants.ActionRequestChanges {
+			return perr("checklists can only be filled in when approving or requesting changes", http.StatusBadRequest)
+		}
+		if len(sids) > 1 {
+			return perr("cannot fill in a checklist for multiple submissions at once", http.StatusBadRequest)
+		}
+		checklistItems, err = s.dal.GetReviewChecklistItems(dbs)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return dberr(err)
+		}
+	}
+
 	utils.LogCtx(ctx).Debugf("searching submissions for comment batch")
 	foundSubmissions, _, err := s.dal.SearchSubmissions(dbs, &types.SubmissionsFilter{SubmissionIDs: sids})
 	if err != nil {
@@ -90,13 +113,27 @@ func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int
 		}
 	}
 
+	userRoles, err := s.dal.GetDiscordUserRoles(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	wf, err := s.loadWorkflow(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
 	commentCounter := 0
 
 	// TODO optimize batch operation even more
 	for _, submission := range foundSubmissions {
 		sid := submission.SubmissionID
 
-		err := isActionValidForSubmission(uid, formAction, submission)
+		err := isActionValidForSubmission(wf, uid, formAction, submission)
+		if err == nil && !wf.HasRequiredRole(userRoles, formAction, submission) {
+			err = perr(fmt.Sprintf("submission %d needs the %s role to %s it", sid, wf.Requirement(submission).RequiredRole, formAction), http.StatusForbidden)
+		}
 		if err != nil {
 			if ignoreDupeActions {
 				continue
@@ -104,10 +141,17 @@ func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int
 			return err
 		}
 
+		var results []*types.ChecklistResult
+		if len(checklist) > 0 {
+			results, err = checklistResults(checklistItems, submission, formAction, checklist)
+			if err != nil {
+				return err
+			}
+		}
+
 		// If marking as added, make sure we update the live metadata before approving the comment
 		if formAction == constants.ActionMarkAdded {
-			gameId, err := s.AddSubmissionToFlashpoint(ctx, submission, subDirFullPath, dataPacksDir,
-				frozenPacksDir, imagesDir, r)
+			gameId, err := s.AddSubmissionToFlashpoint(ctx, submission, r)
 			if err != nil {
 				utils.LogCtx(ctx).Error(err)
 				return err
@@ -165,6 +209,10 @@ func (s *SiteService) ReceiveComments(ctx context.Context, uid int64, sids []int
 			utils.LogCtx(ctx).Error(err)
 			return dberr(err)
 		}
+		if err := s.dal.StoreCommentChecklistResults(dbs, cid, results); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return dberr(err)
+		}
 
 		// unassign if needed
 		if formAction == constants.ActionApprove {
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// orphanGracePeriod keeps files which are still being received from being reported as orphans
+const orphanGracePeriod = 24 * time.Hour
+
+type namedVolume struct {
+	name string
+	st   storage.Storage
+}
+
+// fileConsistencyCheck holds the state of a single CheckFileConsistency run
+type fileConsistencyCheck struct {
+	s         *SiteService
+	ctx       context.Context
+	report    *types.FileConsistencyReport
+	threshold time.Time
+}
+
+// listTopLevel returns the objects in the root of the volume, nested objects belong to something else (e.g. an ingest directory)
+func listTopLevel(ctx context.Context, st storage.Storage) (map[string]*storage.ObjectInfo, error) {
+	result := make(map[string]*storage.ObjectInfo)
+	err := st.Walk(ctx, "", func(info *storage.ObjectInfo) error {
+		if !strings.Contains(info.Key, "/") {
+			result[info.Key] = info
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (c *fileConsistencyCheck) addIssue(kind string, volume namedVolume, key, details string) *types.FileConsistencyIssue {
+	issue := &types.FileConsistencyIssue{
+		Kind:    kind,
+		Volume:  volume.name,
+		Key:     key,
+		Details: details,
+	}
+	c.report.Issues = append(c.report.Issues, issue)
+	c.report.IssueCounts[kind]++
+	utils.LogCtx(c.ctx).WithField("kind", kind).WithField("volume", volume.name).WithField("key", key).Warn(details)
+	return issue
+}
+
+// addOrphan reports the object as orphaned and moves it into the quarantine if asked to
+func (c *fileConsistencyCheck) addOrphan(volume namedVolume, info *storage.ObjectInfo, details string) error {
+	if info.ModTime.After(c.threshold) {
+		return nil
+	}
+	issue := c.addIssue(types.FileIssueOrphan, volume, info.Key, details)
+	if !c.report.Quarantine {
+		return nil
+	}
+	if err := storage.Move(c.ctx, volume.st, info.Key, c.s.volumes.Quarantine, path.Join(volume.name, info.Key)); err != nil {
+		return err
+	}
+	issue.Quarantined = true
+	return nil
+}
+
+// hashObject computes the SHA256 and CRC32 checksums of the object
+func (c *fileConsistencyCheck) hashObject(st storage.Storage, key string) (string, uint32, error) {
+	obj, _, err := st.Open(c.ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer obj.Close()
+
+	sha256sum := sha256.New()
+	crc32sum := crc32.NewIEEE()
+	if _, err := io.Copy(io.MultiWriter(sha256sum, crc32sum), obj); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(sha256sum.Sum(nil)), crc32sum.Sum32(), nil
+}
+
+// expectedPackVolume returns the volume the data pack should be in. Frozen packs stay frozen even when the game is deleted.
+func (c *fileConsistencyCheck) expectedPackVolume(r *types.GameDataFileRecord, dataPacks, frozenPacks, deletedPacks namedVolume) namedVolume {
+	if r.ArchiveState == types.Archived {
+		return frozenPacks
+	}
+	if r.GameDeleted {
+		return deletedPacks
+	}
+	return dataPacks
+}
+
+func (c *fileConsistencyCheck) checkDataPacks(records []*types.GameDataFileRecord, verifyHashes bool) error {
+	dataPacks := namedVolume{"data-packs", c.s.volumes.DataPacks}
+	frozenPacks := namedVolume{"frozen-packs", c.s.volumes.FrozenPacks}
+	deletedPacks := namedVolume{"deleted-data-packs", c.s.volumes.DeletedDataPacks}
+	volumes := []namedVolume{dataPacks, frozenPacks, deletedPacks}
+
+	listings := make(map[string]map[string]*storage.ObjectInfo, len(volumes))
+	for _, volume := range volumes {
+		listing, err := listTopLevel(c.ctx, volume.st)
+		if err != nil {
+			return err
+		}
+		listings[volume.name] = listing
+	}
+
+	known := make(map[string]struct{}, len(records))
+
+	for _, r := range records {
+		key := DataPackKey(r.GameID, r.DateAdded)
+		known[key] = struct{}{}
+		expected := c.expectedPackVolume(r, dataPacks, frozenPacks, deletedPacks)
+
+		if _, ok := listings[expected.name][key]; !ok {
+			found := false
+			for _, volume := range volumes {
+				if _, ok := listings[volume.name][key]; ok {
+					c.addIssue(types.FileIssueMisplaced, volume, key,
+						fmt.Sprintf("data pack of game %s should be in %s", r.GameID, expected.name))
+					found = true
+				}
+			}
+			if !found {
+				c.addIssue(types.FileIssueMissing, expected, key, fmt.Sprintf("data pack of game %s does not exist", r.GameID))
+			}
+			continue
+		}
+
+		c.report.CheckedFiles++
+
+		if !verifyHashes {
+			continue
+		}
+		sha256sum, crc32sum, err := c.hashObject(expected.st, key)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sha256sum, r.SHA256) {
+			c.addIssue(types.FileIssueHashMismatch, expected, key,
+				fmt.Sprintf("data pack of game %s has sha256 %s, expected %s", r.GameID, sha256sum, r.SHA256))
+		} else if r.CRC32 != 0 && int32(crc32sum) != int32(r.CRC32) {
+			c.addIssue(types.FileIssueHashMismatch, expected, key,
+				fmt.Sprintf("data pack of game %s has crc32 %d, expected %d", r.GameID, int32(crc32sum), r.CRC32))
+		}
+	}
+
+	for _, volume := range volumes {
+		for key, info := range listings[volume.name] {
+			if _, ok := known[key]; ok {
+				continue
+			}
+			if err := c.addOrphan(volume, info, "data pack without game data"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *fileConsistencyCheck) checkStoredFiles(records []*types.StoredFileRecord, verifyHashes bool) error {
+	blobs := namedVolume{"blobs", c.s.volumes.Blobs}
+	submissions := namedVolume{"submissions", c.s.volumes.Submissions}
+	flashfreeze := namedVolume{"flashfreeze", c.s.volumes.Flashfreeze}
+	submissionImages := namedVolume{"submission-images", c.s.volumes.SubmissionImages}
+
+	blobKeys := make(map[string]*storage.ObjectInfo)
+	err := c.s.blobs.Walk(c.ctx, func(sha256sum, key string, modTime time.Time) error {
+		blobKeys[sha256sum] = &storage.ObjectInfo{Key: key, ModTime: modTime}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	legacy := make(map[string]map[string]*storage.ObjectInfo)
+	for _, volume := range []namedVolume{submissions, flashfreeze, submissionImages} {
+		listing, err := listTopLevel(c.ctx, volume.st)
+		if err != nil {
+			return err
+		}
+		legacy[volume.name] = listing
+	}
+
+	referencedBlobs := make(map[string]struct{})
+	knownFiles := map[string]map[string]struct{}{
+		submissions.name:      {},
+		flashfreeze.name:      {},
+		submissionImages.name: {},
+	}
+
+	for _, r := range records {
+		var volume namedVolume
+		switch r.Kind {
+		case "submission-file":
+			volume = submissions
+		case "flashfreeze-file":
+			volume = flashfreeze
+		default:
+			volume = submissionImages
+		}
+		knownFiles[volume.name][r.CurrentFilename] = struct{}{}
+		if r.SHA256Sum != "" {
+			referencedBlobs[r.SHA256Sum] = struct{}{}
+		}
+
+		// deleted files can be gone already, the blob collector removes them after the retention period
+		if r.Deleted {
+			continue
+		}
+
+		if _, ok := blobKeys[r.SHA256Sum]; ok && r.SHA256Sum != "" {
+			c.report.CheckedFiles++
+			continue
+		}
+		if _, ok := legacy[volume.name][r.CurrentFilename]; ok {
+			c.report.CheckedFiles++
+			continue
+		}
+		c.addIssue(types.FileIssueMissing, volume, r.CurrentFilename, fmt.Sprintf("%s %d does not exist", r.Kind, r.ID))
+	}
+
+	for sha256sum, info := range blobKeys {
+		if _, ok := referencedBlobs[sha256sum]; !ok {
+			if err := c.addOrphan(blobs, info, "blob without submission or flashfreeze file"); err != nil {
+				return err
+			}
+			continue
+		}
+		if !verifyHashes {
+			continue
+		}
+		actual, _, err := c.hashObject(blobs.st, info.Key)
+		if err != nil {
+			return err
+		}
+		if actual != sha256sum {
+			c.addIssue(types.FileIssueHashMismatch, blobs, info.Key, fmt.Sprintf("blob has sha256 %s", actual))
+		}
+	}
+
+	for _, volume := range []namedVolume{submissions, flashfreeze, submissionImages} {
+		for key, info := range legacy[volume.name] {
+			if _, ok := knownFiles[volume.name][key]; ok {
+				continue
+			}
+			if err := c.addOrphan(volume, info, "file without database record"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckFileConsistency compares the stored files with the database. It reports missing files, files without any record,
+// checksum mismatches and data packs which are in the wrong volume for the state of their game.
+// Orphans can be moved into the quarantine volume.
+func (s *SiteService) CheckFileConsistency(ctx context.Context, verifyHashes, quarantine bool) (*types.FileConsistencyReport, error) {
+	if quarantine && s.volumes.Quarantine == nil {
+		return nil, perr("quarantine storage is not configured", http.StatusBadRequest)
+	}
+
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	gameDataRecords, err := s.pgdal.GetGameDataFileRecords(pgdbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	storedFileRecords, err := s.dal.GetStoredFileRecords(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	c := &fileConsistencyCheck{
+		s:   s,
+		ctx: ctx,
+		report: &types.FileConsistencyReport{
+			StartedAt:    s.clock.Now(),
+			VerifyHashes: verifyHashes,
+			Quarantine:   quarantine,
+			Issues:       make([]*types.FileConsistencyIssue, 0),
+			IssueCounts:  make(map[string]int64),
+		},
+		threshold: s.clock.Now().Add(-orphanGracePeriod),
+	}
+
+	err = c.checkDataPacks(gameDataRecords, verifyHashes)
+	if err == nil {
+		err = c.checkStoredFiles(storedFileRecords, verifyHashes)
+	}
+	c.report.FinishedAt = s.clock.Now()
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		msg := err.Error()
+		c.report.Error = &msg
+	}
+
+	s.fileConsistencyReportMutex.Lock()
+	s.fileConsistencyReport = c.report
+	s.fileConsistencyReportMutex.Unlock()
+
+	return c.report, err
+}
+
+// GetLastFileConsistencyReport returns the report of the last consistency check, if any
+func (s *SiteService) GetLastFileConsistencyReport() *types.FileConsistencyReport {
+	s.fileConsistencyReportMutex.Lock()
+	defer s.fileConsistencyReportMutex.Unlock()
+	return s.fileConsistencyReport
+}
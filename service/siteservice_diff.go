@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// archiveEntryDigest identifies the content of a file inside an archive
+type archiveEntryDigest struct {
+	size   int64
+	sha256 string
+}
+
+// diffCurationMeta lists the meta fields which differ, an empty field is the same as a missing one
+func diffCurationMeta(from, to *types.CurationMeta) []*types.CurationMetaChange {
+	if from == nil {
+		from = &types.CurationMeta{}
+	}
+	if to == nil {
+		to = &types.CurationMeta{}
+	}
+
+	fv := reflect.ValueOf(from).Elem()
+	tv := reflect.ValueOf(to).Elem()
+	stringPtr := reflect.TypeOf((*string)(nil))
+
+	result := make([]*types.CurationMetaChange, 0)
+	for i := 0; i < fv.NumField(); i++ {
+		field := fv.Type().Field(i)
+		if field.Type != stringPtr {
+			continue
+		}
+		a := fv.Field(i).Interface().(*string)
+		b := tv.Field(i).Interface().(*string)
+		if derefOrEmpty(a) == derefOrEmpty(b) {
+			continue
+		}
+		result = append(result, &types.CurationMetaChange{
+			Field: strings.Split(field.Tag.Get("json"), ",")[0],
+			From:  a,
+			To:    b,
+		})
+	}
+
+	return result
+}
+
+func derefOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// diffArchiveEntries lists the added, removed and changed files sorted by name, and counts the unchanged ones
+func diffArchiveEntries(from, to map[string]*archiveEntryDigest) ([]*types.ArchiveEntryChange, int) {
+	result := make([]*types.ArchiveEntryChange, 0)
+	unchanged := 0
+
+	for name, a := range from {
+		b, ok := to[name]
+		if !ok {
+			result = append(result, &types.ArchiveEntryChange{
+				Name: name, Change: types.DiffRemoved,
+				FromSize: &a.size, FromSHA256: &a.sha256,
+			})
+			continue
+		}
+		if a.sha256 == b.sha256 {
+			unchanged++
+			continue
+		}
+		result = append(result, &types.ArchiveEntryChange{
+			Name: name, Change: types.DiffChanged,
+			FromSize: &a.size, FromSHA256: &a.sha256,
+			ToSize: &b.size, ToSHA256: &b.sha256,
+		})
+	}
+	for name, b := range to {
+		if _, ok := from[name]; ok {
+			continue
+		}
+		result = append(result, &types.ArchiveEntryChange{
+			Name: name, Change: types.DiffAdded,
+			ToSize: &b.size, ToSHA256: &b.sha256,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, unchanged
+}
+
+// submissionFileEntries returns the digests of the files in the submission archive. Archives can be several GB, so the digests
+// are cached by the checksum of the archive, which identifies its content.
+func (s *SiteService) submissionFileEntries(ctx context.Context, sf *types.ExtendedSubmissionFile) (map[string]*archiveEntryDigest, error) {
+	entries, err, _ := s.archiveDigestCache.Memoize(sf.SHA256Sum, func() (interface{}, error) {
+		return s.readSubmissionFileEntries(ctx, sf)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries.(map[string]*archiveEntryDigest), nil
+}
+
+// readSubmissionFileEntries lists and hashes the files in the top level of the submission archive
+func (s *SiteService) readSubmissionFileEntries(ctx context.Context, sf *types.ExtendedSubmissionFile) (map[string]*archiveEntryDigest, error) {
+	st, key, err := s.SubmissionFileLocation(ctx, &types.SubmissionFile{
+		ID:              sf.FileID,
+		SubmissionID:    sf.SubmissionID,
+		CurrentFilename: sf.CurrentFilename,
+		SHA256Sum:       sf.SHA256Sum,
+	})
+	if err != nil {
+		return nil, err
+	}
+	filePath, release, err := st.LocalPath(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result := make(map[string]*archiveEntryDigest)
+	err = walkArchiveFile(filePath, func(entry *archiveEntry, r io.Reader) error {
+		h := sha256.New()
+		size, err := io.Copy(h, r)
+		if err != nil {
+			return err
+		}
+		result[entry.Name] = &archiveEntryDigest{size: size, sha256: hex.EncodeToString(h.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// hashCurationImage returns the SHA256 checksum of the stored curation image
+func (s *SiteService) hashCurationImage(ctx context.Context, ci *types.CurationImage) (string, error) {
+	obj, _, err := s.volumes.SubmissionImages.Open(ctx, ci.Filename)
+	if err != nil {
+		return "", err
+	}
+	defer obj.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, obj); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// diffCurationImages pairs the images of both files by type and lists the ones which differ
+func (s *SiteService) diffCurationImages(ctx context.Context, from, to []*types.CurationImage) ([]*types.CurationImageChange, error) {
+	byType := func(cis []*types.CurationImage) map[string]*types.CurationImage {
+		result := make(map[string]*types.CurationImage, len(cis))
+		for _, ci := range cis {
+			result[ci.Type] = ci
+		}
+		return result
+	}
+	fromByType := byType(from)
+	toByType := byType(to)
+
+	imageTypes := make([]string, 0, len(fromByType)+len(toByType))
+	for t := range fromByType {
+		imageTypes = append(imageTypes, t)
+	}
+	for t := range toByType {
+		if _, ok := fromByType[t]; !ok {
+			imageTypes = append(imageTypes, t)
+		}
+	}
+	sort.Strings(imageTypes)
+
+	result := make([]*types.CurationImageChange, 0)
+	for _, t := range imageTypes {
+		a, aok := fromByType[t]
+		b, bok := toByType[t]
+		switch {
+		case !bok:
+			result = append(result, &types.CurationImageChange{Type: t, Change: types.DiffRemoved, FromID: &a.ID})
+		case !aok:
+			result = append(result, &types.CurationImageChange{Type: t, Change: types.DiffAdded, ToID: &b.ID})
+		default:
+			ah, err := s.hashCurationImage(ctx, a)
+			if err != nil {
+				return nil, err
+			}
+			bh, err := s.hashCurationImage(ctx, b)
+			if err != nil {
+				return nil, err
+			}
+			if ah != bh {
+				result = append(result, &types.CurationImageChange{Type: t, Change: types.DiffChanged, FromID: &a.ID, ToID: &b.ID})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// GetSubmissionDiffPageData compares two files of the submission. Zero file ids compare the two newest files.
+func (s *SiteService) GetSubmissionDiffPageData(ctx context.Context, sid, fromSFID, toSFID int64) (*types.SubmissionDiffPageData, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.requireVisibleSubmission(dbs, sid, utils.UserID(ctx)); err != nil {
+		return nil, err
+	}
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sfs, err := s.dal.GetExtendedSubmissionFilesBySubmissionID(dbs, sid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	if len(sfs) < 2 {
+		return nil, perr("submission needs at least two files to compare", http.StatusBadRequest)
+	}
+
+	// files are sorted from the newest
+	if fromSFID == 0 {
+		fromSFID = sfs[1].FileID
+	}
+	if toSFID == 0 {
+		toSFID = sfs[0].FileID
+	}
+
+	var from, to *types.ExtendedSubmissionFile
+	for _, sf := range sfs {
+		if sf.FileID == fromSFID {
+			from = sf
+		}
+		if sf.FileID == toSFID {
+			to = sf
+		}
+	}
+	if from == nil || to == nil {
+		return nil, perr(fmt.Sprintf("submission %d has no such file", sid), http.StatusNotFound)
+	}
+
+	diff := &types.SubmissionFileDiff{
+		From: from,
+		To:   to,
+	}
+
+	fromMeta, err := s.dal.GetCurationMetaBySubmissionFileID(dbs, from.FileID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	toMeta, err := s.dal.GetCurationMetaBySubmissionFileID(dbs, to.FileID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	diff.Meta = diffCurationMeta(fromMeta, toMeta)
+
+	fromImages, err := s.dal.GetCurationImagesBySubmissionFileID(dbs, from.FileID)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	toImages, err := s.dal.GetCurationImagesBySubmissionFileID(dbs, to.FileID)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	// the files are read from the storage from now on, which can take long, so the session isn't kept open meanwhile
+	dbs.Rollback()
+
+	diff.Images, err = s.diffCurationImages(ctx, fromImages, toImages)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, perr("failed to read curation images", http.StatusInternalServerError)
+	}
+
+	if from.SHA256Sum == to.SHA256Sum {
+		diff.IdenticalFiles = true
+		diff.Entries = make([]*types.ArchiveEntryChange, 0)
+	} else {
+		var fromEntries, toEntries map[string]*archiveEntryDigest
+		errs, ectx := errgroup.WithContext(ctx)
+		errs.Go(func() error {
+			var err error
+			fromEntries, err = s.submissionFileEntries(ectx, from)
+			return err
+		})
+		errs.Go(func() error {
+			var err error
+			toEntries, err = s.submissionFileEntries(ectx, to)
+			return err
+		})
+		if err := errs.Wait(); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, perr("failed to read submission files", http.StatusInternalServerError)
+		}
+		diff.Entries, diff.UnchangedEntries = diffArchiveEntries(fromEntries, toEntries)
+	}
+
+	pageData := &types.SubmissionDiffPageData{
+		BasePageData:    *bpd,
+		SubmissionFiles: sfs,
+		Diff:            diff,
+	}
+
+	return pageData, nil
+}
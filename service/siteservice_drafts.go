@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// maxSubmissionTemplateNameLength matches the database column
+const maxSubmissionTemplateNameLength = 255
+
+// PublishSubmission makes the draft visible to everyone and announces it in the curation feed, like a new upload
+func (s *SiteService) PublishSubmission(ctx context.Context, sid int64) error {
+	uid := utils.UserID(ctx)
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	submissions, _, err := s.dal.SearchSubmissions(dbs, &types.SubmissionsFilter{SubmissionIDs: []int64{sid}})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if len(submissions) == 0 {
+		return perr("submission not found", http.StatusNotFound)
+	}
+	submission := submissions[0]
+
+	published, err := s.dal.PublishSubmissionDraft(dbs, sid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if !published {
+		return perr("submission is not a draft", http.StatusBadRequest)
+	}
+
+	meta, err := s.dal.GetCurationMetaBySubmissionFileID(dbs, submission.FileID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if meta == nil {
+		meta = &types.CurationMeta{}
+	}
+
+	isAudition := submission.SubmissionLevel == constants.SubmissionLevelAudition
+	if isAudition {
+		if err := s.subscribeAuditionWatchers(dbs, submission.SubmitterID, sid); err != nil {
+			return err
+		}
+	}
+
+	isCurationValid := submission.BotAction == constants.ActionApprove
+	if err := s.createCurationFeedMessage(dbs, submission.SubmitterID, sid, true, isCurationValid, meta, isAudition); err != nil {
+		return dberr(err)
+	}
+
+	msg := fmt.Sprintf("The draft has been published by <@%d>.", uid)
+	c := &types.Comment{
+		AuthorID:     constants.SystemID,
+		SubmissionID: sid,
+		Message:      &msg,
+		Action:       constants.ActionSystem,
+		CreatedAt:    s.clock.Now(),
+	}
+	cid, err := s.dal.StoreComment(dbs, c)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if err := s.EmitSubmissionCommentEvent(pgdbs, c.AuthorID, c.SubmissionID, cid, c.Action, nil); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := pgdbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	s.announceNotification()
+
+	return nil
+}
+
+// requireVisibleSubmission returns 404 for drafts to anyone but their uploader and staff
+func (s *SiteService) requireVisibleSubmission(dbs database.DBSession, sid, uid int64) error {
+	uploaderID, err := s.dal.GetSubmissionDraftUploader(dbs, sid)
+	if err == sql.ErrNoRows {
+		return perr("submission not found", http.StatusNotFound)
+	}
+	if err != nil {
+		utils.LogCtx(dbs.Ctx()).Error(err)
+		return dberr(err)
+	}
+	if uploaderID == nil || *uploaderID == uid {
+		return nil
+	}
+
+	userRoles, err := s.dal.GetDiscordUserRoles(dbs, uid)
+	if err != nil {
+		utils.LogCtx(dbs.Ctx()).Error(err)
+		return dberr(err)
+	}
+	if constants.IsStaff(userRoles) {
+		return nil
+	}
+
+	return perr("submission not found", http.StatusNotFound)
+}
+
+// RequireVisibleSubmissions returns 404 if any of the submissions is a draft the user can't see
+func (s *SiteService) RequireVisibleSubmissions(ctx context.Context, sids []int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	uid := utils.UserID(ctx)
+	for _, sid := range sids {
+		if err := s.requireVisibleSubmission(dbs, sid, uid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SiteService) GetSubmissionTemplatesPageData(ctx context.Context) (*types.SubmissionTemplatesPageData, error) {
+	uid := utils.UserID(ctx)
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := s.dal.GetSubmissionTemplates(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.SubmissionTemplatesPageData{
+		BasePageData:        *bpd,
+		SubmissionTemplates: templates,
+		MetaFields:          editableCurationMetaFields,
+	}
+
+	return pageData, nil
+}
+
+// SaveSubmissionTemplate creates the template of the user, or updates it if it has an id. Empty fields are not stored.
+func (s *SiteService) SaveSubmissionTemplate(ctx context.Context, template *types.SubmissionTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" || len(template.Name) > maxSubmissionTemplateNameLength {
+		return perr(fmt.Sprintf("name must be between 1 and %d characters long", maxSubmissionTemplateNameLength), http.StatusBadRequest)
+	}
+
+	fields := make(map[string]string, len(template.Fields))
+	for key, value := range template.Fields {
+		if _, ok := curationMetaFieldByKey(&types.CurationMeta{}, key); !ok {
+			return perr(fmt.Sprintf("unknown curation meta field '%s'", key), http.StatusBadRequest)
+		}
+		value = strings.ReplaceAll(value, "\r\n", "\n")
+		if strings.TrimSpace(value) != "" {
+			fields[key] = value
+		}
+	}
+	if len(fields) == 0 {
+		return perr("template has no fields filled in", http.StatusBadRequest)
+	}
+	template.Fields = fields
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	template.UserID = utils.UserID(ctx)
+	template.UpdatedAt = s.clock.Now()
+
+	if _, err := s.dal.StoreSubmissionTemplate(dbs, template); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// DeleteSubmissionTemplate removes the template of the user
+func (s *SiteService) DeleteSubmissionTemplate(ctx context.Context, id int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.DeleteSubmissionTemplate(dbs, utils.UserID(ctx), id); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
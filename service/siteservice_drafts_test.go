@@ -0,0 +1,72 @@
+package service
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+)
+
+// fakeDraftDAL serves the reads of the draft visibility check, the other methods of the DAL are not implemented
+type fakeDraftDAL struct {
+	database.DAL
+	uploaders map[int64]*int64 // by submission, nil for submissions which are not drafts
+	roles     map[int64][]string
+}
+
+func (d *fakeDraftDAL) GetSubmissionDraftUploader(_ database.DBSession, sid int64) (*int64, error) {
+	uploaderID, ok := d.uploaders[sid]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return uploaderID, nil
+}
+
+func (d *fakeDraftDAL) GetDiscordUserRoles(_ database.DBSession, uid int64) ([]string, error) {
+	return d.roles[uid], nil
+}
+
+func Test_requireVisibleSubmission(t *testing.T) {
+	uploader := int64(1)
+	s := &SiteService{dal: &fakeDraftDAL{
+		uploaders: map[int64]*int64{
+			10: nil,
+			20: &uploader,
+		},
+		roles: map[int64][]string{
+			1: {constants.RoleTrialCurator},
+			2: {constants.RoleTrialCurator},
+			3: {constants.RoleCurator},
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		sid        int64
+		uid        int64
+		wantStatus int
+	}{
+		{name: "published submission is visible", sid: 10, uid: 2},
+		{name: "draft is visible to its uploader", sid: 20, uid: 1},
+		{name: "draft is visible to staff", sid: 20, uid: 3},
+		{name: "draft is hidden from others", sid: 20, uid: 2, wantStatus: http.StatusNotFound},
+		{name: "missing submission", sid: 30, uid: 3, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.requireVisibleSubmission(nil, tt.sid, tt.uid)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Errorf("requireVisibleSubmission() error = %v", err)
+				}
+				return
+			}
+			pe, ok := err.(constants.PublicError)
+			if !ok || pe.Status != tt.wantStatus {
+				t.Errorf("requireVisibleSubmission() error = %v, want status %d", err, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// SoftDeleteFlashfreezeRootFile marks a flashfreeze root file as deleted, which hides it and its indexed contents from search
+func (s *SiteService) SoftDeleteFlashfreezeRootFile(ctx context.Context, fid int64, deleteReason string) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if _, err := s.GetFlashfreezeRootFile(ctx, fid); err != nil {
+		return err
+	}
+
+	if err := s.dal.SoftDeleteFlashfreezeRootFile(dbs, fid, deleteReason); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("flashfreezeFileID", fid).Info("flashfreeze file deleted")
+
+	return nil
+}
+
+// UpdateFlashfreezeRootFileMetadata replaces the description and tags of a flashfreeze root file
+func (s *SiteService) UpdateFlashfreezeRootFileMetadata(ctx context.Context, fid int64, description string, tags []string) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if _, err := s.GetFlashfreezeRootFile(ctx, fid); err != nil {
+		return err
+	}
+
+	var desc *string
+	if d := strings.TrimSpace(description); d != "" {
+		desc = &d
+	}
+
+	normalizedTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = types.NormalizeFlashfreezeTag(tag)
+		if tag == "" {
+			continue
+		}
+		if len(tag) > 64 {
+			return perr("tags cannot be longer than 64 characters", http.StatusBadRequest)
+		}
+		normalizedTags = append(normalizedTags, tag)
+	}
+
+	if err := s.dal.UpdateFlashfreezeRootFileDescription(dbs, fid, desc); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	if err := s.dal.ReplaceFlashfreezeRootFileTags(dbs, fid, normalizedTags); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+func (s *SiteService) GetFlashfreezeCollections(ctx context.Context) ([]*types.FlashfreezeCollection, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	collections, err := s.dal.GetFlashfreezeCollections(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	return collections, nil
+}
+
+// CreateFlashfreezeCollection creates a new named collection of flashfreeze items
+func (s *SiteService) CreateFlashfreezeCollection(ctx context.Context, name, description string) (int64, error) {
+	uid := utils.UserID(ctx)
+
+	name = strings.TrimSpace(name)
+	if len(name) < 3 {
+		return 0, perr("collection name must be at least 3 characters long", http.StatusBadRequest)
+	} else if len(name) > 200 {
+		return 0, perr("collection name cannot be longer than 200 characters", http.StatusBadRequest)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	existing, err := s.dal.GetFlashfreezeCollections(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	for _, c := range existing {
+		if strings.EqualFold(c.Name, name) {
+			return 0, perr("collection with this name already exists", http.StatusConflict)
+		}
+	}
+
+	cid, err := s.dal.StoreFlashfreezeCollection(dbs, &types.FlashfreezeCollection{
+		UserID:      uid,
+		Name:        name,
+		Description: strings.TrimSpace(description),
+		CreatedAt:   s.clock.Now(),
+	})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	return cid, nil
+}
+
+// AddFlashfreezeFilesToCollection adds flashfreeze root files to a collection
+func (s *SiteService) AddFlashfreezeFilesToCollection(ctx context.Context, cid int64, fids []int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.requireFlashfreezeCollection(dbs, cid); err != nil {
+		return err
+	}
+	for _, fid := range fids {
+		if _, err := s.GetFlashfreezeRootFile(ctx, fid); err != nil {
+			return err
+		}
+	}
+
+	if err := s.dal.AddFlashfreezeFilesToCollection(dbs, cid, fids); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// RemoveFlashfreezeFileFromCollection removes a flashfreeze root file from a collection
+func (s *SiteService) RemoveFlashfreezeFileFromCollection(ctx context.Context, cid, fid int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.requireFlashfreezeCollection(dbs, cid); err != nil {
+		return err
+	}
+
+	if err := s.dal.RemoveFlashfreezeFileFromCollection(dbs, cid, fid); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// SoftDeleteFlashfreezeCollection deletes a collection, the flashfreeze items in it are left untouched
+func (s *SiteService) SoftDeleteFlashfreezeCollection(ctx context.Context, cid int64, deleteReason string) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.requireFlashfreezeCollection(dbs, cid); err != nil {
+		return err
+	}
+
+	if err := s.dal.SoftDeleteFlashfreezeCollection(dbs, cid, deleteReason); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+func (s *SiteService) requireFlashfreezeCollection(dbs database.DBSession, cid int64) error {
+	if _, err := s.dal.GetFlashfreezeCollection(dbs, cid); err != nil {
+		if err == sql.ErrNoRows {
+			return perr("flashfreeze collection not found", http.StatusNotFound)
+		}
+		utils.LogCtx(dbs.Ctx()).Error(err)
+		return dberr(err)
+	}
+	return nil
+}
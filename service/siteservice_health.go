@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/kofalt/go-memoize"
+)
+
+// readinessCheckTimeout bounds every check, so that a hanging dependency doesn't hang the probe
+const readinessCheckTimeout = 5 * time.Second
+
+// discordPingCache keeps discord from rate limiting the bots when the probe is called often
+var discordPingCache = memoize.NewMemoizer(time.Minute, 10*time.Minute)
+
+// readinessCache keeps the probe, which is public, from hammering the dependencies
+var readinessCache = memoize.NewMemoizer(5*time.Second, time.Minute)
+
+// pingURL checks that the service at the url responds. Any response other than a server error is fine,
+// the services don't have a dedicated health endpoint.
+func pingURL(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// checkWritable stores and removes a probe object
+func (s *SiteService) checkWritable(ctx context.Context, volume storage.Storage) error {
+	key := fmt.Sprintf(".readyz-%s", s.workerID)
+	if err := volume.Put(ctx, key, strings.NewReader("readyz"), 6); err != nil {
+		return err
+	}
+	return volume.Remove(ctx, key)
+}
+
+func pingDiscord(name string, ping func() error) func(ctx context.Context) error {
+	return func(_ context.Context) error {
+		_, err, _ := discordPingCache.Memoize(name, func() (interface{}, error) {
+			return nil, ping()
+		})
+		return err
+	}
+}
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// CheckReadiness checks the dependencies the instance needs to serve, the outcome is cached for a few seconds.
+// The shutdown makes the instance unready right away.
+func (s *SiteService) CheckReadiness(ctx context.Context, sourceOnlyMode bool) *types.ReadinessReport {
+	// the probes share the checks, so a cancelled probe must not cancel them
+	ctx = context.WithoutCancel(ctx)
+	cached, _, _ := readinessCache.Memoize("readiness", func() (interface{}, error) {
+		return s.checkReadiness(ctx, sourceOnlyMode), nil
+	})
+	checks := cached.([]*types.ReadinessCheck)
+
+	report := &types.ReadinessReport{
+		Ready:  true,
+		Checks: checks,
+	}
+
+	if s.isShuttingDown() {
+		msg := "the server is shutting down"
+		report.Checks = append(report.Checks[:len(checks):len(checks)], &types.ReadinessCheck{Name: "shutdown", Ready: false, Error: &msg})
+	}
+
+	for _, c := range report.Checks {
+		if !c.Ready {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+// checkReadiness checks the dependencies the instance needs to serve. Uploads and discord are not used in the source only mode.
+func (s *SiteService) checkReadiness(ctx context.Context, sourceOnlyMode bool) []*types.ReadinessCheck {
+	checks := []readinessCheck{
+		{"mysql", s.dal.Ping},
+		{"postgres", s.pgdal.Ping},
+		{"storage_data_packs", func(ctx context.Context) error { return s.checkWritable(ctx, s.volumes.DataPacks) }},
+		{"storage_images", func(ctx context.Context) error { return s.checkWritable(ctx, s.volumes.Images) }},
+		// submission files are kept in the blob store
+		{"storage_submissions", func(ctx context.Context) error { return s.checkWritable(ctx, s.volumes.Blobs) }},
+		{"storage_submission_images", func(ctx context.Context) error { return s.checkWritable(ctx, s.volumes.SubmissionImages) }},
+	}
+	if p, ok := s.validator.(Pinger); ok {
+		checks = append(checks, readinessCheck{"validator", p.Ping})
+	}
+	if p, ok := s.archiveIndexer.(Pinger); ok {
+		checks = append(checks, readinessCheck{"archive_indexer", p.Ping})
+	}
+	if !sourceOnlyMode {
+		checks = append(checks,
+			readinessCheck{"resumable_uploads", func(_ context.Context) error { return s.resumableUploadService.CheckWritable() }},
+			readinessCheck{"discord_auth_bot", pingDiscord("authBot", s.authBot.Ping)},
+			readinessCheck{"discord_notification_bot", pingDiscord("notificationBot", s.notificationBot.Ping)})
+	}
+
+	result := make([]*types.ReadinessCheck, len(checks))
+
+	wg := sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			done := make(chan error, 1)
+			// the discord client can't be cancelled, so the check is abandoned instead when it takes too long
+			go func() {
+				done <- c.check(ctx)
+			}()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			check := &types.ReadinessCheck{
+				Name:       c.name,
+				Ready:      err == nil,
+				DurationMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				utils.LogCtx(ctx).WithField("check", c.name).Warn(err)
+				msg := err.Error()
+				check.Error = &msg
+			}
+			result[i] = check
+		}()
+	}
+	wg.Wait()
+
+	return result
+}
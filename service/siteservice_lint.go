@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// curationLinter checks the curation meta. The parameter is the configured one, or the default of the rule.
+type curationLinter func(l *lintContext, meta *types.CurationMeta, parameter string) ([]string, error)
+
+type lintContext struct {
+	pgdal     database.PGDAL
+	pgdbs     database.PGDBSession
+	platforms map[string]bool // lower case names and aliases of all platforms, loaded on first use
+}
+
+type curationLintRuleDefinition struct {
+	rule          string
+	description   string
+	severity      string
+	parameter     string
+	parameterHelp string
+	lint          curationLinter
+}
+
+// curationLintRules are the built-in rules, in the order their findings are listed
+var curationLintRules = []*curationLintRuleDefinition{
+	{
+		rule:        "release-date-format",
+		description: "Release date is in the YYYY, YYYY-MM or YYYY-MM-DD format",
+		severity:    types.LintSeverityWarning,
+		lint:        lintReleaseDateFormat,
+	},
+	{
+		rule:          "language-codes",
+		description:   "Languages are two letter ISO 639-1 codes",
+		severity:      types.LintSeverityWarning,
+		parameterHelp: "additional allowed languages, separated by semicolons",
+		lint:          lintLanguageCodes,
+	},
+	{
+		rule:        "known-tags",
+		description: "Tags exist in the tag database",
+		severity:    types.LintSeverityWarning,
+		lint:        lintKnownTags,
+	},
+	{
+		rule:        "known-platforms",
+		description: "Platforms and the primary platform exist in the platform database",
+		severity:    types.LintSeverityError,
+		lint:        lintKnownPlatforms,
+	},
+	{
+		rule:          "launch-command-host",
+		description:   "Launch command does not point to a suspicious host",
+		severity:      types.LintSeverityWarning,
+		parameter:     "localhost; 127.0.0.1; 0.0.0.0",
+		parameterHelp: "suspicious hosts separated by semicolons, subdomains match too",
+		lint:          lintLaunchCommandHost,
+	},
+	{
+		rule:        "original-description",
+		description: "Original description is filled in",
+		severity:    types.LintSeverityWarning,
+		lint:        lintOriginalDescription,
+	},
+}
+
+func findCurationLintRule(rule string) *curationLintRuleDefinition {
+	for _, def := range curationLintRules {
+		if def.rule == rule {
+			return def
+		}
+	}
+	return nil
+}
+
+// splitMetaList splits a semicolon separated meta field, dropping empty items
+func splitMetaList(value *string) []string {
+	if value == nil {
+		return nil
+	}
+	result := make([]string, 0)
+	for _, item := range strings.Split(*value, ";") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// releaseDateFormats are the layouts of the release date in the curation meta, from the least to the most precise
+var releaseDateFormats = []string{"2006", "2006-01", "2006-01-02"}
+
+func lintReleaseDateFormat(_ *lintContext, meta *types.CurationMeta, _ string) ([]string, error) {
+	if meta.ReleaseDate == nil || *meta.ReleaseDate == "" {
+		return nil, nil
+	}
+	for _, layout := range releaseDateFormats {
+		if len(*meta.ReleaseDate) != len(layout) {
+			continue
+		}
+		if _, err := time.Parse(layout, *meta.ReleaseDate); err == nil {
+			return nil, nil
+		}
+	}
+	return []string{fmt.Sprintf("Release date '%s' is not in the YYYY, YYYY-MM or YYYY-MM-DD format.", *meta.ReleaseDate)}, nil
+}
+
+var languageCodeRegex = regexp.MustCompile(`^[a-z]{2}$`)
+
+func lintLanguageCodes(_ *lintContext, meta *types.CurationMeta, parameter string) ([]string, error) {
+	allowed := make(map[string]bool)
+	for _, language := range splitMetaList(&parameter) {
+		allowed[language] = true
+	}
+
+	result := make([]string, 0)
+	for _, language := range splitMetaList(meta.Languages) {
+		if !languageCodeRegex.MatchString(language) && !allowed[language] {
+			result = append(result, fmt.Sprintf("Language '%s' is not a two letter ISO 639-1 code.", language))
+		}
+	}
+	return result, nil
+}
+
+func lintKnownTags(l *lintContext, meta *types.CurationMeta, _ string) ([]string, error) {
+	result := make([]string, 0)
+	for _, tag := range splitMetaList(meta.Tags) {
+		if _, err := l.pgdal.GetTagByName(l.pgdbs, tag); err != nil {
+			if err != pgx.ErrNoRows {
+				return nil, err
+			}
+			result = append(result, fmt.Sprintf("Tag '%s' does not exist, check its spelling or request it to be added.", tag))
+		}
+	}
+	return result, nil
+}
+
+func lintKnownPlatforms(l *lintContext, meta *types.CurationMeta, _ string) ([]string, error) {
+	if l.platforms == nil {
+		platforms, err := l.pgdal.SearchPlatforms(l.pgdbs, nil)
+		if err != nil {
+			return nil, err
+		}
+		l.platforms = make(map[string]bool)
+		for _, platform := range platforms {
+			l.platforms[strings.ToLower(platform.Name)] = true
+			for _, alias := range splitMetaList(platform.Aliases) {
+				l.platforms[strings.ToLower(alias)] = true
+			}
+		}
+	}
+
+	result := make([]string, 0)
+	for _, platform := range splitMetaList(meta.Platform) {
+		if !l.platforms[strings.ToLower(platform)] {
+			result = append(result, fmt.Sprintf("Platform '%s' does not exist.", platform))
+		}
+	}
+	if meta.PrimaryPlatform != nil && *meta.PrimaryPlatform != "" && !l.platforms[strings.ToLower(*meta.PrimaryPlatform)] {
+		result = append(result, fmt.Sprintf("Primary platform '%s' does not exist.", *meta.PrimaryPlatform))
+	}
+	return result, nil
+}
+
+func lintLaunchCommandHost(_ *lintContext, meta *types.CurationMeta, parameter string) ([]string, error) {
+	if meta.LaunchCommand == nil {
+		return nil, nil
+	}
+	hosts := splitMetaList(&parameter)
+
+	result := make([]string, 0)
+	for _, field := range strings.Fields(*meta.LaunchCommand) {
+		u, err := url.Parse(strings.Trim(field, `"'`))
+		if err != nil || u.Host == "" {
+			continue
+		}
+		hostname := strings.ToLower(u.Hostname())
+		for _, host := range hosts {
+			host = strings.ToLower(host)
+			if hostname == host || strings.HasSuffix(hostname, "."+host) {
+				result = append(result, fmt.Sprintf("Launch command points to the suspicious host '%s'.", u.Hostname()))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func lintOriginalDescription(_ *lintContext, meta *types.CurationMeta, _ string) ([]string, error) {
+	if meta.OriginalDescription == nil || strings.TrimSpace(*meta.OriginalDescription) == "" {
+		return []string{"The curation has no original description. Please add one if the game has any."}, nil
+	}
+	return nil, nil
+}
+
+// getCurationLintRules returns all built-in rules with their stored settings. The findings decide the verdict
+// of the validator comment, so the rules are disabled until they are enabled on the lint rules page.
+func (s *SiteService) getCurationLintRules(dbs database.DBSession) ([]*types.CurationLintRule, error) {
+	stored, err := s.dal.GetCurationLintRules(dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*types.CurationLintRule, 0, len(curationLintRules))
+	for _, def := range curationLintRules {
+		rule := &types.CurationLintRule{
+			Rule:      def.rule,
+			Enabled:   false,
+			Severity:  def.severity,
+			Parameter: def.parameter,
+		}
+		if sr, ok := stored[def.rule]; ok {
+			rule = sr
+		}
+		rule.Description = def.description
+		rule.ParameterHelp = def.parameterHelp
+		result = append(result, rule)
+	}
+
+	return result, nil
+}
+
+// lintCurationMeta runs the enabled lint rules on the curation meta
+func (s *SiteService) lintCurationMeta(dbs database.DBSession, pgdbs database.PGDBSession, meta *types.CurationMeta) ([]*types.LintFinding, error) {
+	rules, err := s.getCurationLintRules(dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &lintContext{pgdal: s.pgdal, pgdbs: pgdbs}
+	result := make([]*types.LintFinding, 0)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		messages, err := findCurationLintRule(rule.Rule).lint(l, meta, rule.Parameter)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			result = append(result, &types.LintFinding{Rule: rule.Rule, Severity: rule.Severity, Message: message})
+		}
+	}
+
+	utils.LogCtx(dbs.Ctx()).WithField("findingCount", len(result)).Debug("curation meta linted")
+	return result, nil
+}
+
+// mergeLintFindings adds the findings to the validator response, so they show up in the bot comment
+func mergeLintFindings(vr *types.ValidatorResponse, findings []*types.LintFinding) {
+	for _, finding := range findings {
+		if finding.Severity == types.LintSeverityError {
+			vr.CurationErrors = append(vr.CurationErrors, finding.Message)
+		} else {
+			vr.CurationWarnings = append(vr.CurationWarnings, finding.Message)
+		}
+	}
+}
+
+func (s *SiteService) GetCurationLintRulesPageData(ctx context.Context) (*types.CurationLintRulesPageData, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.getCurationLintRules(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.CurationLintRulesPageData{
+		BasePageData:      *bpd,
+		CurationLintRules: rules,
+	}
+
+	return pageData, nil
+}
+
+// SaveCurationLintRule stores the settings of a built-in lint rule
+func (s *SiteService) SaveCurationLintRule(ctx context.Context, rule *types.CurationLintRule) error {
+	if findCurationLintRule(rule.Rule) == nil {
+		return perr(fmt.Sprintf("unknown lint rule '%s'", rule.Rule), http.StatusNotFound)
+	}
+	if rule.Severity != types.LintSeverityError && rule.Severity != types.LintSeverityWarning {
+		return perr(fmt.Sprintf("invalid severity '%s'", rule.Severity), http.StatusBadRequest)
+	}
+	rule.Parameter = strings.TrimSpace(rule.Parameter)
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	uid := utils.UserID(ctx)
+	now := s.clock.Now()
+	rule.UpdatedBy = &uid
+	rule.UpdatedAt = &now
+
+	if err := s.dal.StoreCurationLintRule(dbs, rule); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+func Test_curationLinters(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name      string
+		lint      curationLinter
+		meta      *types.CurationMeta
+		parameter string
+		want      int
+	}{
+		{name: "release date year", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008")}},
+		{name: "release date month", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008-07")}},
+		{name: "release date day", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008-07-14")}},
+		{name: "missing release date", lint: lintReleaseDateFormat, meta: &types.CurationMeta{}},
+		{name: "release date with slashes", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008/07/14")}, want: 1},
+		{name: "release date without leading zeros", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008-7-14")}, want: 1},
+		{name: "release date which does not exist", lint: lintReleaseDateFormat, meta: &types.CurationMeta{ReleaseDate: str("2008-02-30")}, want: 1},
+		{name: "language codes", lint: lintLanguageCodes, meta: &types.CurationMeta{Languages: str("en; ja")}},
+		{name: "language names", lint: lintLanguageCodes, meta: &types.CurationMeta{Languages: str("English; ja; Japanese")}, want: 2},
+		{name: "allowed language", lint: lintLanguageCodes, meta: &types.CurationMeta{Languages: str("en; zh-Hant")}, parameter: "zh-Hant; zh-Hans"},
+		{name: "upper case language code", lint: lintLanguageCodes, meta: &types.CurationMeta{Languages: str("EN")}, want: 1},
+		{name: "launch command on a public host", lint: lintLaunchCommandHost, meta: &types.CurationMeta{LaunchCommand: str("http://www.example.com/game.swf")}, parameter: "localhost; 127.0.0.1"},
+		{name: "launch command on localhost", lint: lintLaunchCommandHost, meta: &types.CurationMeta{LaunchCommand: str("http://localhost:8080/game.swf")}, parameter: "localhost; 127.0.0.1", want: 1},
+		{name: "launch command on a subdomain", lint: lintLaunchCommandHost, meta: &types.CurationMeta{LaunchCommand: str(`"http://cdn.Example.com/game.swf"`)}, parameter: "example.com", want: 1},
+		{name: "launch command without a url", lint: lintLaunchCommandHost, meta: &types.CurationMeta{LaunchCommand: str("FPSoftware/game.exe")}, parameter: "localhost"},
+		{name: "original description", lint: lintOriginalDescription, meta: &types.CurationMeta{OriginalDescription: str("A game.")}},
+		{name: "blank original description", lint: lintOriginalDescription, meta: &types.CurationMeta{OriginalDescription: str(" \n")}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.lint(nil, tt.meta, tt.parameter)
+			if err != nil {
+				t.Fatalf("lint() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("lint() = %v, want %d findings", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseDate(t *testing.T) {
+	tests := []struct {
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{date: "2008", want: time.Date(2008, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{date: "2008-02", want: time.Date(2008, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{date: "2008-07-14", want: time.Date(2008, time.July, 14, 0, 0, 0, 0, time.UTC)},
+		{date: "2008/07/14", wantErr: true},
+		{date: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, err := parseDate(tt.date)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_lintFindingsVerdict(t *testing.T) {
+	s := &SiteService{clock: &RealClock{}}
+
+	tests := []struct {
+		name         string
+		findings     []*types.LintFinding
+		wantErrors   []string
+		wantWarnings []string
+		wantAction   string
+	}{
+		{
+			name:       "no findings approve",
+			wantAction: constants.ActionApprove,
+		},
+		{
+			name:         "warnings request changes",
+			findings:     []*types.LintFinding{{Rule: "original-description", Severity: types.LintSeverityWarning, Message: "w"}},
+			wantWarnings: []string{"w"},
+			wantAction:   constants.ActionRequestChanges,
+		},
+		{
+			name: "errors request changes",
+			findings: []*types.LintFinding{
+				{Rule: "known-platforms", Severity: types.LintSeverityError, Message: "e"},
+				{Rule: "known-tags", Severity: types.LintSeverityWarning, Message: "w"},
+			},
+			wantErrors:   []string{"e"},
+			wantWarnings: []string{"w"},
+			wantAction:   constants.ActionRequestChanges,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vr := &types.ValidatorResponse{}
+			mergeLintFindings(vr, tt.findings)
+			if !reflect.DeepEqual(vr.CurationErrors, tt.wantErrors) {
+				t.Errorf("CurationErrors = %v, want %v", vr.CurationErrors, tt.wantErrors)
+			}
+			if !reflect.DeepEqual(vr.CurationWarnings, tt.wantWarnings) {
+				t.Errorf("CurationWarnings = %v, want %v", vr.CurationWarnings, tt.wantWarnings)
+			}
+			if c := s.convertValidatorResponseToComment(vr, false); c.Action != tt.wantAction {
+				t.Errorf("convertValidatorResponseToComment() action = %v, want %v", c.Action, tt.wantAction)
+			}
+		})
+	}
+}
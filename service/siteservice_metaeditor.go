@@ -0,0 +1,373 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/resumableuploadservice"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// editableCurationMetaFields are the meta.yaml keys which can be changed in the web editor, in the order they are shown
+var editableCurationMetaFields = []string{
+	"Title", "Alternate Titles", "Library", "Series", "Developer", "Publisher", "Play Mode", "Release Date",
+	"Version", "Languages", "Extreme", "Tags", "Tag Categories", "Source", "Platforms", "Primary Platform", "Status",
+	"Application Path", "Launch Command", "Mount Parameters", "Ruffle Support", "Game Notes", "Original Description",
+	"Curation Notes",
+}
+
+// multilineCurationMetaFields are edited in a textarea and written as yaml block scalars
+var multilineCurationMetaFields = map[string]bool{
+	"Game Notes":           true,
+	"Original Description": true,
+	"Curation Notes":       true,
+}
+
+// curationMetaFieldByKey returns the meta field with the given meta.yaml key
+func curationMetaFieldByKey(meta *types.CurationMeta, key string) (*string, bool) {
+	v := reflect.ValueOf(meta).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0] != key {
+			continue
+		}
+		value, ok := v.Field(i).Interface().(*string)
+		return value, ok
+	}
+	return nil, false
+}
+
+// editableCurationMeta lists the fields of the web editor with their current values
+func editableCurationMeta(meta *types.CurationMeta) []*types.CurationMetaField {
+	if meta == nil {
+		meta = &types.CurationMeta{}
+	}
+	result := make([]*types.CurationMetaField, 0, len(editableCurationMetaFields))
+	for _, key := range editableCurationMetaFields {
+		value, _ := curationMetaFieldByKey(meta, key)
+		result = append(result, &types.CurationMetaField{
+			Name:      key,
+			Value:     derefOrEmpty(value),
+			Multiline: multilineCurationMetaFields[key],
+		})
+	}
+	return result
+}
+
+// isCurationMetaFile reports whether the archive entry is the meta.yaml of the curation, which sits in the root or in the curation folder
+func isCurationMetaFile(name string) bool {
+	base := strings.ToLower(path.Base(name))
+	return (base == "meta.yaml" || base == "meta.yml") && strings.Count(strings.Trim(name, "/"), "/") <= 1
+}
+
+// rewriteCurationMetaYAML sets the changed keys in the meta.yaml, everything else is kept as it was
+func rewriteCurationMetaYAML(content []byte, changes []*types.CurationMetaChange) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("meta.yaml is not a mapping")
+	}
+	root := doc.Content[0]
+
+	for _, change := range changes {
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: derefOrEmpty(change.To)}
+		if strings.Contains(value.Value, "\n") {
+			value.Style = yaml.LiteralStyle
+		}
+
+		found := false
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == change.Field {
+				root.Content[i+1] = value
+				found = true
+				break
+			}
+		}
+		if !found {
+			root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: change.Field}, value)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewriteCurationArchive copies the curation into a zip, with the meta.yaml rewritten
+func rewriteCurationArchive(archive *storedArchive, w io.Writer, changes []*types.CurationMetaChange) error {
+	zw := zip.NewWriter(w)
+	rewritten := false
+
+	err := visitArchive(archive.obj, archive.size, archive.name, func(entry *archiveEntry, open func() (io.ReadCloser, error)) error {
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     entry.Name,
+			Method:   zip.Deflate,
+			Modified: entry.Modified,
+		})
+		if err != nil {
+			return err
+		}
+
+		if rewritten || !isCurationMetaFile(entry.Name) {
+			_, err = io.Copy(fw, rc)
+			return err
+		}
+
+		content, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		content, err = rewriteCurationMetaYAML(content, changes)
+		if err != nil {
+			return err
+		}
+		rewritten = true
+		_, err = fw.Write(content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if !rewritten {
+		return perr("only curations with a meta.yaml can be edited in the web editor", http.StatusBadRequest)
+	}
+
+	return zw.Close()
+}
+
+// curationMetaEditChunkSize is the size of the chunks the edited curation is stored in, until it's processed like a resumable upload
+const curationMetaEditChunkSize = 32 * 1024 * 1024
+
+// resumableChunkWriter stores what is written as the chunks of a resumable upload
+type resumableChunkWriter struct {
+	rsu    *resumableuploadservice.ResumableUploadService
+	uid    int64
+	fileID string
+	buf    []byte
+	chunks int
+	size   int64
+}
+
+func (w *resumableChunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), curationMetaEditChunkSize-len(w.buf))
+		w.buf = append(w.buf, p[:n]...)
+		p = p[n:]
+		written += n
+		if len(w.buf) == curationMetaEditChunkSize {
+			if err := w.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush stores the buffered rest as the last chunk
+func (w *resumableChunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if err := w.rsu.PutChunk(w.uid, w.fileID, w.chunks+1, w.buf); err != nil {
+		return err
+	}
+	w.chunks++
+	w.size += int64(len(w.buf))
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// describeCurationMetaChanges is the system comment posted with the edited version
+func describeCurationMetaChanges(changes []*types.CurationMetaChange) string {
+	var sb strings.Builder
+	sb.WriteString("The curation meta was edited in the web editor:\n")
+	for _, change := range changes {
+		sb.WriteString(fmt.Sprintf("%s: %q → %q\n", change.Field, derefOrEmpty(change.From), derefOrEmpty(change.To)))
+	}
+	return sb.String()
+}
+
+// storeCurationMetaEditComment posts the changes made in the web editor, after the comment of the new version
+func (s *SiteService) storeCurationMetaEditComment(dbs database.DBSession, pgdbs database.PGDBSession, sid int64, changes []*types.CurationMetaChange) error {
+	msg := describeCurationMetaChanges(changes)
+	c := &types.Comment{
+		AuthorID:     constants.SystemID,
+		SubmissionID: sid,
+		Message:      &msg,
+		Action:       constants.ActionSystem,
+		CreatedAt:    s.clock.Now().Add(time.Second * 3),
+	}
+	cid, err := s.dal.StoreComment(dbs, c)
+	if err != nil {
+		return err
+	}
+	return s.EmitSubmissionCommentEvent(pgdbs, c.AuthorID, c.SubmissionID, cid, c.Action, nil)
+}
+
+// EditCurationMeta rewrites the meta.yaml of the newest submission file with the given values (by meta.yaml key)
+// and queues the result as a new version of the submission, which goes through the usual validation.
+// The curation is always rewritten as a zip, as there is no 7z writer, the validator repack can't be used for it
+// as it produces a data pack rather than a curation.
+func (s *SiteService) EditCurationMeta(ctx context.Context, sid int64, values map[string]string) error {
+	uid := utils.UserID(ctx)
+
+	if s.isShuttingDown() {
+		return shuttingDownError()
+	}
+
+	submission, changes, err := func() (*types.ExtendedSubmission, []*types.CurationMetaChange, error) {
+		dbs, err := s.dal.NewSession(ctx)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, nil, dberr(err)
+		}
+		defer dbs.Rollback()
+
+		submissions, _, err := s.dal.SearchSubmissions(dbs, &types.SubmissionsFilter{SubmissionIDs: []int64{sid}})
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, nil, dberr(err)
+		}
+		if len(submissions) == 0 {
+			return nil, nil, perr("submission not found", http.StatusNotFound)
+		}
+		submission := submissions[0]
+
+		for _, action := range submission.DistinctActions {
+			if action == constants.ActionMarkAdded {
+				return nil, nil, perr("submission has already been added to flashpoint", http.StatusBadRequest)
+			}
+		}
+
+		meta, err := s.dal.GetCurationMetaBySubmissionFileID(dbs, submission.FileID)
+		if err != nil && err != sql.ErrNoRows {
+			utils.LogCtx(ctx).Error(err)
+			return nil, nil, dberr(err)
+		}
+		if meta == nil {
+			meta = &types.CurationMeta{}
+		}
+
+		changes := make([]*types.CurationMetaChange, 0)
+		for _, key := range editableCurationMetaFields {
+			value, ok := values[key]
+			if !ok {
+				continue
+			}
+			value = strings.ReplaceAll(value, "\r\n", "\n")
+			current, _ := curationMetaFieldByKey(meta, key)
+			if value == derefOrEmpty(current) {
+				continue
+			}
+			changes = append(changes, &types.CurationMetaChange{Field: key, From: current, To: &value})
+		}
+		for key := range values {
+			if _, ok := curationMetaFieldByKey(meta, key); !ok {
+				return nil, nil, perr(fmt.Sprintf("unknown curation meta field '%s'", key), http.StatusBadRequest)
+			}
+		}
+		if len(changes) == 0 {
+			return nil, nil, perr("nothing has changed", http.StatusBadRequest)
+		}
+
+		return submission, changes, nil
+	}()
+	if err != nil {
+		return err
+	}
+
+	// the edited curation is stored like a resumable upload, so that the processing queue takes care of it
+	cw := &resumableChunkWriter{
+		rsu:    s.resumableUploadService,
+		uid:    uid,
+		fileID: "meta-edit-" + s.randomStringProvider.RandomString(32),
+		buf:    make([]byte, 0, curationMetaEditChunkSize),
+	}
+	cleanup := func() {
+		if err := s.resumableUploadService.DeleteFile(cw.uid, cw.fileID, cw.chunks); err != nil {
+			utils.LogCtx(ctx).Error(err)
+		}
+	}
+
+	err = func() error {
+		archive, err := s.openSubmissionArchive(ctx, sid, submission.FileID)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+		if err := rewriteCurationArchive(archive, cw, changes); err != nil {
+			if _, ok := err.(constants.PublicError); ok {
+				return err
+			}
+			utils.LogCtx(ctx).Error(err)
+			return perr("failed to rewrite curation", http.StatusInternalServerError)
+		}
+		if err := cw.flush(); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return perr("failed to rewrite curation", http.StatusInternalServerError)
+		}
+		return nil
+	}()
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	// the processing reports its progress under a temporary name, nobody polls it for edits
+	tempName := s.randomStringProvider.RandomString(32)
+	now := s.clock.Now()
+	job := &types.SubmissionProcessingJob{
+		TempName:     tempName,
+		UserID:       uid,
+		SubmissionID: &sid,
+		ResumableParams: &types.ResumableParams{
+			ResumableChunkSize:   curationMetaEditChunkSize,
+			ResumableTotalSize:   cw.size,
+			ResumableIdentifier:  cw.fileID,
+			ResumableFilename:    strings.TrimSuffix(submission.OriginalFilename, filepath.Ext(submission.OriginalFilename)) + ".zip",
+			ResumableTotalChunks: cw.chunks,
+		},
+		CurationMetaChanges: changes,
+		Status:              constants.SubmissionStatusReceived,
+		NextAttemptAt:       now,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	if err := s.enqueueSubmissionProcessingJob(ctx, job); err != nil {
+		cleanup()
+		return err
+	}
+
+	utils.LogCtx(ctx).WithField("uid", uid).WithField("fieldCount", len(changes)).WithField("tempName", tempName).Debug("curation meta edited, queued for processing")
+
+	return nil
+}
@@ -260,6 +260,11 @@ func (m *mockAuthBot) GetFlashpointRoles() ([]types.DiscordRole, error) {
 	return args.Get(0).([]types.DiscordRole), args.Error(1)
 }
 
+func (m *mockAuthBot) Ping() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 ////////////////////////////////////////////////
 
 type mockNotificationBot struct {
@@ -271,6 +276,11 @@ func (m *mockNotificationBot) SendNotification(msg, notificationType string) err
 	return args.Error(0)
 }
 
+func (m *mockNotificationBot) Ping() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 ////////////////////////////////////////////////
 
 type mockValidator struct {
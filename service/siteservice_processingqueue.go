@@ -0,0 +1,449 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// submissionJobClaimDuration is how long a job stays claimed without a heartbeat, before another worker picks it up
+	submissionJobClaimDuration = 5 * time.Minute
+	submissionJobHeartbeat     = time.Minute
+	submissionJobPollInterval  = 30 * time.Second
+	// submissionJobRetryBackoff is doubled with every failed attempt
+	submissionJobRetryBackoff = time.Minute
+	submissionJobMaxBackoff   = time.Hour
+	// submissionJobRetention is how long the status of finished jobs can be polled
+	submissionJobRetention = 7 * 24 * time.Hour
+)
+
+// retryableError marks a failure which is not caused by the upload itself, like the validator being unavailable
+type retryableError struct {
+	error
+}
+
+func (e retryableError) Unwrap() error {
+	return e.error
+}
+
+func retryable(err error) error {
+	return retryableError{err}
+}
+
+func newWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", hostname, utils.NewRealRandomStringProvider().RandomString(8))
+}
+
+// enqueueSubmissionProcessingJob adds the received upload to the processing queue
+func (s *SiteService) enqueueSubmissionProcessingJob(ctx context.Context, job *types.SubmissionProcessingJob) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.StoreSubmissionProcessingJob(dbs, job); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	s.announceSubmissionProcessingJob()
+
+	return nil
+}
+
+func (s *SiteService) announceSubmissionProcessingJob() {
+	select {
+	// non-blocking announce that something is in the queue
+	case s.submissionJobQueued <- true:
+	default:
+	}
+}
+
+// RunSubmissionProcessingQueue processes the received uploads, at most `workers` at a time. The jobs are claimed
+// in the database, so any number of instances can share the queue.
+func (s *SiteService) RunSubmissionProcessingQueue(logger *logrus.Entry, ctx context.Context, wg *sync.WaitGroup, workers, maxAttempts int) {
+	defer wg.Done()
+	l := logger.WithField("serviceName", "submissionProcessingQueue").WithField("workerID", s.workerID)
+	defer l.Info("submission processing queue stopped")
+
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+
+	pollTicker := time.NewTicker(submissionJobPollInterval)
+	defer pollTicker.Stop()
+	cleanupTicker := time.NewTicker(time.Hour)
+	defer cleanupTicker.Stop()
+
+	s.announceSubmissionProcessingJob()
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Info("context cancelled, stopping submission processing queue")
+			return
+		case <-cleanupTicker.C:
+			ctx := context.WithValue(ctx, utils.CtxKeys.Log, l)
+			err := metrics.ObserveJob("submission_processing_job_cleanup", func() error {
+				return s.deleteFinishedSubmissionProcessingJobs(ctx)
+			})
+			if err != nil {
+				l.Error(err)
+			}
+			continue
+		case <-pollTicker.C:
+		case <-s.submissionJobQueued:
+		}
+
+		// the loop is the only one taking the slots, so there is a free one for every job claimed here
+		for len(slots) < cap(slots) {
+			if !s.startProcessing() {
+				break
+			}
+
+			job, err := s.claimSubmissionProcessingJob(context.WithValue(ctx, utils.CtxKeys.Log, l))
+			if err != nil || job == nil {
+				s.processingWG.Done()
+				break
+			}
+
+			slots <- struct{}{}
+			go func() {
+				defer func() {
+					<-slots
+					s.processingWG.Done()
+					// there may be more jobs waiting for the free slot
+					s.announceSubmissionProcessingJob()
+				}()
+				s.processSubmissionProcessingJob(l, job, maxAttempts)
+			}()
+		}
+	}
+}
+
+// claimSubmissionProcessingJob returns nil if there is no job to process
+func (s *SiteService) claimSubmissionProcessingJob(ctx context.Context) (*types.SubmissionProcessingJob, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	now := s.clock.Now()
+	job, err := s.dal.ClaimSubmissionProcessingJob(dbs, s.workerID, now, now.Add(submissionJobClaimDuration))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	return job, nil
+}
+
+// processSubmissionProcessingJob processes the claimed job and stores its outcome. Jobs failing on the validator are retried
+// with a backoff, jobs interrupted by the shutdown are left for the next start without counting the attempt.
+func (s *SiteService) processSubmissionProcessingJob(logger *logrus.Entry, job *types.SubmissionProcessingJob, maxAttempts int) {
+	uid := job.UserID
+	sid := job.SubmissionID
+	resumableParams := job.ResumableParams
+	tempName := job.TempName
+
+	ctx := context.WithValue(context.Background(), utils.CtxKeys.UserID, uid)
+	ctx = context.WithValue(ctx, utils.CtxKeys.Log, logger.WithField("tempName", tempName).WithField("attempt", job.Attempts))
+	ctx = context.WithValue(ctx, utils.CtxKeys.Log, resumableLog(ctx, resumableParams))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(s.processingCtx, cancel)
+	defer stop()
+
+	ctx, span := tracing.Start(ctx, "processSubmissionProcessingJob",
+		attribute.String("resumable.identifier", resumableParams.ResumableIdentifier), attribute.String("submission.tempName", tempName),
+		attribute.Int("job.attempt", job.Attempts))
+	defer span.End()
+
+	heartbeatDone := make(chan struct{})
+	defer close(heartbeatDone)
+	go func() {
+		ticker := time.NewTicker(submissionJobHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatDone:
+				return
+			case <-ticker.C:
+				s.extendSubmissionProcessingJobClaim(ctx, tempName)
+			}
+		}
+	}()
+
+	s.SSK.SetReceived(tempName)
+	s.SSK.watch(tempName, func(status types.SubmissionStatus) {
+		s.storeSubmissionProcessingJobStatus(ctx, tempName, status)
+	})
+	defer s.SSK.unwatch(tempName)
+
+	isComplete, err := s.resumableUploadService.IsUploadFinished(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks, resumableParams.ResumableTotalSize)
+	if err != nil {
+		err = retryable(err)
+	} else if !isComplete {
+		// the chunks are gone, e.g. the same upload got processed under a different name
+		utils.LogCtx(ctx).Warn("chunks of the queued upload are missing")
+		msg := "the uploaded file is missing, please upload it again"
+		s.SSK.SetFailed(tempName, msg)
+		s.finishSubmissionProcessingJob(ctx, job, constants.SubmissionStatusFailed, &msg, nil)
+		metrics.SubmissionProcessingJobs.WithLabelValues("failed").Inc()
+		return
+	}
+
+	utils.LogCtx(ctx).Debug("processing queued submission upload")
+
+	processReceivedResumableSubmission := func() (interface{}, error) {
+		return nil, s.processReceivedResumableSubmission(ctx, uid, sid, resumableParams, job.CurationMetaChanges, tempName)
+	}
+	processReceivedResumableSubmissionKey := fmt.Sprintf("%d-%s", uid, resumableParams.ResumableIdentifier)
+
+	cached := false
+	if err == nil {
+		_, err, cached = resumableMemoizer.Memoize(processReceivedResumableSubmissionKey, processReceivedResumableSubmission)
+		utils.LogCtx(ctx).WithField("cached", utils.BoolToString(cached)).Debug("processed resumable submission upload")
+	}
+
+	if err != nil {
+		resumableMemoizer.Storage.Delete(processReceivedResumableSubmissionKey)
+		utils.LogCtx(ctx).Error(err)
+		tracing.Fail(span, err)
+
+		if s.processingCtx.Err() != nil {
+			utils.LogCtx(ctx).Warn("processing interrupted by the shutdown, keeping the upload for the next start")
+			job.Attempts--
+			msg := "the server restarted, the upload will be processed again"
+			s.finishSubmissionProcessingJob(ctx, job, constants.SubmissionStatusReceived, &msg, nil)
+			metrics.SubmissionProcessingJobs.WithLabelValues("interrupted").Inc()
+			return
+		}
+
+		if errors.As(err, &retryableError{}) && job.Attempts < maxAttempts {
+			backoff := submissionJobRetryBackoff << (job.Attempts - 1)
+			if backoff > submissionJobMaxBackoff || backoff <= 0 {
+				backoff = submissionJobMaxBackoff
+			}
+			job.NextAttemptAt = s.clock.Now().Add(backoff)
+			utils.LogCtx(ctx).WithField("nextAttemptAt", job.NextAttemptAt).Warn("processing failed, the upload will be processed again")
+			msg := fmt.Sprintf("processing failed (attempt %d of %d), trying again at %s", job.Attempts, maxAttempts, job.NextAttemptAt.UTC().Format(time.RFC3339))
+			s.finishSubmissionProcessingJob(ctx, job, constants.SubmissionStatusReceived, &msg, nil)
+			metrics.SubmissionProcessingJobs.WithLabelValues("retry").Inc()
+			return
+		}
+	}
+
+	if !cached {
+		utils.LogCtx(ctx).Debug("deleting the resumable file chunks")
+		if err := s.resumableUploadService.DeleteFile(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks); err != nil {
+			utils.LogCtx(ctx).Error(err)
+		}
+
+		utils.LogCtx(ctx).WithField("memoizerKey", processReceivedResumableSubmissionKey).Debug("deleting the memoized call")
+		resumableMemoizer.Storage.Delete(processReceivedResumableSubmissionKey)
+	}
+
+	ss := s.SSK.Get(tempName)
+	if err != nil {
+		msg := "processing failed"
+		if ss != nil && ss.Status == constants.SubmissionStatusFailed && ss.Message != nil {
+			msg = *ss.Message
+		}
+		s.finishSubmissionProcessingJob(ctx, job, constants.SubmissionStatusFailed, &msg, nil)
+		metrics.SubmissionProcessingJobs.WithLabelValues("failed").Inc()
+		return
+	}
+
+	var resultSID *int64
+	if ss != nil {
+		resultSID = ss.SubmissionID
+	}
+	s.finishSubmissionProcessingJob(ctx, job, constants.SubmissionStatusSuccess, nil, resultSID)
+	metrics.SubmissionProcessingJobs.WithLabelValues("success").Inc()
+}
+
+// finishSubmissionProcessingJob stores the outcome of the attempt and releases the claim,
+// even when the processing got cancelled by the shutdown
+func (s *SiteService) finishSubmissionProcessingJob(ctx context.Context, job *types.SubmissionProcessingJob, status string, message *string, resultSID *int64) {
+	ctx = context.WithoutCancel(ctx)
+
+	job.Status = status
+	job.Message = message
+	job.ResultSubmissionID = resultSID
+	job.ClaimedBy = nil
+	job.ClaimedUntil = nil
+	job.UpdatedAt = s.clock.Now()
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.UpdateSubmissionProcessingJob(dbs, job); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+	}
+}
+
+func (s *SiteService) extendSubmissionProcessingJobClaim(ctx context.Context, tempName string) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.ExtendSubmissionProcessingJobClaim(dbs, tempName, s.workerID, s.clock.Now().Add(submissionJobClaimDuration)); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+	}
+}
+
+// storeSubmissionProcessingJobStatus makes the progress visible to the other instances and after a restart
+func (s *SiteService) storeSubmissionProcessingJobStatus(ctx context.Context, tempName string, status types.SubmissionStatus) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.UpdateSubmissionProcessingJobStatus(dbs, tempName, status.Status, status.Message, s.clock.Now()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+	}
+}
+
+func (s *SiteService) deleteFinishedSubmissionProcessingJobs(ctx context.Context) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	deleted, err := s.dal.DeleteFinishedSubmissionProcessingJobs(dbs, s.clock.Now().Add(-submissionJobRetention))
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if deleted > 0 {
+		utils.LogCtx(ctx).WithField("amount", deleted).Info("deleted finished submission processing jobs")
+	}
+
+	return nil
+}
+
+// GetUploadStatus returns the status of the received upload, or nil if there is no such upload
+func (s *SiteService) GetUploadStatus(ctx context.Context, tempName string) (*types.SubmissionStatus, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	job, err := s.dal.GetSubmissionProcessingJob(dbs, tempName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	// the progress within a stage is only known to the instance processing the upload
+	if ss := s.SSK.Get(tempName); ss != nil && ss.Status == job.Status {
+		return ss, nil
+	}
+
+	return &types.SubmissionStatus{
+		Status:       job.Status,
+		Message:      job.Message,
+		SubmissionID: job.ResultSubmissionID,
+	}, nil
+}
+
+func (s *SiteService) GetSubmissionProcessingJobsPageData(ctx context.Context) (*types.SubmissionProcessingJobsPageData, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.dal.GetSubmissionProcessingJobs(dbs, 200)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.SubmissionProcessingJobsPageData{
+		BasePageData:             *bpd,
+		SubmissionProcessingJobs: jobs,
+	}
+
+	return pageData, nil
+}
@@ -6,6 +6,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
 	"github.com/FlashpointProject/flashpoint-submission-system/resumableuploadservice"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
@@ -26,12 +28,17 @@ func (s *SiteService) ReceiveSubmissionChunk(ctx context.Context, sid *int64, re
 		utils.LogCtx(ctx).Panic("no user associated with request")
 	}
 
+	if s.isShuttingDown() {
+		return nil, shuttingDownError()
+	}
+
 	utils.LogCtx(ctx).Debug("storing submission chunk")
 	err := s.resumableUploadService.PutChunk(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableChunkNumber, chunk)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		return nil, err
 	}
+	metrics.UploadChunks.WithLabelValues("submission").Inc()
 
 	isComplete, err := s.resumableUploadService.IsUploadFinished(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks, resumableParams.ResumableTotalSize)
 	if err != nil {
@@ -40,37 +47,28 @@ func (s *SiteService) ReceiveSubmissionChunk(ctx context.Context, sid *int64, re
 	}
 
 	if isComplete {
+		metrics.UploadsCompleted.WithLabelValues("submission").Inc()
+
 		// tempName is used as the ID of the submission while the submission is being processed, used by the client to poll for status
 		tempName := s.randomStringProvider.RandomString(32)
-		s.SSK.SetReceived(tempName)
-
-		go func() {
-			ctx := utils.ValueOnlyContext{Context: ctx}
-			utils.LogCtx(ctx).Debug("submission resumable upload finished")
-
-			processReceivedResumableSubmission := func() (interface{}, error) {
-				return nil, s.processReceivedResumableSubmission(ctx, uid, sid, resumableParams, tempName)
-			}
-			processReceivedResumableSubmissionKey := fmt.Sprintf("%d-%s", uid, resumableParams.ResumableIdentifier)
-
-			_, err, cached := resumableMemoizer.Memoize(processReceivedResumableSubmissionKey, processReceivedResumableSubmission)
-			utils.LogCtx(ctx).WithField("cached", utils.BoolToString(cached)).Debug("processed resumable submission upload")
-
-			if err != nil {
-				resumableMemoizer.Storage.Delete(processReceivedResumableSubmissionKey)
-				utils.LogCtx(ctx).Error(err)
-			}
 
-			if !cached {
-				utils.LogCtx(ctx).Debug("deleting the resumable file chunks")
-				if err := s.resumableUploadService.DeleteFile(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks); err != nil {
-					utils.LogCtx(ctx).Error(err)
-				}
+		// the upload is processed by the queue, so that a restart in the meantime doesn't lose it
+		now := s.clock.Now()
+		job := &types.SubmissionProcessingJob{
+			TempName:        tempName,
+			UserID:          uid,
+			SubmissionID:    sid,
+			ResumableParams: resumableParams,
+			Status:          constants.SubmissionStatusReceived,
+			NextAttemptAt:   now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := s.enqueueSubmissionProcessingJob(ctx, job); err != nil {
+			return nil, err
+		}
 
-				utils.LogCtx(ctx).WithField("memoizerKey", processReceivedResumableSubmissionKey).Debug("deleting the memoized call")
-				resumableMemoizer.Storage.Delete(processReceivedResumableSubmissionKey)
-			}
-		}()
+		utils.LogCtx(ctx).WithField("tempName", tempName).Debug("submission resumable upload finished, queued for processing")
 
 		return &tempName, nil
 	}
@@ -102,12 +100,17 @@ func (s *SiteService) ReceiveFlashfreezeChunk(ctx context.Context, resumablePara
 		utils.LogCtx(ctx).Panic("no user associated with request")
 	}
 
+	if s.isShuttingDown() {
+		return nil, shuttingDownError()
+	}
+
 	utils.LogCtx(ctx).Debug("storing flashfreeze chunk")
 	err := s.resumableUploadService.PutChunk(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableChunkNumber, chunk)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		return nil, err
 	}
+	metrics.UploadChunks.WithLabelValues("flashfreeze").Inc()
 
 	isComplete, err := s.resumableUploadService.IsUploadFinished(uid, resumableParams.ResumableIdentifier, resumableParams.ResumableTotalChunks, resumableParams.ResumableTotalSize)
 	if err != nil {
@@ -116,6 +119,7 @@ func (s *SiteService) ReceiveFlashfreezeChunk(ctx context.Context, resumablePara
 	}
 
 	if isComplete {
+		metrics.UploadsCompleted.WithLabelValues("flashfreeze").Inc()
 		utils.LogCtx(ctx).Debug("flashfreeze resumable upload finished")
 
 		processReceivedResumableFlashfreeze := func() (interface{}, error) {
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/FlashpointProject/flashpoint-submission-system/workflow"
+)
+
+// maxRequiredReviews keeps a typo from making submissions impossible to add
+const maxRequiredReviews = 10
+
+func submissionLevels() []string {
+	return []string{constants.SubmissionLevelAudition, constants.SubmissionLevelTrial, constants.SubmissionLevelStaff}
+}
+
+// loadWorkflow returns the review workflow with the review requirements stored in the database. They are read
+// on every use, so that a change saved on one instance applies to all of them right away.
+func (s *SiteService) loadWorkflow(dbs database.DBSession) (*workflow.Workflow, error) {
+	requirements, err := s.dal.GetReviewRequirements(dbs)
+	if err != nil {
+		return nil, err
+	}
+
+	wf := workflow.Default()
+	wf.Requirements = requirements
+	return wf, nil
+}
+
+func (s *SiteService) GetReviewRequirementsPageData(ctx context.Context) (*types.ReviewRequirementsPageData, error) {
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	requirements, err := s.dal.GetReviewRequirements(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.ReviewRequirementsPageData{
+		BasePageData:       *bpd,
+		ReviewRequirements: requirements,
+		SubmissionLevels:   submissionLevels(),
+		Roles:              constants.StaffRoles(),
+	}
+
+	return pageData, nil
+}
+
+// validateReviewRequirement checks the requirement sent by an admin
+func validateReviewRequirement(r *types.ReviewRequirement) error {
+	if r.SubmissionLevel != "" && !slices.Contains(submissionLevels(), r.SubmissionLevel) {
+		return perr(fmt.Sprintf("invalid submission level '%s'", r.SubmissionLevel), http.StatusBadRequest)
+	}
+	if r.RequiredRole != "" && !slices.Contains(constants.StaffRoles(), r.RequiredRole) {
+		return perr(fmt.Sprintf("invalid role '%s'", r.RequiredRole), http.StatusBadRequest)
+	}
+	if r.RequiredApprovals < 0 || r.RequiredApprovals > maxRequiredReviews ||
+		r.RequiredVerifications < 0 || r.RequiredVerifications > maxRequiredReviews {
+		return perr(fmt.Sprintf("required approvals and verifications must be between 0 and %d", maxRequiredReviews), http.StatusBadRequest)
+	}
+	if r.RequiredApprovals+r.RequiredVerifications < 1 {
+		return perr("at least one approval or verification must be required", http.StatusBadRequest)
+	}
+	return nil
+}
+
+// SaveReviewRequirement stores the review requirement for its level and platform
+func (s *SiteService) SaveReviewRequirement(ctx context.Context, r *types.ReviewRequirement) error {
+	r.Platform = strings.TrimSpace(r.Platform)
+
+	if err := validateReviewRequirement(r); err != nil {
+		return err
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	r.UpdatedBy = utils.UserID(ctx)
+	r.UpdatedAt = s.clock.Now()
+
+	if err := s.dal.StoreReviewRequirement(dbs, r); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// DeleteReviewRequirement removes the review requirement
+func (s *SiteService) DeleteReviewRequirement(ctx context.Context, id int64) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.DeleteReviewRequirement(dbs, id); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// fillReviewProgress sets the review progress of the submissions and the actions the user can perform on them
+func (s *SiteService) fillReviewProgress(dbs database.DBSession, uid int64, roles []string, submissions []*types.ExtendedSubmission) error {
+	wf, err := s.loadWorkflow(dbs)
+	if err != nil {
+		return err
+	}
+	for _, submission := range submissions {
+		submission.ReviewProgress = wf.Progress(submission)
+		submission.AvailableActions = wf.AvailableActions(uid, roles, submission)
+	}
+	return nil
+}
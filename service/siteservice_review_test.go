@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+func Test_validateReviewRequirement(t *testing.T) {
+	tests := []struct {
+		name        string
+		requirement *types.ReviewRequirement
+		wantErr     bool
+	}{
+		{
+			name:        "approvals and verifications",
+			requirement: &types.ReviewRequirement{SubmissionLevel: constants.SubmissionLevelAudition, RequiredApprovals: 2, RequiredVerifications: 1},
+		},
+		{
+			name:        "verification only",
+			requirement: &types.ReviewRequirement{RequiredVerifications: 1},
+		},
+		{
+			name:        "approval only",
+			requirement: &types.ReviewRequirement{RequiredApprovals: 1},
+		},
+		{
+			name:        "no reviews at all",
+			requirement: &types.ReviewRequirement{},
+			wantErr:     true,
+		},
+		{
+			name:        "negative approvals",
+			requirement: &types.ReviewRequirement{RequiredApprovals: -1, RequiredVerifications: 2},
+			wantErr:     true,
+		},
+		{
+			name:        "too many verifications",
+			requirement: &types.ReviewRequirement{RequiredApprovals: 1, RequiredVerifications: maxRequiredReviews + 1},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown level",
+			requirement: &types.ReviewRequirement{SubmissionLevel: "gold", RequiredApprovals: 1},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown role",
+			requirement: &types.ReviewRequirement{RequiredRole: "Nobody", RequiredApprovals: 1},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateReviewRequirement(tt.requirement); (err != nil) != tt.wantErr {
+				t.Errorf("validateReviewRequirement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/FlashpointProject/flashpoint-submission-system/workflow"
+)
+
+// maxQueueAssignments is the most submissions a tester can ask the queue to keep them busy with
+const maxQueueAssignments = 10
+
+// reviewQueueCandidates is how many of the oldest waiting submissions are considered when picking one for a tester
+const reviewQueueCandidates = 200
+
+// reviewQueueHistory is how far back the dashboard shows finished assignments
+const reviewQueueHistory = 7 * 24 * time.Hour
+
+// waitingSubmissionsFilter matches submissions which passed the validator and can be tested, ordered by their first upload
+// so that comments don't move a submission to the back of the queue
+func waitingSubmissionsFilter() *types.SubmissionsFilter {
+	requestedChanges := "none"
+	frozen := "no"
+	orderBy := "uploaded"
+	ascDesc := "asc"
+	return &types.SubmissionsFilter{
+		BotActions:             []string{constants.ActionApprove},
+		RequestedChangedStatus: &requestedChanges,
+		DistinctActionsNot:     []string{constants.ActionMarkAdded, constants.ActionReject},
+		IsFrozen:               &frozen,
+		OrderBy:                &orderBy,
+		AscDesc:                &ascDesc,
+		ExcludeLegacy:          true,
+	}
+}
+
+// needsTester reports whether the submission needs more testers than there are assigned to it or have approved it
+func needsTester(wf *workflow.Workflow, s *types.ExtendedSubmission) bool {
+	return len(s.AssignedTestingUserIDs)+len(s.ApprovedUserIDs) < wf.Requirement(s).RequiredApprovals
+}
+
+// platformsMatch reports whether any of the submission's platforms is one of the platforms, empty platforms match everything
+func platformsMatch(platforms []string, s *types.ExtendedSubmission) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	if s.CurationPlatform == nil {
+		return false
+	}
+	for _, p := range strings.Split(*s.CurationPlatform, ";") {
+		for _, platform := range platforms {
+			if strings.EqualFold(strings.TrimSpace(p), platform) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// testingLoad returns the number of submissions the user is assigned to test
+func (s *SiteService) testingLoad(dbs database.DBSession, uid int64) (int64, error) {
+	assigned := "assigned"
+	var one int64 = 1
+	_, count, err := s.dal.SearchSubmissions(dbs, &types.SubmissionsFilter{
+		AssignedStatusUserID:      &uid,
+		AssignedStatusTestingUser: &assigned,
+		DistinctActionsNot:        []string{constants.ActionMarkAdded, constants.ActionReject},
+		ResultsPerPage:            &one,
+	})
+	return count, err
+}
+
+func (s *SiteService) getReviewQueueMember(dbs database.DBSession, uid int64) (*types.ReviewQueueMember, error) {
+	members, err := s.dal.GetReviewQueueMembers(dbs)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		if m.UserID == uid {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+// storeComments stores the comments and emits their events
+func (s *SiteService) storeComments(dbs database.DBSession, pgdbs database.PGDBSession, comments ...*types.Comment) error {
+	for _, c := range comments {
+		cid, err := s.dal.StoreComment(dbs, c)
+		if err != nil {
+			return err
+		}
+		if err := s.EmitSubmissionCommentEvent(pgdbs, c.AuthorID, c.SubmissionID, cid, c.Action, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JoinReviewQueue adds the current user to the review queue, or updates their preferences if they are already in it
+func (s *SiteService) JoinReviewQueue(ctx context.Context, m *types.ReviewQueueMember) error {
+	platforms := make([]string, 0, len(m.Platforms))
+	for _, p := range m.Platforms {
+		p = strings.TrimSpace(p)
+		if p != "" && !slices.Contains(platforms, p) {
+			platforms = append(platforms, p)
+		}
+	}
+	m.Platforms = platforms
+
+	for _, level := range m.SubmissionLevels {
+		if !slices.Contains(submissionLevels(), level) {
+			return perr(fmt.Sprintf("invalid submission level '%s'", level), http.StatusBadRequest)
+		}
+	}
+	if m.MaxAssignments < 1 || m.MaxAssignments > maxQueueAssignments {
+		return perr(fmt.Sprintf("max assignments must be between 1 and %d", maxQueueAssignments), http.StatusBadRequest)
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	m.UserID = utils.UserID(ctx)
+	m.JoinedAt = s.clock.Now()
+
+	if err := s.dal.StoreReviewQueueMember(dbs, m); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// LeaveReviewQueue removes the current user from the review queue, submissions they are testing stay assigned to them
+func (s *SiteService) LeaveReviewQueue(ctx context.Context) error {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+	defer dbs.Rollback()
+
+	if err := s.dal.DeleteReviewQueueMember(dbs, utils.UserID(ctx)); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return dberr(err)
+	}
+
+	return nil
+}
+
+// AssignNextQueuedSubmission assigns the current user to test the oldest submission which matches their preferences
+// and needs a tester, preferring submissions nobody is testing yet. Returns the id of the submission.
+func (s *SiteService) AssignNextQueuedSubmission(ctx context.Context) (int64, error) {
+	uid := utils.UserID(ctx)
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	member, err := s.getReviewQueueMember(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if member == nil {
+		return 0, perr("you are not in the review queue", http.StatusBadRequest)
+	}
+
+	load, err := s.testingLoad(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if load >= member.MaxAssignments {
+		return 0, perr(fmt.Sprintf("you are already assigned to test %d submissions, finish some of them first", load), http.StatusBadRequest)
+	}
+
+	userRoles, err := s.dal.GetDiscordUserRoles(dbs, uid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	notMe := "yes"
+	var resultsPerPage int64 = reviewQueueCandidates
+	filter := waitingSubmissionsFilter()
+	filter.LastUploaderNotMe = &notMe
+	filter.SubmissionLevels = member.SubmissionLevels
+	filter.ResultsPerPage = &resultsPerPage
+
+	candidates, _, err := s.dal.SearchSubmissions(dbs, filter)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	wf, err := s.loadWorkflow(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	matching := make([]*types.ExtendedSubmission, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !needsTester(wf, candidate) || !platformsMatch(member.Platforms, candidate) {
+			continue
+		}
+		if !slices.Contains(wf.AvailableActions(uid, userRoles, candidate), constants.ActionAssignTesting) {
+			continue
+		}
+		matching = append(matching, candidate)
+	}
+	// candidates are sorted oldest first, so the oldest one wins among those with the same number of testers
+	slices.SortStableFunc(matching, func(a, b *types.ExtendedSubmission) int {
+		return len(a.AssignedTestingUserIDs) - len(b.AssignedTestingUserIDs)
+	})
+
+	// two testers asking at once, possibly on different instances, must not get the same submission. The submission
+	// is locked while it's assigned, the other tester skips it and its testers are read again after locking.
+	var next *types.ExtendedSubmission
+	for _, candidate := range matching {
+		testing, approved, err := s.dal.LockSubmissionReviewers(dbs, candidate.SubmissionID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+		candidate.AssignedTestingUserIDs = testing
+		candidate.ApprovedUserIDs = approved
+		if !needsTester(wf, candidate) || slices.Contains(testing, uid) || slices.Contains(approved, uid) {
+			continue
+		}
+		next = candidate
+		break
+	}
+	if next == nil {
+		return 0, perr("there is no submission waiting for you in the review queue", http.StatusNotFound)
+	}
+
+	sid := next.SubmissionID
+	now := s.clock.Now()
+
+	err = s.storeComments(dbs, pgdbs, &types.Comment{
+		AuthorID:     uid,
+		SubmissionID: sid,
+		Action:       constants.ActionAssignTesting,
+		CreatedAt:    now,
+	})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	subscribed, err := s.dal.IsUserSubscribedToSubmission(dbs, uid, sid)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if !subscribed {
+		if err := s.dal.SubscribeUserToSubmission(dbs, uid, sid); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+	}
+
+	if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	_, err = s.dal.StoreReviewQueueAssignment(dbs, &types.ReviewQueueAssignment{
+		SubmissionID: sid,
+		UserID:       uid,
+		AssignedAt:   now,
+	})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	if err := pgdbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	utils.LogCtx(ctx).WithField("submissionID", sid).WithField("load", load+1).Info("review queue assigned a submission")
+
+	return sid, nil
+}
+
+// ExpireReviewQueueAssignments closes assignments of the review queue which were finished by the tester,
+// and unassigns testers who did not comment on their submission for longer than the idle timeout.
+// Zero idle timeout never unassigns anybody. Returns how many testers were unassigned.
+func (s *SiteService) ExpireReviewQueueAssignments(ctx context.Context, idleTimeout time.Duration) (int, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	assignments, err := s.dal.GetOpenReviewQueueAssignments(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if len(assignments) == 0 {
+		return 0, nil
+	}
+
+	sids := make([]int64, 0, len(assignments))
+	for _, a := range assignments {
+		sids = append(sids, a.SubmissionID)
+	}
+	resultsPerPage := int64(len(sids))
+	found, _, err := s.dal.SearchSubmissions(dbs, &types.SubmissionsFilter{SubmissionIDs: sids, ResultsPerPage: &resultsPerPage})
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	submissions := make(map[int64]*types.ExtendedSubmission, len(found))
+	for _, submission := range found {
+		submissions[submission.SubmissionID] = submission
+	}
+
+	now := s.clock.Now()
+	expired := 0
+
+	for _, a := range assignments {
+		submission := submissions[a.SubmissionID]
+
+		if submission == nil || !slices.Contains(submission.AssignedTestingUserIDs, a.UserID) {
+			outcome := types.ReviewQueueOutcomeUnassigned
+			if submission != nil && slices.Contains(submission.ApprovedUserIDs, a.UserID) {
+				outcome = types.ReviewQueueOutcomeApproved
+			}
+			if err := s.dal.FinishReviewQueueAssignment(dbs, a.ID, now, outcome); err != nil {
+				utils.LogCtx(ctx).Error(err)
+				return 0, dberr(err)
+			}
+			continue
+		}
+
+		if idleTimeout <= 0 || now.Sub(a.LastActivityAt) < idleTimeout {
+			continue
+		}
+
+		msg := fmt.Sprintf("%s was unassigned from testing after %d hours without activity, the submission is back in the review queue.",
+			a.Username, int64(idleTimeout.Hours()))
+		err = s.storeComments(dbs, pgdbs, &types.Comment{
+			AuthorID:     constants.SystemID,
+			SubmissionID: a.SubmissionID,
+			Message:      &msg,
+			Action:       constants.ActionUnassignTesting,
+			CreatedAt:    now,
+			TargetUserID: &a.UserID,
+		})
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+
+		if err := s.dal.UpdateSubmissionCacheTable(dbs, a.SubmissionID); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+		if err := s.dal.FinishReviewQueueAssignment(dbs, a.ID, now, types.ReviewQueueOutcomeExpired); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return 0, dberr(err)
+		}
+
+		utils.LogCtx(ctx).WithField("submissionID", a.SubmissionID).WithField("userID", a.UserID).Info("review queue assignment expired")
+		expired++
+	}
+
+	if err := pgdbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return 0, dberr(err)
+	}
+
+	if expired > 0 {
+		utils.LogCtx(ctx).WithField("amount", expired).Info("expired review queue assignments")
+	}
+
+	return expired, nil
+}
+
+func (s *SiteService) GetReviewQueuePageData(ctx context.Context, idleTimeout time.Duration) (*types.ReviewQueuePageData, error) {
+	bpd, err := s.GetBasePageData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	members, err := s.dal.GetReviewQueueMembers(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData := &types.ReviewQueuePageData{
+		BasePageData:     *bpd,
+		Members:          members,
+		OutcomeCounts:    make(map[string]int64),
+		IdleTimeoutHours: int64(idleTimeout.Hours()),
+		SubmissionLevels: submissionLevels(),
+	}
+
+	for _, m := range members {
+		m.Load, err = s.testingLoad(dbs, m.UserID)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, dberr(err)
+		}
+		if m.UserID == bpd.UserID {
+			pageData.Member = m
+		}
+	}
+
+	pageData.OpenAssignments, err = s.dal.GetOpenReviewQueueAssignments(dbs)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	pageData.RecentAssignments, err = s.dal.GetFinishedReviewQueueAssignments(dbs, s.clock.Now().Add(-reviewQueueHistory))
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	for _, a := range pageData.RecentAssignments {
+		pageData.OutcomeCounts[*a.Outcome]++
+	}
+
+	// the backlog is what nobody is testing yet, submissions waiting for a second tester are not counted
+	unassigned := "unassigned"
+	noApprovals := "none"
+	var one int64 = 1
+	filter := waitingSubmissionsFilter()
+	filter.AssignedStatusTesting = &unassigned
+	filter.ApprovalsStatus = &noApprovals
+	filter.ResultsPerPage = &one
+
+	oldest, backlog, err := s.dal.SearchSubmissions(dbs, filter)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	pageData.Backlog = backlog
+	if len(oldest) > 0 {
+		pageData.OldestWaitingAt = &oldest[0].UploadedAt
+	}
+
+	return pageData, nil
+}
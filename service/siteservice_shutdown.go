@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// processingCancelGracePeriod is how long the cancelled processing gets to roll back after the drain deadline passed
+const processingCancelGracePeriod = 10 * time.Second
+
+func shuttingDownError() error {
+	return perr("the server is restarting, please try again in a minute", http.StatusServiceUnavailable)
+}
+
+// BeginShutdown makes the service refuse new uploads. The uploads already received are still processed.
+func (s *SiteService) BeginShutdown() {
+	s.processingMutex.Lock()
+	defer s.processingMutex.Unlock()
+	s.shuttingDown = true
+}
+
+func (s *SiteService) isShuttingDown() bool {
+	s.processingMutex.Lock()
+	defer s.processingMutex.Unlock()
+	return s.shuttingDown
+}
+
+// startProcessing registers background work which has to finish before the server stops.
+// It returns false once the shutdown began, the caller must call s.processingWG.Done() otherwise.
+func (s *SiteService) startProcessing() bool {
+	s.processingMutex.Lock()
+	defer s.processingMutex.Unlock()
+	if s.shuttingDown {
+		return false
+	}
+	s.processingWG.Add(1)
+	return true
+}
+
+// DrainProcessing waits for the background processing of received uploads. When the context expires first,
+// the processing is cancelled and the interrupted uploads go back to the queue, to be processed on the next start.
+func (s *SiteService) DrainProcessing(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.processingWG.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.cancelProcessing()
+
+	select {
+	case <-done:
+	case <-time.After(processingCancelGracePeriod):
+	}
+	return ctx.Err()
+}
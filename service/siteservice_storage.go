@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+// StorageVolumes are the storages all stored files are kept in.
+// Incoming uploads are still received into local directories first, because the validator and the indexers work with file paths.
+type StorageVolumes struct {
+	Blobs             storage.Storage
+	Submissions       storage.Storage // submission files uploaded before the blob store existed
+	SubmissionImages  storage.Storage
+	Flashfreeze       storage.Storage // flashfreeze files uploaded before the blob store existed
+	DataPacks         storage.Storage
+	FrozenPacks       storage.Storage
+	DeletedDataPacks  storage.Storage
+	Images            storage.Storage
+	DeletedImages     storage.Storage
+	Quarantine        storage.Storage // orphaned files found by the consistency check, nil if not configured
+	MetadataSnapshots storage.Storage // generated exports of the metadata, nil if not configured
+}
+
+// Volumes returns the storages the service keeps its files in
+func (s *SiteService) Volumes() *StorageVolumes {
+	return s.volumes
+}
+
+// DataPackKey returns the storage key of a game's data pack
+func DataPackKey(gameID string, dateAdded time.Time) string {
+	return fmt.Sprintf("%s-%d%s", gameID, dateAdded.UnixMilli(), ".zip")
+}
+
+// LogoKey returns the storage key of a game's logo
+func LogoKey(gameID string) string {
+	return fmt.Sprintf("Logos/%s/%s/%s.png", gameID[:2], gameID[2:4], gameID)
+}
+
+// ScreenshotKey returns the storage key of a game's screenshot
+func ScreenshotKey(gameID string) string {
+	return fmt.Sprintf("Screenshots/%s/%s/%s.png", gameID[:2], gameID[2:4], gameID)
+}
+
+// SubmissionFileLocation returns the storage and key of the stored submission file.
+// Files uploaded before the blob store existed are still in the submissions volume.
+func (s *SiteService) SubmissionFileLocation(ctx context.Context, sf *types.SubmissionFile) (storage.Storage, string, error) {
+	key, ok, err := s.blobs.Find(ctx, sf.SHA256Sum)
+	if err != nil {
+		return nil, "", err
+	}
+	if ok {
+		return s.volumes.Blobs, key, nil
+	}
+	return s.volumes.Submissions, sf.CurrentFilename, nil
+}
+
+// FlashfreezeFileLocation returns the storage and key of the stored flashfreeze root file.
+// Files uploaded before the blob store existed are still in the flashfreeze volume.
+func (s *SiteService) FlashfreezeFileLocation(ctx context.Context, ff *types.FlashfreezeFile) (storage.Storage, string, error) {
+	key, ok, err := s.blobs.Find(ctx, ff.SHA256Sum)
+	if err != nil {
+		return nil, "", err
+	}
+	if ok {
+		return s.volumes.Blobs, key, nil
+	}
+	return s.volumes.Flashfreeze, ff.CurrentFilename, nil
+}
+
+// moveGameImages moves the logo and screenshot of a game between image volumes, if they exist
+func (s *SiteService) moveGameImages(ctx context.Context, gameID string, src, dst storage.Storage) error {
+	for _, key := range []string{LogoKey(gameID), ScreenshotKey(gameID)} {
+		if _, err := storage.MoveIfExists(ctx, src, key, dst, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveGameDataPacks moves all data packs of a game between data pack volumes, if they exist
+func (s *SiteService) moveGameDataPacks(ctx context.Context, game *types.Game, src, dst storage.Storage) error {
+	for _, data := range game.Data {
+		key := DataPackKey(game.ID, data.DateAdded)
+		if _, err := storage.MoveIfExists(ctx, src, key, dst, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"crypto/sha256"
@@ -8,7 +9,6 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -19,31 +19,32 @@ import (
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
 	"github.com/FlashpointProject/flashpoint-submission-system/database"
 	"github.com/FlashpointProject/flashpoint-submission-system/resumableuploadservice"
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/go-sql-driver/mysql"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
-func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs database.DBSession, pgdbs database.PGDBSession, fileReadCloserProvider resumableuploadservice.ReadCloserInformerProvider, filename string, filesize int64, sid *int64, submissionLevel string, tempName string) (*string, []string, int64, error) {
+func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs database.DBSession, pgdbs database.PGDBSession, fileReadCloserProvider resumableuploadservice.ReadCloserInformerProvider, filename string, filesize int64, sid *int64, submissionLevel string, draft bool, tempName string) (_ *string, _ []string, _ int64, err error) {
+	ctx, span := tracing.Start(ctx, "processReceivedSubmission",
+		attribute.String("file.name", filename), attribute.Int64("file.size", filesize), attribute.Bool("submission.draft", draft))
+	defer func() { tracing.End(span, err) }()
+
 	uid := utils.UserID(ctx)
 	if uid == 0 {
 		s.SSK.SetFailed(tempName, "internal error")
 		utils.LogCtx(ctx).Panic("no user associated with request")
 	}
 
-	var err error
-
 	utils.LogCtx(ctx).Debugf("received a file '%s' - %d bytes", filename, filesize)
 
 	if err := os.MkdirAll(s.submissionsDir, os.ModeDir); err != nil {
 		s.SSK.SetFailed(tempName, "internal error")
 		return nil, nil, 0, err
 	}
-	if err := os.MkdirAll(s.submissionImagesDir, os.ModeDir); err != nil {
-		s.SSK.SetFailed(tempName, "internal error")
-		return nil, nil, 0, err
-	}
 
 	ext := filepath.Ext(filename)
 
@@ -68,10 +69,11 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 	md5sum := md5.New()
 	sha256sum := sha256.New()
 
-	err = func() error {
-		utils.LogCtx(ctx).Debug("processing submission file...")
+	err = func() (err error) {
+		ctx, span := tracing.Start(ctx, "copySubmissionFile")
+		defer func() { tracing.End(span, err) }()
 
-		var err error
+		utils.LogCtx(ctx).Debug("processing submission file...")
 
 		readCloserInformer, err := fileReadCloserProvider.GetReadCloserInformer()
 		if err != nil {
@@ -142,10 +144,11 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 		}
 		defer readCloser.Close()
 
-		vr, err = s.validator.ProvideArchiveForValidation(destinationFilePath)
+		vr, err = s.validator.ProvideArchiveForValidation(ctx, destinationFilePath)
 		if err != nil {
 			utils.LogCtx(ctx).Error(err)
-			return perr(fmt.Sprintf("validator bot: %s", err.Error()), http.StatusInternalServerError)
+			// the validator being down is not the fault of the upload, the processing queue tries again later
+			return retryable(perr(fmt.Sprintf("validator bot: %s", err.Error()), http.StatusInternalServerError))
 		}
 		if vr.CurationErrors != nil {
 			for _, curError := range vr.CurationErrors {
@@ -221,9 +224,24 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 			s.SSK.SetFailed(tempName, "internal error")
 			return &destinationFilePath, nil, 0, dberr(err)
 		}
+		if draft {
+			if err := s.dal.MarkSubmissionDraft(dbs, submissionID); err != nil {
+				utils.LogCtx(ctx).Error(err)
+				s.SSK.SetFailed(tempName, "internal error")
+				return &destinationFilePath, nil, 0, dberr(err)
+			}
+		}
 	} else {
 		submissionID = *sid
 		isSubmissionNew = false
+
+		// updates of a draft stay a draft until it is published
+		draft, err = s.dal.IsSubmissionDraft(dbs, submissionID)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			s.SSK.SetFailed(tempName, "internal error")
+			return &destinationFilePath, nil, 0, dberr(err)
+		}
 	}
 
 	// send notification about new file uploaded
@@ -243,21 +261,11 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 
 	isAudition := submissionLevel == constants.SubmissionLevelAudition
 
-	// also subscribe all those that want to subscribe to new audition uploads
-	if isSubmissionNew && isAudition {
-		auditionSubscribeUserIDs, err := s.dal.GetUsersForUniversalNotification(dbs, uid, constants.ActionAuditionSubscribe)
-		if err != nil {
-			utils.LogCtx(dbs.Ctx()).Error(err)
+	// also subscribe all those that want to subscribe to new audition uploads, drafts do that when they are published
+	if isSubmissionNew && isAudition && !draft {
+		if err := s.subscribeAuditionWatchers(dbs, uid, submissionID); err != nil {
 			s.SSK.SetFailed(tempName, "internal error")
-			return &destinationFilePath, nil, 0, dberr(err)
-		}
-
-		for _, subUID := range auditionSubscribeUserIDs {
-			if err := s.dal.SubscribeUserToSubmission(dbs, subUID, submissionID); err != nil {
-				utils.LogCtx(ctx).Error(err)
-				s.SSK.SetFailed(tempName, "internal error")
-				return &destinationFilePath, nil, 0, dberr(err)
-			}
+			return &destinationFilePath, nil, 0, err
 		}
 	}
 
@@ -272,10 +280,23 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 		SHA256Sum:        hex.EncodeToString(sha256sum.Sum(nil)),
 	}
 
+	present, err := s.dal.IsSubmissionFilePresent(dbs, sf.SHA256Sum)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		s.SSK.SetFailed(tempName, "internal error")
+		return &destinationFilePath, nil, 0, dberr(err)
+	}
+	if present {
+		msg := fmt.Sprintf("file '%s' with checksums md5:%s sha256:%s already present in the DB", filename, sf.MD5Sum, sf.SHA256Sum)
+		s.SSK.SetFailed(tempName, msg)
+		return &destinationFilePath, nil, 0, perr(msg, http.StatusConflict)
+	}
+
 	fid, err := s.dal.StoreSubmissionFile(dbs, sf)
 	if err != nil {
 		me, ok := err.(*mysql.MySQLError)
 		if ok {
+			// the same file uploaded concurrently, only one live copy is allowed
 			if me.Number == 1062 {
 				msg := fmt.Sprintf("file '%s' with checksums md5:%s sha256:%s already present in the DB", filename, sf.MD5Sum, sf.SHA256Sum)
 				s.SSK.SetFailed(tempName, msg)
@@ -287,6 +308,13 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 		return &destinationFilePath, nil, 0, dberr(err)
 	}
 
+	// identical files deleted earlier, or in flashfreeze, share the blob
+	if err := s.dal.AcquireFileBlob(dbs, sf.SHA256Sum, sf.Size); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		s.SSK.SetFailed(tempName, "internal error")
+		return &destinationFilePath, nil, 0, dberr(err)
+	}
+
 	utils.LogCtx(ctx).Debug("storing submission comment...")
 
 	c := &types.Comment{
@@ -327,6 +355,16 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 		return &destinationFilePath, nil, 0, dberr(err)
 	}
 
+	utils.LogCtx(ctx).Debug("linting curation meta...")
+
+	findings, err := s.lintCurationMeta(dbs, pgdbs, &vr.Meta)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		s.SSK.SetFailed(tempName, "internal error")
+		return &destinationFilePath, nil, 0, dberr(err)
+	}
+	mergeLintFindings(vr, findings)
+
 	// feed the curation feed
 	isCurationValid := len(vr.CurationErrors) == 0 && len(vr.CurationWarnings) == 0
 
@@ -354,22 +392,27 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 	}
 
 	// remove freezer warning from the validator response
-	if len(vr.CurationWarnings) > 0 {
+	if shouldAutofreeze {
 		vr.CurationWarnings = append(vr.CurationWarnings[:warningIndex], vr.CurationWarnings[warningIndex+1:]...)
 	}
 
-	if err := s.createCurationFeedMessage(dbs, uid, submissionID, isSubmissionNew, isCurationValid, &vr.Meta, isAudition); err != nil {
-		s.SSK.SetFailed(tempName, "internal error")
-		return &destinationFilePath, nil, 0, dberr(err)
+	if !draft {
+		if err := s.createCurationFeedMessage(dbs, uid, submissionID, isSubmissionNew, isCurationValid, &vr.Meta, isAudition); err != nil {
+			s.SSK.SetFailed(tempName, "internal error")
+			return &destinationFilePath, nil, 0, dberr(err)
+		}
 	}
 
 	errs, ectx := errgroup.WithContext(ctx)
 
 	// save images
-	imageFilePaths := make([]string, 0, len(vr.Images))
+	imageKeys := make([]string, 0, len(vr.Images))
 	cis := make([]*types.CurationImage, 0, len(vr.Images))
 
-	errs.Go(func() error {
+	errs.Go(func() (err error) {
+		ectx, span := tracing.Start(ectx, "saveCurationImages", attribute.Int("image.count", len(vr.Images)))
+		defer func() { tracing.End(span, err) }()
+
 		utils.LogCtx(ectx).Debug("processing meta images in goroutine")
 		for _, image := range vr.Images {
 			imageData, err := base64.StdEncoding.DecodeString(image.Data)
@@ -378,18 +421,20 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 			}
 
 			var imageFilename string
-			var imageFilenameFilePath string
 			for {
 				imageFilename = s.randomStringProvider.RandomString(64)
-				imageFilenameFilePath = fmt.Sprintf("%s/%s", s.submissionImagesDir, imageFilename)
-				if !utils.FileExists(imageFilenameFilePath) {
+				exists, err := storage.Exists(ectx, s.volumes.SubmissionImages, imageFilename)
+				if err != nil {
+					return err
+				}
+				if !exists {
 					break
 				}
 			}
 
-			imageFilePaths = append(imageFilePaths, imageFilenameFilePath)
+			imageKeys = append(imageKeys, imageFilename)
 
-			if err := ioutil.WriteFile(imageFilenameFilePath, imageData, 0644); err != nil {
+			if err := s.volumes.SubmissionImages.Put(ectx, imageFilename, bytes.NewReader(imageData), int64(len(imageData))); err != nil {
 				return err
 			}
 
@@ -408,14 +453,14 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 	if err := errs.Wait(); err != nil {
 		utils.LogCtx(ctx).Error(err)
 		s.SSK.SetFailed(tempName, "internal error")
-		return &destinationFilePath, imageFilePaths, 0, err
+		return &destinationFilePath, imageKeys, 0, err
 	}
 
 	for _, ci := range cis {
 		if _, err := s.dal.StoreCurationImage(dbs, ci); err != nil {
 			utils.LogCtx(ctx).Error(err)
 			s.SSK.SetFailed(tempName, "internal error")
-			return &destinationFilePath, imageFilePaths, 0, dberr(err)
+			return &destinationFilePath, imageKeys, 0, dberr(err)
 		}
 	}
 
@@ -486,10 +531,33 @@ func (s *SiteService) processReceivedSubmission(ctx context.Context, dbs databas
 	if err := s.dal.UpdateSubmissionCacheTable(dbs, submissionID); err != nil {
 		utils.LogCtx(ctx).Error(err)
 		s.SSK.SetFailed(tempName, "internal error")
-		return &destinationFilePath, imageFilePaths, 0, dberr(err)
+		return &destinationFilePath, imageKeys, 0, dberr(err)
+	}
+
+	if err := s.moveToBlobStore(ctx, destinationFilePath, sf.SHA256Sum); err != nil {
+		s.SSK.SetFailed(tempName, "internal error")
+		return &destinationFilePath, imageKeys, 0, err
+	}
+
+	return &destinationFilePath, imageKeys, submissionID, nil
+}
+
+// subscribeAuditionWatchers subscribes the users who want to follow all new audition uploads to the submission
+func (s *SiteService) subscribeAuditionWatchers(dbs database.DBSession, uid, sid int64) error {
+	auditionSubscribeUserIDs, err := s.dal.GetUsersForUniversalNotification(dbs, uid, constants.ActionAuditionSubscribe)
+	if err != nil {
+		utils.LogCtx(dbs.Ctx()).Error(err)
+		return dberr(err)
+	}
+
+	for _, subUID := range auditionSubscribeUserIDs {
+		if err := s.dal.SubscribeUserToSubmission(dbs, subUID, sid); err != nil {
+			utils.LogCtx(dbs.Ctx()).Error(err)
+			return dberr(err)
+		}
 	}
 
-	return &destinationFilePath, imageFilePaths, submissionID, nil
+	return nil
 }
 
 // convertValidatorResponseToComment produces appropriate comment based on validator response
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/activityevents"
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// sweepLimit is the most submissions a single sweep looks at for each rule
+const sweepLimit = 10000
+
+// SweeperRules configure the stalled submission sweeper, zero disables a rule
+type SweeperRules struct {
+	// UnassignInactiveAfter unassigns testers and verifiers who did not comment on their submission for this long,
+	// except testers assigned by the review queue, which has its own idle timeout
+	UnassignInactiveAfter time.Duration
+	// RemindAfter reminds submitters who did not upload a new version this long after changes were requested
+	RemindAfter time.Duration
+	// RejectGracePeriod rejects submissions which got no new version this long after the reminder, requires reminders
+	RejectGracePeriod time.Duration
+}
+
+func days(d time.Duration) int64 {
+	return int64(d.Hours() / 24)
+}
+
+// inactiveAssignees returns the assigned users whose last comment, if any, is not newer than the threshold
+func inactiveAssignees(uids []int64, lastComments map[int64]time.Time, threshold time.Time) []int64 {
+	inactive := make([]int64, 0)
+	for _, uid := range uids {
+		if !lastComments[uid].After(threshold) {
+			inactive = append(inactive, uid)
+		}
+	}
+	return inactive
+}
+
+// abandonedSubmissionStep is what the sweeper does with a submission waiting for requested changes
+type abandonedSubmissionStep int
+
+const (
+	abandonedSubmissionWait abandonedSubmissionStep = iota
+	abandonedSubmissionRemind
+	abandonedSubmissionReject
+)
+
+// nextAbandonedSubmissionStep applies the reminder and rejection rules to a submission whose changes were requested.
+// Zero uploaded or reminded time means there was no upload or reminder.
+func nextAbandonedSubmissionStep(rules SweeperRules, now, requested, uploaded, reminded time.Time) abandonedSubmissionStep {
+	// a new version is waiting for the reviewers, not for the submitter
+	if uploaded.After(requested) {
+		return abandonedSubmissionWait
+	}
+	if now.Sub(requested) < rules.RemindAfter {
+		return abandonedSubmissionWait
+	}
+	// the reminder is sent once for every request
+	if reminded.Before(requested) {
+		return abandonedSubmissionRemind
+	}
+	if rules.RejectGracePeriod <= 0 || now.Sub(reminded) < rules.RejectGracePeriod {
+		return abandonedSubmissionWait
+	}
+	return abandonedSubmissionReject
+}
+
+// sweepSubmissions returns the submissions matching the filter which are not added, rejected or frozen
+func (s *SiteService) sweepSubmissions(dbs database.DBSession, filter *types.SubmissionsFilter) ([]*types.ExtendedSubmission, error) {
+	frozen := "no"
+	var limit int64 = sweepLimit
+	filter.DistinctActionsNot = []string{constants.ActionMarkAdded, constants.ActionReject}
+	filter.IsFrozen = &frozen
+	filter.ResultsPerPage = &limit
+	filter.ExcludeLegacy = true
+	submissions, _, err := s.dal.SearchSubmissions(dbs, filter)
+	return submissions, err
+}
+
+func submissionIDs(submissions []*types.ExtendedSubmission) []int64 {
+	sids := make([]int64, 0, len(submissions))
+	for _, submission := range submissions {
+		sids = append(sids, submission.SubmissionID)
+	}
+	return sids
+}
+
+// sweepInactiveAssignments unassigns users who are assigned to a submission but did not comment on it for too long
+func (s *SiteService) sweepInactiveAssignments(dbs database.DBSession, pgdbs database.PGDBSession, rules SweeperRules, report *types.SweepReport) error {
+	assigned := "assigned"
+	testing, err := s.sweepSubmissions(dbs, &types.SubmissionsFilter{AssignedStatusTesting: &assigned})
+	if err != nil {
+		return err
+	}
+	verification, err := s.sweepSubmissions(dbs, &types.SubmissionsFilter{AssignedStatusVerification: &assigned})
+	if err != nil {
+		return err
+	}
+
+	submissions := make(map[int64]*types.ExtendedSubmission)
+	for _, submission := range append(testing, verification...) {
+		submissions[submission.SubmissionID] = submission
+	}
+	all := make([]*types.ExtendedSubmission, 0, len(submissions))
+	for _, submission := range submissions {
+		all = append(all, submission)
+	}
+
+	lastComments, err := s.dal.GetLatestUserCommentTimes(dbs, submissionIDs(all))
+	if err != nil {
+		return err
+	}
+
+	// testers who got the submission from the review queue are unassigned by the queue, after its own idle timeout
+	queueAssignments, err := s.dal.GetOpenReviewQueueAssignments(dbs)
+	if err != nil {
+		return err
+	}
+	queued := make(map[int64][]int64, len(queueAssignments))
+	for _, a := range queueAssignments {
+		queued[a.SubmissionID] = append(queued[a.SubmissionID], a.UserID)
+	}
+
+	now := s.clock.Now()
+	threshold := now.Add(-rules.UnassignInactiveAfter)
+
+	for _, submission := range all {
+		sid := submission.SubmissionID
+		assignments := []struct {
+			uids        []int64
+			skip        []int64
+			action      string
+			description string
+		}{
+			{submission.AssignedTestingUserIDs, queued[sid], constants.ActionUnassignTesting, "testing"},
+			{submission.AssignedVerificationUserIDs, nil, constants.ActionUnassignVerification, "verification"},
+		}
+
+		unassigned := false
+		for _, assignment := range assignments {
+			for _, uid := range inactiveAssignees(assignment.uids, lastComments[sid], threshold) {
+				if slices.Contains(assignment.skip, uid) {
+					continue
+				}
+				user, err := s.dal.GetDiscordUser(dbs, uid)
+				if err != nil {
+					return err
+				}
+
+				msg := fmt.Sprintf("%s was unassigned from %s, there was no activity from them for %d days.",
+					user.Username, assignment.description, days(rules.UnassignInactiveAfter))
+				err = s.storeComments(dbs, pgdbs, &types.Comment{
+					AuthorID:     constants.SystemID,
+					SubmissionID: sid,
+					Message:      &msg,
+					Action:       assignment.action,
+					CreatedAt:    now,
+					TargetUserID: &uid,
+				})
+				if err != nil {
+					return err
+				}
+
+				report.Unassigned = append(report.Unassigned, &types.SweepUnassignment{SubmissionID: sid, UserID: uid, Action: assignment.action})
+				unassigned = true
+			}
+		}
+		if !unassigned {
+			continue
+		}
+
+		if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sweepAbandonedSubmissions reminds submitters who did not react to requested changes, and rejects the submission
+// if there is still no new version after the grace period
+func (s *SiteService) sweepAbandonedSubmissions(dbs database.DBSession, pgdbs database.PGDBSession, rules SweeperRules, report *types.SweepReport) error {
+	ongoing := "ongoing"
+	submissions, err := s.sweepSubmissions(dbs, &types.SubmissionsFilter{RequestedChangedStatus: &ongoing})
+	if err != nil {
+		return err
+	}
+
+	sids := submissionIDs(submissions)
+	requestedAt, err := s.dal.GetLatestActionTimes(dbs, sids, constants.ActionRequestChanges)
+	if err != nil {
+		return err
+	}
+	remindedAt, err := s.dal.GetSubmissionReminders(dbs, sids)
+	if err != nil {
+		return err
+	}
+	uploadedAt, err := s.dal.GetNewestFileTimes(dbs, sids)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+
+	for _, submission := range submissions {
+		sid := submission.SubmissionID
+		requested, ok := requestedAt[sid]
+		if !ok {
+			continue
+		}
+
+		switch nextAbandonedSubmissionStep(rules, now, requested, uploadedAt[sid], remindedAt[sid]) {
+		case abandonedSubmissionWait:
+			continue
+		case abandonedSubmissionRemind:
+			rejectAt := now.Add(rules.RejectGracePeriod)
+
+			var msg string
+			if rules.RejectGracePeriod > 0 {
+				msg = fmt.Sprintf("Changes were requested %d days ago and there is no new version yet. The submission will be rejected on %s unless a new version is uploaded.",
+					days(now.Sub(requested)), rejectAt.Format("2006-01-02"))
+			} else {
+				msg = fmt.Sprintf("Changes were requested %d days ago and there is no new version yet.", days(now.Sub(requested)))
+			}
+
+			err := s.storeComments(dbs, pgdbs, &types.Comment{
+				AuthorID:     constants.SystemID,
+				SubmissionID: sid,
+				Message:      &msg,
+				Action:       constants.ActionSystem,
+				CreatedAt:    now,
+			})
+			if err != nil {
+				return err
+			}
+			if err := s.dal.StoreSubmissionReminder(dbs, sid, now); err != nil {
+				return err
+			}
+			if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+				return err
+			}
+
+			var b strings.Builder
+			b.WriteString(fmt.Sprintf("You've got mail! <@%d>\n", submission.SubmitterID))
+			b.WriteString(fmt.Sprintf("<https://fpfss.unstable.life/web/submission/%d>\n", sid))
+			b.WriteString(msg)
+			b.WriteString("\n----------------------------------------------------------\n")
+			if err := s.dal.StoreNotification(dbs, b.String(), constants.NotificationDefault); err != nil {
+				return err
+			}
+
+			report.Reminded = append(report.Reminded, sid)
+			continue
+		}
+
+		msg := fmt.Sprintf("Rejected automatically, changes were requested %d days ago and no new version was uploaded.", days(now.Sub(requested)))
+		err := s.storeComments(dbs, pgdbs, &types.Comment{
+			AuthorID:     constants.SystemID,
+			SubmissionID: sid,
+			Message:      &msg,
+			Action:       constants.ActionReject,
+			CreatedAt:    now,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.createNotification(dbs, constants.SystemID, sid, constants.ActionReject); err != nil {
+			return err
+		}
+		if err := s.dal.UpdateSubmissionCacheTable(dbs, sid); err != nil {
+			return err
+		}
+
+		report.Rejected = append(report.Rejected, sid)
+	}
+
+	return nil
+}
+
+// SweepStalledSubmissions applies the sweeper rules once and reports what it did, also into the activity log
+func (s *SiteService) SweepStalledSubmissions(ctx context.Context, rules SweeperRules) (*types.SweepReport, error) {
+	dbs, err := s.dal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer dbs.Rollback()
+
+	pgdbs, err := s.pgdal.NewSession(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	defer pgdbs.Rollback()
+
+	report := &types.SweepReport{
+		StartedAt:  s.clock.Now(),
+		Unassigned: make([]*types.SweepUnassignment, 0),
+		Reminded:   make([]int64, 0),
+		Rejected:   make([]int64, 0),
+	}
+
+	if rules.UnassignInactiveAfter > 0 {
+		if err := s.sweepInactiveAssignments(dbs, pgdbs, rules, report); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, dberr(err)
+		}
+	}
+	if rules.RemindAfter > 0 {
+		if err := s.sweepAbandonedSubmissions(dbs, pgdbs, rules, report); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, dberr(err)
+		}
+	}
+
+	if len(report.Unassigned) > 0 || len(report.Reminded) > 0 || len(report.Rejected) > 0 {
+		unassigned := make([]int64, 0, len(report.Unassigned))
+		for _, u := range report.Unassigned {
+			unassigned = append(unassigned, u.SubmissionID)
+		}
+		event := activityevents.BuildSubmissionSweepEvent(constants.SystemID, unassigned, report.Reminded, report.Rejected)
+		if err := s.pgdal.CreateActivityEvent(pgdbs, event); err != nil {
+			utils.LogCtx(ctx).Error(err)
+			return nil, dberr(err)
+		}
+	}
+
+	if err := pgdbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+	if err := dbs.Commit(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		return nil, dberr(err)
+	}
+
+	utils.LogCtx(ctx).
+		WithField("unassigned", len(report.Unassigned)).
+		WithField("reminded", len(report.Reminded)).
+		WithField("rejected", len(report.Rejected)).
+		Info("stalled submissions swept")
+
+	s.announceNotification()
+
+	return report, nil
+}
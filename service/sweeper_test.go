@@ -0,0 +1,130 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_inactiveAssignees(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	threshold := now.Add(-14 * 24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		uids         []int64
+		lastComments map[int64]time.Time
+		want         []int64
+	}{
+		{
+			name:         "recent comment keeps the assignment",
+			uids:         []int64{1},
+			lastComments: map[int64]time.Time{1: now.Add(-time.Hour)},
+			want:         []int64{},
+		},
+		{
+			name:         "old comment unassigns",
+			uids:         []int64{1},
+			lastComments: map[int64]time.Time{1: threshold.Add(-time.Hour)},
+			want:         []int64{1},
+		},
+		{
+			name:         "comment exactly at the threshold unassigns",
+			uids:         []int64{1},
+			lastComments: map[int64]time.Time{1: threshold},
+			want:         []int64{1},
+		},
+		{
+			name:         "no comment at all unassigns",
+			uids:         []int64{1},
+			lastComments: nil,
+			want:         []int64{1},
+		},
+		{
+			name:         "comments of other users don't count",
+			uids:         []int64{1, 2},
+			lastComments: map[int64]time.Time{2: now},
+			want:         []int64{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inactiveAssignees(tt.uids, tt.lastComments, threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("inactiveAssignees() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_nextAbandonedSubmissionStep(t *testing.T) {
+	day := 24 * time.Hour
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	rules := SweeperRules{RemindAfter: 30 * day, RejectGracePeriod: 14 * day}
+	var never time.Time
+
+	type args struct {
+		rules     SweeperRules
+		requested time.Time
+		uploaded  time.Time
+		reminded  time.Time
+	}
+
+	tests := []struct {
+		name string
+		args args
+		want abandonedSubmissionStep
+	}{
+		{
+			name: "changes requested recently",
+			args: args{rules: rules, requested: now.Add(-10 * day), uploaded: now.Add(-20 * day), reminded: never},
+			want: abandonedSubmissionWait,
+		},
+		{
+			name: "changes requested long ago are reminded",
+			args: args{rules: rules, requested: now.Add(-31 * day), uploaded: now.Add(-40 * day), reminded: never},
+			want: abandonedSubmissionRemind,
+		},
+		{
+			name: "new version after the request waits for the reviewers",
+			args: args{rules: rules, requested: now.Add(-60 * day), uploaded: now.Add(-50 * day), reminded: never},
+			want: abandonedSubmissionWait,
+		},
+		{
+			name: "comments after the request don't count as a new version",
+			args: args{rules: rules, requested: now.Add(-31 * day), uploaded: now.Add(-32 * day), reminded: never},
+			want: abandonedSubmissionRemind,
+		},
+		{
+			name: "reminder about an earlier request is sent again",
+			args: args{rules: rules, requested: now.Add(-31 * day), uploaded: now.Add(-32 * day), reminded: now.Add(-90 * day)},
+			want: abandonedSubmissionRemind,
+		},
+		{
+			name: "grace period after the reminder is not over",
+			args: args{rules: rules, requested: now.Add(-40 * day), uploaded: never, reminded: now.Add(-10 * day)},
+			want: abandonedSubmissionWait,
+		},
+		{
+			name: "grace period after the reminder is over",
+			args: args{rules: rules, requested: now.Add(-50 * day), uploaded: never, reminded: now.Add(-15 * day)},
+			want: abandonedSubmissionReject,
+		},
+		{
+			name: "no grace period never rejects",
+			args: args{rules: SweeperRules{RemindAfter: 30 * day}, requested: now.Add(-300 * day), uploaded: never, reminded: now.Add(-200 * day)},
+			want: abandonedSubmissionWait,
+		},
+		{
+			name: "new version after the reminder stops the rejection",
+			args: args{rules: rules, requested: now.Add(-50 * day), uploaded: now.Add(-day), reminded: now.Add(-15 * day)},
+			want: abandonedSubmissionWait,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextAbandonedSubmissionStep(tt.args.rules, now, tt.args.requested, tt.args.uploaded, tt.args.reminded); got != tt.want {
+				t.Errorf("nextAbandonedSubmissionStep() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,9 +11,12 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/kofalt/go-memoize"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var cache = memoize.NewMemoizer(10*time.Minute, 60*time.Minute)
@@ -28,13 +31,19 @@ func NewValidator(validatorServerURL string) *curationValidator {
 	}
 }
 
-func (c *curationValidator) ProvideArchiveForRepacking(filePath string) (*types.ValidatorRepackResponse, error) {
+func (c *curationValidator) ProvideArchiveForRepacking(ctx context.Context, filePath string) (*types.ValidatorRepackResponse, error) {
 	filePath, err := filepath.Abs(filePath)
 	if err != nil {
 		return nil, err
 	}
-	client := http.Client{Timeout: 86400 * time.Second}
-	resp, err := client.Post(fmt.Sprintf("%s/pack-path?path=%s", c.validatorServerURL, url.QueryEscape(filePath)), "application/json;charset=utf-8", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/pack-path?path=%s", c.validatorServerURL, url.QueryEscape(filePath)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	client := http.Client{Timeout: 86400 * time.Second, Transport: tracing.NewTransport(nil)}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -59,13 +68,19 @@ func (c *curationValidator) ProvideArchiveForRepacking(filePath string) (*types.
 	return &vr, nil
 }
 
-func (c *curationValidator) ProvideArchiveForValidation(filePath string) (*types.ValidatorResponse, error) {
+func (c *curationValidator) ProvideArchiveForValidation(ctx context.Context, filePath string) (*types.ValidatorResponse, error) {
 	filePath, err := filepath.Abs(filePath)
 	if err != nil {
 		return nil, err
 	}
-	client := http.Client{Timeout: 86400 * time.Second}
-	resp, err := client.Post(fmt.Sprintf("%s/provide-path?path=%s", c.validatorServerURL, url.QueryEscape(filePath)), "application/json;charset=utf-8", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/provide-path?path=%s", c.validatorServerURL, url.QueryEscape(filePath)), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json;charset=utf-8")
+
+	client := http.Client{Timeout: 86400 * time.Second, Transport: tracing.NewTransport(nil)}
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -123,16 +138,80 @@ func (c *curationValidator) GetTags(ctx context.Context) ([]types.Tag, error) {
 }
 
 func (c *curationValidator) getTags(ctx context.Context) ([]types.Tag, error) {
-	resp, err := utils.GetURL(c.validatorServerURL + "/tags")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.validatorServerURL+"/tags", nil)
 	if err != nil {
 		return nil, err
 	}
 
+	client := http.Client{Timeout: 600 * time.Second, Transport: tracing.NewTransport(nil)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response not OK: %d", resp.StatusCode)
+	}
+
 	var tr types.ValidatorTagResponse
-	err = json.Unmarshal(resp, &tr)
+	err = json.NewDecoder(resp.Body).Decode(&tr)
 	if err != nil {
 		return nil, err
 	}
 
 	return tr.Tags, nil
 }
+
+// Ping checks that the validator responds
+func (c *curationValidator) Ping(ctx context.Context) error {
+	return pingURL(ctx, c.validatorServerURL)
+}
+
+// instrumentedValidator records the latency and errors of every call to the wrapped validator, and traces it
+type instrumentedValidator struct {
+	validator Validator
+}
+
+func (v *instrumentedValidator) Validate(ctx context.Context, file io.Reader, filename string) (*types.ValidatorResponse, error) {
+	ctx, span := tracing.Start(ctx, "Validator.Validate", attribute.String("file.name", filename))
+	start := time.Now()
+	vr, err := v.validator.Validate(ctx, file, filename)
+	metrics.ObserveValidatorCall("validate", start, err)
+	tracing.End(span, err)
+	return vr, err
+}
+
+func (v *instrumentedValidator) Ping(ctx context.Context) error {
+	if p, ok := v.validator.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (v *instrumentedValidator) GetTags(ctx context.Context) ([]types.Tag, error) {
+	ctx, span := tracing.Start(ctx, "Validator.GetTags")
+	start := time.Now()
+	tags, err := v.validator.GetTags(ctx)
+	metrics.ObserveValidatorCall("get_tags", start, err)
+	tracing.End(span, err)
+	return tags, err
+}
+
+func (v *instrumentedValidator) ProvideArchiveForValidation(ctx context.Context, filePath string) (*types.ValidatorResponse, error) {
+	ctx, span := tracing.Start(ctx, "Validator.ProvideArchiveForValidation", attribute.String("file.path", filePath))
+	start := time.Now()
+	vr, err := v.validator.ProvideArchiveForValidation(ctx, filePath)
+	metrics.ObserveValidatorCall("provide_path", start, err)
+	tracing.End(span, err)
+	return vr, err
+}
+
+func (v *instrumentedValidator) ProvideArchiveForRepacking(ctx context.Context, filePath string) (*types.ValidatorRepackResponse, error) {
+	ctx, span := tracing.Start(ctx, "Validator.ProvideArchiveForRepacking", attribute.String("file.path", filePath))
+	start := time.Now()
+	vr, err := v.validator.ProvideArchiveForRepacking(ctx, filePath)
+	metrics.ObserveValidatorCall("pack_path", start, err)
+	tracing.End(span, err)
+	return vr, err
+}
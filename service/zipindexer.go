@@ -6,36 +6,40 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
-	"os"
-	"path"
+	"io/fs"
 	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/FlashpointProject/flashpoint-submission-system/database"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ZipIndexer struct {
-	stopSignal   chan bool
-	status       string
-	error        error
-	statusMutex  *sync.Mutex
-	stopped      bool
-	dataPacksDir string
-	pool         *pgxpool.Pool
-	wg           *sync.WaitGroup
-	ctx          context.Context
+	stopSignal  chan bool
+	status      string
+	error       error
+	statusMutex *sync.Mutex
+	stopped     bool
+	dataPacks   storage.Storage
+	pool        *pgxpool.Pool
+	wg          *sync.WaitGroup
+	ctx         context.Context
 }
 
-func NewZipIndexer(pool *pgxpool.Pool, dataPacksDir string, l *logrus.Entry) ZipIndexer {
+func NewZipIndexer(pool *pgxpool.Pool, dataPacks storage.Storage, l *logrus.Entry) ZipIndexer {
 	var syncMutex sync.Mutex
 	var wg sync.WaitGroup
 	ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, l)
@@ -45,7 +49,7 @@ func NewZipIndexer(pool *pgxpool.Pool, dataPacksDir string, l *logrus.Entry) Zip
 		nil,
 		&syncMutex,
 		true,
-		dataPacksDir,
+		dataPacks,
 		pool,
 		&wg,
 		ctx,
@@ -54,22 +58,30 @@ func NewZipIndexer(pool *pgxpool.Pool, dataPacksDir string, l *logrus.Entry) Zip
 
 func (z *ZipIndexer) run() {
 	defer z.wg.Done()
+	metrics.ZipIndexerRunning.Set(1)
+	defer metrics.ZipIndexerRunning.Set(0)
+	stopSignal := z.stopSignal
 	// Create DAL
-	pgdal := database.NewPostgresDAL(z.pool)
+	pgdal := database.NewTracedPGDAL(database.NewPostgresDAL(z.pool))
 
 	for {
 		select {
-		case <-z.stopSignal:
+		case <-stopSignal:
 			// Got stop signal, exit this loop
+			z.statusMutex.Lock()
 			z.stopped = true
+			z.statusMutex.Unlock()
 			return
 		default:
 			// Fetch next data zip
 			data, err := pgdal.IndexerGetNext(z.ctx)
 			if err != nil {
 				if err == pgx.ErrNoRows {
-					// Wait 10 seconds and check again for a fresh data pack
-					time.Sleep(10 * time.Second)
+					// Wait 10 seconds and check again for a fresh data pack, unless stopped in the meantime
+					select {
+					case <-stopSignal:
+					case <-time.After(10 * time.Second):
+					}
 					continue
 				} else {
 					z.statusMutex.Lock()
@@ -88,22 +100,22 @@ func (z *ZipIndexer) run() {
 					Debug(z.status)
 				z.statusMutex.Unlock()
 			}
-			err = func() error {
-				// Find data path
-				newBase := fmt.Sprintf("%s-%d%s", data.GameID, data.DateAdded.UnixMilli(), ".zip")
-				filePath := path.Join(z.dataPacksDir, newBase)
-				// If zip doesn't exist locally, let the error return handle marking it as failure
-				_, err = os.Stat(filePath)
+			err = func() (err error) {
+				ctx, span := tracing.Start(z.ctx, "ZipIndexer.indexDataPack", attribute.String("game.id", data.GameID))
+				defer func() { tracing.End(span, err) }()
+
+				// Find data pack, if it doesn't exist let the error return handle marking it as failure
+				obj, info, err := z.dataPacks.Open(ctx, DataPackKey(data.GameID, data.DateAdded))
 				if err != nil {
 					return err
 				}
+				defer obj.Close()
 				// Hash the file
 				err = func() error {
-					zipReader, err := zip.OpenReader(filePath)
+					zipReader, err := zip.NewReader(obj, info.Size)
 					if err != nil {
 						return err
 					}
-					defer zipReader.Close()
 
 					for _, file := range zipReader.File {
 						if strings.HasSuffix(file.Name, "/") || file.Name == "content.json" {
@@ -134,17 +146,18 @@ func (z *ZipIndexer) run() {
 
 						cleanName := forceUTF8Compliant(file.Name)
 
-						err = pgdal.IndexerInsert(z.ctx, crc32hasher.Sum(nil), md5hasher.Sum(nil), sha256hasher.Sum(nil),
+						err = pgdal.IndexerInsert(ctx, crc32hasher.Sum(nil), md5hasher.Sum(nil), sha256hasher.Sum(nil),
 							sha1hasher.Sum(nil), size, cleanName, data.GameID, data.DateAdded)
 						if err != nil {
 							return err
 						}
-
+						metrics.ZipIndexerFiles.Inc()
 					}
 
 					// Print the game just indexed
 					utils.LogCtx(z.ctx).
 						Debug(fmt.Sprintf("Finished Indexing %s", data.GameID))
+					metrics.ZipIndexerIndexed.WithLabelValues("success").Inc()
 
 					return nil
 				}()
@@ -154,7 +167,8 @@ func (z *ZipIndexer) run() {
 				return nil
 			}()
 			if err != nil {
-				if os.IsNotExist(err) {
+				metrics.ZipIndexerIndexed.WithLabelValues("error").Inc()
+				if errors.Is(err, fs.ErrNotExist) {
 					// Mark as failure
 					utils.LogCtx(z.ctx).
 						Error(fmt.Sprintf("Index failure due to missing file %s", data.GameID))
@@ -197,12 +211,14 @@ func (z *ZipIndexer) Start() {
 	go z.run()
 }
 
+// Stop waits for the data pack being indexed, a partially indexed one would be marked as indexed
 func (z *ZipIndexer) Stop() {
-	if z.stopped {
-		return
+	z.statusMutex.Lock()
+	if !z.stopped && z.stopSignal != nil {
+		close(z.stopSignal)
+		z.stopSignal = nil
 	}
-
-	z.stopSignal <- true
+	z.statusMutex.Unlock()
 
 	z.wg.Wait()
 }
@@ -0,0 +1,212 @@
+package sqlitefile
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// btree bulk loads a table or index b-tree. Full leaves are written out as they fill up,
+// the interior pages are built from the remembered children once the tree is finished.
+type btree struct {
+	w     *Writer
+	root  uint32
+	index bool
+	// capacity is the space for cells and their pointers on a page
+	leafCapacity     int
+	interiorCapacity int
+
+	leaf     [][]byte
+	leafUsed int
+	// leafRowid is the largest rowid on the leaf, it separates table leaves
+	leafRowid int64
+
+	// children are the written leaves, separators[i] is the cell content between children i and i+1
+	children   []uint32
+	separators [][]byte
+
+	// an index keeps the last full leaf back until the next entry arrives, with the entry which
+	// didn't fit as the separator, so that the last leaf is never empty
+	heldLeaf  [][]byte
+	separator []byte
+}
+
+func (w *Writer) newBtree(index bool) *btree {
+	t := &btree{
+		w:                w,
+		root:             w.allocPage(),
+		index:            index,
+		leafCapacity:     pageSize - 8,
+		interiorCapacity: pageSize - 12,
+	}
+	if t.root == 1 {
+		// the pages of the schema table could end up as the first page, after the database header
+		t.leafCapacity -= headerSize
+		t.interiorCapacity -= headerSize
+	}
+	return t
+}
+
+func (t *btree) leafFlag() byte {
+	if t.index {
+		return pageIndexLeaf
+	}
+	return pageTableLeaf
+}
+
+func (t *btree) interiorFlag() byte {
+	if t.index {
+		return pageIndexInterior
+	}
+	return pageTableInterior
+}
+
+// insert adds the record, rowid is only used by tables
+func (t *btree) insert(record []byte, rowid int64) error {
+	var cell []byte
+	if t.index {
+		local, err := t.w.spill(record, maxLocalIndex)
+		if err != nil {
+			return err
+		}
+		cell = putVarint(nil, uint64(len(record)))
+		cell = append(cell, local...)
+	} else {
+		local, err := t.w.spill(record, maxLocalTable)
+		if err != nil {
+			return err
+		}
+		cell = putVarint(nil, uint64(len(record)))
+		cell = putVarint(cell, uint64(rowid))
+		cell = append(cell, local...)
+	}
+	if len(cell)+2 > t.leafCapacity || (t.index && len(cell)+6 > t.interiorCapacity) {
+		return errors.New("record does not fit on a page")
+	}
+
+	if t.index && t.separator != nil {
+		if err := t.flush(t.heldLeaf, t.separator); err != nil {
+			return err
+		}
+		t.heldLeaf = nil
+		t.separator = nil
+	}
+
+	if t.leafUsed+len(cell)+2 > t.leafCapacity {
+		if t.index {
+			t.heldLeaf = t.leaf
+			t.separator = cell
+			t.leaf = nil
+			t.leafUsed = 0
+			return nil
+		}
+		if err := t.flush(t.leaf, putVarint(nil, uint64(t.leafRowid))); err != nil {
+			return err
+		}
+		t.leaf = nil
+		t.leafUsed = 0
+	}
+
+	t.leaf = append(t.leaf, cell)
+	t.leafUsed += len(cell) + 2
+	t.leafRowid = rowid
+	return nil
+}
+
+// flush writes a full leaf, separator is what follows it in the parent
+func (t *btree) flush(cells [][]byte, separator []byte) error {
+	pgno := t.w.allocPage()
+	if err := t.w.writeBtreePage(pgno, t.leafFlag(), cells, 0); err != nil {
+		return err
+	}
+	t.children = append(t.children, pgno)
+	t.separators = append(t.separators, separator)
+	return nil
+}
+
+func (t *btree) finish() error {
+	if t.separator != nil {
+		// the entry which didn't fit is the whole last leaf, the held leaf gives up its last entry as the separator
+		last := len(t.heldLeaf) - 1
+		if err := t.flush(t.heldLeaf[:last], t.heldLeaf[last]); err != nil {
+			return err
+		}
+		t.leaf = [][]byte{t.separator}
+		t.heldLeaf = nil
+		t.separator = nil
+	}
+
+	if len(t.children) == 0 {
+		return t.w.writeBtreePage(t.root, t.leafFlag(), t.leaf, 0)
+	}
+
+	pgno := t.w.allocPage()
+	if err := t.w.writeBtreePage(pgno, t.leafFlag(), t.leaf, 0); err != nil {
+		return err
+	}
+	children := append(t.children, pgno)
+	separators := t.separators
+	t.children = nil
+	t.separators = nil
+	t.leaf = nil
+
+	for {
+		pages := t.layoutInterior(separators)
+		if len(pages) == 1 {
+			return t.writeInterior(t.root, children, separators, pages[0])
+		}
+
+		parentChildren := make([]uint32, len(pages))
+		parentSeparators := make([][]byte, 0, len(pages)-1)
+		for i, p := range pages {
+			parentChildren[i] = t.w.allocPage()
+			if err := t.writeInterior(parentChildren[i], children, separators, p); err != nil {
+				return err
+			}
+			if i < len(pages)-1 {
+				parentSeparators = append(parentSeparators, separators[p.right])
+			}
+		}
+		children = parentChildren
+		separators = parentSeparators
+	}
+}
+
+// interiorPage holds the cells for children first to right-1, and children[right] as the right-most child
+type interiorPage struct {
+	first int
+	right int
+}
+
+// layoutInterior splits the children of a level over interior pages, the separators after
+// each page except the last one move up to the parent level
+func (t *btree) layoutInterior(separators [][]byte) []interiorPage {
+	last := len(separators)
+	var pages []interiorPage
+	first := 0
+	for {
+		right := first
+		used := 0
+		for right < last && used+len(separators[right])+6 <= t.interiorCapacity {
+			used += len(separators[right]) + 6
+			right++
+		}
+		if right == last {
+			return append(pages, interiorPage{first: first, right: right})
+		}
+		if right+1 == last {
+			// leave a cell for the last page
+			right--
+		}
+		pages = append(pages, interiorPage{first: first, right: right})
+		first = right + 1
+	}
+}
+
+func (t *btree) writeInterior(pgno uint32, children []uint32, separators [][]byte, p interiorPage) error {
+	cells := make([][]byte, 0, p.right-p.first)
+	for i := p.first; i < p.right; i++ {
+		cell := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(separators[i])), children[i])
+		cells = append(cells, append(cell, separators[i]...))
+	}
+	return t.w.writeBtreePage(pgno, t.interiorFlag(), cells, children[p.right])
+}
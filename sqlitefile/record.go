@@ -0,0 +1,233 @@
+package sqlitefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// putVarint appends v in the SQLite variable length integer encoding, which is big endian
+// with seven bits per byte, except for the ninth byte which holds eight
+func putVarint(b []byte, v uint64) []byte {
+	if v > 0x00ffffffffffffff {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf [8]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	v >>= 7
+	for v > 0 {
+		i--
+		buf[i] = byte(v&0x7f) | 0x80
+		v >>= 7
+	}
+	return append(b, buf[i:]...)
+}
+
+func varintLen(v uint64) int {
+	return len(putVarint(nil, v))
+}
+
+// normalize converts the supported Go values into nil, int64, float64, string or []byte
+func normalize(v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return x, nil
+	case int:
+		return int64(x), nil
+	case int32:
+		return int64(x), nil
+	case bool:
+		if x {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case float64:
+		return x, nil
+	case string:
+		return x, nil
+	case []byte:
+		if x == nil {
+			return nil, nil
+		}
+		return x, nil
+	case *string:
+		if x == nil {
+			return nil, nil
+		}
+		return *x, nil
+	case *int64:
+		if x == nil {
+			return nil, nil
+		}
+		return *x, nil
+	case *int:
+		if x == nil {
+			return nil, nil
+		}
+		return int64(*x), nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", v)
+}
+
+func normalizeAll(values []interface{}) ([]interface{}, error) {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		n, err := normalize(v)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+// serialType returns the serial type of a normalized value and the size of its content
+func serialType(v interface{}) (uint64, int) {
+	switch x := v.(type) {
+	case int64:
+		switch {
+		case x == 0:
+			return 8, 0
+		case x == 1:
+			return 9, 0
+		case x >= math.MinInt8 && x <= math.MaxInt8:
+			return 1, 1
+		case x >= math.MinInt16 && x <= math.MaxInt16:
+			return 2, 2
+		case x >= -1<<23 && x < 1<<23:
+			return 3, 3
+		case x >= math.MinInt32 && x <= math.MaxInt32:
+			return 4, 4
+		case x >= -1<<47 && x < 1<<47:
+			return 5, 6
+		}
+		return 6, 8
+	case float64:
+		return 7, 8
+	case string:
+		return uint64(len(x))*2 + 13, len(x)
+	case []byte:
+		return uint64(len(x))*2 + 12, len(x)
+	}
+	return 0, 0
+}
+
+// encodeRecord builds a record in the SQLite record format from normalized values
+func encodeRecord(values []interface{}) []byte {
+	types := make([]uint64, len(values))
+	typesLen := 0
+	bodyLen := 0
+	for i, v := range values {
+		t, n := serialType(v)
+		types[i] = t
+		typesLen += varintLen(t)
+		bodyLen += n
+	}
+
+	// the header size includes its own varint
+	headerLen := typesLen + 1
+	for headerLen != typesLen+varintLen(uint64(headerLen)) {
+		headerLen = typesLen + varintLen(uint64(headerLen))
+	}
+
+	b := make([]byte, 0, headerLen+bodyLen)
+	b = putVarint(b, uint64(headerLen))
+	for _, t := range types {
+		b = putVarint(b, t)
+	}
+	for _, v := range values {
+		switch x := v.(type) {
+		case int64:
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], uint64(x))
+			_, n := serialType(x)
+			b = append(b, buf[8-n:]...)
+		case float64:
+			b = binary.BigEndian.AppendUint64(b, math.Float64bits(x))
+		case string:
+			b = append(b, x...)
+		case []byte:
+			b = append(b, x...)
+		}
+	}
+	return b
+}
+
+// valueClass orders the storage classes the way SQLite does: NULL, numbers, text, blobs
+func valueClass(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case int64, float64:
+		return 1
+	case string:
+		return 2
+	}
+	return 3
+}
+
+// compareValues compares normalized values with the BINARY collation
+func compareValues(a, b interface{}) int {
+	ca, cb := valueClass(a), valueClass(b)
+	if ca != cb {
+		if ca < cb {
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case nil:
+		return 0
+	case int64:
+		if y, ok := b.(int64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+		return compareFloats(float64(x), b.(float64))
+	case float64:
+		if y, ok := b.(int64); ok {
+			return compareFloats(x, float64(y))
+		}
+		return compareFloats(x, b.(float64))
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	return bytes.Compare(a.([]byte), b.([]byte))
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareKeys compares index keys column by column
+func compareKeys(a, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,279 @@
+// Package sqlitefile writes SQLite database files without SQLite itself.
+//
+// The file is built bottom-up in a single pass, so rows have to be inserted in rowid order
+// and index entries in index order. Nothing can be read back or changed once inserted.
+package sqlitefile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	pageSize = 4096
+	// headerSize is the size of the database header at the start of page 1
+	headerSize = 100
+
+	pageIndexInterior = 0x02
+	pageTableInterior = 0x05
+	pageIndexLeaf     = 0x0a
+	pageTableLeaf     = 0x0d
+
+	// lockBytePage holds the bytes SQLite locks at offset 1GB, it is never used for data
+	lockBytePage = 0x40000000/pageSize + 1
+
+	// sqliteVersion is the SQLite version number recorded in the header
+	sqliteVersion = 3045000
+)
+
+// maximum and minimum payload kept on a b-tree page, the rest goes to overflow pages
+const (
+	maxLocalTable = pageSize - 35
+	maxLocalIndex = (pageSize-12)*64/255 - 23
+	minLocal      = (pageSize-12)*32/255 - 23
+)
+
+// Writer creates a new database file
+type Writer struct {
+	f           *os.File
+	pages       uint32
+	schema      *btree
+	objects     []*schemaObject
+	userVersion int32
+	closed      bool
+}
+
+type schemaObject struct {
+	kind    string
+	name    string
+	tblName string
+	sql     *string
+	tree    *btree
+}
+
+// Table is a rowid table being filled
+type Table struct {
+	tree      *btree
+	lastRowid int64
+	hasRows   bool
+}
+
+// Index is an index being filled
+type Index struct {
+	tree    *btree
+	lastKey []interface{}
+}
+
+// Create creates the database file, an existing file is replaced
+func Create(path string) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{f: f}
+	w.schema = w.newBtree(false)
+	if w.schema.root != 1 {
+		panic("schema table is not on the first page")
+	}
+	return w, nil
+}
+
+// SetUserVersion sets the user_version pragma of the database
+func (w *Writer) SetUserVersion(v int32) {
+	w.userVersion = v
+}
+
+// CreateTable adds a rowid table, sql is the CREATE TABLE statement as SQLite would store it
+func (w *Writer) CreateTable(name, sql string) *Table {
+	t := &Table{tree: w.newBtree(false)}
+	w.objects = append(w.objects, &schemaObject{kind: "table", name: name, tblName: name, sql: &sql, tree: t.tree})
+	return t
+}
+
+// CreateIndex adds an index on the table. An empty sql adds the automatic index SQLite creates for
+// PRIMARY KEY and UNIQUE constraints, these have to be named sqlite_autoindex_<table>_<n>.
+func (w *Writer) CreateIndex(name, table, sql string) *Index {
+	x := &Index{tree: w.newBtree(true)}
+	o := &schemaObject{kind: "index", name: name, tblName: table, tree: x.tree}
+	if sql != "" {
+		o.sql = &sql
+	}
+	w.objects = append(w.objects, o)
+	return x
+}
+
+// Insert adds a row, rowids have to be increasing. The column of an INTEGER PRIMARY KEY
+// aliases the rowid and has to be nil.
+func (t *Table) Insert(rowid int64, values ...interface{}) error {
+	if t.hasRows && rowid <= t.lastRowid {
+		return fmt.Errorf("rowid %d inserted after rowid %d", rowid, t.lastRowid)
+	}
+	values, err := normalizeAll(values)
+	if err != nil {
+		return err
+	}
+	if err := t.tree.insert(encodeRecord(values), rowid); err != nil {
+		return err
+	}
+	t.lastRowid = rowid
+	t.hasRows = true
+	return nil
+}
+
+// Insert adds an index entry, the values are the indexed columns followed by the rowid of the row.
+// Entries have to be inserted in ascending order, comparing text by its bytes.
+func (x *Index) Insert(values ...interface{}) error {
+	key, err := normalizeAll(values)
+	if err != nil {
+		return err
+	}
+	if x.lastKey != nil && compareKeys(x.lastKey, key) >= 0 {
+		return fmt.Errorf("index entry %v inserted after %v", key, x.lastKey)
+	}
+	if err := x.tree.insert(encodeRecord(key), 0); err != nil {
+		return err
+	}
+	x.lastKey = key
+	return nil
+}
+
+// Close finishes the tables and indexes and writes the schema, the file is not a valid database before
+func (w *Writer) Close() error {
+	if w.closed {
+		return errors.New("writer is already closed")
+	}
+	w.closed = true
+
+	err := w.finish()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Abort closes the file without finishing it, the caller should remove it
+func (w *Writer) Abort() {
+	if !w.closed {
+		w.closed = true
+		w.f.Close()
+	}
+}
+
+func (w *Writer) finish() error {
+	for i, o := range w.objects {
+		if err := o.tree.finish(); err != nil {
+			return fmt.Errorf("%s %s: %w", o.kind, o.name, err)
+		}
+		var sql interface{}
+		if o.sql != nil {
+			sql = *o.sql
+		}
+		record := encodeRecord([]interface{}{o.kind, o.name, o.tblName, int64(o.tree.root), sql})
+		if err := w.schema.insert(record, int64(i+1)); err != nil {
+			return fmt.Errorf("schema: %w", err)
+		}
+	}
+	if err := w.schema.finish(); err != nil {
+		return fmt.Errorf("schema: %w", err)
+	}
+
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.f.Sync()
+}
+
+func (w *Writer) writeHeader() error {
+	h := make([]byte, headerSize)
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], pageSize)
+	h[18] = 1 // legacy journal mode
+	h[19] = 1
+	h[21] = 64 // payload fractions, fixed by the file format
+	h[22] = 32
+	h[23] = 32
+	binary.BigEndian.PutUint32(h[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(h[28:], w.pages)
+	binary.BigEndian.PutUint32(h[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4) // schema format
+	binary.BigEndian.PutUint32(h[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(h[60:], uint32(w.userVersion))
+	binary.BigEndian.PutUint32(h[92:], 1) // version valid for the change counter above
+	binary.BigEndian.PutUint32(h[96:], sqliteVersion)
+	_, err := w.f.WriteAt(h, 0)
+	return err
+}
+
+func (w *Writer) allocPage() uint32 {
+	w.pages++
+	if w.pages == lockBytePage {
+		w.pages++
+	}
+	return w.pages
+}
+
+func (w *Writer) writePage(pgno uint32, buf []byte) error {
+	_, err := w.f.WriteAt(buf, int64(pgno-1)*pageSize)
+	return err
+}
+
+// writeBtreePage writes a b-tree page with the cells in order, right is the right-most child of interior pages
+func (w *Writer) writeBtreePage(pgno uint32, flag byte, cells [][]byte, right uint32) error {
+	buf := make([]byte, pageSize)
+	hdr := 0
+	if pgno == 1 {
+		hdr = headerSize
+	}
+	ptr := hdr + 8
+	buf[hdr] = flag
+	binary.BigEndian.PutUint16(buf[hdr+3:], uint16(len(cells)))
+	if flag == pageIndexInterior || flag == pageTableInterior {
+		binary.BigEndian.PutUint32(buf[hdr+8:], right)
+		ptr += 4
+	}
+	end := pageSize
+	for _, c := range cells {
+		end -= len(c)
+		copy(buf[end:], c)
+		binary.BigEndian.PutUint16(buf[ptr:], uint16(end))
+		ptr += 2
+	}
+	if ptr > end {
+		return fmt.Errorf("page %d overflows", pgno)
+	}
+	binary.BigEndian.PutUint16(buf[hdr+5:], uint16(end))
+	return w.writePage(pgno, buf)
+}
+
+// spill returns the part of the payload stored in the cell, followed by the first overflow page if it doesn't fit
+func (w *Writer) spill(payload []byte, maxLocal int) ([]byte, error) {
+	if len(payload) <= maxLocal {
+		return payload, nil
+	}
+	local := minLocal + (len(payload)-minLocal)%(pageSize-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+
+	first := w.allocPage()
+	pgno := first
+	for rest := payload[local:]; len(rest) > 0; {
+		n := min(len(rest), pageSize-4)
+		var next uint32
+		if len(rest) > n {
+			next = w.allocPage()
+		}
+		buf := make([]byte, pageSize)
+		binary.BigEndian.PutUint32(buf, next)
+		copy(buf[4:], rest[:n])
+		if err := w.writePage(pgno, buf); err != nil {
+			return nil, err
+		}
+		rest = rest[n:]
+		pgno = next
+	}
+
+	return binary.BigEndian.AppendUint32(payload[:local:local], first), nil
+}
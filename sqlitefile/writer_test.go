@@ -0,0 +1,474 @@
+package sqlitefile
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// testReader reads back the files written by the Writer, following the SQLite file format
+type testReader struct {
+	t    *testing.T
+	data []byte
+}
+
+type testRow struct {
+	rowid  int64
+	values []interface{}
+}
+
+func openTestFile(t *testing.T, path string) *testReader {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < pageSize || len(data)%pageSize != 0 {
+		t.Fatalf("file size %d is not a multiple of the page size", len(data))
+	}
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		t.Fatal("file has no SQLite header")
+	}
+	if pages := binary.BigEndian.Uint32(data[28:]); int(pages)*pageSize != len(data) {
+		t.Fatalf("header says %d pages, the file has %d", pages, len(data)/pageSize)
+	}
+	return &testReader{t: t, data: data}
+}
+
+func (r *testReader) page(pgno uint32) []byte {
+	if pgno == 0 || int(pgno)*pageSize > len(r.data) {
+		r.t.Fatalf("page %d is out of the file", pgno)
+	}
+	return r.data[int(pgno-1)*pageSize : int(pgno)*pageSize]
+}
+
+func readTestVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+// payload reads the payload of the cell at b, following the overflow pages
+func (r *testReader) payload(b []byte, maxLocal int) []byte {
+	size, n := readTestVarint(b)
+	b = b[n:]
+	if int(size) <= maxLocal {
+		return b[:size]
+	}
+	local := minLocal + (int(size)-minLocal)%(pageSize-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	result := append([]byte{}, b[:local]...)
+	next := binary.BigEndian.Uint32(b[local:])
+	for next != 0 {
+		p := r.page(next)
+		next = binary.BigEndian.Uint32(p)
+		result = append(result, p[4:4+min(pageSize-4, int(size)-len(result))]...)
+	}
+	return result
+}
+
+// cells returns the flag, the cells and the right-most child of a b-tree page
+func (r *testReader) cells(pgno uint32) (byte, [][]byte, uint32) {
+	p := r.page(pgno)
+	hdr := 0
+	if pgno == 1 {
+		hdr = headerSize
+	}
+	flag := p[hdr]
+	count := int(binary.BigEndian.Uint16(p[hdr+3:]))
+	ptr := hdr + 8
+	var right uint32
+	if flag == pageIndexInterior || flag == pageTableInterior {
+		right = binary.BigEndian.Uint32(p[hdr+8:])
+		ptr += 4
+	}
+	cells := make([][]byte, count)
+	for i := range cells {
+		cells[i] = p[binary.BigEndian.Uint16(p[ptr+2*i:]):]
+	}
+	return flag, cells, right
+}
+
+func (r *testReader) table(root uint32) []testRow {
+	flag, cells, right := r.cells(root)
+	var rows []testRow
+	switch flag {
+	case pageTableLeaf:
+		for _, c := range cells {
+			_, n := readTestVarint(c)
+			rowid, m := readTestVarint(c[n:])
+			payload := r.payload(append(c[:n:n], c[n+m:]...), maxLocalTable)
+			rows = append(rows, testRow{rowid: int64(rowid), values: decodeTestRecord(r.t, payload)})
+		}
+	case pageTableInterior:
+		for _, c := range cells {
+			key, _ := readTestVarint(c[4:])
+			children := r.table(binary.BigEndian.Uint32(c))
+			if len(children) == 0 || children[len(children)-1].rowid > int64(key) {
+				r.t.Fatalf("page %d: child ends after its separator %d", root, key)
+			}
+			rows = append(rows, children...)
+		}
+		rows = append(rows, r.table(right)...)
+	default:
+		r.t.Fatalf("page %d is not a table page: %#x", root, flag)
+	}
+	return rows
+}
+
+func (r *testReader) index(root uint32) [][]interface{} {
+	flag, cells, right := r.cells(root)
+	var keys [][]interface{}
+	switch flag {
+	case pageIndexLeaf:
+		for _, c := range cells {
+			keys = append(keys, decodeTestRecord(r.t, r.payload(c, maxLocalIndex)))
+		}
+	case pageIndexInterior:
+		for _, c := range cells {
+			keys = append(keys, r.index(binary.BigEndian.Uint32(c))...)
+			keys = append(keys, decodeTestRecord(r.t, r.payload(c[4:], maxLocalIndex)))
+		}
+		keys = append(keys, r.index(right)...)
+	default:
+		r.t.Fatalf("page %d is not an index page: %#x", root, flag)
+	}
+	return keys
+}
+
+func decodeTestRecord(t *testing.T, b []byte) []interface{} {
+	headerLen, n := readTestVarint(b)
+	header := b[n:headerLen]
+	body := b[headerLen:]
+	var values []interface{}
+	for len(header) > 0 {
+		st, n := readTestVarint(header)
+		header = header[n:]
+		switch {
+		case st == 0:
+			values = append(values, nil)
+		case st >= 1 && st <= 6:
+			size := []int{0, 1, 2, 3, 4, 6, 8}[st]
+			var v int64
+			for _, c := range body[:size] {
+				v = v<<8 | int64(c)
+			}
+			// sign extend
+			shift := uint(64 - 8*size)
+			values = append(values, v<<shift>>shift)
+			body = body[size:]
+		case st == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(body)))
+			body = body[8:]
+		case st == 8:
+			values = append(values, int64(0))
+		case st == 9:
+			values = append(values, int64(1))
+		case st >= 12 && st%2 == 0:
+			size := int(st-12) / 2
+			values = append(values, append([]byte{}, body[:size]...))
+			body = body[size:]
+		case st >= 13:
+			size := int(st-13) / 2
+			values = append(values, string(body[:size]))
+			body = body[size:]
+		default:
+			t.Fatalf("invalid serial type %d", st)
+		}
+	}
+	if len(body) != 0 {
+		t.Fatalf("%d bytes left after the record", len(body))
+	}
+	return values
+}
+
+// objects returns the rows of the schema table by name
+func (r *testReader) objects() map[string][]interface{} {
+	result := make(map[string][]interface{})
+	for _, row := range r.table(1) {
+		result[row.values[1].(string)] = row.values
+	}
+	return result
+}
+
+// querySQLite runs the queries with SQLite itself, the rows are formatted like the sqlite3 shell does
+func querySQLite(t *testing.T, path string, queries ...string) []string {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	result := make([]string, 0, len(queries))
+	for _, q := range queries {
+		rows, err := db.Query(q)
+		if err != nil {
+			t.Fatalf("query %q failed: %v", q, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := make([]string, 0)
+		for rows.Next() {
+			values := make([]sql.NullString, len(columns))
+			dest := make([]interface{}, len(columns))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatalf("query %q failed: %v", q, err)
+			}
+			fields := make([]string, len(values))
+			for i, v := range values {
+				fields[i] = v.String
+			}
+			lines = append(lines, strings.Join(fields, "|"))
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatalf("query %q failed: %v", q, err)
+		}
+		rows.Close()
+		result = append(result, strings.Join(lines, "\n"))
+	}
+	return result
+}
+
+// checkIntegrity runs the integrity check of SQLite on the file and the query, which must return want
+func checkIntegrity(t *testing.T, path string, query string, want string) {
+	t.Helper()
+	got := querySQLite(t, path, "PRAGMA integrity_check;", query)
+	if got[0] != "ok" {
+		t.Errorf("integrity check = %q, want %q", got[0], "ok")
+	}
+	if got[1] != want {
+		t.Errorf("query = %q, want %q", got[1], want)
+	}
+}
+
+func Test_putVarint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{v: 0, want: []byte{0x00}},
+		{v: 0x7f, want: []byte{0x7f}},
+		{v: 0x80, want: []byte{0x81, 0x00}},
+		{v: 0x3fff, want: []byte{0xff, 0x7f}},
+		{v: 0x4000, want: []byte{0x81, 0x80, 0x00}},
+		{v: 0x00ffffffffffffff, want: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+		{v: 0x0100000000000000, want: []byte{0x80, 0xc0, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}},
+		{v: math.MaxUint64, want: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, tt := range tests {
+		got := putVarint(nil, tt.v)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("putVarint(%#x) = %x, want %x", tt.v, got, tt.want)
+		}
+		if v, n := readTestVarint(got); v != tt.v || n != len(got) {
+			t.Errorf("putVarint(%#x) reads back as %#x", tt.v, v)
+		}
+	}
+}
+
+func Test_encodeRecord(t *testing.T) {
+	s := "text"
+	var nilString *string
+	id := int64(-5)
+
+	values := []interface{}{nil, 0, 1, true, false, int32(-100), 1000, 1 << 20, -1 << 30, int64(1) << 40, int64(math.MinInt64),
+		2.5, "", "héllo", []byte{1, 2}, []byte(nil), &s, nilString, &id}
+	want := []interface{}{nil, int64(0), int64(1), int64(1), int64(0), int64(-100), int64(1000), int64(1 << 20), int64(-1 << 30),
+		int64(1) << 40, int64(math.MinInt64), 2.5, "", "héllo", []byte{1, 2}, nil, "text", nil, int64(-5)}
+
+	normalized, err := normalizeAll(values)
+	if err != nil {
+		t.Fatalf("normalizeAll() error = %v", err)
+	}
+	if got := decodeTestRecord(t, encodeRecord(normalized)); !reflect.DeepEqual(got, want) {
+		t.Errorf("encodeRecord() reads back as %v, want %v", got, want)
+	}
+
+	if _, err := normalize(uint8(1)); err == nil {
+		t.Error("normalize() of an unsupported type error = nil")
+	}
+}
+
+func Test_compareKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []interface{}
+		want int
+	}{
+		{name: "null before numbers", a: []interface{}{nil}, b: []interface{}{int64(-10)}, want: -1},
+		{name: "numbers before text", a: []interface{}{int64(10)}, b: []interface{}{"1"}, want: -1},
+		{name: "text before blobs", a: []interface{}{"z"}, b: []interface{}{[]byte("a")}, want: -1},
+		{name: "integers and floats", a: []interface{}{int64(2)}, b: []interface{}{1.5}, want: 1},
+		{name: "equal integer and float", a: []interface{}{int64(2)}, b: []interface{}{2.0}, want: 0},
+		{name: "text by bytes", a: []interface{}{"B"}, b: []interface{}{"a"}, want: -1},
+		{name: "second column", a: []interface{}{"a", int64(2)}, b: []interface{}{"a", int64(1)}, want: 1},
+		{name: "prefix first", a: []interface{}{"a"}, b: []interface{}{"a", int64(1)}, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareKeys(tt.a, tt.b)
+			if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+				t.Errorf("compareKeys() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		name string
+		rows int
+		// textSize is the size of the text column, large values go to overflow pages
+		textSize int
+	}{
+		{name: "empty table", rows: 0, textSize: 10},
+		{name: "single page", rows: 10, textSize: 10},
+		{name: "several levels", rows: 20000, textSize: 30},
+		{name: "overflow pages", rows: 50, textSize: 10000},
+		{name: "index overflow pages", rows: 300, textSize: 1500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			w, err := Create(path)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			w.SetUserVersion(7)
+
+			text := func(i int) string {
+				prefix := strings.Repeat("x", tt.textSize)
+				return prefix[:tt.textSize-6] + strings.Repeat("0", 6-len(strconv.Itoa(i))) + strconv.Itoa(i)
+			}
+
+			table := w.CreateTable("item", `CREATE TABLE "item" ("id" integer PRIMARY KEY NOT NULL, "name" varchar NOT NULL UNIQUE, "size" integer, "data" blob)`)
+			nameIndex := w.CreateIndex("sqlite_autoindex_item_1", "item", "")
+			sizeIndex := w.CreateIndex("IDX_item_size", "item", `CREATE INDEX "IDX_item_size" ON "item" ("size")`)
+			var wantRows []testRow
+			var wantNames, wantSizes [][]interface{}
+			for i := 1; i <= tt.rows; i++ {
+				var size interface{}
+				if i%5 != 0 {
+					size = int64(tt.rows - i)
+				}
+				if err := table.Insert(int64(i), nil, text(i), size, []byte{byte(i)}); err != nil {
+					t.Fatalf("Insert() error = %v", err)
+				}
+				if err := nameIndex.Insert(text(i), int64(i)); err != nil {
+					t.Fatalf("Index.Insert() error = %v", err)
+				}
+				wantRows = append(wantRows, testRow{rowid: int64(i), values: []interface{}{nil, text(i), size, []byte{byte(i)}}})
+				wantNames = append(wantNames, []interface{}{text(i), int64(i)})
+			}
+			// nulls first, then the sizes which are descending with the rowid
+			for i := 5; i <= tt.rows; i += 5 {
+				wantSizes = append(wantSizes, []interface{}{nil, int64(i)})
+			}
+			for i := tt.rows; i >= 1; i-- {
+				if i%5 != 0 {
+					wantSizes = append(wantSizes, []interface{}{int64(tt.rows - i), int64(i)})
+				}
+			}
+			for _, k := range wantSizes {
+				if err := sizeIndex.Insert(k...); err != nil {
+					t.Fatalf("Index.Insert() error = %v", err)
+				}
+			}
+
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			r := openTestFile(t, path)
+			if v := binary.BigEndian.Uint32(r.data[60:]); v != 7 {
+				t.Errorf("user_version = %d, want 7", v)
+			}
+			objects := r.objects()
+			if len(objects) != 3 {
+				t.Fatalf("schema has %d objects, want 3", len(objects))
+			}
+			if o := objects["sqlite_autoindex_item_1"]; o[0] != "index" || o[2] != "item" || o[4] != nil {
+				t.Errorf("automatic index schema = %v", o)
+			}
+			if o := objects["IDX_item_size"]; o[0] != "index" || !strings.HasPrefix(o[4].(string), "CREATE INDEX") {
+				t.Errorf("index schema = %v", o)
+			}
+
+			rows := r.table(uint32(objects["item"][3].(int64)))
+			if !reflect.DeepEqual(rows, wantRows) {
+				t.Errorf("table has %d rows, want %d rows", len(rows), len(wantRows))
+			}
+			if names := r.index(uint32(objects["sqlite_autoindex_item_1"][3].(int64))); !reflect.DeepEqual(names, wantNames) {
+				t.Errorf("name index has %d entries, want %d entries", len(names), len(wantNames))
+			}
+			if sizes := r.index(uint32(objects["IDX_item_size"][3].(int64))); !reflect.DeepEqual(sizes, wantSizes) {
+				t.Errorf("size index has %d entries, want %d entries", len(sizes), len(wantSizes))
+			}
+
+			checkIntegrity(t, path, `SELECT count(*), count(DISTINCT name), count(size) FROM item;`,
+				strconv.Itoa(tt.rows)+"|"+strconv.Itoa(tt.rows)+"|"+strconv.Itoa(tt.rows-tt.rows/5))
+		})
+	}
+}
+
+func TestWriter_insertOrder(t *testing.T) {
+	w, err := Create(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	defer w.Abort()
+
+	table := w.CreateTable("item", `CREATE TABLE "item" ("id" integer PRIMARY KEY NOT NULL)`)
+	if err := table.Insert(2, nil); err != nil {
+		t.Fatalf("Insert() error = %v", err)
+	}
+	if err := table.Insert(2, nil); err == nil {
+		t.Error("Insert() of the same rowid error = nil")
+	}
+	if err := table.Insert(1, nil); err == nil {
+		t.Error("Insert() of a smaller rowid error = nil")
+	}
+	if err := table.Insert(3, uint8(1)); err == nil {
+		t.Error("Insert() of an unsupported value error = nil")
+	}
+
+	index := w.CreateIndex("IDX_item", "item", `CREATE INDEX "IDX_item" ON "item" ("id")`)
+	if err := index.Insert("b", int64(1)); err != nil {
+		t.Fatalf("Index.Insert() error = %v", err)
+	}
+	if err := index.Insert("b", int64(1)); err == nil {
+		t.Error("Index.Insert() of the same entry error = nil")
+	}
+	if err := index.Insert("a", int64(2)); err == nil {
+		t.Error("Index.Insert() of a smaller entry error = nil")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := w.Close(); err == nil {
+		t.Error("second Close() error = nil")
+	}
+}
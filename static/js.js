@@ -122,6 +122,14 @@ async function batchComment(checkboxClassName, attribute, action, body) {
         }
         url += `/comment?action=${encodeURIComponent(action)}&message=${encodeURIComponent(textArea.value)}&ignore-duplicate-actions=${checked}`
 
+        if (action === "approve" || action === "request-changes") {
+            for (let item of document.querySelectorAll(".checklist-item")) {
+                if (item.value !== "") {
+                    url += `&checklist-${item.dataset.itemId}=${encodeURIComponent(item.value)}`
+                }
+            }
+        }
+
         if (body) {
             await sendXHR(url, "POST", null, reload,
                 `Failed to post comment(s) with action '${action}'.`, successMessage, null)
@@ -260,6 +268,31 @@ async function deleteSubmission(sid) {
         "Please provide a reason to delete this submission and all its related data:")
 }
 
+async function deleteFlashfreezeFile(fid) {
+    await sendXHR(`/api/flashfreeze/file/${fid}`, "DELETE", null, true,
+        "Failed to delete flashfreeze file.",
+        null,
+        "Please provide a reason to delete this flashfreeze file:")
+}
+
+async function editFlashfreezeMetadata(fid, description, tags) {
+    let newDescription = prompt("Description:", description)
+    if (newDescription == null) {
+        return
+    }
+    let newTags = prompt("Tags (semicolon-separated):", tags)
+    if (newTags == null) {
+        return
+    }
+    let data = new URLSearchParams()
+    data.set("description", newDescription)
+    data.set("tags", newTags)
+    await sendXHR(`/api/flashfreeze/file/${fid}/metadata`, "POST", data, true,
+        "Failed to update flashfreeze file.",
+        null,
+        null)
+}
+
 async function freezeSubmission(sid) {
     await sendXHR(`/api/submission/${sid}/freeze`, "POST", null, true,
         "Failed to freeze submission.",
@@ -267,6 +300,51 @@ async function freezeSubmission(sid) {
         null)
 }
 
+async function editCurationMeta(sid) {
+    const form = document.getElementById("curation-meta-form")
+    const data = new URLSearchParams()
+    for (const input of form.querySelectorAll("[data-meta-key]")) {
+        if (input.value !== input.defaultValue) {
+            data.set(input.dataset.metaKey, input.value)
+        }
+    }
+    if (data.toString() === "") {
+        alert("Nothing has changed.")
+        return
+    }
+    if (!confirm("This uploads a new version of the submission with the edited meta. Continue?")) {
+        return
+    }
+    await sendXHR(`/api/submission/${sid}/curation-meta`, "POST", data, true,
+        "Failed to edit curation meta.",
+        null,
+        null)
+}
+
+function fillCurationMetaFromTemplate() {
+    const id = parseInt(document.getElementById("curation-meta-template").value)
+    const template = submissionTemplates.find(t => t.id === id)
+    if (!template) {
+        return
+    }
+    const form = document.getElementById("curation-meta-form")
+    for (const input of form.querySelectorAll("[data-meta-key]")) {
+        if (input.dataset.metaKey in template.fields) {
+            input.value = template.fields[input.dataset.metaKey]
+        }
+    }
+}
+
+async function publishSubmission(sid) {
+    if (!confirm("Publish the draft? It will be listed to everyone and announced in the curation feed.")) {
+        return
+    }
+    await sendXHR(`/api/submission/${sid}/publish`, "POST", null, true,
+        "Failed to publish submission.",
+        null,
+        null)
+}
+
 async function unfreezeSubmission(sid) {
     await sendXHR(`/api/submission/${sid}/unfreeze`, "POST", null, true,
         "Failed to unfreeze submission.",
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// Local keeps objects as files under a root directory
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{
+		root: root,
+	}
+}
+
+func (l *Local) String() string {
+	return l.root
+}
+
+func (l *Local) filePath(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(cleaned)), nil
+}
+
+func (l *Local) Open(_ context.Context, key string) (Object, *ObjectInfo, error) {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, &ObjectInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+func (l *Local) Stat(_ context.Context, key string) (*ObjectInfo, error) {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return nil, &fs.PathError{Op: "stat", Path: filePath, Err: fs.ErrNotExist}
+	}
+	return &ObjectInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+// Put writes into a temporary file first, so readers never see a partially written object
+func (l *Local) Put(_ context.Context, key string, r io.Reader, _ int64) error {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (l *Local) Import(ctx context.Context, key string, filePath string) error {
+	dest, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filePath, dest); err == nil {
+		return nil
+	}
+
+	// the file can be on a different filesystem
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	err = l.Put(ctx, key, f, -1)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filePath)
+}
+
+func (l *Local) Remove(_ context.Context, key string) error {
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Walk(_ context.Context, prefix string, fn func(info *ObjectInfo) error) error {
+	dir := prefix
+	if !strings.HasSuffix(prefix, "/") {
+		dir = path.Dir(prefix)
+	}
+	walkRoot := l.root
+	if dir != "." && dir != "" {
+		cleaned, err := cleanKey(dir)
+		if err != nil {
+			return err
+		}
+		walkRoot = filepath.Join(l.root, filepath.FromSlash(cleaned))
+	}
+
+	err := filepath.WalkDir(walkRoot, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") && strings.HasSuffix(d.Name(), ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(&ObjectInfo{Key: key, Size: fi.Size(), ModTime: fi.ModTime()})
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// LocalPath returns the path of the object itself, there is nothing to release
+func (l *Local) LocalPath(ctx context.Context, key string) (string, func(), error) {
+	if _, err := l.Stat(ctx, key); err != nil {
+		return "", nil, err
+	}
+	filePath, err := l.filePath(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return filePath, func() {}, nil
+}
+
+func (l *Local) PresignGet(_ context.Context, _ string, _ string, _ time.Duration) (string, error) {
+	return "", ErrPresignUnsupported
+}
+
+func (l *Local) moveTo(srcKey string, dst *Local, dstKey string) error {
+	src, err := l.filePath(srcKey)
+	if err != nil {
+		return err
+	}
+	dest, err := dst.filePath(dstKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dest); err == nil {
+		return nil
+	}
+
+	// the directories can be on different filesystems
+	if err := utils.CopyFile(src, dest); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 keeps objects in a bucket of an S3 compatible service, under a key prefix
+type S3 struct {
+	client     *minio.Client
+	bucket     string
+	prefix     string
+	stagingDir string
+}
+
+// NewS3Client connects to an S3 compatible service, e.g. a MinIO instance
+func NewS3Client(endpoint, accessKeyID, secretAccessKey, region string, useSSL bool) (*minio.Client, error) {
+	return minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+}
+
+// NewS3 creates a storage in given bucket. Local copies of objects are downloaded into stagingDir,
+// which defaults to the system temp directory.
+func NewS3(client *minio.Client, bucket, prefix, stagingDir string) *S3 {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &S3{
+		client:     client,
+		bucket:     bucket,
+		prefix:     prefix,
+		stagingDir: stagingDir,
+	}
+}
+
+func (s *S3) String() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+func (s *S3) objectName(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.prefix + cleaned, nil
+}
+
+// translateError maps missing objects to fs.ErrNotExist
+func translateError(op, objectName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound {
+		return &fs.PathError{Op: op, Path: objectName, Err: fs.ErrNotExist}
+	}
+	return err
+}
+
+func (s *S3) Open(ctx context.Context, key string) (Object, *ObjectInfo, error) {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translateError("open", objectName, err)
+	}
+	oi, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, translateError("open", objectName, err)
+	}
+	return obj, &ObjectInfo{Key: key, Size: oi.Size, ModTime: oi.LastModified}, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	oi, err := s.client.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translateError("stat", objectName, err)
+	}
+	return &ObjectInfo{Key: key, Size: oi.Size, ModTime: oi.LastModified}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64) error {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, objectName, r, size, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	return err
+}
+
+func (s *S3) Import(ctx context.Context, key string, filePath string) error {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.FPutObject(ctx, s.bucket, objectName, filePath, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return err
+	}
+	return os.Remove(filePath)
+}
+
+func (s *S3) Remove(ctx context.Context, key string) error {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) Walk(ctx context.Context, prefix string, fn func(info *ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for oi := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.prefix + prefix, Recursive: true}) {
+		if oi.Err != nil {
+			return oi.Err
+		}
+		if err := fn(&ObjectInfo{Key: strings.TrimPrefix(oi.Key, s.prefix), Size: oi.Size, ModTime: oi.LastModified}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LocalPath downloads the object into the staging directory, the extension is kept because tools detect formats by it
+func (s *S3) LocalPath(ctx context.Context, key string) (string, func(), error) {
+	obj, _, err := s.Open(ctx, key)
+	if err != nil {
+		return "", nil, err
+	}
+	defer obj.Close()
+
+	tmp, err := os.CreateTemp(s.stagingDir, "fpfss-storage-*"+keyExtension(key))
+	if err != nil {
+		return "", nil, err
+	}
+	release := func() {
+		os.Remove(tmp.Name())
+	}
+
+	if _, err := io.Copy(tmp, obj); err != nil {
+		tmp.Close()
+		release()
+		return "", nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		release()
+		return "", nil, err
+	}
+
+	return tmp.Name(), release, nil
+}
+
+func (s *S3) PresignGet(ctx context.Context, key string, filename string, expiry time.Duration) (string, error) {
+	objectName, err := s.objectName(key)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	if filename != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, objectName, expiry, params)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// moveTo copies the object server-side and removes the source.
+// ComposeObject is used because plain copies are limited to 5 GB.
+func (s *S3) moveTo(ctx context.Context, srcKey string, dst *S3, dstKey string) error {
+	srcName, err := s.objectName(srcKey)
+	if err != nil {
+		return err
+	}
+	dstName, err := dst.objectName(dstKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: dst.bucket, Object: dstName},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: srcName})
+	if err != nil {
+		return translateError("move", srcName, err)
+	}
+	return s.client.RemoveObject(ctx, s.bucket, srcName, minio.RemoveObjectOptions{})
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrPresignUnsupported is returned by drivers which cannot hand out direct download URLs
+var ErrPresignUnsupported = errors.New("presigned urls are not supported by this storage")
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Object is an opened object, zip archives can be read straight from it
+type Object interface {
+	io.ReadSeekCloser
+	io.ReaderAt
+}
+
+// Storage is a flat namespace of objects addressed by slash separated keys.
+// Missing objects are reported with errors satisfying errors.Is(err, fs.ErrNotExist).
+type Storage interface {
+	// Open opens the object for reading
+	Open(ctx context.Context, key string) (Object, *ObjectInfo, error)
+	// Stat returns information about the object
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// Put stores the content of r, size may be -1 if unknown
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Import moves a local file into the storage, the file is gone afterwards
+	Import(ctx context.Context, key string, filePath string) error
+	// Remove deletes the object, it is not an error if the object doesn't exist
+	Remove(ctx context.Context, key string) error
+	// Walk calls fn for every object whose key starts with prefix
+	Walk(ctx context.Context, prefix string, fn func(info *ObjectInfo) error) error
+	// LocalPath returns a path of a local copy of the object, for tools which can only work with files.
+	// The returned function must be called once the copy is no longer needed.
+	LocalPath(ctx context.Context, key string) (string, func(), error)
+	// PresignGet returns a temporary URL the object can be downloaded from directly,
+	// or ErrPresignUnsupported if the driver can't do that
+	PresignGet(ctx context.Context, key string, filename string, expiry time.Duration) (string, error)
+	// String describes where the storage lives, for logging
+	String() string
+}
+
+// Exists reports whether the object exists
+func Exists(ctx context.Context, s Storage, key string) (bool, error) {
+	_, err := s.Stat(ctx, key)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// Move moves an object, possibly between two different storages.
+// Storages of the same driver move the object without streaming it through this process when they can.
+func Move(ctx context.Context, src Storage, srcKey string, dst Storage, dstKey string) error {
+	switch s := src.(type) {
+	case *Local:
+		if d, ok := dst.(*Local); ok {
+			return s.moveTo(srcKey, d, dstKey)
+		}
+	case *S3:
+		if d, ok := dst.(*S3); ok && s.client == d.client {
+			return s.moveTo(ctx, srcKey, d, dstKey)
+		}
+	}
+
+	obj, info, err := src.Open(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	err = dst.Put(ctx, dstKey, obj, info.Size)
+	obj.Close()
+	if err != nil {
+		return err
+	}
+
+	return src.Remove(ctx, srcKey)
+}
+
+// MoveIfExists moves the object if it exists and reports whether it did
+func MoveIfExists(ctx context.Context, src Storage, srcKey string, dst Storage, dstKey string) (bool, error) {
+	exists, err := Exists(ctx, src, srcKey)
+	if err != nil || !exists {
+		return false, err
+	}
+	return true, Move(ctx, src, srcKey, dst, dstKey)
+}
+
+// cleanKey validates the key so it can't escape the storage root
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(key, "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid storage key '%s'", key)
+	}
+	return cleaned, nil
+}
+
+// keyExtension returns everything after the first dot of the key's base name, so compound extensions like .tar.gz survive
+func keyExtension(key string) string {
+	base := path.Base(key)
+	if i := strings.Index(base, "."); i > 0 {
+		return base[i:]
+	}
+	return ""
+}
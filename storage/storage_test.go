@@ -0,0 +1,452 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// fakeS3 is an in-memory S3 endpoint supporting what the driver uses: object reads, writes, deletes, listings
+// and the multipart copies of ComposeObject
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte         // bucket/object
+	uploads map[string]map[int][]byte // upload ID, part number
+	modTime time.Time
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, object, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	name := bucket + "/" + object
+
+	if object == "" && r.Method == http.MethodGet {
+		f.list(w, bucket, r.URL.Query().Get("prefix"))
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Has("uploads") || query.Has("uploadId") {
+		f.multipart(w, r, name)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[name]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, object, f.modTime, bytes.NewReader(data))
+	case http.MethodPut:
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			source, _ = url.PathUnescape(strings.TrimPrefix(source, "/"))
+			data, ok := f.objects[source]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+				return
+			}
+			f.objects[name] = bytes.Clone(data)
+			io.WriteString(w, `<CopyObjectResult><LastModified>`+f.modTime.Format(time.RFC3339)+`</LastModified><ETag>"etag"</ETag></CopyObjectResult>`)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.objects[name] = data
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// multipart serves the multipart uploads whose parts are copied from other objects
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[uploadID] = make(map[int][]byte)
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, uploadID)
+	case r.Method == http.MethodPut && f.uploads[uploadID] != nil:
+		source, _ := url.PathUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+		data, ok := f.objects[source]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("X-Amz-Copy-Source-Range"), "bytes=%d-%d", &start, &end); err == nil {
+			data = data[start : end+1]
+		}
+		part, _ := strconv.Atoi(query.Get("partNumber"))
+		f.uploads[uploadID][part] = bytes.Clone(data)
+		io.WriteString(w, `<CopyPartResult><LastModified>`+f.modTime.Format(time.RFC3339)+`</LastModified><ETag>"etag"</ETag></CopyPartResult>`)
+	case r.Method == http.MethodPost && f.uploads[uploadID] != nil:
+		parts := f.uploads[uploadID]
+		numbers := make([]int, 0, len(parts))
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, n := range numbers {
+			data = append(data, parts[n]...)
+		}
+		f.objects[name] = data
+		delete(f.uploads, uploadID)
+		bucket, object, _ := strings.Cut(name, "/")
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`, bucket, object)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, bucket, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int64
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix, MaxKeys: 1000}
+
+	for name, data := range f.objects {
+		key, ok := strings.CutPrefix(name, bucket+"/")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		result.Contents = append(result.Contents, content{Key: key, LastModified: f.modTime.Format(time.RFC3339), ETag: `"etag"`, Size: int64(len(data))})
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(result)
+}
+
+func newTestS3(t *testing.T, prefix string) *S3 {
+	t.Helper()
+	server := httptest.NewTLSServer(&fakeS3{objects: make(map[string][]byte), uploads: make(map[string]map[int][]byte), modTime: time.Now().UTC().Truncate(time.Second)})
+	t.Cleanup(server.Close)
+
+	client, err := minio.New(strings.TrimPrefix(server.URL, "https://"), &minio.Options{
+		Creds:     credentials.NewStaticV4("access", "secret", ""),
+		Secure:    true,
+		Region:    "us-east-1",
+		Transport: server.Client().Transport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewS3(client, "fpfss", prefix, t.TempDir())
+}
+
+func readObject(t *testing.T, s Storage, key string) string {
+	t.Helper()
+	obj, _, err := s.Open(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Open(%s) error = %v", key, err)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		t.Fatalf("reading %s: %v", key, err)
+	}
+	return string(data)
+}
+
+func putObject(t *testing.T, s Storage, key, content string) {
+	t.Helper()
+	if err := s.Put(context.Background(), key, strings.NewReader(content), int64(len(content))); err != nil {
+		t.Fatalf("Put(%s) error = %v", key, err)
+	}
+}
+
+func testDrivers(t *testing.T) map[string]func(t *testing.T) Storage {
+	return map[string]func(t *testing.T) Storage{
+		"local": func(t *testing.T) Storage { return NewLocal(t.TempDir()) },
+		"s3":    func(t *testing.T) Storage { return newTestS3(t, "blobs") },
+	}
+}
+
+func TestStorage_PutOpenStat(t *testing.T) {
+	for driver, newStorage := range testDrivers(t) {
+		t.Run(driver, func(t *testing.T) {
+			s := newStorage(t)
+			ctx := context.Background()
+
+			putObject(t, s, "ab/cd/file.zip", "content")
+
+			if got := readObject(t, s, "ab/cd/file.zip"); got != "content" {
+				t.Errorf("Open() content = %q, want %q", got, "content")
+			}
+
+			info, err := s.Stat(ctx, "ab/cd/file.zip")
+			if err != nil {
+				t.Fatalf("Stat() error = %v", err)
+			}
+			if info.Key != "ab/cd/file.zip" || info.Size != 7 {
+				t.Errorf("Stat() = %s (%d bytes), want ab/cd/file.zip (7 bytes)", info.Key, info.Size)
+			}
+
+			obj, _, err := s.Open(ctx, "ab/cd/file.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, 4)
+			if _, err := obj.ReadAt(b, 3); err != nil && err != io.EOF {
+				t.Errorf("ReadAt() error = %v", err)
+			}
+			obj.Close()
+			if string(b) != "tent" {
+				t.Errorf("ReadAt() = %q, want %q", b, "tent")
+			}
+
+			putObject(t, s, "ab/cd/file.zip", "replaced")
+			if got := readObject(t, s, "ab/cd/file.zip"); got != "replaced" {
+				t.Errorf("Open() after overwrite = %q, want %q", got, "replaced")
+			}
+		})
+	}
+}
+
+func TestStorage_missingObjects(t *testing.T) {
+	for driver, newStorage := range testDrivers(t) {
+		t.Run(driver, func(t *testing.T) {
+			s := newStorage(t)
+			ctx := context.Background()
+
+			if _, err := s.Stat(ctx, "missing"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Stat() error = %v, want fs.ErrNotExist", err)
+			}
+			if _, _, err := s.Open(ctx, "missing"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open() error = %v, want fs.ErrNotExist", err)
+			}
+			if exists, err := Exists(ctx, s, "missing"); err != nil || exists {
+				t.Errorf("Exists() = %v, %v, want false, nil", exists, err)
+			}
+			if err := s.Remove(ctx, "missing"); err != nil {
+				t.Errorf("Remove() error = %v, want nil", err)
+			}
+			if moved, err := MoveIfExists(ctx, s, "missing", s, "elsewhere"); err != nil || moved {
+				t.Errorf("MoveIfExists() = %v, %v, want false, nil", moved, err)
+			}
+		})
+	}
+}
+
+func TestStorage_invalidKeys(t *testing.T) {
+	keys := []string{"", "..", "../escape", "a/../../escape"}
+	for driver, newStorage := range testDrivers(t) {
+		t.Run(driver, func(t *testing.T) {
+			s := newStorage(t)
+			for _, key := range keys {
+				if err := s.Put(context.Background(), key, strings.NewReader("x"), 1); err == nil {
+					t.Errorf("Put(%q) error = nil, want an error", key)
+				}
+			}
+		})
+	}
+}
+
+func TestStorage_Walk(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{name: "everything", prefix: "", want: []string{"aa/1.zip", "aa/2.zip", "ab/3.zip"}},
+		{name: "directory", prefix: "aa/", want: []string{"aa/1.zip", "aa/2.zip"}},
+		{name: "partial name", prefix: "a", want: []string{"aa/1.zip", "aa/2.zip", "ab/3.zip"}},
+		{name: "nothing matches", prefix: "zz/", want: []string{}},
+	}
+	for driver, newStorage := range testDrivers(t) {
+		t.Run(driver, func(t *testing.T) {
+			s := newStorage(t)
+			for _, key := range []string{"aa/1.zip", "aa/2.zip", "ab/3.zip"} {
+				putObject(t, s, key, key)
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got := make([]string, 0)
+					err := s.Walk(context.Background(), tt.prefix, func(info *ObjectInfo) error {
+						got = append(got, info.Key)
+						return nil
+					})
+					if err != nil {
+						t.Fatalf("Walk() error = %v", err)
+					}
+					sort.Strings(got)
+					if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+						t.Errorf("Walk() = %v, want %v", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStorage_ImportAndLocalPath(t *testing.T) {
+	for driver, newStorage := range testDrivers(t) {
+		t.Run(driver, func(t *testing.T) {
+			s := newStorage(t)
+			ctx := context.Background()
+
+			source := filepath.Join(t.TempDir(), "upload.tmp")
+			if err := os.WriteFile(source, []byte("uploaded"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Import(ctx, "ab/file.tar.gz", source); err != nil {
+				t.Fatalf("Import() error = %v", err)
+			}
+			if _, err := os.Stat(source); !os.IsNotExist(err) {
+				t.Errorf("Import() left the source file behind")
+			}
+
+			localPath, release, err := s.LocalPath(ctx, "ab/file.tar.gz")
+			if err != nil {
+				t.Fatalf("LocalPath() error = %v", err)
+			}
+			if !strings.HasSuffix(localPath, ".tar.gz") {
+				t.Errorf("LocalPath() = %s, the extension is lost", localPath)
+			}
+			data, err := os.ReadFile(localPath)
+			if err != nil || string(data) != "uploaded" {
+				t.Errorf("LocalPath() content = %q, %v, want %q", data, err, "uploaded")
+			}
+			release()
+
+			if _, _, err := s.LocalPath(ctx, "missing.zip"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("LocalPath() of a missing object error = %v, want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestMove(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    func(t *testing.T) Storage
+		dst    func(t *testing.T) Storage
+		shared bool // src and dst are the same storage
+	}{
+		{name: "local to local", src: testDrivers(t)["local"], dst: testDrivers(t)["local"]},
+		{name: "s3 to s3", src: testDrivers(t)["s3"], shared: true},
+		{name: "local to s3", src: testDrivers(t)["local"], dst: testDrivers(t)["s3"]},
+		{name: "s3 to local", src: testDrivers(t)["s3"], dst: testDrivers(t)["local"]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			src := tt.src(t)
+			dst := src
+			if !tt.shared {
+				dst = tt.dst(t)
+			}
+
+			putObject(t, src, "from/file.zip", "moved")
+
+			if err := Move(ctx, src, "from/file.zip", dst, "to/file.zip"); err != nil {
+				t.Fatalf("Move() error = %v", err)
+			}
+			if got := readObject(t, dst, "to/file.zip"); got != "moved" {
+				t.Errorf("moved content = %q, want %q", got, "moved")
+			}
+			if exists, err := Exists(ctx, src, "from/file.zip"); err != nil || exists {
+				t.Errorf("source still exists after Move(): %v, %v", exists, err)
+			}
+		})
+	}
+}
+
+func TestStorage_PresignGet(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewLocal(t.TempDir()).PresignGet(ctx, "file.zip", "game.zip", time.Hour); !errors.Is(err, ErrPresignUnsupported) {
+		t.Errorf("Local.PresignGet() error = %v, want ErrPresignUnsupported", err)
+	}
+
+	u, err := newTestS3(t, "blobs").PresignGet(ctx, "ab/file.zip", "game.zip", time.Hour)
+	if err != nil {
+		t.Fatalf("S3.PresignGet() error = %v", err)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Path != "/fpfss/blobs/ab/file.zip" {
+		t.Errorf("S3.PresignGet() path = %s, want /fpfss/blobs/ab/file.zip", parsed.Path)
+	}
+	query := parsed.Query()
+	if query.Get("X-Amz-Signature") == "" || query.Get("X-Amz-Expires") != "3600" {
+		t.Errorf("S3.PresignGet() = %s, not a signed url valid for an hour", u)
+	}
+	if query.Get("response-content-disposition") != "attachment; filename=game.zip" {
+		t.Errorf("S3.PresignGet() content disposition = %s", query.Get("response-content-disposition"))
+	}
+}
+
+func Test_keyExtension(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "ab/cd/file.zip", want: ".zip"},
+		{key: "ab/cd/file.tar.gz", want: ".tar.gz"},
+		{key: "ab/cd/file", want: ""},
+		{key: "ab/.hidden", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := keyExtension(tt.key); got != tt.want {
+				t.Errorf("keyExtension() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/config"
+	"github.com/felixge/httpsnoop"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "flashpoint-submission-system"
+
+// tracer is a no-op until InitTracer installs the exporter, so spans can be started unconditionally
+var tracer = otel.Tracer("github.com/FlashpointProject/flashpoint-submission-system")
+
+// InitTracer installs the OTLP exporter when tracing is enabled. The returned function flushes the remaining spans.
+func InitTracer(l *logrus.Entry, conf *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !conf.TracingEnabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := make([]otlptracehttp.Option, 0)
+	if conf.TracingOTLPEndpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(conf.TracingOTLPEndpoint))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.Bool("deployment.dev", conf.IsDev),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.AlwaysSample())),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		l.WithField("serviceName", "tracing").Error(err)
+	}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span which is a child of the span in the context, if there is any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail records the error on the span and marks it as failed
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// End records the error, if there is any, and ends the span
+func End(span trace.Span, err error) {
+	if err != nil {
+		Fail(span, err)
+	}
+	span.End()
+}
+
+// HandleRequests starts a server span for every request, continuing the trace of the caller if it sent one.
+// The span is named after the route template so that requests to the same endpoint are grouped together.
+func HandleRequests(router *mux.Router, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if template, err := match.Route.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, fmt.Sprintf("%s %s", r.Method, route),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("user_agent.original", r.UserAgent()),
+			))
+		defer span.End()
+
+		m := httpsnoop.CaptureMetrics(h, w, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", m.Code), attribute.Int64("http.response.body.size", m.Written))
+		if m.Code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(m.Code))
+		}
+	})
+}
+
+// transport starts a client span for every outbound request and passes the trace on to the called service
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps the transport, or the default one if base is nil
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx, span := tracer.Start(r.Context(), fmt.Sprintf("%s %s", r.Method, r.URL.Host),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("server.address", r.URL.Host),
+			attribute.String("url.path", r.URL.Path),
+		))
+	defer span.End()
+
+	r = r.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+
+	resp, err := t.base.RoundTrip(r)
+	if err != nil {
+		Fail(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -18,8 +18,10 @@ import (
 
 	"github.com/FlashpointProject/flashpoint-submission-system/config"
 	"github.com/FlashpointProject/flashpoint-submission-system/logging"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
 	"github.com/FlashpointProject/flashpoint-submission-system/resumableuploadservice"
 	"github.com/FlashpointProject/flashpoint-submission-system/service"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/bwmarrin/discordgo"
 	"github.com/gorilla/mux"
@@ -42,6 +44,12 @@ type App struct {
 
 func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Pool, authBotSession, notificationBotSession *discordgo.Session, rsu *resumableuploadservice.ResumableUploadService) {
 	l.Infoln("initializing the server")
+
+	shutdownTracer, err := tracing.InitTracer(l, conf)
+	if err != nil {
+		panic(err)
+	}
+
 	router := mux.NewRouter()
 	host := "0.0.0.0"
 	if conf.FlashpointSourceOnlyAdminMode {
@@ -49,7 +57,7 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 	}
 	srv := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", host, conf.Port),
-		Handler:      logging.LogRequestHandler(l, router),
+		Handler:      metrics.InstrumentHandler(router, logging.LogRequestHandler(l, tracing.HandleRequests(router, router))),
 		ReadTimeout:  time.Duration(1) * time.Hour,
 		WriteTimeout: time.Duration(1) * time.Hour,
 	}
@@ -60,7 +68,7 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 
 	// Generate the admin password, only used in source only admin mode
 	b := make([]byte, 12)
-	_, err := rand.Read(b)
+	_, err = rand.Read(b)
 	if err != nil {
 		panic(err)
 	}
@@ -71,6 +79,12 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 		l.WithField("admin_password", adminPass).Infoln(fmt.Sprintf("generated admin password: %s", adminPass))
 	}
 
+	volumes, err := newStorageVolumes(conf)
+	if err != nil {
+		panic(err)
+	}
+	l.WithField("dataPacks", volumes.DataPacks.String()).Infoln("storage initialized")
+
 	a := &App{
 		Conf: conf,
 		CC: utils.CookieCutter{
@@ -81,14 +95,17 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 		DFStorage:       NewDeviceFlowStorage(conf.HostBaseURL),
 		Service: service.New(l, db, pgdb, authBotSession, notificationBotSession, conf.FlashpointServerID,
 			conf.NotificationChannelID, conf.CurationFeedChannelID, conf.ValidatorServerURL, conf.SessionExpirationSeconds,
-			conf.SubmissionsDirFullPath, conf.SubmissionImagesDirFullPath, conf.FlashfreezeDirFullPath, conf.IsDev,
-			rsu, conf.ArchiveIndexerServerURL, conf.FlashfreezeIngestDirFullPath,
-			conf.DataPacksDir),
+			conf.SubmissionsDirFullPath, conf.FlashfreezeDirFullPath, conf.IsDev,
+			rsu, conf.ArchiveIndexerServerURL, conf.FlashfreezeIngestDirFullPath, volumes,
+			reviewQueueIdleTimeout(conf), sweeperRules(conf)),
 		decoder:             decoder,
 		authMiddlewareCache: memoize.NewMemoizer(5*time.Second, 60*time.Minute),
 		AdminModePassword:   adminPass,
 	}
 
+	metrics.RegisterDatabases(db, pgdb)
+	a.Service.RegisterMetrics(l)
+
 	l.WithField("port", conf.Port).Infoln("starting the server...")
 
 	go func() {
@@ -104,6 +121,12 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 	a.Service.DataPacksIndexer.Start()
 
 	if !conf.FlashpointSourceOnlyMode {
+		l.Infoln("starting the submission processing queue...")
+		wg.Add(1)
+		go func() {
+			a.Service.RunSubmissionProcessingQueue(l, ctx, wg, int(a.Conf.SubmissionProcessingWorkers), int(a.Conf.SubmissionProcessingAttempts))
+		}()
+
 		l.Infoln("starting the notification consumer...")
 
 		wg.Add(1)
@@ -111,13 +134,21 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 			a.Service.RunNotificationConsumer(l, ctx, wg)
 		}()
 
-		l.Infoln("starting the autounfreezer...")
+		if err := a.Service.ImportAutounfreezerExemptions(context.WithValue(ctx, utils.CtxKeys.Log, l), a.Conf.DoNotUnfreezeGameList); err != nil {
+			l.Error(err)
+		}
+
+		l.Infoln("starting the scheduler...")
 		wg.Add(1)
 		go func() {
-			a.Service.RunAutounfreezer(l, ctx, wg, a.Conf.DataPacksDir, a.Conf.FrozenPacksDir, a.Conf.DeletedDataPacksDir, a.Conf.DoNotUnfreezeGameList)
+			a.Service.RunScheduler(l, ctx, wg)
 		}()
 	}
 
+	l.Infoln("starting the config reloader...")
+	wg.Add(1)
+	go configReloader(l, ctx, wg, conf)
+
 	// disable memstats for now
 	//l.Infoln("starting the memstats printer...")
 	//wg.Add(1)
@@ -128,6 +159,30 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 	<-term
 	l.Infoln("signal received")
 
+	l.Infoln("refusing new uploads...")
+	a.Service.BeginShutdown()
+
+	// the requests and the processing are drained side by side, so that a slow request does not eat into the time
+	// of the processing, each of them gets the whole drain period
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), time.Duration(conf.ShutdownDrainSeconds)*time.Second)
+	defer cancelDrain()
+
+	drainWG := &sync.WaitGroup{}
+	drainWG.Add(1)
+	go func() {
+		defer drainWG.Done()
+		l.Infoln("waiting for the received uploads to be processed...")
+		if err := a.Service.DrainProcessing(drainCtx); err != nil {
+			l.WithError(err).Warnln("processing did not finish in time, the unfinished uploads will be processed on the next start")
+		}
+	}()
+
+	l.Infoln("shutting down the server...")
+	if err := srv.Shutdown(drainCtx); err != nil {
+		l.WithError(err).Errorln("server shutdown failed")
+	}
+	drainWG.Wait()
+
 	l.Infoln("waiting for all goroutines to finish...")
 	cancelFunc()
 	wg.Wait()
@@ -143,14 +198,59 @@ func InitApp(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Poo
 	l.Infoln("closing data pack indexer...")
 	a.Service.DataPacksIndexer.Stop()
 
-	l.Infoln("shutting down the server...")
-	if err := srv.Shutdown(context.Background()); err != nil {
-		l.WithError(err).Errorln("server shutdown failed")
+	l.Infoln("flushing traces...")
+	if err := shutdownTracer(context.Background()); err != nil {
+		l.WithError(err).Errorln("tracer shutdown failed")
 	}
 
 	l.Infoln("goodbye")
 }
 
+// reviewQueueIdleTimeout returns how long the testers of the review queue may be idle before they are unassigned
+func reviewQueueIdleTimeout(conf *config.Config) time.Duration {
+	return time.Duration(conf.ReviewQueueIdleHours) * time.Hour
+}
+
+// sweeperRules returns the configured rules of the stalled submission sweeper
+func sweeperRules(conf *config.Config) service.SweeperRules {
+	day := time.Hour * 24
+	return service.SweeperRules{
+		UnassignInactiveAfter: time.Duration(conf.SweeperUnassignInactiveDays) * day,
+		RemindAfter:           time.Duration(conf.SweeperRemindAfterDays) * day,
+		RejectGracePeriod:     time.Duration(conf.SweeperRejectGraceDays) * day,
+	}
+}
+
+// configReloader reloads the config file on SIGHUP. Only the reloadable settings are applied,
+// the changes of the other settings are logged and take effect after a restart.
+func configReloader(l *logrus.Entry, ctx context.Context, wg *sync.WaitGroup, conf *config.Config) {
+	defer wg.Done()
+	l = l.WithField("serviceName", "configReloader")
+	defer l.Infoln("config reloader stopped")
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.Infoln("context cancelled, stopping config reloader")
+			return
+		case <-hup:
+			reloaded, ignored, err := conf.Reload()
+			if err != nil {
+				l.WithError(err).Errorln("config reload failed, keeping the current config")
+				continue
+			}
+			l.WithField("settings", reloaded).Infoln("config reloaded")
+			if len(ignored) > 0 {
+				l.WithField("settings", ignored).Warnln("changed settings need a restart to take effect")
+			}
+		}
+	}
+}
+
 func memstatsPrinter(l *logrus.Entry, ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 	defer l.Infoln("memstats printer stopped")
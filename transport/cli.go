@@ -0,0 +1,363 @@
+package transport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sirupsen/logrus"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/config"
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/service"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+// exit codes of the commands
+const (
+	cliOK     = 0
+	cliIssues = 1 // the command worked and found problems, like inconsistent files
+	cliFailed = 2
+)
+
+// newCLIService creates a service for one-off commands, without the discord bots and the resumable upload service
+func newCLIService(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Pool) (*service.SiteService, error) {
+	volumes, err := newStorageVolumes(conf)
+	if err != nil {
+		return nil, err
+	}
+	return service.New(l, db, pgdb, nil, nil, conf.FlashpointServerID,
+		conf.NotificationChannelID, conf.CurationFeedChannelID, conf.ValidatorServerURL, conf.SessionExpirationSeconds,
+		conf.SubmissionsDirFullPath, conf.FlashfreezeDirFullPath, conf.IsDev,
+		nil, conf.ArchiveIndexerServerURL, conf.FlashfreezeIngestDirFullPath, volumes,
+		reviewQueueIdleTimeout(conf), sweeperRules(conf)), nil
+}
+
+// RunFileConsistencyCheck runs the file consistency check, prints the report to stdout and returns the exit code
+func RunFileConsistencyCheck(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Pool, verifyHashes, quarantine bool) int {
+	s, err := newCLIService(l, conf, db, pgdb)
+	if err != nil {
+		l.Error(err)
+		return cliFailed
+	}
+
+	ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, l)
+	report, err := s.CheckFileConsistency(ctx, verifyHashes, quarantine)
+	if report != nil {
+		writeCLIOutput(l, os.Stdout, report)
+	}
+	if err != nil {
+		l.Error(err)
+		return cliFailed
+	}
+	if len(report.Issues) > 0 {
+		return cliIssues
+	}
+	return cliOK
+}
+
+// adminCommand is a subcommand of `admin`, its result is printed to stdout as JSON
+type adminCommand struct {
+	usage       string
+	description string
+	// run parses the flags of the command and returns its result and exit code
+	run func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error)
+}
+
+type adminResult struct {
+	Status string `json:"status"`
+}
+
+var adminSuccess = &adminResult{Status: "success"}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+var adminCommands = map[string]*adminCommand{
+	"sessions delete": {
+		usage:       "-user <discord user id>",
+		description: "deletes the sessions of the user, logging them out everywhere",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			userID := fs.Int64("user", 0, "discord ID of the user")
+			fs.Parse(args)
+			if *userID == 0 {
+				return nil, cliFailed, fmt.Errorf("-user is required")
+			}
+			count, err := s.DeleteUserSessions(ctx, *userID)
+			if err != nil {
+				return nil, cliFailed, err
+			}
+			return map[string]interface{}{"status": "success", "deleted_sessions": count}, cliOK, nil
+		},
+	},
+	"sessions nuke": {
+		usage:       "-yes",
+		description: "deletes all sessions, logging everyone out",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			yes := fs.Bool("yes", false, "confirm deleting all sessions")
+			fs.Parse(args)
+			if !*yes {
+				return nil, cliFailed, fmt.Errorf("-yes is required to delete all sessions")
+			}
+			if err := s.NukeSessionTable(ctx); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"cache recompute": {
+		description: "recomputes the cached state of all submissions",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			fs.Parse(args)
+			if _, err := s.RecomputeSubmissionCacheAll(ctx); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"metadata snapshot": {
+		description: "exports a new snapshot of the metadata and deletes the old ones",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			fs.Parse(args)
+			snapshot, err := s.ExportMetadataSnapshot(ctx)
+			if err != nil {
+				return nil, cliFailed, err
+			}
+			return snapshot, cliOK, nil
+		},
+	},
+	"game delete": {
+		usage:       fmt.Sprintf("-id <game id> -reason <%s> [-redirect <game id>]", strings.Join(constants.GetValidDeleteReasons(), "|")),
+		description: "deletes the game, a duplicate must be redirected to the game it duplicates",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			gameID := fs.String("id", "", "ID of the game")
+			reason := fs.String("reason", "", "reason of the deletion")
+			destID := fs.String("redirect", "", "ID of the game to redirect to")
+			fs.Parse(args)
+			if *gameID == "" {
+				return nil, cliFailed, fmt.Errorf("-id is required")
+			}
+			fullReason, err := gameDeleteReason(*reason, *destID)
+			if err != nil {
+				return nil, cliFailed, err
+			}
+			if err := s.DeleteGame(ctx, *gameID, fullReason, *destID); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"game restore": {
+		usage:       fmt.Sprintf("-id <game id> -reason <%s>", strings.Join(constants.GetValidRestoreReasons(), "|")),
+		description: "restores the deleted game",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			gameID := fs.String("id", "", "ID of the game")
+			reason := fs.String("reason", "", "reason of the restoration")
+			fs.Parse(args)
+			if *gameID == "" {
+				return nil, cliFailed, fmt.Errorf("-id is required")
+			}
+			if err := checkGameRestoreReason(*reason); err != nil {
+				return nil, cliFailed, err
+			}
+			if err := s.RestoreGame(ctx, *gameID, *reason); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"game freeze": {
+		usage:       "-id <game id>",
+		description: "freezes the game and moves its data packs into the freezer",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			gameID := fs.String("id", "", "ID of the game")
+			fs.Parse(args)
+			if *gameID == "" {
+				return nil, cliFailed, fmt.Errorf("-id is required")
+			}
+			if err := s.FreezeGame(ctx, *gameID, utils.UserID(ctx)); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"game unfreeze": {
+		usage:       "-id <game id>",
+		description: "unfreezes the game and moves its data packs out of the freezer",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			gameID := fs.String("id", "", "ID of the game")
+			fs.Parse(args)
+			if *gameID == "" {
+				return nil, cliFailed, fmt.Errorf("-id is required")
+			}
+			if err := s.UnfreezeGame(ctx, *gameID, utils.UserID(ctx)); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"game redirect": {
+		usage:       "-from <game id> -to <game id>",
+		description: "redirects a game ID to another game",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			srcID := fs.String("from", "", "ID to redirect")
+			destID := fs.String("to", "", "ID of the game to redirect to")
+			fs.Parse(args)
+			if *srcID == "" || *destID == "" {
+				return nil, cliFailed, fmt.Errorf("-from and -to are required")
+			}
+			if err := s.AddNewGameRedirect(ctx, *srcID, *destID); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"import dump": {
+		usage:       "-file <launcher dump json>",
+		description: "imports the games, tags and platforms of a launcher database dump",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			path := fs.String("file", "", "path of the dump")
+			fs.Parse(args)
+			if *path == "" {
+				return nil, cliFailed, fmt.Errorf("-file is required")
+			}
+			f, err := os.Open(*path)
+			if err != nil {
+				return nil, cliFailed, err
+			}
+			defer f.Close()
+			var dump types.LauncherDump
+			if err := json.NewDecoder(f).Decode(&dump); err != nil {
+				return nil, cliFailed, fmt.Errorf("failed to decode the dump: %w", err)
+			}
+			if err := s.DeveloperImportDatabaseJson(ctx, &dump); err != nil {
+				return nil, cliFailed, err
+			}
+			return map[string]interface{}{
+				"status":    "success",
+				"games":     len(dump.Games.Games),
+				"tags":      len(dump.Tags.Tags),
+				"platforms": len(dump.Platforms.Platforms),
+			}, cliOK, nil
+		},
+	},
+	"import tag-descriptions": {
+		description: "updates the tag descriptions from the validator",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			fs.Parse(args)
+			if err := s.DeveloperTagDescFromValidator(ctx); err != nil {
+				return nil, cliFailed, err
+			}
+			return adminSuccess, cliOK, nil
+		},
+	},
+	"check files": {
+		usage:       "[-verify-hashes] [-quarantine]",
+		description: "checks the stored files against the database, exits with 1 when there are issues",
+		run: func(ctx context.Context, s *service.SiteService, fs *flag.FlagSet, args []string) (interface{}, int, error) {
+			verifyHashes := fs.Bool("verify-hashes", false, "verify the checksums of data packs and blobs, reads every file")
+			quarantine := fs.Bool("quarantine", false, "move orphaned files into the quarantine storage")
+			fs.Parse(args)
+			report, err := s.CheckFileConsistency(ctx, *verifyHashes, *quarantine)
+			if err != nil {
+				return report, cliFailed, err
+			}
+			if len(report.Issues) > 0 {
+				return report, cliIssues, nil
+			}
+			return report, cliOK, nil
+		},
+	},
+}
+
+// PrintAdminUsage lists the admin commands
+func PrintAdminUsage(w io.Writer) {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "usage: admin [-as <discord user id>] <command> [flags]")
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		cmd := adminCommands[name]
+		fmt.Fprintf(w, "  %s\n", strings.TrimSpace(name+" "+cmd.usage))
+		fmt.Fprintf(w, "        %s\n", cmd.description)
+	}
+}
+
+// RunAdminCommand runs an admin command with the same service methods as the god tools, prints the result to stdout
+// as JSON and returns the exit code. The changes are recorded in the activity log as done by the -as user,
+// the system user by default.
+func RunAdminCommand(l *logrus.Entry, conf *config.Config, db *sql.DB, pgdb *pgxpool.Pool, args []string) int {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	as := fs.Int64("as", constants.SystemID, "discord ID of the user the changes are recorded as")
+	fs.Usage = func() { PrintAdminUsage(os.Stderr) }
+	if err := fs.Parse(args); err != nil {
+		return cliFailed
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		PrintAdminUsage(os.Stderr)
+		return cliFailed
+	}
+	name := rest[0] + " " + rest[1]
+	cmd, ok := adminCommands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n", name)
+		PrintAdminUsage(os.Stderr)
+		return cliFailed
+	}
+
+	s, err := newCLIService(l, conf, db, pgdb)
+	if err != nil {
+		l.Error(err)
+		return cliFailed
+	}
+
+	// interrupting cancels the command instead of killing it in the middle of a transaction
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = context.WithValue(ctx, utils.CtxKeys.UserID, *as)
+	ctx = context.WithValue(ctx, utils.CtxKeys.Log, l.WithField("command", name))
+
+	cmdFlags := flag.NewFlagSet(name, flag.ExitOnError)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: admin %s %s\n", name, cmd.usage)
+		cmdFlags.PrintDefaults()
+	}
+
+	result, code, err := cmd.run(ctx, s, cmdFlags, rest[2:])
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		if result == nil {
+			result = &adminError{Error: err.Error()}
+		}
+	}
+	if result != nil {
+		writeCLIOutput(l, os.Stdout, result)
+	}
+
+	return code
+}
+
+func writeCLIOutput(l *logrus.Entry, w io.Writer, v interface{}) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		l.Error(err)
+	}
+}
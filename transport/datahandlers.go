@@ -1,13 +1,18 @@
 package transport
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
+	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/service"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/gorilla/mux"
 )
@@ -32,24 +37,25 @@ func (a *App) HandleDownloadSubmissionFile(w http.ResponseWriter, r *http.Reques
 	}
 	sf := sfs[0]
 
+	if err := a.Service.RequireVisibleSubmissions(ctx, []int64{sf.SubmissionID}); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
 	err = a.Service.EmitSubmissionDownloadEvent(ctx, uid, sf.SubmissionID, sfid)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	f, err := os.Open(fmt.Sprintf("%s/%s", a.Conf.SubmissionsDirFullPath, sf.CurrentFilename))
-
+	st, key, err := a.Service.SubmissionFileLocation(ctx, sf)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
 		return
 	}
-	defer f.Close()
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", sf.CurrentFilename))
-	w.Header().Set("Content-Type", "application/octet-stream")
-	http.ServeContent(w, r, sf.CurrentFilename, sf.UploadedAt, f)
+	a.serveStoredFile(ctx, w, r, st, key, sf.CurrentFilename, true)
 }
 
 func (a *App) HandleDownloadSubmissionBatch(w http.ResponseWriter, r *http.Request) {
@@ -75,10 +81,34 @@ func (a *App) HandleDownloadSubmissionBatch(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	filePaths := make([]string, 0, len(sfs))
+	sids := make([]int64, 0, len(sfs))
+	for _, sf := range sfs {
+		sids = append(sids, sf.SubmissionID)
+	}
+	if err := a.Service.RequireVisibleSubmissions(ctx, sids); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	entries := make([]utils.TarballEntry, 0, len(sfs))
 
 	for _, sf := range sfs {
-		filePaths = append(filePaths, fmt.Sprintf("%s/%s", a.Conf.SubmissionsDirFullPath, sf.CurrentFilename))
+		st, key, err := a.Service.SubmissionFileLocation(ctx, sf)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
+			return
+		}
+		entries = append(entries, utils.TarballEntry{
+			Name: fmt.Sprintf("%s/%s", a.Conf.SubmissionsDirFullPath, sf.CurrentFilename),
+			Open: func() (io.ReadCloser, int64, time.Time, error) {
+				obj, info, err := st.Open(ctx, key)
+				if err != nil {
+					return nil, 0, time.Time{}, err
+				}
+				return obj, info.Size, info.ModTime, nil
+			},
+		})
 
 		err = a.Service.EmitSubmissionDownloadEvent(ctx, uid, sf.SubmissionID, sf.ID)
 		if err != nil {
@@ -90,7 +120,7 @@ func (a *App) HandleDownloadSubmissionBatch(w http.ResponseWriter, r *http.Reque
 	filename := fmt.Sprintf("fpfss-batch-%dfiles-%s.tar", len(sfs), utils.NewRealRandomStringProvider().RandomString(16))
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	w.Header().Set("Content-Type", "application/octet-stream")
-	if err := utils.WriteTarball(w, filePaths); err != nil {
+	if err := utils.WriteTarball(w, entries); err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, perr("failed to create tarball", http.StatusInternalServerError))
 		return
@@ -115,60 +145,147 @@ func (a *App) HandleDownloadCurationImage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	f, err := os.Open(fmt.Sprintf("%s/%s", a.Conf.SubmissionImagesDirFullPath, ci.Filename))
+	a.serveStoredFile(ctx, w, r, a.Service.Volumes().SubmissionImages, ci.Filename, ci.Filename, false)
+}
+
+func (a *App) HandleDownloadFlashfreezeRootFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	fileID := params[constants.ResourceKeyFlashfreezeRootFileID]
+
+	fid, err := strconv.ParseInt(fileID, 10, 64)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
 		return
 	}
 
-	fi, err := f.Stat()
+	ci, err := a.Service.GetFlashfreezeRootFile(ctx, fid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	st, key, err := a.Service.FlashfreezeFileLocation(ctx, ci)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
 		return
 	}
-	defer f.Close()
 
-	w.Header().Set("Content-Type", "image")
-	http.ServeContent(w, r, ci.Filename, fi.ModTime(), f)
+	filename := fmt.Sprintf("flashfreeze-%d-%s", fid, ci.CurrentFilename)
+	a.serveStoredFile(ctx, w, r, st, key, filename, true)
 }
 
-func (a *App) HandleDownloadFlashfreezeRootFile(w http.ResponseWriter, r *http.Request) {
+func metadataSnapshotFileURL(version int64, format string) string {
+	return fmt.Sprintf("/api/metadata-snapshots/%d/%s", version, format)
+}
+
+// HandleDownloadMetadataSnapshot serves a file of a metadata snapshot, of the version in the url or the latest one.
+// The ETag is the checksum of the file, so an unchanged snapshot is not downloaded again.
+func (a *App) HandleDownloadMetadataSnapshot(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params := mux.Vars(r)
-	fileID := params[constants.ResourceKeyFlashfreezeRootFileID]
 
-	fid, err := strconv.ParseInt(fileID, 10, 64)
-	if err != nil {
-		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
-		return
+	var version *int64
+	if v, ok := params[constants.ResourceKeyMetadataSnapshotVersion]; ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			writeError(ctx, w, perr("invalid snapshot version", http.StatusBadRequest))
+			return
+		}
+		version = &n
 	}
 
-	ci, err := a.Service.GetFlashfreezeRootFile(ctx, fid)
+	snapshot, err := a.Service.GetMetadataSnapshot(ctx, version)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	f, err := os.Open(fmt.Sprintf("%s/%s", a.Conf.FlashfreezeDirFullPath, ci.CurrentFilename))
+	var file *types.MetadataSnapshotFile
+	switch params[constants.ResourceKeyMetadataSnapshotFormat] {
+	case "sqlite":
+		file = snapshot.SQLite
+	case "json":
+		file = snapshot.JSON
+	default:
+		writeError(ctx, w, perr("unknown snapshot format, use sqlite or json", http.StatusNotFound))
+		return
+	}
+
+	etag := fmt.Sprintf(`"%s"`, file.SHA256)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Checksum-Sha256", file.SHA256)
+	w.Header().Set("X-Metadata-Snapshot-Version", strconv.FormatInt(snapshot.Version, 10))
+	w.Header().Set("X-Metadata-Snapshot-Timestamp", snapshot.Timestamp.Format(time.RFC3339Nano))
+	// checked here as well, because presigned downloads are redirected before http.ServeContent checks it
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	a.serveStoredFile(ctx, w, r, a.Service.Volumes().MetadataSnapshots, file.Key, file.Key, true)
+}
+
+// writeArchiveEntry streams the archive entry. Only images are shown inline, everything else is downloaded,
+// because html and swf content would run with the site's origin.
+func writeArchiveEntry(w http.ResponseWriter, r *http.Request) func(entry *types.ArchiveBrowserEntry, er io.Reader) error {
+	return func(entry *types.ArchiveBrowserEntry, er io.Reader) error {
+		br := bufio.NewReaderSize(er, 512)
+		head, _ := br.Peek(512)
+		contentType := http.DetectContentType(head)
+
+		filename := path.Base(entry.Name)
+		if r.URL.Query().Get("download") == "true" || !service.IsInlineImage(contentType) {
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", strings.ReplaceAll(filename, "\"", "")))
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Content-Length", strconv.FormatInt(entry.Size, 10))
+
+		_, err := io.Copy(w, br)
+		return err
+	}
+}
+
+func (a *App) HandleDownloadSubmissionArchiveEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	sid, err := strconv.ParseInt(params[constants.ResourceKeySubmissionID], 10, 64)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+	sfid, err := strconv.ParseInt(params[constants.ResourceKeyFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission file id", http.StatusBadRequest))
 		return
 	}
 
-	fi, err := f.Stat()
+	if err := a.Service.ReadSubmissionArchiveEntry(ctx, sid, sfid, r.URL.Query().Get("name"), writeArchiveEntry(w, r)); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+}
+
+func (a *App) HandleDownloadFlashfreezeArchiveEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	fid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeRootFileID], 10, 64)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
 		return
 	}
-	defer f.Close()
 
-	filename := fmt.Sprintf("flashfreeze-%d-%s", fid, ci.CurrentFilename)
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	w.Header().Set("Content-Type", "application/octet-stream")
-	http.ServeContent(w, r, filename, fi.ModTime(), f)
+	if err := a.Service.ReadFlashfreezeArchiveEntry(ctx, fid, r.URL.Query().Get("name"), writeArchiveEntry(w, r)); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
 }
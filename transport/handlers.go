@@ -3,13 +3,12 @@ package transport
 import (
 	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -21,6 +20,9 @@ import (
 
 	"github.com/FlashpointProject/flashpoint-submission-system/clients"
 	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/metrics"
+	"github.com/FlashpointProject/flashpoint-submission-system/service"
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
 	"github.com/gorilla/mux"
@@ -102,8 +104,20 @@ func (a *App) HandleCommentReceiverBatch(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := a.Service.ReceiveComments(ctx, uid, sids, formAction, formMessage, formIgnoreDupeActions,
-		a.Conf.SubmissionsDirFullPath, a.Conf.DataPacksDir, a.Conf.FrozenPacksDir, a.Conf.ImagesDir, r); err != nil {
+	checklist := make(map[int64]string)
+	for key := range r.Form {
+		if !strings.HasPrefix(key, "checklist-") {
+			continue
+		}
+		itemID, err := strconv.ParseInt(strings.TrimPrefix(key, "checklist-"), 10, 64)
+		if err != nil {
+			writeError(ctx, w, perr("invalid checklist item id", http.StatusBadRequest))
+			return
+		}
+		checklist[itemID] = r.Form.Get(key)
+	}
+
+	if err := a.Service.ReceiveComments(ctx, uid, sids, formAction, formMessage, formIgnoreDupeActions, checklist, r); err != nil {
 		writeError(ctx, w, err)
 		return
 	}
@@ -453,7 +467,22 @@ func (a *App) HandleFlashfreezeSubmitPage(w http.ResponseWriter, r *http.Request
 func (a *App) HandleMinLauncherVersion(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	writeResponse(ctx, w, map[string]interface{}{"min-version": a.Conf.MinLauncherVersion}, http.StatusOK)
+	writeResponse(ctx, w, map[string]interface{}{"min-version": a.Conf.Reloadable().MinLauncherVersion}, http.StatusOK)
+}
+
+// HandleMetadataSnapshot returns the latest metadata snapshot, with the urls of its files
+func (a *App) HandleMetadataSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	snapshot, err := a.Service.GetMetadataSnapshot(ctx, nil)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	snapshot.SQLite.URL = metadataSnapshotFileURL(snapshot.Version, "sqlite")
+	snapshot.JSON.URL = metadataSnapshotFileURL(snapshot.Version, "json")
+	writeResponse(ctx, w, snapshot, http.StatusOK)
 }
 
 func (a *App) HandleMetadataStats(w http.ResponseWriter, r *http.Request) {
@@ -788,7 +817,7 @@ func (a *App) HandleGamePage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	pageData, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.ImagesCdn, a.Conf.ImagesCdnCompressed, revisionDate)
+	pageData, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.Reloadable().ImagesCdn, a.Conf.Reloadable().ImagesCdnCompressed, revisionDate)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
@@ -884,27 +913,15 @@ func (a *App) HandleDeleteGame(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	gameId := params[constants.ResourceKeyGameID]
 	query := r.URL.Query()
-	reason := query.Get("reason")
 	destId := query.Get("destId")
-	validReasons := constants.GetValidDeleteReasons()
 
-	if !isElementExist(validReasons, reason) {
-		writeError(ctx, w,
-			perr(fmt.Sprintf("reason query param must be of [%s], got %s", strings.Join(validReasons, ", "), reason), http.StatusBadRequest))
+	reason, err := gameDeleteReason(query.Get("reason"), destId)
+	if err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
-	if reason == "Duplicate" {
-		if destId == "" {
-			writeError(ctx, w, perr("Redirect ID must be provided when deleting a duplicate game", http.StatusBadRequest))
-			return
-		} else {
-			reason += " - Redirect to " + destId
-		}
-	}
-
-	err := a.Service.DeleteGame(ctx, gameId, reason, destId, a.Conf.ImagesDir, a.Conf.DataPacksDir, a.Conf.DeletedImagesDir,
-		a.Conf.DeletedDataPacksDir, a.Conf.FrozenPacksDir)
+	err = a.Service.DeleteGame(ctx, gameId, reason, destId)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, perr("Error deleting game - "+err.Error(), http.StatusInternalServerError))
@@ -920,16 +937,13 @@ func (a *App) HandleRestoreGame(w http.ResponseWriter, r *http.Request) {
 	gameId := params[constants.ResourceKeyGameID]
 	query := r.URL.Query()
 	reason := query.Get("reason")
-	validReasons := constants.GetValidRestoreReasons()
 
-	if !isElementExist(validReasons, reason) {
-		writeError(ctx, w,
-			perr(fmt.Sprintf("reason query param must be of [%s], got %s", strings.Join(validReasons, ", "), reason), http.StatusBadRequest))
+	if err := checkGameRestoreReason(reason); err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
-	err := a.Service.RestoreGame(ctx, gameId, reason, a.Conf.ImagesDir, a.Conf.DataPacksDir, a.Conf.DeletedImagesDir,
-		a.Conf.DeletedDataPacksDir, a.Conf.FrozenPacksDir)
+	err := a.Service.RestoreGame(ctx, gameId, reason)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, perr("Error restoring game "+err.Error(), http.StatusInternalServerError))
@@ -1044,7 +1058,7 @@ func (a *App) HandleGameLogo(w http.ResponseWriter, r *http.Request) {
 	gameId := params[constants.ResourceKeyGameID]
 	revisionDate := ""
 
-	game, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.ImagesCdn, a.Conf.ImagesCdnCompressed, revisionDate)
+	game, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.Reloadable().ImagesCdn, a.Conf.Reloadable().ImagesCdnCompressed, revisionDate)
 	if err != nil {
 		http.Error(w, "Game does not exist", http.StatusNotFound)
 		return
@@ -1090,28 +1104,9 @@ func (a *App) HandleGameLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logoPath := fmt.Sprintf("%s/Logos/%s/%s/%s.png", a.Conf.ImagesDir, gameId[:2], gameId[2:4], gameId)
-	if err := os.MkdirAll(filepath.Dir(logoPath), os.ModePerm); err != nil {
-		http.Error(w, "Failed to create directory", http.StatusInternalServerError)
-		return
-	}
-
-	// Create a new file
-	dst, err := os.Create(logoPath)
-	if err != nil {
-		http.Error(w, "Failed to create file", http.StatusInternalServerError)
-		return
-	}
-	defer dst.Close()
-
-	// Reset the file read pointer to the beginning of the uploaded file
-	if _, err := file.Seek(0, 0); err != nil {
-		http.Error(w, "Error reading file", http.StatusInternalServerError)
-		return
-	}
-
-	// Copy the contents of the uploaded file to the new file
-	if _, err := io.Copy(dst, file); err != nil {
+	// Store the uploaded file, the read pointer is already at the beginning
+	if err := a.Service.Volumes().Images.Put(ctx, service.LogoKey(gameId), file, -1); err != nil {
+		utils.LogCtx(ctx).Error(err)
 		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
@@ -1121,14 +1116,14 @@ func (a *App) HandleGameLogo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	url := fmt.Sprintf("%s/Logos/%s/%s/%s.png",
-		a.Conf.ImagesCdn, gameId[:2], gameId[2:4], gameId)
+		a.Conf.Reloadable().ImagesCdn, gameId[:2], gameId[2:4], gameId)
 	// Clear the image microservice cached file
 	client := &http.Client{}
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
 		http.Error(w, "Updated file, but failed to clear image cache", http.StatusInternalServerError)
 	}
-	req.Header.Set("Authorization", "Bearer "+a.Conf.ImagesCdnApiKey)
+	req.Header.Set("Authorization", "Bearer "+a.Conf.Reloadable().ImagesCdnApiKey)
 	_, err = client.Do(req)
 	if err != nil {
 		http.Error(w, "Updated file, but failed to clear image cache", http.StatusInternalServerError)
@@ -1146,7 +1141,7 @@ func (a *App) HandleGameScreenshot(w http.ResponseWriter, r *http.Request) {
 	gameId := params[constants.ResourceKeyGameID]
 	revisionDate := ""
 
-	game, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.ImagesCdn, a.Conf.ImagesCdnCompressed, revisionDate)
+	game, err := a.Service.GetGamePageData(ctx, gameId, a.Conf.Reloadable().ImagesCdn, a.Conf.Reloadable().ImagesCdnCompressed, revisionDate)
 	if err != nil {
 		http.Error(w, "Game does not exist", http.StatusNotFound)
 		return
@@ -1192,28 +1187,9 @@ func (a *App) HandleGameScreenshot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	screenshotPath := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png", a.Conf.ImagesDir, gameId[:2], gameId[2:4], gameId)
-	if err := os.MkdirAll(filepath.Dir(screenshotPath), os.ModePerm); err != nil {
-		http.Error(w, "Failed to create directory", http.StatusInternalServerError)
-		return
-	}
-
-	// Create a new file
-	dst, err := os.Create(screenshotPath)
-	if err != nil {
-		http.Error(w, "Failed to create file", http.StatusInternalServerError)
-		return
-	}
-	defer dst.Close()
-
-	// Reset the file read pointer to the beginning of the uploaded file
-	if _, err := file.Seek(0, 0); err != nil {
-		http.Error(w, "Error reading file", http.StatusInternalServerError)
-		return
-	}
-
-	// Copy the contents of the uploaded file to the new file
-	if _, err := io.Copy(dst, file); err != nil {
+	// Store the uploaded file, the read pointer is already at the beginning
+	if err := a.Service.Volumes().Images.Put(ctx, service.ScreenshotKey(gameId), file, -1); err != nil {
+		utils.LogCtx(ctx).Error(err)
 		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
@@ -1223,14 +1199,14 @@ func (a *App) HandleGameScreenshot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	url := fmt.Sprintf("%s/Screenshots/%s/%s/%s.png",
-		a.Conf.ImagesCdn, gameId[:2], gameId[2:4], gameId)
+		a.Conf.Reloadable().ImagesCdn, gameId[:2], gameId[2:4], gameId)
 	// Clear the image microservice cached file
 	client := &http.Client{}
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
 		http.Error(w, "Updated file, but failed to clear image cache", http.StatusInternalServerError)
 	}
-	req.Header.Set("Authorization", "Bearer "+a.Conf.ImagesCdnApiKey)
+	req.Header.Set("Authorization", "Bearer "+a.Conf.Reloadable().ImagesCdnApiKey)
 	_, err = client.Do(req)
 	if err != nil {
 		http.Error(w, "Updated file, but failed to clear image cache", http.StatusInternalServerError)
@@ -1248,11 +1224,7 @@ func (a *App) HandleFreezeGame(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	gameId := params[constants.ResourceKeyGameID]
 
-	dataPacksPath := a.Conf.DataPacksDir
-	frozenPacksPath := a.Conf.FrozenPacksDir
-	deletedPacksPath := a.Conf.DeletedDataPacksDir
-
-	err := a.Service.FreezeGame(ctx, gameId, uid, dataPacksPath, frozenPacksPath, deletedPacksPath)
+	err := a.Service.FreezeGame(ctx, gameId, uid)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
@@ -1267,11 +1239,7 @@ func (a *App) HandleUnfreezeGame(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	gameId := params[constants.ResourceKeyGameID]
 
-	dataPacksPath := a.Conf.DataPacksDir
-	frozenPacksPath := a.Conf.FrozenPacksDir
-	deletedPacksPath := a.Conf.DeletedDataPacksDir
-
-	err := a.Service.UnfreezeGame(ctx, gameId, uid, dataPacksPath, frozenPacksPath, deletedPacksPath)
+	err := a.Service.UnfreezeGame(ctx, gameId, uid)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
@@ -1315,227 +1283,764 @@ func (a *App) HandleGameRedirects(w http.ResponseWriter, r *http.Request) {
 		"templates/game-redirects.gohtml")
 }
 
-func (a *App) HandleSubmissionsPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleReviewRequirementsPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	filter := &types.SubmissionsFilter{}
+	pageData, err := a.Service.GetReviewRequirementsPageData(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
 
-	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
-		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.ReviewRequirements, http.StatusOK)
 		return
 	}
 
-	if err := filter.Validate(); err != nil {
+	a.RenderTemplates(ctx, w, r, pageData, "templates/review-requirements.gohtml")
+}
+
+func (a *App) HandleSaveReviewRequirement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr(err.Error(), http.StatusBadRequest))
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
 		return
 	}
 
-	pageData, err := a.Service.GetSubmissionsPageData(ctx, filter)
+	requiredApprovals, err := strconv.Atoi(r.FormValue("required-approvals"))
 	if err != nil {
-		writeError(ctx, w, err)
+		writeError(ctx, w, perr("invalid required approvals", http.StatusBadRequest))
+		return
+	}
+	requiredVerifications, err := strconv.Atoi(r.FormValue("required-verifications"))
+	if err != nil {
+		writeError(ctx, w, perr("invalid required verifications", http.StatusBadRequest))
 		return
 	}
 
-	pageData.FilterLayout = r.FormValue("filter-layout")
+	requirement := &types.ReviewRequirement{
+		SubmissionLevel:       r.FormValue("submission-level"),
+		Platform:              r.FormValue("platform"),
+		RequiredApprovals:     requiredApprovals,
+		RequiredVerifications: requiredVerifications,
+		RequiredRole:          r.FormValue("required-role"),
+	}
 
-	if utils.RequestType(ctx) != constants.RequestWeb {
-		writeResponse(ctx, w, pageData, http.StatusOK)
+	if err := a.Service.SaveReviewRequirement(ctx, requirement); err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData,
-		"templates/submissions.gohtml",
-		"templates/submission-filter.gohtml",
-		"templates/submission-table.gohtml",
-		"templates/submission-pagenav.gohtml",
-		"templates/submission-filter-chunks.gohtml",
-		"templates/comment-form.gohtml")
+	writeResponse(ctx, w, presp("review requirement saved", http.StatusOK), http.StatusOK)
 }
 
-func (a *App) HandleMySubmissionsPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleDeleteReviewRequirement(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	uid := utils.UserID(ctx)
-
-	filter := &types.SubmissionsFilter{}
+	params := mux.Vars(r)
+	requirementID := params[constants.ResourceKeyReviewRequirementID]
 
-	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
+	id, err := strconv.ParseInt(requirementID, 10, 64)
+	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		writeError(ctx, w, perr("invalid review requirement id", http.StatusBadRequest))
 		return
 	}
 
-	if err := filter.Validate(); err != nil {
-		utils.LogCtx(ctx).Error(err)
+	if err := a.Service.DeleteReviewRequirement(ctx, id); err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	filter.SubmitterID = &uid
+	writeResponse(ctx, w, presp("review requirement deleted", http.StatusOK), http.StatusOK)
+}
 
-	pageData, err := a.Service.GetSubmissionsPageData(ctx, filter)
+func (a *App) HandleReviewQueuePage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pageData, err := a.Service.GetReviewQueuePageData(ctx, reviewQueueIdleTimeout(a.Conf))
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	pageData.FilterLayout = r.FormValue("filter-layout")
-
 	if utils.RequestType(ctx) != constants.RequestWeb {
 		writeResponse(ctx, w, pageData, http.StatusOK)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData,
-		"templates/my-submissions.gohtml",
-		"templates/submission-filter.gohtml",
-		"templates/submission-table.gohtml",
-		"templates/submission-pagenav.gohtml",
-		"templates/submission-filter-chunks.gohtml",
-		"templates/comment-form.gohtml")
+	a.RenderTemplates(ctx, w, r, pageData, "templates/review-queue.gohtml")
 }
 
-func (a *App) HandleApplyContentPatchPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleJoinReviewQueue(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	params := mux.Vars(r)
-	submissionID := params[constants.ResourceKeySubmissionID]
 
-	sid, err := strconv.ParseInt(submissionID, 10, 64)
-	if err != nil {
+	if err := r.ParseForm(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
 		return
 	}
 
-	pageData, err := a.Service.GetApplyContentPatchPageData(ctx, sid)
+	maxAssignments, err := strconv.ParseInt(r.FormValue("max-assignments"), 10, 64)
 	if err != nil {
+		writeError(ctx, w, perr("invalid max assignments", http.StatusBadRequest))
+		return
+	}
+
+	member := &types.ReviewQueueMember{
+		Platforms:        strings.Split(r.FormValue("platforms"), ";"),
+		SubmissionLevels: r.Form["submission-level"],
+		MaxAssignments:   maxAssignments,
+	}
+
+	if err := a.Service.JoinReviewQueue(ctx, member); err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	if utils.RequestType(ctx) != constants.RequestWeb {
-		writeResponse(ctx, w, pageData, http.StatusOK)
+	writeResponse(ctx, w, presp("review queue preferences saved", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleLeaveReviewQueue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := a.Service.LeaveReviewQueue(ctx); err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData,
-		"templates/submission-content-patch-apply.gohtml")
+	writeResponse(ctx, w, presp("left the review queue", http.StatusOK), http.StatusOK)
 }
 
-func (a *App) HandleViewSubmissionPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleAssignNextQueuedSubmission(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	uid := utils.UserID(ctx)
-	params := mux.Vars(r)
-	submissionID := params[constants.ResourceKeySubmissionID]
 
-	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	sid, err := a.Service.AssignNextQueuedSubmission(ctx)
 	if err != nil {
-		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		writeError(ctx, w, err)
 		return
 	}
 
-	pageData, err := a.Service.GetViewSubmissionPageData(ctx, uid, sid)
+	writeResponse(ctx, w, map[string]int64{"submission_id": sid}, http.StatusOK)
+}
+
+func (a *App) HandleReviewChecklistsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pageData, err := a.Service.GetReviewChecklistsPageData(ctx)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
 	if utils.RequestType(ctx) != constants.RequestWeb {
-		writeResponse(ctx, w, pageData, http.StatusOK)
+		writeResponse(ctx, w, pageData.ReviewChecklistItems, http.StatusOK)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData,
-		"templates/submission.gohtml",
-		"templates/submission-table.gohtml",
-		"templates/comment-form.gohtml",
-		"templates/view-submission-nav.gohtml")
+	a.RenderTemplates(ctx, w, r, pageData, "templates/review-checklists.gohtml")
 }
 
-func (a *App) HandleViewSubmissionFilesPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleSaveReviewChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	var id int64
+	if r.FormValue("id") != "" {
+		var err error
+		id, err = strconv.ParseInt(r.FormValue("id"), 10, 64)
+		if err != nil {
+			writeError(ctx, w, perr("invalid review checklist item id", http.StatusBadRequest))
+			return
+		}
+	}
+	position, err := strconv.ParseInt(r.FormValue("position"), 10, 64)
+	if err != nil {
+		writeError(ctx, w, perr("invalid position", http.StatusBadRequest))
+		return
+	}
+
+	item := &types.ReviewChecklistItem{
+		ID:       id,
+		Platform: r.FormValue("platform"),
+		Label:    r.FormValue("label"),
+		Position: position,
+	}
+
+	if err := a.Service.SaveReviewChecklistItem(ctx, item); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("review checklist item saved", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleDeleteReviewChecklistItem(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params := mux.Vars(r)
-	submissionID := params[constants.ResourceKeySubmissionID]
+	itemID := params[constants.ResourceKeyReviewChecklistItemID]
 
-	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	id, err := strconv.ParseInt(itemID, 10, 64)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		writeError(ctx, w, perr("invalid review checklist item id", http.StatusBadRequest))
 		return
 	}
 
-	pageData, err := a.Service.GetSubmissionsFilesPageData(ctx, sid)
+	if err := a.Service.DeleteReviewChecklistItem(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("review checklist item deleted", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleCurationLintRulesPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pageData, err := a.Service.GetCurationLintRulesPageData(ctx)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
 	if utils.RequestType(ctx) != constants.RequestWeb {
-		writeResponse(ctx, w, pageData, http.StatusOK)
+		writeResponse(ctx, w, pageData.CurationLintRules, http.StatusOK)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData, "templates/submission-files.gohtml", "templates/submission-files-table.gohtml")
+	a.RenderTemplates(ctx, w, r, pageData, "templates/curation-lint-rules.gohtml")
 }
 
-func (a *App) HandleUpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleSubmissionProcessingJobsPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	uid := utils.UserID(ctx)
 
-	notificationSettings := &types.UpdateNotificationSettings{}
+	pageData, err := a.Service.GetSubmissionProcessingJobsPageData(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
 
-	if err := a.decoder.Decode(notificationSettings, r.URL.Query()); err != nil {
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.SubmissionProcessingJobs, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/submission-processing-jobs.gohtml")
+}
+
+func (a *App) HandleSaveCurationLintRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
 		return
 	}
 
-	if err := a.Service.UpdateNotificationSettings(ctx, uid, notificationSettings.NotificationActions); err != nil {
+	rule := &types.CurationLintRule{
+		Rule:      r.FormValue("rule"),
+		Enabled:   r.FormValue("enabled") == "true",
+		Severity:  r.FormValue("severity"),
+		Parameter: r.FormValue("parameter"),
+	}
+
+	if err := a.Service.SaveCurationLintRule(ctx, rule); err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+	writeResponse(ctx, w, presp("lint rule saved", http.StatusOK), http.StatusOK)
 }
 
-func (a *App) HandleUpdateSubscriptionSettings(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleSubmissionTemplatesPage(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	uid := utils.UserID(ctx)
-	params := mux.Vars(r)
-	submissionID := params[constants.ResourceKeySubmissionID]
 
-	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	pageData, err := a.Service.GetSubmissionTemplatesPageData(ctx)
 	if err != nil {
-		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		writeError(ctx, w, err)
 		return
 	}
 
-	subscriptionSettings := &types.UpdateSubscriptionSettings{}
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.SubmissionTemplates, http.StatusOK)
+		return
+	}
 
-	if err := a.decoder.Decode(subscriptionSettings, r.URL.Query()); err != nil {
+	a.RenderTemplates(ctx, w, r, pageData, "templates/submission-templates.gohtml")
+}
+
+func (a *App) HandleSaveSubmissionTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
 		return
 	}
 
-	if err := a.Service.UpdateSubscriptionSettings(ctx, uid, sid, subscriptionSettings.Subscribe); err != nil {
+	var id int64
+	if r.PostForm.Get("id") != "" {
+		var err error
+		id, err = strconv.ParseInt(r.PostForm.Get("id"), 10, 64)
+		if err != nil {
+			writeError(ctx, w, perr("invalid submission template id", http.StatusBadRequest))
+			return
+		}
+	}
+
+	// every other form key is a meta.yaml key
+	template := &types.SubmissionTemplate{
+		ID:     id,
+		Name:   r.PostForm.Get("name"),
+		Fields: make(map[string]string),
+	}
+	for key := range r.PostForm {
+		if key == "id" || key == "name" {
+			continue
+		}
+		template.Fields[key] = r.PostForm.Get(key)
+	}
+
+	if err := a.Service.SaveSubmissionTemplate(ctx, template); err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+	writeResponse(ctx, w, presp("submission template saved", http.StatusOK), http.StatusOK)
 }
 
-func (a *App) HandleInternalPage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleDeleteSubmissionTemplate(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	params := mux.Vars(r)
+	templateID := params[constants.ResourceKeySubmissionTemplateID]
 
-	pageData, err := a.Service.GetBasePageData(ctx)
+	id, err := strconv.ParseInt(templateID, 10, 64)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, err)
+		writeError(ctx, w, perr("invalid submission template id", http.StatusBadRequest))
+		return
+	}
+
+	if err := a.Service.DeleteSubmissionTemplate(ctx, id); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("submission template deleted", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleSubmissionsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter := &types.SubmissionsFilter{}
+
+	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := filter.Validate(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetSubmissionsPageData(ctx, filter)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	pageData.FilterLayout = r.FormValue("filter-layout")
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData,
+		"templates/submissions.gohtml",
+		"templates/submission-filter.gohtml",
+		"templates/submission-table.gohtml",
+		"templates/submission-pagenav.gohtml",
+		"templates/submission-filter-chunks.gohtml",
+		"templates/comment-form.gohtml")
+}
+
+func (a *App) HandleMySubmissionsPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := utils.UserID(ctx)
+
+	filter := &types.SubmissionsFilter{}
+
+	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := filter.Validate(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, err)
+		return
+	}
+
+	filter.SubmitterID = &uid
+
+	pageData, err := a.Service.GetSubmissionsPageData(ctx, filter)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	pageData.FilterLayout = r.FormValue("filter-layout")
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData,
+		"templates/my-submissions.gohtml",
+		"templates/submission-filter.gohtml",
+		"templates/submission-table.gohtml",
+		"templates/submission-pagenav.gohtml",
+		"templates/submission-filter-chunks.gohtml",
+		"templates/comment-form.gohtml")
+}
+
+func (a *App) HandleApplyContentPatchPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetApplyContentPatchPageData(ctx, sid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData,
+		"templates/submission-content-patch-apply.gohtml")
+}
+
+func (a *App) HandleViewSubmissionPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := utils.UserID(ctx)
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetViewSubmissionPageData(ctx, uid, sid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData,
+		"templates/submission.gohtml",
+		"templates/submission-table.gohtml",
+		"templates/comment-form.gohtml",
+		"templates/view-submission-nav.gohtml")
+}
+
+func (a *App) HandleViewSubmissionFilesPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetSubmissionsFilesPageData(ctx, sid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/submission-files.gohtml", "templates/submission-files-table.gohtml")
+}
+
+func (a *App) HandleEditCurationMeta(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	// form keys are the meta.yaml keys of the edited fields
+	values := make(map[string]string, len(r.PostForm))
+	for key := range r.PostForm {
+		values[key] = r.PostForm.Get(key)
+	}
+
+	if err := a.Service.EditCurationMeta(ctx, sid, values); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("curation meta edited, the new version is being processed", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleSubmissionDiffPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	// both are optional, the two newest files are compared by default
+	sfids := make([]int64, 2)
+	for i, key := range []string{"from", "to"} {
+		value := r.URL.Query().Get(key)
+		if value == "" {
+			continue
+		}
+		sfids[i], err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			writeError(ctx, w, perr(fmt.Sprintf("invalid submission file id '%s'", key), http.StatusBadRequest))
+			return
+		}
+	}
+
+	pageData, err := a.Service.GetSubmissionDiffPageData(ctx, sid, sfids[0], sfids[1])
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.Diff, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/submission-diff.gohtml")
+}
+
+func (a *App) HandleSubmissionArchiveBrowserPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	sid, err := strconv.ParseInt(params[constants.ResourceKeySubmissionID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+	sfid, err := strconv.ParseInt(params[constants.ResourceKeyFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission file id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetSubmissionArchiveBrowserPageData(ctx, sid, sfid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.Entries, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/archive-browser.gohtml")
+}
+
+func (a *App) HandleSubmissionArchiveEntryPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	sid, err := strconv.ParseInt(params[constants.ResourceKeySubmissionID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+	sfid, err := strconv.ParseInt(params[constants.ResourceKeyFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission file id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetSubmissionArchiveEntryPageData(ctx, sid, sfid, r.URL.Query().Get("name"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.Entry, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/archive-entry.gohtml")
+}
+
+func (a *App) HandleFlashfreezeArchiveBrowserPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	fid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeRootFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetFlashfreezeArchiveBrowserPageData(ctx, fid)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.Entries, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/archive-browser.gohtml")
+}
+
+func (a *App) HandleFlashfreezeArchiveEntryPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	fid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeRootFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetFlashfreezeArchiveEntryPageData(ctx, fid, r.URL.Query().Get("name"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData.Entry, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData, "templates/archive-entry.gohtml")
+}
+
+func (a *App) HandleUpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := utils.UserID(ctx)
+
+	notificationSettings := &types.UpdateNotificationSettings{}
+
+	if err := a.decoder.Decode(notificationSettings, r.URL.Query()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.UpdateNotificationSettings(ctx, uid, notificationSettings.NotificationActions); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleUpdateSubscriptionSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	uid := utils.UserID(ctx)
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	subscriptionSettings := &types.UpdateSubscriptionSettings{}
+
+	if err := a.decoder.Decode(subscriptionSettings, r.URL.Query()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.UpdateSubscriptionSettings(ctx, uid, sid, subscriptionSettings.Subscribe); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleInternalPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	pageData, err := a.Service.GetInternalPageData(ctx)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, err)
 		return
 	}
 
@@ -1644,94 +2149,312 @@ func (a *App) HandleFlashfreezeReceiverResumable(w http.ResponseWriter, r *http.
 		return
 	}
 
-	var url *string
-	if fid != nil {
-		x := fmt.Sprintf("/flashfreeze/files?file-id=%d", *fid)
-		url = &x
+	var url *string
+	if fid != nil {
+		x := fmt.Sprintf("/flashfreeze/files?file-id=%d", *fid)
+		url = &x
+	}
+
+	resp := types.ReceiveFileResp{
+		Message: "success",
+		URL:     url,
+	}
+	writeResponse(ctx, w, resp, http.StatusOK)
+}
+
+func (a *App) HandleSearchFlashfreezePage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter := &types.FlashfreezeFilter{}
+
+	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := filter.Validate(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr(err.Error(), http.StatusBadRequest))
+		return
+	}
+
+	pageData, err := a.Service.GetSearchFlashfreezeData(ctx, filter)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	if utils.RequestType(ctx) != constants.RequestWeb {
+		writeResponse(ctx, w, pageData, http.StatusOK)
+		return
+	}
+
+	a.RenderTemplates(ctx, w, r, pageData,
+		"templates/flashfreeze-files.gohtml",
+		"templates/flashfreeze-table.gohtml",
+		"templates/flashfreeze-filter.gohtml",
+		"templates/flashfreeze-pagenav.gohtml")
+}
+
+func (a *App) HandleSoftDeleteFlashfreezeRootFile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	fileID := params[constants.ResourceKeyFlashfreezeRootFileID]
+
+	fid, err := strconv.ParseInt(fileID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	deleteReason := r.FormValue("reason")
+	if len(deleteReason) < 3 {
+		writeError(ctx, w, perr("reason must be at least 3 characters long", http.StatusBadRequest))
+		return
+	} else if len(deleteReason) > 255 {
+		writeError(ctx, w, perr("reason cannot be longer than 255 characters", http.StatusBadRequest))
+		return
+	}
+
+	if err := a.Service.SoftDeleteFlashfreezeRootFile(ctx, fid, deleteReason); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, nil, http.StatusNoContent)
+}
+
+func (a *App) HandleUpdateFlashfreezeRootFileMetadata(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	fileID := params[constants.ResourceKeyFlashfreezeRootFileID]
+
+	fid, err := strconv.ParseInt(fileID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	// tags are semicolon-separated, same as in curation meta
+	tags := strings.Split(r.FormValue("tags"), ";")
+
+	if err := a.Service.UpdateFlashfreezeRootFileMetadata(ctx, fid, r.FormValue("description"), tags); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleGetFlashfreezeCollections(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	collections, err := a.Service.GetFlashfreezeCollections(ctx)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, collections, http.StatusOK)
+}
+
+func (a *App) HandleCreateFlashfreezeCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	cid, err := a.Service.CreateFlashfreezeCollection(ctx, r.FormValue("name"), r.FormValue("description"))
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, map[string]int64{"collection_id": cid}, http.StatusOK)
+}
+
+func (a *App) HandleAddFlashfreezeFilesToCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	collectionID := params[constants.ResourceKeyFlashfreezeCollectionID]
+
+	cid, err := strconv.ParseInt(collectionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid collection id", http.StatusBadRequest))
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	fids := make([]int64, 0)
+	for _, fileID := range strings.Split(r.FormValue("file-ids"), ",") {
+		fileID = strings.TrimSpace(fileID)
+		if fileID == "" {
+			continue
+		}
+		fid, err := strconv.ParseInt(fileID, 10, 64)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+			writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+			return
+		}
+		fids = append(fids, fid)
+	}
+	if len(fids) == 0 {
+		writeError(ctx, w, perr("no root file ids provided", http.StatusBadRequest))
+		return
+	}
+
+	if err := a.Service.AddFlashfreezeFilesToCollection(ctx, cid, fids); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("success", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleRemoveFlashfreezeFileFromCollection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+
+	cid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeCollectionID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid collection id", http.StatusBadRequest))
+		return
+	}
+
+	fid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeRootFileID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid root file id", http.StatusBadRequest))
+		return
+	}
+
+	if err := a.Service.RemoveFlashfreezeFileFromCollection(ctx, cid, fid); err != nil {
+		writeError(ctx, w, err)
+		return
 	}
 
-	resp := types.ReceiveFileResp{
-		Message: "success",
-		URL:     url,
-	}
-	writeResponse(ctx, w, resp, http.StatusOK)
+	writeResponse(ctx, w, nil, http.StatusNoContent)
 }
 
-func (a *App) HandleSearchFlashfreezePage(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleSoftDeleteFlashfreezeCollection(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	params := mux.Vars(r)
 
-	filter := &types.FlashfreezeFilter{}
-
-	if err := a.decoder.Decode(filter, r.URL.Query()); err != nil {
+	cid, err := strconv.ParseInt(params[constants.ResourceKeyFlashfreezeCollectionID], 10, 64)
+	if err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		writeError(ctx, w, perr("invalid collection id", http.StatusBadRequest))
 		return
 	}
 
-	if err := filter.Validate(); err != nil {
+	if err := r.ParseForm(); err != nil {
 		utils.LogCtx(ctx).Error(err)
-		writeError(ctx, w, perr(err.Error(), http.StatusBadRequest))
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
 		return
 	}
 
-	pageData, err := a.Service.GetSearchFlashfreezeData(ctx, filter)
-	if err != nil {
-		writeError(ctx, w, err)
+	deleteReason := r.FormValue("reason")
+	if len(deleteReason) < 3 {
+		writeError(ctx, w, perr("reason must be at least 3 characters long", http.StatusBadRequest))
+		return
+	} else if len(deleteReason) > 255 {
+		writeError(ctx, w, perr("reason cannot be longer than 255 characters", http.StatusBadRequest))
 		return
 	}
 
-	if utils.RequestType(ctx) != constants.RequestWeb {
-		writeResponse(ctx, w, pageData, http.StatusOK)
+	if err := a.Service.SoftDeleteFlashfreezeCollection(ctx, cid, deleteReason); err != nil {
+		writeError(ctx, w, err)
 		return
 	}
 
-	a.RenderTemplates(ctx, w, r, pageData,
-		"templates/flashfreeze-files.gohtml",
-		"templates/flashfreeze-table.gohtml",
-		"templates/flashfreeze-filter.gohtml",
-		"templates/flashfreeze-pagenav.gohtml")
+	writeResponse(ctx, w, nil, http.StatusNoContent)
 }
 
-var ingestGuard = make(chan struct{}, 1)
-
 func (a *App) HandleIngestFlashfreeze(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobIngestFlashfreeze)
+}
+
+func (a *App) HandleRecomputeSubmissionCacheAll(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobRecomputeSubmissionCache)
+}
+
+func (a *App) HandleCollectFileBlobGarbage(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobFileBlobCollector)
+}
+
+var checkFileConsistencyGuard = make(chan struct{}, 1)
+
+// HandleCheckFileConsistency compares the stored files with the database in the background,
+// the result can be read from HandleGetFileConsistencyReport once it's done
+func (a *App) HandleCheckFileConsistency(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	verifyHashes := r.URL.Query().Get("verify-hashes") == "true"
+	quarantine := r.URL.Query().Get("quarantine") == "true"
+
+	if quarantine && a.Service.Volumes().Quarantine == nil {
+		writeError(ctx, w, perr("quarantine storage is not configured", http.StatusBadRequest))
+		return
+	}
+
 	select {
-	case ingestGuard <- struct{}{}:
-		utils.LogCtx(ctx).Debug("starting flashfreeze ingestion")
+	case checkFileConsistencyGuard <- struct{}{}:
+		utils.LogCtx(ctx).Debug("starting file consistency check")
 	default:
-		writeResponse(ctx, w, presp("ingestion already running", http.StatusForbidden), http.StatusForbidden)
+		writeResponse(ctx, w, presp("file consistency check already running", http.StatusForbidden), http.StatusForbidden)
 		return
 	}
 
 	go func() {
-		a.Service.IngestFlashfreezeItems(utils.LogCtx(context.WithValue(context.Background(), utils.CtxKeys.Log, utils.LogCtx(ctx))))
-		<-ingestGuard
+		ctx := context.WithValue(context.Background(), utils.CtxKeys.Log, utils.LogCtx(ctx))
+		report, err := a.Service.CheckFileConsistency(ctx, verifyHashes, quarantine)
+		if err != nil {
+			utils.LogCtx(ctx).Error(err)
+		} else {
+			utils.LogCtx(ctx).WithField("checked", report.CheckedFiles).WithField("issues", len(report.Issues)).Info("file consistency check finished")
+		}
+		<-checkFileConsistencyGuard
 	}()
 
-	writeResponse(ctx, w, presp("starting flashfreeze ingestion", http.StatusOK), http.StatusOK)
+	writeResponse(ctx, w, presp("starting file consistency check", http.StatusOK), http.StatusOK)
 }
 
-var recomputeSubmissionCacheAllGuard = make(chan struct{}, 1)
-
-func (a *App) HandleRecomputeSubmissionCacheAll(w http.ResponseWriter, r *http.Request) {
+func (a *App) HandleGetFileConsistencyReport(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	select {
-	case recomputeSubmissionCacheAllGuard <- struct{}{}:
-		utils.LogCtx(ctx).Debug("starting recompute submission cache all")
-	default:
-		writeResponse(ctx, w, presp("recompute submission cache all already running", http.StatusForbidden), http.StatusForbidden)
+	report := a.Service.GetLastFileConsistencyReport()
+	if report == nil {
+		writeError(ctx, w, perr("no file consistency check has finished yet", http.StatusNotFound))
 		return
 	}
 
-	go func() {
-		a.Service.RecomputeSubmissionCacheAll(context.WithValue(context.Background(), utils.CtxKeys.Log, utils.LogCtx(ctx)))
-		<-recomputeSubmissionCacheAllGuard
-	}()
-
-	writeResponse(ctx, w, presp("starting recompute submission cache all", http.StatusOK), http.StatusOK)
+	writeResponse(ctx, w, report, http.StatusOK)
 }
 
 var ingestUnknownGuard = make(chan struct{}, 1)
@@ -1757,25 +2480,8 @@ func (a *App) HandleIngestUnknownFlashfreeze(w http.ResponseWriter, r *http.Requ
 	writeResponse(ctx, w, presp("starting flashfreeze ingestion of unknown files", http.StatusOK), http.StatusOK)
 }
 
-var indexUnindexedGuard = make(chan struct{}, 1)
-
 func (a *App) HandleIndexUnindexedFlashfreeze(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	select {
-	case indexUnindexedGuard <- struct{}{}:
-		utils.LogCtx(ctx).Debug("starting flashfreeze indexing of unindexed files")
-	default:
-		writeResponse(ctx, w, presp("indexing already running", http.StatusForbidden), http.StatusForbidden)
-		return
-	}
-
-	go func() {
-		a.Service.IndexUnindexedFlashfreezeItems(utils.LogCtx(context.WithValue(context.Background(), utils.CtxKeys.Log, utils.LogCtx(ctx))))
-		<-indexUnindexedGuard
-	}()
-
-	writeResponse(ctx, w, presp("starting flashfreeze indexing of unindexed files", http.StatusOK), http.StatusOK)
+	a.triggerScheduledJob(w, r, constants.ScheduledJobIndexUnindexedFlashfreeze)
 }
 
 func (a *App) HandleDeleteUserSessions(w http.ResponseWriter, r *http.Request) {
@@ -1856,15 +2562,102 @@ func (a *App) HandleUserStatisticsPage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) HandleSendRemindersAboutRequestedChanges(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobRequestedChangesReminders)
+}
+
+func (a *App) HandleSweepStalledSubmissions(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobStalledSubmissionSweeper)
+}
+
+func (a *App) HandlePreviewAutounfreeze(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	count, err := a.Service.ProduceRemindersAboutRequestedChanges(ctx)
+	report, err := a.Service.PreviewAutounfreeze(ctx)
 	if err != nil {
 		writeError(ctx, w, err)
 		return
 	}
 
-	writeResponse(ctx, w, presp(fmt.Sprintf("%d notifications added to the queue", count), http.StatusOK), http.StatusOK)
+	writeResponse(ctx, w, report, http.StatusOK)
+}
+
+func (a *App) HandleAutounfreeze(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, constants.ScheduledJobAutounfreezer)
+}
+
+func (a *App) HandleUpdateAutounfreezerPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	req := &types.UpdateAutounfreezerPolicyRequest{}
+
+	if err := a.decoder.Decode(req, r.PostForm); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.UpdateAutounfreezerPolicy(ctx, req.AgeThresholdDays); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("autounfreezer policy updated", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleAddAutounfreezerExemption(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	req := &types.AddAutounfreezerExemptionRequest{}
+
+	if err := a.decoder.Decode(req, r.PostForm); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.AddAutounfreezerExemption(ctx, strings.TrimSpace(req.GameID), req.Reason); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("exemption added", http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleRemoveAutounfreezerExemption(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	req := &types.RemoveAutounfreezerExemptionRequest{}
+
+	if err := a.decoder.Decode(req, r.PostForm); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.RemoveAutounfreezerExemption(ctx, req.GameID); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp("exemption removed", http.StatusOK), http.StatusOK)
 }
 
 func (a *App) HandleGetUsers(w http.ResponseWriter, r *http.Request) {
@@ -1951,10 +2744,16 @@ func (a *App) HandleGetUploadProgress(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 	tempName := params[constants.ResourceKeyTempName]
 
+	status, err := a.Service.GetUploadStatus(ctx, tempName)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
 	data := struct {
 		Status *types.SubmissionStatus `json:"status"`
 	}{
-		a.Service.SSK.Get(tempName),
+		status,
 	}
 
 	writeResponse(ctx, w, data, http.StatusOK)
@@ -2024,6 +2823,34 @@ func (a *App) HandleDeveloperDumpUpload(w http.ResponseWriter, r *http.Request)
 	writeResponse(ctx, w, nil, http.StatusNoContent)
 }
 
+// gameDeleteReason checks the reason of a game deletion, and adds the redirect to the reason of a duplicate
+func gameDeleteReason(reason, destId string) (string, error) {
+	validReasons := constants.GetValidDeleteReasons()
+
+	if !isElementExist(validReasons, reason) {
+		return "", perr(fmt.Sprintf("reason query param must be of [%s], got %s", strings.Join(validReasons, ", "), reason), http.StatusBadRequest)
+	}
+
+	if reason == "Duplicate" {
+		if destId == "" {
+			return "", perr("Redirect ID must be provided when deleting a duplicate game", http.StatusBadRequest)
+		}
+		reason += " - Redirect to " + destId
+	}
+
+	return reason, nil
+}
+
+func checkGameRestoreReason(reason string) error {
+	validReasons := constants.GetValidRestoreReasons()
+
+	if !isElementExist(validReasons, reason) {
+		return perr(fmt.Sprintf("reason query param must be of [%s], got %s", strings.Join(validReasons, ", "), reason), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
 func isElementExist(s []string, str string) bool {
 	for _, v := range s {
 		if v == str {
@@ -2053,6 +2880,26 @@ func (a *App) HandleFreezeSubmission(w http.ResponseWriter, r *http.Request) {
 	writeResponse(ctx, w, nil, http.StatusNoContent)
 }
 
+func (a *App) HandlePublishSubmission(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := mux.Vars(r)
+	submissionID := params[constants.ResourceKeySubmissionID]
+
+	sid, err := strconv.ParseInt(submissionID, 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid submission id", http.StatusBadRequest))
+		return
+	}
+
+	if err := a.Service.PublishSubmission(ctx, sid); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, nil, http.StatusNoContent)
+}
+
 func (a *App) HandleUnfreezeSubmission(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	params := mux.Vars(r)
@@ -2093,6 +2940,54 @@ func (a *App) HandleStat(w http.ResponseWriter, r *http.Request) {
 	writeResponse(ctx, w, map[string]interface{}{"postgres": stat}, http.StatusOK)
 }
 
+// hasMetricsToken tells whether the request carries the configured metrics token
+func (a *App) hasMetricsToken(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return a.Conf.MetricsToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.Conf.MetricsToken)) == 1
+}
+
+// HandleMetrics serves the prometheus metrics to scrapers which know the configured token
+func (a *App) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !a.hasMetricsToken(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	metrics.Handler().ServeHTTP(w, r)
+}
+
+// HandleHealthz tells that the process is alive
+func (a *App) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	writeResponse(ctx, w, map[string]string{"status": "ok"}, http.StatusOK)
+}
+
+// HandleReadyz tells whether the instance can serve, with the outcome of every dependency check.
+// The errors and durations of the checks are only shown to the holders of the metrics token, they are logged anyway.
+func (a *App) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	report := a.Service.CheckReadiness(ctx, a.Conf.FlashpointSourceOnlyMode)
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	if !a.hasMetricsToken(r) {
+		public := &types.ReadinessReport{
+			Ready:  report.Ready,
+			Checks: make([]*types.ReadinessCheck, 0, len(report.Checks)),
+		}
+		for _, c := range report.Checks {
+			public.Checks = append(public.Checks, &types.ReadinessCheck{Name: c.Name, Ready: c.Ready})
+		}
+		report = public
+	}
+
+	writeResponse(ctx, w, report, status)
+}
+
 func (a *App) HandleGetActivityEvents(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -2146,14 +3041,14 @@ func (a *App) HandleRecommendationEngine(w http.ResponseWriter, r *http.Request)
 	}
 	r.Body.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/%s", a.Conf.RecommendationEngineURL, recommendationOperation), bytes.NewBuffer(originalBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/%s", a.Conf.Reloadable().RecommendationEngineURL, recommendationOperation), bytes.NewBuffer(originalBody))
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
 		writeError(ctx, w, err)
 		return
 	}
 
-	client := &http.Client{}
+	client := &http.Client{Transport: tracing.NewTransport(nil)}
 	resp, err := client.Do(req)
 	if err != nil {
 		utils.LogCtx(ctx).Error(err)
@@ -2184,3 +3079,64 @@ func (a *App) HandleRecommendationPlaygroundPage(w http.ResponseWriter, r *http.
 
 	a.RenderTemplates(ctx, w, r, pageData, "templates/recommendation-playground.gohtml")
 }
+
+// triggerScheduledJob queues a run of the job, the scheduler runs it unless it's already running
+func (a *App) triggerScheduledJob(w http.ResponseWriter, r *http.Request, name string) {
+	ctx := r.Context()
+
+	if err := a.Service.TriggerScheduledJob(ctx, name); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp(fmt.Sprintf("job %s queued, see its runs on /web/internal", name), http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleTriggerScheduledJob(w http.ResponseWriter, r *http.Request) {
+	a.triggerScheduledJob(w, r, mux.Vars(r)[constants.ResourceKeyScheduledJobName])
+}
+
+func (a *App) HandleUpdateScheduledJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := mux.Vars(r)[constants.ResourceKeyScheduledJobName]
+
+	if err := r.ParseForm(); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to parse form", http.StatusBadRequest))
+		return
+	}
+
+	req := &types.UpdateScheduledJobRequest{}
+
+	if err := a.decoder.Decode(req, r.PostForm); err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to decode query params", http.StatusInternalServerError))
+		return
+	}
+
+	if err := a.Service.UpdateScheduledJob(ctx, name, req.Schedule, req.Enabled); err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, presp(fmt.Sprintf("job %s updated", name), http.StatusOK), http.StatusOK)
+}
+
+func (a *App) HandleGetScheduledJobRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	runID, err := strconv.ParseInt(mux.Vars(r)[constants.ResourceKeyScheduledJobRunID], 10, 64)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("invalid run id", http.StatusBadRequest))
+		return
+	}
+
+	run, err := a.Service.GetScheduledJobRun(ctx, runID)
+	if err != nil {
+		writeError(ctx, w, err)
+		return
+	}
+
+	writeResponse(ctx, w, run, http.StatusOK)
+}
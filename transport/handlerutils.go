@@ -41,6 +41,7 @@ func (a *App) RenderTemplates(ctx context.Context, w http.ResponseWriter, r *htt
 		"isAdder":                       constants.IsAdder,
 		"isInAudit":                     constants.IsInAudit,
 		"isGod":                         constants.IsGod,
+		"isReviewAdmin":                 constants.IsReviewAdmin,
 		"sizeToString":                  utils.SizeToString,
 		"splitMultilineText":            utils.SplitMultilineText,
 		"capitalizeAscii":               utils.CapitalizeASCII,
@@ -50,6 +51,7 @@ func (a *App) RenderTemplates(ctx context.Context, w http.ResponseWriter, r *htt
 		"capString":                     capString,
 		"er":                            equalReference,
 		"ner":                           notEqualReference,
+		"eir":                           equalInt64Reference,
 		"msTime":                        milliTime,
 		"localeNum":                     localeNum,
 	})
@@ -270,6 +272,10 @@ func notEqualReference(ref *string, str string) bool {
 	}
 }
 
+func equalInt64Reference(ref *int64, i int64) bool {
+	return ref != nil && *ref == i
+}
+
 func milliTime(date time.Time) int64 {
 	return date.UnixMilli()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/FlashpointProject/flashpoint-submission-system/service"
 	"github.com/FlashpointProject/flashpoint-submission-system/types"
 	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+	"github.com/FlashpointProject/flashpoint-submission-system/workflow"
 	"github.com/gorilla/mux"
 	"golang.org/x/exp/slices"
 )
@@ -428,7 +429,7 @@ func (a *App) IsUserWithinResourceLimit(r *http.Request, uid int64, resourceKey
 	return true, nil
 }
 
-// UserCanCommentAction accepts user that has all of requiredRoles and owns given resource(s)
+// UserCanCommentAction accepts user whose roles allow the comment action in the review workflow
 func (a *App) UserCanCommentAction(r *http.Request, uid int64) (bool, error) {
 	if err := r.ParseForm(); err != nil {
 		return false, err
@@ -449,34 +450,8 @@ func (a *App) UserCanCommentAction(r *http.Request, uid int64) (bool, error) {
 
 	formAction := r.FormValue("action")
 
-	canDo := func(actions, roles []string) bool {
-		for _, action := range actions {
-			if action == formAction {
-				for _, userRole := range userRoles.([]string) {
-					hasRole := false
-					for _, role := range roles {
-						if role == userRole {
-							hasRole = true
-							break
-						}
-					}
-					if hasRole {
-						return true
-					}
-				}
-				break
-			}
-		}
-		return false
-	}
-
-	canComment := formAction == constants.ActionComment
-	isAdder := canDo([]string{constants.ActionMarkAdded}, constants.AdderRoles())
-	isDecider := canDo([]string{constants.ActionApprove, constants.ActionRequestChanges,
-		constants.ActionVerify, constants.ActionAssignTesting, constants.ActionUnassignTesting,
-		constants.ActionAssignVerification, constants.ActionUnassignVerification, constants.ActionReject}, constants.DeciderRoles())
-
-	return canComment || isAdder || isDecider, nil
+	// the roles of the actions don't depend on the review requirements
+	return workflow.Default().Allows(userRoles.([]string), formAction), nil
 }
 
 // IsResourceFrozen accepts resource that is frozen, or if any of them is when multiple are given
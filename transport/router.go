@@ -28,6 +28,12 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 	isFreezer := func(r *http.Request, uid int64) (bool, error) {
 		return a.UserHasAnyRole(r, uid, constants.FreezerRoles())
 	}
+	isDecider := func(r *http.Request, uid int64) (bool, error) {
+		return a.UserHasAnyRole(r, uid, constants.DeciderRoles())
+	}
+	isReviewAdmin := func(r *http.Request, uid int64) (bool, error) {
+		return a.UserHasAnyRole(r, uid, constants.ReviewAdminRoles())
+	}
 	isInAudit := func(r *http.Request, uid int64) (bool, error) {
 		s, err := a.UserHasAnyRole(r, uid, constants.StaffRoles())
 		if err != nil {
@@ -295,6 +301,27 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 
 	////////////////////////
 
+	f = a.HandleMetadataSnapshot
+
+	router.Handle(
+		"/api/metadata-snapshot",
+		http.HandlerFunc(a.RequestJSON(f, true))).
+		Methods("GET")
+
+	f = a.HandleDownloadMetadataSnapshot
+
+	router.Handle(
+		fmt.Sprintf("/api/metadata-snapshot/{%s}", constants.ResourceKeyMetadataSnapshotFormat),
+		http.HandlerFunc(a.RequestData(f, true))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/metadata-snapshots/{%s}/{%s}", constants.ResourceKeyMetadataSnapshotVersion, constants.ResourceKeyMetadataSnapshotFormat),
+		http.HandlerFunc(a.RequestData(f, true))).
+		Methods("GET")
+
+	////////////////////////
+
 	f = a.HandleGameCountSinceDate
 
 	router.Handle(
@@ -549,6 +576,26 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 		http.HandlerFunc(a.RequestJSON(f, false))).
 		Methods("GET")
 
+	// the diff reads the content of the files, so it's guarded like downloading them
+	f = a.UserAuthMux(
+		a.RequestScope(a.HandleSubmissionDiffPage, types.AuthScopeSubmissionReadFiles),
+		muxAny(
+			muxAll(muxNot(isSubmissionFrozen), isStaff),
+			muxAll(muxNot(isSubmissionFrozen), isTrialCurator),
+			muxAll(muxNot(isSubmissionFrozen), isInAudit),
+			muxAll(isSubmissionFrozen, isFreezer, muxAny(isStaff, isTrialCurator, isInAudit)),
+		))
+
+	router.Handle(
+		fmt.Sprintf("/web/submission/{%s}/diff", constants.ResourceKeySubmissionID),
+		http.HandlerFunc(a.RequestWeb(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/submission/{%s}/diff", constants.ResourceKeySubmissionID),
+		http.HandlerFunc(a.RequestJSON(f, false))).
+		Methods("GET")
+
 	////////////////////////
 
 	//f = a.UserAuthMux(
@@ -639,6 +686,18 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 				muxAll(isInAudit, userOwnsSubmission))), false))).
 		Methods("GET")
 
+	router.Handle(
+		fmt.Sprintf("/api/submission/{%s}/curation-meta", constants.ResourceKeySubmissionID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleEditCurationMeta, types.AuthScopeSubmissionUpload),
+			muxAll(
+				muxNot(isSubmissionFrozen),
+				muxAny(
+					isStaff,
+					muxAll(isTrialCurator, userOwnsSubmission),
+					muxAll(isInAudit, userOwnsSubmission)))), false))).
+		Methods("POST")
+
 	////////////////////////
 
 	// flashfreeze disabled for now
@@ -761,6 +820,298 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
 		Methods("GET")
 
+	// archive browser, guarded like downloading the whole file
+
+	f = a.UserAuthMux(
+		a.RequestScope(a.HandleSubmissionArchiveBrowserPage, types.AuthScopeSubmissionReadFiles),
+		muxAny(
+			muxAll(muxNot(isSubmissionFrozen), isStaff),
+			muxAll(muxNot(isSubmissionFrozen), isTrialCurator),
+			muxAll(muxNot(isSubmissionFrozen), isInAudit),
+			muxAll(isSubmissionFrozen, isFreezer, muxAny(isStaff, isTrialCurator, isInAudit)),
+		))
+
+	router.Handle(
+		fmt.Sprintf("/web/submission/{%s}/file/{%s}/browse", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
+		http.HandlerFunc(a.RequestWeb(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/submission/{%s}/file/{%s}/browse", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
+		http.HandlerFunc(a.RequestJSON(f, false))).
+		Methods("GET")
+
+	f = a.UserAuthMux(
+		a.RequestScope(a.HandleSubmissionArchiveEntryPage, types.AuthScopeSubmissionReadFiles),
+		muxAny(
+			muxAll(muxNot(isSubmissionFrozen), isStaff),
+			muxAll(muxNot(isSubmissionFrozen), isTrialCurator),
+			muxAll(muxNot(isSubmissionFrozen), isInAudit),
+			muxAll(isSubmissionFrozen, isFreezer, muxAny(isStaff, isTrialCurator, isInAudit)),
+		))
+
+	router.Handle(
+		fmt.Sprintf("/web/submission/{%s}/file/{%s}/browse/entry", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
+		http.HandlerFunc(a.RequestWeb(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/submission/{%s}/file/{%s}/browse/entry", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
+		http.HandlerFunc(a.RequestJSON(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/data/submission/{%s}/file/{%s}/browse/entry", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
+		http.HandlerFunc(a.RequestData(a.UserAuthMux(
+			a.RequestScope(a.HandleDownloadSubmissionArchiveEntry, types.AuthScopeSubmissionReadFiles),
+			muxAny(
+				muxAll(muxNot(isSubmissionFrozen), isStaff),
+				muxAll(muxNot(isSubmissionFrozen), isTrialCurator),
+				muxAll(muxNot(isSubmissionFrozen), isInAudit),
+				muxAll(isSubmissionFrozen, isFreezer, muxAny(isStaff, isTrialCurator, isInAudit)),
+			)), false))).
+		Methods("GET")
+
+	f = a.UserAuthMux(
+		a.RequestScope(a.HandleFlashfreezeArchiveBrowserPage, types.AuthScopeSubmissionReadFiles),
+		muxAny(isStaff, isTrialCurator, isInAudit))
+
+	router.Handle(
+		fmt.Sprintf("/web/flashfreeze/file/{%s}/browse", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestWeb(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/file/{%s}/browse", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestJSON(f, false))).
+		Methods("GET")
+
+	f = a.UserAuthMux(
+		a.RequestScope(a.HandleFlashfreezeArchiveEntryPage, types.AuthScopeSubmissionReadFiles),
+		muxAny(isStaff, isTrialCurator, isInAudit))
+
+	router.Handle(
+		fmt.Sprintf("/web/flashfreeze/file/{%s}/browse/entry", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestWeb(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/file/{%s}/browse/entry", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestJSON(f, false))).
+		Methods("GET")
+
+	router.Handle(
+		fmt.Sprintf("/data/flashfreeze/file/{%s}/browse/entry", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestData(a.UserAuthMux(
+			a.RequestScope(a.HandleDownloadFlashfreezeArchiveEntry, types.AuthScopeSubmissionReadFiles),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("GET")
+
+	// flashfreeze metadata
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/file/{%s}", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSoftDeleteFlashfreezeRootFile, types.AuthScopeAll),
+			isDeleter), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/file/{%s}/metadata", constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleUpdateFlashfreezeRootFileMetadata, types.AuthScopeAll),
+			isStaff), false))).
+		Methods("POST")
+
+	router.Handle(
+		"/web/review-requirements",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewRequirementsPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-requirements",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewRequirementsPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-requirements",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSaveReviewRequirement, types.AuthScopeAll),
+			isReviewAdmin), false))).
+		Methods("POST")
+
+	router.Handle(
+		fmt.Sprintf("/api/review-requirement/{%s}", constants.ResourceKeyReviewRequirementID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleDeleteReviewRequirement, types.AuthScopeAll),
+			isReviewAdmin), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		"/web/review-checklists",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewChecklistsPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-checklists",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewChecklistsPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-checklists",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSaveReviewChecklistItem, types.AuthScopeAll),
+			isReviewAdmin), false))).
+		Methods("POST")
+
+	router.Handle(
+		fmt.Sprintf("/api/review-checklist-item/{%s}", constants.ResourceKeyReviewChecklistItemID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleDeleteReviewChecklistItem, types.AuthScopeAll),
+			isReviewAdmin), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		"/web/submission-templates",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleSubmissionTemplatesPage, types.AuthScopeSubmissionRead),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/submission-templates",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSubmissionTemplatesPage, types.AuthScopeSubmissionRead),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/submission-templates",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSaveSubmissionTemplate, types.AuthScopeSubmissionUpload),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("POST")
+
+	router.Handle(
+		fmt.Sprintf("/api/submission-template/{%s}", constants.ResourceKeySubmissionTemplateID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleDeleteSubmissionTemplate, types.AuthScopeSubmissionUpload),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		"/web/curation-lint-rules",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleCurationLintRulesPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/curation-lint-rules",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleCurationLintRulesPage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/curation-lint-rules",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSaveCurationLintRule, types.AuthScopeAll),
+			isReviewAdmin), false))).
+		Methods("POST")
+
+	router.Handle(
+		"/web/submission-processing-jobs",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleSubmissionProcessingJobsPage, types.AuthScopeSubmissionRead),
+			isGod), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/submission-processing-jobs",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSubmissionProcessingJobsPage, types.AuthScopeSubmissionRead),
+			isGod), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/web/review-queue",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewQueuePage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-queue",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleReviewQueuePage, types.AuthScopeSubmissionRead),
+			isStaff), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/review-queue/member",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleJoinReviewQueue, types.AuthScopeAll),
+			isDecider), false))).
+		Methods("POST")
+
+	router.Handle(
+		"/api/review-queue/member",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleLeaveReviewQueue, types.AuthScopeAll),
+			isDecider), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		"/api/review-queue/next",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleAssignNextQueuedSubmission, types.AuthScopeAll),
+			isDecider), false))).
+		Methods("POST")
+
+	router.Handle(
+		"/api/flashfreeze/collections",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleGetFlashfreezeCollections, types.AuthScopeFlashfreezeRead),
+			muxAny(isStaff, isTrialCurator, isInAudit)), false))).
+		Methods("GET")
+
+	router.Handle(
+		"/api/flashfreeze/collections",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleCreateFlashfreezeCollection, types.AuthScopeAll),
+			isStaff), false))).
+		Methods("POST")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/collection/{%s}/files", constants.ResourceKeyFlashfreezeCollectionID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleAddFlashfreezeFilesToCollection, types.AuthScopeAll),
+			isStaff), false))).
+		Methods("POST")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/collection/{%s}/file/{%s}", constants.ResourceKeyFlashfreezeCollectionID, constants.ResourceKeyFlashfreezeRootFileID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleRemoveFlashfreezeFileFromCollection, types.AuthScopeAll),
+			isStaff), false))).
+		Methods("DELETE")
+
+	router.Handle(
+		fmt.Sprintf("/api/flashfreeze/collection/{%s}", constants.ResourceKeyFlashfreezeCollectionID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandleSoftDeleteFlashfreezeCollection, types.AuthScopeAll),
+			isDeleter), false))).
+		Methods("DELETE")
+
 	// soft delete
 	router.Handle(
 		fmt.Sprintf("/api/submission/{%s}/file/{%s}", constants.ResourceKeySubmissionID, constants.ResourceKeyFileID),
@@ -794,6 +1145,16 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 
 	// freeze
 
+	router.Handle(
+		fmt.Sprintf("/api/submission/{%s}/publish", constants.ResourceKeySubmissionID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
+			a.RequestScope(a.HandlePublishSubmission, types.AuthScopeSubmissionUpload),
+			muxAny(
+				muxAll(isStaff, userOwnsSubmission),
+				muxAll(isTrialCurator, userOwnsSubmission),
+				muxAll(isInAudit, userOwnsSubmission))), false))).
+		Methods("POST")
+
 	router.Handle(
 		fmt.Sprintf("/api/submission/{%s}/freeze", constants.ResourceKeySubmissionID),
 		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(
@@ -902,6 +1263,18 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleIndexUnindexedFlashfreeze, types.AuthScopeAll), isGod), false))).
 		Methods("GET")
 
+	router.Handle("/api/internal/collect-file-blob-garbage",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleCollectFileBlobGarbage, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
+	router.Handle("/api/internal/check-file-consistency",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleCheckFileConsistency, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
+	router.Handle("/api/internal/file-consistency-report",
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(a.RequestScope(a.HandleGetFileConsistencyReport, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
 	router.Handle("/api/internal/delete-user-sessions",
 		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleDeleteUserSessions, types.AuthScopeAll), isGod), false))).
 		Methods("POST")
@@ -910,6 +1283,42 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleSendRemindersAboutRequestedChanges, types.AuthScopeAll), isGod), false))).
 		Methods("GET")
 
+	router.Handle("/api/internal/sweep-stalled-submissions",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleSweepStalledSubmissions, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
+	router.Handle("/api/internal/autounfreezer/preview",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandlePreviewAutounfreeze, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
+	router.Handle("/api/internal/autounfreezer/run",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleAutounfreeze, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
+	router.Handle("/api/internal/autounfreezer/policy",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleUpdateAutounfreezerPolicy, types.AuthScopeAll), isGod), false))).
+		Methods("POST")
+
+	router.Handle("/api/internal/autounfreezer/exemptions",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleAddAutounfreezerExemption, types.AuthScopeAll), isGod), false))).
+		Methods("POST")
+
+	router.Handle("/api/internal/autounfreezer/exemptions/delete",
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleRemoveAutounfreezerExemption, types.AuthScopeAll), isGod), false))).
+		Methods("POST")
+
+	router.Handle(fmt.Sprintf("/api/internal/scheduled-jobs/{%s}", constants.ResourceKeyScheduledJobName),
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleUpdateScheduledJob, types.AuthScopeAll), isGod), false))).
+		Methods("POST")
+
+	router.Handle(fmt.Sprintf("/api/internal/scheduled-jobs/{%s}/run", constants.ResourceKeyScheduledJobName),
+		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleTriggerScheduledJob, types.AuthScopeAll), isGod), false))).
+		Methods("POST")
+
+	router.Handle(fmt.Sprintf("/api/internal/scheduled-job-runs/{%s}", constants.ResourceKeyScheduledJobRunID),
+		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(a.RequestScope(a.HandleGetScheduledJobRun, types.AuthScopeAll), isGod), false))).
+		Methods("GET")
+
 	router.Handle("/api/internal/nuke-session-table",
 		http.HandlerFunc(a.RequestWeb(a.UserAuthMux(a.RequestScope(a.HandleNukeSessionTable, types.AuthScopeAll), isGod), false))).
 		Methods("GET")
@@ -918,8 +1327,19 @@ func (a *App) handleRequests(l *logrus.Entry, srv *http.Server, router *mux.Rout
 		http.HandlerFunc(a.RequestJSON(a.UserAuthMux(a.RequestScope(a.HandleStat, types.AuthScopeAll), isGod), false))).
 		Methods("GET")
 
+	// probes for orchestrators
+	router.Handle("/healthz", http.HandlerFunc(a.RequestJSON(a.HandleHealthz, true))).
+		Methods("GET")
+	router.Handle("/readyz", http.HandlerFunc(a.RequestJSON(a.HandleReadyz, true))).
+		Methods("GET")
+
+	if a.Conf.MetricsToken != "" {
+		router.Handle("/metrics", http.HandlerFunc(a.HandleMetrics)).
+			Methods("GET")
+	}
+
 	err := srv.ListenAndServe()
-	if err != nil {
+	if err != nil && err != http.ErrServerClosed {
 		l.Fatal(err)
 	}
 }
@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/config"
+	"github.com/FlashpointProject/flashpoint-submission-system/service"
+	"github.com/FlashpointProject/flashpoint-submission-system/storage"
+	"github.com/FlashpointProject/flashpoint-submission-system/utils"
+)
+
+const presignedDownloadExpiry = 15 * time.Minute
+
+// newStorageVolumes creates the storages according to the configured driver.
+// The s3 driver keeps every volume under a fixed prefix of one bucket.
+func newStorageVolumes(conf *config.Config) (*service.StorageVolumes, error) {
+	switch conf.StorageDriver {
+	case "", "local":
+		volumes := &service.StorageVolumes{
+			Blobs:            storage.NewLocal(conf.BlobStoreDirFullPath),
+			Submissions:      storage.NewLocal(conf.SubmissionsDirFullPath),
+			SubmissionImages: storage.NewLocal(conf.SubmissionImagesDirFullPath),
+			Flashfreeze:      storage.NewLocal(conf.FlashfreezeDirFullPath),
+			DataPacks:        storage.NewLocal(conf.DataPacksDir),
+			FrozenPacks:      storage.NewLocal(conf.FrozenPacksDir),
+			DeletedDataPacks: storage.NewLocal(conf.DeletedDataPacksDir),
+			Images:           storage.NewLocal(conf.ImagesDir),
+			DeletedImages:    storage.NewLocal(conf.DeletedImagesDir),
+		}
+		if conf.QuarantineDirFullPath != "" {
+			volumes.Quarantine = storage.NewLocal(conf.QuarantineDirFullPath)
+		}
+		if conf.MetadataSnapshotsDirFullPath != "" {
+			volumes.MetadataSnapshots = storage.NewLocal(conf.MetadataSnapshotsDirFullPath)
+		}
+		return volumes, nil
+	case "s3":
+		client, err := storage.NewS3Client(conf.S3Endpoint, conf.S3AccessKeyID, conf.S3SecretAccessKey, conf.S3Region, conf.S3UseSSL)
+		if err != nil {
+			return nil, err
+		}
+		volume := func(prefix string) storage.Storage {
+			return storage.NewS3(client, conf.S3Bucket, prefix, conf.S3StagingDir)
+		}
+		return &service.StorageVolumes{
+			Blobs:             volume("blobs"),
+			Submissions:       volume("submissions"),
+			SubmissionImages:  volume("submission-images"),
+			Flashfreeze:       volume("flashfreeze"),
+			DataPacks:         volume("data-packs"),
+			FrozenPacks:       volume("frozen-packs"),
+			DeletedDataPacks:  volume("deleted-data-packs"),
+			Images:            volume("images"),
+			DeletedImages:     volume("deleted-images"),
+			Quarantine:        volume("quarantine"),
+			MetadataSnapshots: volume("metadata-snapshots"),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage driver '%s'", conf.StorageDriver)
+}
+
+// serveStoredFile serves the stored object, or redirects to a presigned url when those are enabled and supported
+func (a *App) serveStoredFile(ctx context.Context, w http.ResponseWriter, r *http.Request, st storage.Storage, key, filename string, attachment bool) {
+	if a.Conf.Reloadable().StoragePresignedDownloads {
+		dispositionFilename := ""
+		if attachment {
+			dispositionFilename = filename
+		}
+		u, err := st.PresignGet(ctx, key, dispositionFilename, presignedDownloadExpiry)
+		if err == nil {
+			http.Redirect(w, r, u, http.StatusFound)
+			return
+		}
+		if !errors.Is(err, storage.ErrPresignUnsupported) {
+			utils.LogCtx(ctx).Error(err)
+		}
+	}
+
+	obj, info, err := st.Open(ctx, key)
+	if err != nil {
+		utils.LogCtx(ctx).Error(err)
+		writeError(ctx, w, perr("failed to read file", http.StatusInternalServerError))
+		return
+	}
+	defer obj.Close()
+
+	if attachment {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		w.Header().Set("Content-Type", "application/octet-stream")
+	} else {
+		w.Header().Set("Content-Type", "image")
+	}
+	http.ServeContent(w, r, filename, info.ModTime, obj)
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type BasePageData struct {
 	Username      string
 	UserID        int64
@@ -95,12 +97,21 @@ type GameRedirectsPageData struct {
 	GameRedirects []*GameRedirect
 }
 
+type ReviewRequirementsPageData struct {
+	BasePageData
+	ReviewRequirements []*ReviewRequirement
+	SubmissionLevels   []string
+	Roles              []string
+}
+
 type SubmissionsPageData struct {
 	BasePageData
 	Submissions  []*ExtendedSubmission
 	TotalCount   int64
 	Filter       SubmissionsFilter
 	FilterLayout string
+	// ReviewChecklistItems are all checks, submissions can be filtered by the ones they failed
+	ReviewChecklistItems []*ReviewChecklistItem
 }
 
 type ApplyContentPatchPageData struct {
@@ -119,6 +130,9 @@ type ViewSubmissionPageData struct {
 	NextSubmissionID     *int64
 	PreviousSubmissionID *int64
 	TagList              []Tag
+	ReviewChecklist      []*ReviewChecklistItem // checks which apply to the submission
+	EditableMetaFields   []*CurationMetaField   // fields of the curation meta editor
+	SubmissionTemplates  []*SubmissionTemplate  // templates of the user which can fill in the curation meta editor
 }
 
 type SubmissionsFilesPageData struct {
@@ -126,11 +140,32 @@ type SubmissionsFilesPageData struct {
 	SubmissionFiles []*ExtendedSubmissionFile
 }
 
+type SubmissionDiffPageData struct {
+	BasePageData
+	SubmissionFiles []*ExtendedSubmissionFile
+	Diff            *SubmissionFileDiff
+}
+
+type ArchiveBrowserPageData struct {
+	BasePageData
+	ArchiveName string
+	FileURL     string // path of the file below /data, the browser is below /web and /api, at FileURL/browse
+	Entries     []*ArchiveBrowserEntry
+}
+
+type ArchiveEntryPageData struct {
+	BasePageData
+	ArchiveName string
+	FileURL     string
+	Entry       *ArchiveEntryDetails
+}
+
 type SearchFlashfreezePageData struct {
 	BasePageData
 	FlashfreezeFiles []*ExtendedFlashfreezeItem
 	TotalCount       int64
 	Filter           FlashfreezeFilter
+	Collections      []*FlashfreezeCollection
 }
 
 type StatisticsPageData struct {
@@ -168,3 +203,45 @@ type DeviceAuthPageData struct {
 	States DeviceAuthStates
 	Scopes []AuthScope
 }
+
+type ReviewQueuePageData struct {
+	BasePageData
+	Member            *ReviewQueueMember // the current user, nil if not in the queue
+	Members           []*ReviewQueueMember
+	OpenAssignments   []*ReviewQueueAssignment
+	RecentAssignments []*ReviewQueueAssignment
+	OutcomeCounts     map[string]int64
+	Backlog           int64
+	OldestWaitingAt   *time.Time
+	IdleTimeoutHours  int64
+	SubmissionLevels  []string
+}
+
+type ReviewChecklistsPageData struct {
+	BasePageData
+	ReviewChecklistItems []*ReviewChecklistItem
+}
+
+type SubmissionTemplatesPageData struct {
+	BasePageData
+	SubmissionTemplates []*SubmissionTemplate
+	MetaFields          []string // meta.yaml keys a template can fill in
+}
+
+type CurationLintRulesPageData struct {
+	BasePageData
+	CurationLintRules []*CurationLintRule
+}
+
+type SubmissionProcessingJobsPageData struct {
+	BasePageData
+	SubmissionProcessingJobs []*SubmissionProcessingJob
+}
+
+type InternalPageData struct {
+	BasePageData
+	AutounfreezerPolicy     *AutounfreezerPolicy
+	AutounfreezerExemptions []*AutounfreezerExemption
+	ScheduledJobs           []*ScheduledJob
+	ScheduledJobRuns        []*ScheduledJobRun
+}
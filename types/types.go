@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -78,6 +79,7 @@ type Comment struct {
 	Action       string
 	Message      *string
 	CreatedAt    time.Time
+	TargetUserID *int64 // user an action of the system applies to instead of the author, e.g. the unassigned tester
 }
 
 type SubmissionFile struct {
@@ -120,7 +122,7 @@ type ExtendedSubmission struct {
 	CurrentFilename             string    // newest file
 	Size                        int64     // newest file
 	UploadedAt                  time.Time // oldest file
-	UpdatedAt                   time.Time // newest file
+	UpdatedAt                   time.Time // newest comment
 	LastUploaderID              int64     // newest file
 	CurationTitle               *string   // newest file
 	CurationAlternateTitles     *string   // newest file
@@ -138,7 +140,120 @@ type ExtendedSubmission struct {
 	DistinctActions             []string
 	GameExists                  bool
 	IsFrozen                    bool
+	IsDraft                     bool
 	ShouldAutofreeze            bool
+	ReviewProgress              *ReviewProgress
+	AvailableActions            []string // comment actions the current user can perform
+}
+
+// ReviewRequirement is what submissions of given level and platform need before they can be marked as added.
+// Empty level or platform matches all of them, the most specific requirement wins.
+type ReviewRequirement struct {
+	ID                    int64     `json:"id"`
+	SubmissionLevel       string    `json:"submission_level"`
+	Platform              string    `json:"platform"`
+	RequiredApprovals     int       `json:"required_approvals"`
+	RequiredVerifications int       `json:"required_verifications"`
+	RequiredRole          string    `json:"required_role"` // only users with this role can approve and verify, empty means any decider
+	UpdatedBy             int64     `json:"updated_by"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// ReviewProgress is how far a submission is in the review
+type ReviewProgress struct {
+	State                 string `json:"state"`
+	Approvals             int    `json:"approvals"`
+	RequiredApprovals     int    `json:"required_approvals"`
+	Verifications         int    `json:"verifications"`
+	RequiredVerifications int    `json:"required_verifications"`
+	RequiredRole          string `json:"required_role"`
+}
+
+// ReviewQueueMember is a tester who takes submissions to test from the review queue
+type ReviewQueueMember struct {
+	UserID           int64     `json:"user_id"`
+	Username         string    `json:"username"`
+	Platforms        []string  `json:"platforms"`         // platforms the tester knows, empty means any
+	SubmissionLevels []string  `json:"submission_levels"` // empty means any
+	MaxAssignments   int64     `json:"max_assignments"`   // the queue does not give more submissions to a tester assigned to this many
+	JoinedAt         time.Time `json:"joined_at"`
+	Load             int64     `json:"load"` // submissions the tester is currently assigned to test, not only those from the queue
+}
+
+const (
+	ReviewQueueOutcomeApproved   = "approved"
+	ReviewQueueOutcomeUnassigned = "unassigned"
+	ReviewQueueOutcomeExpired    = "expired"
+)
+
+// ReviewQueueAssignment is a submission the review queue assigned to a tester
+type ReviewQueueAssignment struct {
+	ID             int64      `json:"id"`
+	SubmissionID   int64      `json:"submission_id"`
+	UserID         int64      `json:"user_id"`
+	Username       string     `json:"username"`
+	AssignedAt     time.Time  `json:"assigned_at"`
+	LastActivityAt time.Time  `json:"last_activity_at"` // newest comment of the tester on the submission
+	FinishedAt     *time.Time `json:"finished_at"`
+	Outcome        *string    `json:"outcome"`
+}
+
+// ReviewChecklistItem is a check reviewers go through when approving or requesting changes on a submission.
+// Empty platform means the check applies to all submissions.
+type ReviewChecklistItem struct {
+	ID        int64     `json:"id"`
+	Platform  string    `json:"platform"`
+	Label     string    `json:"label"`
+	Position  int64     `json:"position"`
+	UpdatedBy int64     `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	ChecklistPass          = "pass"
+	ChecklistFail          = "fail"
+	ChecklistNotApplicable = "n/a"
+)
+
+// ChecklistResult is the outcome of a single check, stored with the comment of the reviewer
+type ChecklistResult struct {
+	ItemID int64  `json:"item_id"`
+	Label  string `json:"label"`
+	Result string `json:"result"`
+}
+
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// CurationLintRule is a built-in check of the curation meta, admins can turn it off, change its severity and its parameter.
+// Rules which were never changed have no UpdatedAt.
+type CurationLintRule struct {
+	Rule          string     `json:"rule"`
+	Description   string     `json:"description"`
+	Enabled       bool       `json:"enabled"`
+	Severity      string     `json:"severity"`
+	Parameter     string     `json:"parameter"`
+	ParameterHelp string     `json:"parameter_help,omitempty"`
+	UpdatedBy     *int64     `json:"updated_by,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// LintFinding is a problem a lint rule found in the curation meta
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// SubmissionTemplate is curation meta a user saved to fill in their submissions with
+type SubmissionTemplate struct {
+	ID        int64             `json:"id"`
+	UserID    int64             `json:"user_id"`
+	Name      string            `json:"name"`
+	Fields    map[string]string `json:"fields"` // values by meta.yaml key
+	UpdatedAt time.Time         `json:"updated_at"`
 }
 
 type SubmissionsFilter struct {
@@ -184,6 +299,7 @@ type SubmissionsFilter struct {
 	ExcludeLegacy                  bool
 	UpdatedByID                    *int64
 	IsFrozen                       *string `schema:"is-frozen"`
+	ChecklistFailed                []int64 `schema:"checklist-failed"`
 }
 
 func unzeroNilPointers(x interface{}) {
@@ -316,14 +432,15 @@ func (sf *SubmissionsFilter) Validate() error {
 }
 
 type ExtendedComment struct {
-	CommentID    int64
-	AuthorID     int64
-	Username     string
-	AvatarURL    string
-	SubmissionID int64
-	Action       string
-	Message      *string
-	CreatedAt    time.Time
+	CommentID        int64
+	AuthorID         int64
+	Username         string
+	AvatarURL        string
+	SubmissionID     int64
+	Action           string
+	Message          *string
+	CreatedAt        time.Time
+	ChecklistResults []*ChecklistResult
 }
 
 type UpdateNotificationSettings struct {
@@ -349,6 +466,96 @@ type CurationImage struct {
 	Filename         string
 }
 
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// SubmissionFileDiff is the comparison of two files of the same submission, only differences are listed
+type SubmissionFileDiff struct {
+	From             *ExtendedSubmissionFile `json:"from"`
+	To               *ExtendedSubmissionFile `json:"to"`
+	Meta             []*CurationMetaChange   `json:"meta"`
+	Entries          []*ArchiveEntryChange   `json:"entries"`
+	UnchangedEntries int                     `json:"unchanged_entries"`
+	Images           []*CurationImageChange  `json:"images"`
+	// IdenticalFiles is set when both files have the same checksum, their entries are not compared then
+	IdenticalFiles bool `json:"identical_files"`
+}
+
+// CurationMetaField is a curation meta field as shown in the web editor
+type CurationMetaField struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Multiline bool   `json:"multiline"`
+}
+
+// CurationMetaChange is a curation meta field which differs, nil means the field is not set
+type CurationMetaChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+// ArchiveEntryChange is a file inside the archive which was added, removed or changed
+type ArchiveEntryChange struct {
+	Name       string  `json:"name"`
+	Change     string  `json:"change"`
+	FromSize   *int64  `json:"from_size"`
+	ToSize     *int64  `json:"to_size"`
+	FromSHA256 *string `json:"from_sha256"`
+	ToSHA256   *string `json:"to_sha256"`
+}
+
+// CurationImageChange is a logo or screenshot which was added, removed or changed
+type CurationImageChange struct {
+	Type   string `json:"type"`
+	Change string `json:"change"`
+	FromID *int64 `json:"from_id"`
+	ToID   *int64 `json:"to_id"`
+}
+
+// ArchiveBrowserEntry is a file inside a stored submission or flashfreeze archive
+type ArchiveBrowserEntry struct {
+	Name           string    `json:"name"`
+	Size           int64     `json:"size"`
+	SizeCompressed *int64    `json:"size_compressed"` // nil if the format doesn't store it per entry
+	Modified       time.Time `json:"modified"`
+	CRC32          *string   `json:"crc32"` // nil if the format doesn't store checksums
+}
+
+const (
+	ArchivePreviewNone  = "none"
+	ArchivePreviewText  = "text"
+	ArchivePreviewHTML  = "html"
+	ArchivePreviewImage = "image"
+	ArchivePreviewSWF   = "swf"
+)
+
+// ArchiveEntryDetails is an archive entry read in full, with a preview of its content
+type ArchiveEntryDetails struct {
+	ArchiveBrowserEntry
+	SHA256        string     `json:"sha256"`
+	MD5           string     `json:"md5"`
+	FileType      string     `json:"file_type"`
+	PreviewKind   string     `json:"preview_kind"`
+	PreviewText   *string    `json:"preview_text"` // text and html entries
+	TextTruncated bool       `json:"text_truncated"`
+	SWFHeader     *SWFHeader `json:"swf_header"`
+}
+
+// SWFHeader is the header of a Flash movie, dimensions are in pixels
+type SWFHeader struct {
+	Compression string  `json:"compression"`
+	Version     int     `json:"version"`
+	FileLength  uint32  `json:"file_length"`
+	Width       float64 `json:"width"`
+	Height      float64 `json:"height"`
+	FrameRate   float64 `json:"frame_rate"`
+	FrameCount  uint16  `json:"frame_count"`
+}
+
 type ValidatorResponseImage struct {
 	Type string `json:"type"`
 	Data string `json:"data"`
@@ -425,6 +632,7 @@ type GameDump struct {
 	AddApps         []*AdditionalApp `json:"add_apps"`
 	ActiveDataID    *int             `json:"active_data_id,omitempty"`
 	Data            []*GameData      `json:"data,omitempty"`
+	ArchiveState    ArchiveState     `json:"archive_state"`
 	RuffleSupport   string           `json:"ruffle_support,omitempty"`
 	Action          string           `json:"action"`
 	Reason          string           `json:"reason"`
@@ -556,6 +764,26 @@ type LauncherDump struct {
 	PlatformRelations []LauncherDumpRelation `json:"platform_relations"`
 }
 
+// MetadataSnapshot is a generated export of the whole metadata database. Timestamp is the time the data is from,
+// the launchers continue syncing the changes made since then.
+type MetadataSnapshot struct {
+	Version       int64                 `json:"version"`
+	FormatVersion int64                 `json:"format_version"`
+	Timestamp     time.Time             `json:"timestamp"`
+	CreatedAt     time.Time             `json:"created_at"`
+	GameCount     int64                 `json:"game_count"`
+	SQLite        *MetadataSnapshotFile `json:"sqlite"`
+	JSON          *MetadataSnapshotFile `json:"json"`
+}
+
+// MetadataSnapshotFile is one of the files of a metadata snapshot
+type MetadataSnapshotFile struct {
+	Key    string `json:"-"`
+	URL    string `json:"url"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
 type LauncherDumpGames struct {
 	AddApps  []AdditionalApp `json:"add_apps"`
 	GameData []GameData      `json:"game_data"`
@@ -579,16 +807,18 @@ type LauncherDumpTagsAliases struct {
 }
 
 type LauncherDumpTagsTag struct {
-	ID           int64  `json:"id"`
-	CategoryID   int64  `json:"category_id"`
-	Description  string `json:"description"`
-	PrimaryAlias string `json:"primary_alias"`
+	ID           int64     `json:"id"`
+	CategoryID   int64     `json:"category_id"`
+	Description  string    `json:"description"`
+	PrimaryAlias string    `json:"primary_alias"`
+	DateModified time.Time `json:"date_modified"` // not imported
 }
 
 type LauncherDumpPlatformsPlatform struct {
-	ID           int64  `json:"id"`
-	Description  string `json:"description"`
-	PrimaryAlias string `json:"primary_alias"`
+	ID           int64     `json:"id"`
+	Description  string    `json:"description"`
+	PrimaryAlias string    `json:"primary_alias"`
+	DateModified time.Time `json:"date_modified"` // not imported
 }
 
 func (t *LauncherDumpTagsTag) UnmarshalJSON(data []byte) error {
@@ -642,6 +872,67 @@ type ResumableParams struct {
 	ResumableRelativePath     string `schema:"resumableRelativePath"`
 	ResumableCurrentChunkSize int64  `schema:"resumableCurrentChunkSize"`
 	ResumableTotalChunks      int    `schema:"resumableTotalChunks"`
+	Draft                     bool   `schema:"draft"`
+}
+
+// SubmissionProcessingJob is a fully received upload in the processing queue. Finished jobs are kept for a while
+// so that their status can still be polled.
+type SubmissionProcessingJob struct {
+	TempName        string           `json:"temp_name"`
+	UserID          int64            `json:"user_id"`
+	SubmissionID    *int64           `json:"submission_id"`
+	ResumableParams *ResumableParams `json:"resumable_params"`
+	// CurationMetaChanges is set for the versions made in the curation meta editor, they are posted as a system comment
+	CurationMetaChanges []*CurationMetaChange `json:"curation_meta_changes"`
+	Status              string                `json:"status"`
+	Message             *string               `json:"message"`
+	ResultSubmissionID  *int64                `json:"result_submission_id"`
+	Attempts            int                   `json:"attempts"`
+	NextAttemptAt       time.Time             `json:"next_attempt_at"`
+	ClaimedBy           *string               `json:"claimed_by"`
+	ClaimedUntil        *time.Time            `json:"claimed_until"`
+	CreatedAt           time.Time             `json:"created_at"`
+	UpdatedAt           time.Time             `json:"updated_at"`
+}
+
+// ScheduledJob is a recurring internal task run by the scheduler. The lock keeps it from running more than once at a time.
+type ScheduledJob struct {
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Schedule       string           `json:"schedule"` // cron expression in UTC
+	Enabled        bool             `json:"enabled"`
+	NextRunAt      time.Time        `json:"next_run_at"`
+	RunRequestedBy *int64           `json:"run_requested_by"` // set when an admin triggered a run which did not start yet
+	LockedBy       *string          `json:"locked_by"`
+	LockedUntil    *time.Time       `json:"locked_until"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	LastRun        *ScheduledJobRun `json:"last_run"`
+}
+
+// ScheduledJobRun is one run of a scheduled job, the log is only loaded for a single run
+type ScheduledJobRun struct {
+	ID          int64      `json:"id"`
+	JobName     string     `json:"job_name"`
+	TriggeredBy *int64     `json:"triggered_by"` // nil if started by the schedule
+	WorkerID    string     `json:"worker_id"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	Status      string     `json:"status"`
+	Message     *string    `json:"message"`
+	Log         *string    `json:"log,omitempty"`
+}
+
+// Duration returns how long the run took, or has been running so far
+func (r *ScheduledJobRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return time.Since(r.StartedAt).Truncate(time.Second)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+type UpdateScheduledJobRequest struct {
+	Schedule string `schema:"schedule"`
+	Enabled  bool   `schema:"enabled"`
 }
 
 type FlashfreezeFile struct {
@@ -653,6 +944,80 @@ type FlashfreezeFile struct {
 	UploadedAt       time.Time
 	MD5Sum           string
 	SHA256Sum        string
+	Description      *string
+	Tags             []string
+	DeletedAt        *time.Time
+	DeletedReason    *string
+}
+
+type FlashfreezeCollection struct {
+	ID          int64
+	UserID      int64
+	Username    string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	FileCount   int64
+}
+
+// StoredFileRecord is a database row which should have a stored file
+type StoredFileRecord struct {
+	Kind            string
+	ID              int64
+	CurrentFilename string
+	SHA256Sum       string
+	Deleted         bool
+}
+
+// GameDataFileRecord is a game data row together with the state of its game, which decides where the data pack should be
+type GameDataFileRecord struct {
+	GameID       string
+	DateAdded    time.Time
+	SHA256       string
+	CRC32        int
+	GameDeleted  bool
+	ArchiveState ArchiveState
+}
+
+const (
+	FileIssueMissing      = "missing"
+	FileIssueOrphan       = "orphan"
+	FileIssueHashMismatch = "hash-mismatch"
+	FileIssueMisplaced    = "misplaced"
+)
+
+type FileConsistencyIssue struct {
+	Kind        string `json:"kind"`
+	Volume      string `json:"volume"`
+	Key         string `json:"key"`
+	Details     string `json:"details"`
+	Quarantined bool   `json:"quarantined"`
+}
+
+type FileConsistencyReport struct {
+	StartedAt    time.Time               `json:"started_at"`
+	FinishedAt   time.Time               `json:"finished_at"`
+	VerifyHashes bool                    `json:"verify_hashes"`
+	Quarantine   bool                    `json:"quarantine"`
+	CheckedFiles int64                   `json:"checked_files"`
+	Issues       []*FileConsistencyIssue `json:"issues"`
+	IssueCounts  map[string]int64        `json:"issue_counts"`
+	Error        *string                 `json:"error,omitempty"`
+}
+
+// SweepUnassignment is a user the sweeper unassigned from a submission
+type SweepUnassignment struct {
+	SubmissionID int64  `json:"submission_id"`
+	UserID       int64  `json:"user_id"`
+	Action       string `json:"action"`
+}
+
+// SweepReport is what a run of the stalled submission sweeper did
+type SweepReport struct {
+	StartedAt  time.Time            `json:"started_at"`
+	Unassigned []*SweepUnassignment `json:"unassigned"`
+	Reminded   []int64              `json:"reminded"`
+	Rejected   []int64              `json:"rejected"`
 }
 
 type IndexerResp struct {
@@ -679,12 +1044,15 @@ type ExtendedFlashfreezeItem struct {
 	SHA256Sum         string
 	Size              int64
 	UploadedAt        *time.Time // only for root files
-	Description       *string    // only for inner files
+	Description       *string    // file type for inner files, free-text description for root files
 	IsRootFile        bool
 	IsDeepFile        bool
 	IndexingTime      *time.Duration // only for root files
 	FileCount         *int64         // only for root files
 	IndexingErrors    *int64         // only for root files
+	Tags              []string       // only for root files
+	DeletedAt         *time.Time
+	DeletedReason     *string
 }
 
 type FlashfreezeFilter struct {
@@ -707,6 +1075,10 @@ type FlashfreezeFilter struct {
 	SearchFiles            *bool `schema:"search-files"`
 	SearchFilesRecursively *bool `schema:"search-files-recursively"`
 
+	Tags         []string `schema:"tag"`           // root file (or the root of an inner file) must have all of these
+	CollectionID *int64   `schema:"collection-id"` // root file (or the root of an inner file) must be in this collection
+	IsDeleted    *bool    `schema:"is-deleted"`    // nil hides deleted files
+
 	ResultsPerPage *int64 `schema:"results-per-page"`
 	Page           *int64 `schema:"page"`
 }
@@ -754,6 +1126,17 @@ func (ff *FlashfreezeFilter) Validate() error {
 		}
 	}
 
+	if ff.CollectionID != nil && *ff.CollectionID < 1 {
+		return fmt.Errorf("collection id must be >= 1")
+	}
+	tags := make([]string, 0, len(ff.Tags))
+	for _, tag := range ff.Tags {
+		if tag = NormalizeFlashfreezeTag(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	ff.Tags = tags
+
 	if ff.ResultsPerPage != nil && *ff.ResultsPerPage < 1 {
 		if *ff.ResultsPerPage == 0 {
 			ff.ResultsPerPage = nil
@@ -772,6 +1155,11 @@ func (ff *FlashfreezeFilter) Validate() error {
 	return nil
 }
 
+// NormalizeFlashfreezeTag lowercases and trims a flashfreeze tag, commas are not allowed because tags are aggregated with them
+func NormalizeFlashfreezeTag(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), ",", "")
+}
+
 type DeleteUserSessionsRequest struct {
 	DiscordID int64 `schema:"discord-user-id"`
 }
@@ -974,6 +1362,67 @@ type IndexPathRequest struct {
 } // @name IndexPathRequest
 
 type AutounfreezerGame struct {
-	GameID      string
-	ReleaseDate string
+	GameID      string `json:"game_id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// AutounfreezerPolicy configures which frozen games the autounfreezer unfreezes, its schedule is the one of the autounfreezer job
+type AutounfreezerPolicy struct {
+	AgeThresholdDays int64     `json:"age_threshold_days"` // games released longer ago than this are unfrozen
+	UpdatedBy        *int64    `json:"updated_by"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// AutounfreezerExemption is a frozen game the autounfreezer never unfreezes
+type AutounfreezerExemption struct {
+	GameID    string    `json:"game_id"`
+	Title     *string   `json:"title"` // nil if the game does not exist
+	Reason    string    `json:"reason"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AutounfreezerFailure is a game the autounfreezer failed to unfreeze
+type AutounfreezerFailure struct {
+	GameID string `json:"game_id"`
+	Error  string `json:"error"`
+}
+
+// AutounfreezeReport is what a run of the autounfreezer did, or would do in a dry run
+type AutounfreezeReport struct {
+	StartedAt      time.Time               `json:"started_at"`
+	DryRun         bool                    `json:"dry_run"`
+	ReleasedBefore time.Time               `json:"released_before"`
+	Unfrozen       []*AutounfreezerGame    `json:"unfrozen"`
+	Exempted       []*AutounfreezerGame    `json:"exempted"`
+	InvalidDate    []*AutounfreezerGame    `json:"invalid_release_date"`
+	Failed         []*AutounfreezerFailure `json:"failed"`
+}
+
+type UpdateAutounfreezerPolicyRequest struct {
+	AgeThresholdDays int64 `schema:"age-threshold-days"`
+}
+
+type AddAutounfreezerExemptionRequest struct {
+	GameID string `schema:"game-id"`
+	Reason string `schema:"reason"`
+}
+
+type RemoveAutounfreezerExemptionRequest struct {
+	GameID string `schema:"game-id"`
+}
+
+// ReadinessCheck is the outcome of checking one dependency of the instance
+type ReadinessCheck struct {
+	Name       string  `json:"name"`
+	Ready      bool    `json:"ready"`
+	Error      *string `json:"error,omitempty"`
+	DurationMs int64   `json:"duration_ms,omitempty"`
+}
+
+// ReadinessReport tells whether the instance can serve, it is ready only if all the checks are
+type ReadinessReport struct {
+	Ready  bool              `json:"ready"`
+	Checks []*ReadinessCheck `json:"checks"`
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type contextString string
@@ -77,6 +78,9 @@ func LogCtx(ctx context.Context) *logrus.Entry {
 	if scope := Scope(ctx); len(scope) > 0 {
 		entry = entry.WithField(string(CtxKeys.Scope), scope)
 	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		entry = entry.WithField("traceID", sc.TraceID().String())
+	}
 
 	return entry
 }
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression with the five standard fields (minute, hour, day of month, month, day of week),
+// evaluated in UTC
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// when both days are restricted, a day matching either of them matches, like in cron
+	domRestricted, dowRestricted bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSearchLimit bounds the search for the next run, so that schedules like "0 0 30 2 *" don't loop forever
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a cron expression like "*/15 * * * *", "0 4 * * 1-5" or "@daily"
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+
+	c := &CronSchedule{}
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	// both 0 and 7 are sunday
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domRestricted = fields[2] != "*"
+	c.dowRestricted = fields[4] != "*"
+
+	if c.Next(time.Now()).IsZero() {
+		return nil, fmt.Errorf("the schedule never runs")
+	}
+
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps into a bit set
+func parseCronField(field string, min, max int) (uint64, error) {
+	var result uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step '%s'", stepPart)
+			}
+		}
+
+		var low, high int
+		if rangePart == "*" {
+			low, high = min, max
+		} else if from, to, isRange := strings.Cut(rangePart, "-"); isRange {
+			var err error
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", from)
+			}
+			if high, err = strconv.Atoi(to); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", to)
+			}
+		} else {
+			var err error
+			if low, err = strconv.Atoi(rangePart); err != nil {
+				return 0, fmt.Errorf("invalid value '%s'", rangePart)
+			}
+			high = low
+			// "5/10" means from 5 to the end with a step of 10
+			if hasStep {
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("'%s' is out of the range %d-%d", part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+
+	return result, nil
+}
+
+func (c *CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domRestricted && c.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// Next returns the first time after t matching the schedule, or the zero time if there is none within five years
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
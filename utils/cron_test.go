@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "every minute", spec: "* * * * *"},
+		{name: "lists, ranges and steps", spec: "*/15 0-6,22 1,15 */3 1-5"},
+		{name: "step from a value", spec: "5/10 * * * *"},
+		{name: "sunday as 7", spec: "0 0 * * 7"},
+		{name: "macro", spec: "@daily"},
+		{name: "surrounding whitespace", spec: "  0 4 * * *  "},
+		{name: "too few fields", spec: "0 4 * *", wantErr: true},
+		{name: "too many fields", spec: "0 0 4 * * *", wantErr: true},
+		{name: "unknown macro", spec: "@fortnightly", wantErr: true},
+		{name: "minute out of range", spec: "60 * * * *", wantErr: true},
+		{name: "day of month out of range", spec: "0 0 0 * *", wantErr: true},
+		{name: "reversed range", spec: "0 10-5 * * *", wantErr: true},
+		{name: "zero step", spec: "*/0 * * * *", wantErr: true},
+		{name: "not a number", spec: "0 noon * * *", wantErr: true},
+		{name: "names are not supported", spec: "0 0 * * mon", wantErr: true},
+		{name: "never runs", spec: "0 0 30 2 *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	// 2024-06-01 is a saturday
+	from := time.Date(2024, 6, 1, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{name: "next minute", spec: "* * * * *", from: from, want: time.Date(2024, 6, 1, 10, 31, 0, 0, time.UTC)},
+		{name: "exact match is not returned again", spec: "30 10 * * *", from: time.Date(2024, 6, 1, 10, 30, 0, 0, time.UTC), want: time.Date(2024, 6, 2, 10, 30, 0, 0, time.UTC)},
+		{name: "every quarter hour", spec: "*/15 * * * *", from: from, want: time.Date(2024, 6, 1, 10, 45, 0, 0, time.UTC)},
+		{name: "hourly", spec: "@hourly", from: from, want: time.Date(2024, 6, 1, 11, 0, 0, 0, time.UTC)},
+		{name: "later today", spec: "0 22 * * *", from: from, want: time.Date(2024, 6, 1, 22, 0, 0, 0, time.UTC)},
+		{name: "tomorrow", spec: "0 4 * * *", from: from, want: time.Date(2024, 6, 2, 4, 0, 0, 0, time.UTC)},
+		{name: "weekdays skip the weekend", spec: "0 4 * * 1-5", from: from, want: time.Date(2024, 6, 3, 4, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", spec: "0 0 * * 7", from: from, want: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "next month", spec: "0 0 1 * *", from: from, want: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "next year", spec: "@yearly", from: from, want: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", spec: "0 0 29 2 *", from: from, want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "either restricted day matches", spec: "0 0 15 * 1", from: from, want: time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "day of month alone", spec: "0 0 15 * *", from: from, want: time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{name: "other timezones are converted to utc", spec: "0 12 * * *", from: time.Date(2024, 6, 1, 13, 0, 0, 0, time.FixedZone("CEST", 2*60*60)), want: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron() error = %v", err)
+			}
+			if got := c.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
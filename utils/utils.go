@@ -15,6 +15,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/tracing"
 )
 
 // https://stackoverflow.com/a/31832326
@@ -67,12 +69,19 @@ func FormatLike(s string) string {
 	return "%" + s + "%"
 }
 
-func WriteTarball(w io.Writer, filePaths []string) error {
+// TarballEntry is a file to be written into a tarball, Open is called only when the entry is being written
+type TarballEntry struct {
+	Name string
+	Open func() (io.ReadCloser, int64, time.Time, error)
+}
+
+// WriteTarball writes the entries into a tarball
+func WriteTarball(w io.Writer, entries []TarballEntry) error {
 	tarWriter := tar.NewWriter(w)
 	defer tarWriter.Close()
 
-	for _, filePath := range filePaths {
-		err := addFileToTarWriter(filePath, tarWriter)
+	for _, entry := range entries {
+		err := addEntryToTarWriter(entry, tarWriter)
 		if err != nil {
 			return fmt.Errorf("add file to tar: %s", err.Error())
 		}
@@ -81,23 +90,18 @@ func WriteTarball(w io.Writer, filePaths []string) error {
 	return nil
 }
 
-func addFileToTarWriter(filePath string, tarWriter *tar.Writer) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	stat, err := file.Stat()
+func addEntryToTarWriter(entry TarballEntry, tarWriter *tar.Writer) error {
+	rc, size, modTime, err := entry.Open()
 	if err != nil {
 		return err
 	}
+	defer rc.Close()
 
 	header := &tar.Header{
-		Name:    filePath,
-		Size:    stat.Size(),
-		Mode:    int64(stat.Mode()),
-		ModTime: stat.ModTime(),
+		Name:    entry.Name,
+		Size:    size,
+		Mode:    0644,
+		ModTime: modTime,
 	}
 
 	err = tarWriter.WriteHeader(header)
@@ -105,7 +109,7 @@ func addFileToTarWriter(filePath string, tarWriter *tar.Writer) error {
 		return err
 	}
 
-	_, err = io.Copy(tarWriter, file)
+	_, err = io.Copy(tarWriter, rc)
 	if err != nil {
 		return err
 	}
@@ -213,7 +217,7 @@ func BoolToString(b bool) string {
 
 func UploadMultipartFile(ctx context.Context, url string, f io.Reader, filename string) ([]byte, error) {
 	body, writer := io.Pipe()
-	client := http.Client{Timeout: 86400 * time.Second}
+	client := http.Client{Timeout: 86400 * time.Second, Transport: tracing.NewTransport(nil)}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
 	if err != nil {
@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+func assignedTesting(s *types.ExtendedSubmission) []int64      { return s.AssignedTestingUserIDs }
+func assignedVerification(s *types.ExtendedSubmission) []int64 { return s.AssignedVerificationUserIDs }
+func approved(s *types.ExtendedSubmission) []int64             { return s.ApprovedUserIDs }
+func verified(s *types.ExtendedSubmission) []int64             { return s.VerifiedUserIDs }
+
+// notUploader stops the last uploader from deciding on their own submission
+func notUploader(description string) Guard {
+	return func(uid int64, s *types.ExtendedSubmission) error {
+		if uid == s.LastUploaderID {
+			return fmt.Errorf("you are the uploader of the newest version of submission %d, so you cannot %s", s.SubmissionID, description)
+		}
+		return nil
+	}
+}
+
+// userNotIn fails with the message (formatted with the submission id) if the user is in the list
+func userNotIn(ids func(*types.ExtendedSubmission) []int64, format string) Guard {
+	return func(uid int64, s *types.ExtendedSubmission) error {
+		if uidIn(uid, ids(s)) {
+			return fmt.Errorf(format, s.SubmissionID)
+		}
+		return nil
+	}
+}
+
+// userIn fails with the message (formatted with the submission id) if the user is not in the list
+func userIn(ids func(*types.ExtendedSubmission) []int64, format string) Guard {
+	return func(uid int64, s *types.ExtendedSubmission) error {
+		if !uidIn(uid, ids(s)) {
+			return fmt.Errorf(format, s.SubmissionID)
+		}
+		return nil
+	}
+}
+
+// Default returns the review workflow of Flashpoint: a submission is assigned for testing and approved by a tester,
+// then assigned for verification and verified by somebody else, and finally added to Flashpoint by an adder.
+func Default() *Workflow {
+	beforeAdded := []State{StateReceived, StateInTesting, StateApproved, StateVerified}
+
+	return &Workflow{
+		FrozenRoles: constants.FreezerRoles(),
+		Transitions: []*Transition{
+			{
+				Action:      constants.ActionComment,
+				Description: "comment on it",
+			},
+			{
+				Action:      constants.ActionUpload,
+				Description: "upload a new version",
+				From:        []State{StateReceived, StateInTesting, StateApproved, StateVerified, StateAdded},
+				Internal:    true,
+			},
+			{
+				Action:      constants.ActionAssignTesting,
+				Description: "assign it for testing",
+				From:        beforeAdded,
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					notUploader("assign it"),
+					userNotIn(assignedTesting, "you are already assigned to test submission %d"),
+					userNotIn(assignedVerification, "you are already assigned to verify submission %d so you cannot assign it for testing"),
+					userNotIn(verified, "you have already verified submission %d so you cannot assign it for testing"),
+					userNotIn(approved, "you have already approved submission %d so you cannot assign it for testing"),
+				},
+			},
+			{
+				Action:      constants.ActionUnassignTesting,
+				Description: "unassign it from testing",
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					userIn(assignedTesting, "you are not assigned to test submission %d"),
+				},
+			},
+			{
+				Action:      constants.ActionAssignVerification,
+				Description: "assign it for verification",
+				From:        []State{StateApproved, StateVerified},
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					notUploader("assign it"),
+					userNotIn(assignedVerification, "you are already assigned to verify submission %d"),
+					userNotIn(assignedTesting, "you are already assigned to test submission %d so you cannot assign it for verification"),
+					userNotIn(approved, "you have already approved (tested) submission %d so you cannot assign it for verification"),
+					userNotIn(verified, "you have already verified submission %d so you cannot assign it for verification"),
+				},
+			},
+			{
+				Action:      constants.ActionUnassignVerification,
+				Description: "unassign it from verification",
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					userIn(assignedVerification, "you are not assigned to verify submission %d"),
+				},
+			},
+			{
+				Action:      constants.ActionRequestChanges,
+				Description: "request changes on it",
+				From:        beforeAdded,
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					notUploader("request changes on it"),
+				},
+			},
+			{
+				Action:      constants.ActionApprove,
+				Description: "approve it",
+				From:        []State{StateInTesting, StateApproved, StateVerified},
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					notUploader("approve it"),
+					userNotIn(approved, "you have already approved submission %d"),
+					userNotIn(verified, "you have already verified submission %d so you cannot approve it"),
+					userIn(assignedTesting, "you are not assigned to test submission %d so you cannot approve it"),
+				},
+			},
+			{
+				Action:      constants.ActionVerify,
+				Description: "verify it",
+				From:        []State{StateApproved, StateVerified},
+				Roles:       constants.DeciderRoles(),
+				Guards: []Guard{
+					notUploader("verify it"),
+					userNotIn(verified, "you have already verified submission %d"),
+					userNotIn(approved, "you have already approved (tested) submission %d so you cannot verify it"),
+					userIn(assignedVerification, "you are not assigned to verify submission %d so you cannot verify it"),
+				},
+			},
+			{
+				Action:      constants.ActionMarkAdded,
+				Description: "mark it as added",
+				From:        []State{StateVerified},
+				Roles:       constants.AdderRoles(),
+			},
+			{
+				Action:      constants.ActionReject,
+				Description: "reject it",
+				From:        beforeAdded,
+				Roles:       constants.DeciderRoles(),
+			},
+		},
+	}
+}
@@ -0,0 +1,251 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+// State is the review state of a submission, derived from its comment actions
+type State string
+
+const (
+	StateReceived  State = "received"
+	StateInTesting State = "in testing"
+	StateApproved  State = "approved"
+	StateVerified  State = "verified"
+	StateAdded     State = "added"
+	StateRejected  State = "rejected"
+	StateFrozen    State = "frozen"
+)
+
+// Guard returns an error describing why uid cannot perform the transition on the submission
+type Guard func(uid int64, s *types.ExtendedSubmission) error
+
+// Transition is a comment action that can be performed on a submission
+type Transition struct {
+	Action string
+	// Description finishes the sentence "you cannot ...", e.g. "assign it for testing"
+	Description string
+	From        []State  // review states the action can be performed in, empty means any
+	Roles       []string // user needs any of these roles, empty means anyone who can comment on the submission
+	Levels      []string // submission levels the action is available on, empty means all
+	Guards      []Guard
+	// Internal transitions are performed by the site itself (e.g. uploading a new version), never through the comment form
+	Internal bool
+}
+
+// Workflow is the state machine submissions go through during the review
+type Workflow struct {
+	Transitions []*Transition
+	// FrozenRoles may act on frozen submissions, nobody else can
+	FrozenRoles []string
+	// Requirements are configured by admins, submissions not matching any of them need one approval and one verification
+	Requirements []*types.ReviewRequirement
+}
+
+func has(s *types.ExtendedSubmission, action string) bool {
+	for _, distinctAction := range s.DistinctActions {
+		if distinctAction == action {
+			return true
+		}
+	}
+	return false
+}
+
+func uidIn(uid int64, ids []int64) bool {
+	for _, id := range ids {
+		if uid == id {
+			return true
+		}
+	}
+	return false
+}
+
+func stateIn(state State, states []State) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func levelIn(level string, levels []string) bool {
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// platformMatches reports whether the platform is one of the submission's platforms, which may be a semicolon separated list
+func platformMatches(platform string, s *types.ExtendedSubmission) bool {
+	if s.CurationPlatform == nil {
+		return false
+	}
+	for _, p := range strings.Split(*s.CurationPlatform, ";") {
+		if strings.EqualFold(strings.TrimSpace(p), platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// Requirement returns the most specific requirement matching the submission. A platform match is more specific than a level match,
+// if several platforms of the submission match equally, the strictest requirement wins.
+func (w *Workflow) Requirement(s *types.ExtendedSubmission) *types.ReviewRequirement {
+	result := &types.ReviewRequirement{RequiredApprovals: 1, RequiredVerifications: 1}
+	bestScore := -1
+
+	for _, r := range w.Requirements {
+		score := 0
+		if r.SubmissionLevel != "" {
+			if r.SubmissionLevel != s.SubmissionLevel {
+				continue
+			}
+			score += 1
+		}
+		if r.Platform != "" {
+			if !platformMatches(r.Platform, s) {
+				continue
+			}
+			score += 2
+		}
+		stricter := r.RequiredApprovals+r.RequiredVerifications > result.RequiredApprovals+result.RequiredVerifications
+		if score > bestScore || (score == bestScore && stricter) {
+			result = r
+			bestScore = score
+		}
+	}
+
+	return result
+}
+
+// Progress returns the review progress of the submission
+func (w *Workflow) Progress(s *types.ExtendedSubmission) *types.ReviewProgress {
+	r := w.Requirement(s)
+	return &types.ReviewProgress{
+		State:                 string(w.State(s)),
+		Approvals:             len(s.ApprovedUserIDs),
+		RequiredApprovals:     r.RequiredApprovals,
+		Verifications:         len(s.VerifiedUserIDs),
+		RequiredVerifications: r.RequiredVerifications,
+		RequiredRole:          r.RequiredRole,
+	}
+}
+
+// HasRequiredRole reports whether the user has the role the submission's requirement asks for when approving and verifying
+func (w *Workflow) HasRequiredRole(roles []string, action string, s *types.ExtendedSubmission) bool {
+	if action != constants.ActionApprove && action != constants.ActionVerify {
+		return true
+	}
+	role := w.Requirement(s).RequiredRole
+	return role == "" || constants.HasAnyRole(roles, []string{role})
+}
+
+// ReviewState returns the state of the submission ignoring whether it's frozen
+func (w *Workflow) ReviewState(s *types.ExtendedSubmission) State {
+	r := w.Requirement(s)
+	// somebody has to review the submission even if the requirement asks for no approvals or no verifications
+	reviewed := len(s.ApprovedUserIDs) > 0 || len(s.VerifiedUserIDs) > 0
+	approved := reviewed && len(s.ApprovedUserIDs) >= r.RequiredApprovals
+	verified := len(s.VerifiedUserIDs) >= r.RequiredVerifications
+
+	switch {
+	case has(s, constants.ActionReject):
+		return StateRejected
+	case has(s, constants.ActionMarkAdded):
+		return StateAdded
+	case approved && verified:
+		return StateVerified
+	case approved:
+		return StateApproved
+	case len(s.AssignedTestingUserIDs) > 0 || len(s.AssignedVerificationUserIDs) > 0 ||
+		len(s.ApprovedUserIDs) > 0 || len(s.VerifiedUserIDs) > 0 || len(s.RequestedChangesUserIDs) > 0:
+		return StateInTesting
+	}
+	return StateReceived
+}
+
+// State returns the state of the submission as shown to users
+func (w *Workflow) State(s *types.ExtendedSubmission) State {
+	if s.IsFrozen {
+		return StateFrozen
+	}
+	return w.ReviewState(s)
+}
+
+func (w *Workflow) transition(action string) *Transition {
+	for _, t := range w.Transitions {
+		if t.Action == action {
+			return t
+		}
+	}
+	return nil
+}
+
+// Allows reports whether a user with given roles may perform the action through the comment form at all
+func (w *Workflow) Allows(roles []string, action string) bool {
+	t := w.transition(action)
+	if t == nil || t.Internal {
+		return false
+	}
+	return len(t.Roles) == 0 || constants.HasAnyRole(roles, t.Roles)
+}
+
+// Validate returns an error if uid cannot perform the action on the submission in its current state.
+// Roles are not checked here, see Allows.
+func (w *Workflow) Validate(uid int64, action string, s *types.ExtendedSubmission) error {
+	sid := s.SubmissionID
+
+	t := w.transition(action)
+	if t == nil {
+		return fmt.Errorf("invalid comment action '%s'", action)
+	}
+	if !levelIn(s.SubmissionLevel, t.Levels) {
+		return fmt.Errorf("action '%s' is not available for %s submissions", action, s.SubmissionLevel)
+	}
+
+	state := w.ReviewState(s)
+	if !stateIn(state, t.From) {
+		switch state {
+		case StateRejected:
+			return fmt.Errorf("submission %d is alrady rejected so you cannot %s", sid, t.Description)
+		case StateAdded:
+			return fmt.Errorf("submission %d is alrady marked as added so you cannot %s, please submit a bug report or a pending fix if there is a problem with the submission", sid, t.Description)
+		}
+		return fmt.Errorf("submission %d is %s so you cannot %s", sid, state, t.Description)
+	}
+
+	for _, guard := range t.Guards {
+		if err := guard(uid, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AvailableActions returns the actions the user can perform on the submission right now, in the order of the transitions
+func (w *Workflow) AvailableActions(uid int64, roles []string, s *types.ExtendedSubmission) []string {
+	if s.IsFrozen && !constants.HasAnyRole(roles, w.FrozenRoles) {
+		return []string{}
+	}
+	result := make([]string, 0, len(w.Transitions))
+	for _, t := range w.Transitions {
+		if w.Allows(roles, t.Action) && w.HasRequiredRole(roles, t.Action, s) && w.Validate(uid, t.Action, s) == nil {
+			result = append(result, t.Action)
+		}
+	}
+	return result
+}
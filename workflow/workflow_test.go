@@ -0,0 +1,436 @@
+package workflow
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/FlashpointProject/flashpoint-submission-system/constants"
+	"github.com/FlashpointProject/flashpoint-submission-system/types"
+)
+
+var (
+	testerID   int64 = 1
+	uploaderID int64 = 2
+	otherID    int64 = 3
+)
+
+func platform(p string) *string {
+	return &p
+}
+
+func TestWorkflow_ReviewState(t *testing.T) {
+	twoApprovals := &Workflow{Requirements: []*types.ReviewRequirement{
+		{SubmissionLevel: constants.SubmissionLevelStaff, RequiredApprovals: 2, RequiredVerifications: 1},
+	}}
+	verificationOnly := &Workflow{Requirements: []*types.ReviewRequirement{{RequiredApprovals: 0, RequiredVerifications: 1}}}
+	noReviews := &Workflow{Requirements: []*types.ReviewRequirement{{RequiredApprovals: 0, RequiredVerifications: 0}}}
+
+	tests := []struct {
+		name       string
+		workflow   *Workflow
+		submission *types.ExtendedSubmission
+		want       State
+	}{
+		{
+			name:       "new submission is received",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{},
+			want:       StateReceived,
+		},
+		{
+			name:       "assigned submission is in testing",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{AssignedTestingUserIDs: []int64{testerID}},
+			want:       StateInTesting,
+		},
+		{
+			name:       "submission with requested changes is in testing",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{RequestedChangesUserIDs: []int64{testerID}},
+			want:       StateInTesting,
+		},
+		{
+			name:       "one approval is enough by default",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}},
+			want:       StateApproved,
+		},
+		{
+			name:       "approved and verified submission is verified",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}, VerifiedUserIDs: []int64{otherID}},
+			want:       StateVerified,
+		},
+		{
+			name:     "verification without enough approvals is in testing",
+			workflow: twoApprovals,
+			submission: &types.ExtendedSubmission{
+				SubmissionLevel: constants.SubmissionLevelStaff,
+				ApprovedUserIDs: []int64{testerID},
+				VerifiedUserIDs: []int64{otherID},
+			},
+			want: StateInTesting,
+		},
+		{
+			name:     "required approvals of another level don't apply",
+			workflow: twoApprovals,
+			submission: &types.ExtendedSubmission{
+				SubmissionLevel: constants.SubmissionLevelAudition,
+				ApprovedUserIDs: []int64{testerID},
+			},
+			want: StateApproved,
+		},
+		{
+			name:       "no required approvals doesn't approve an untouched submission",
+			workflow:   verificationOnly,
+			submission: &types.ExtendedSubmission{},
+			want:       StateReceived,
+		},
+		{
+			name:       "verification is enough without required approvals",
+			workflow:   verificationOnly,
+			submission: &types.ExtendedSubmission{VerifiedUserIDs: []int64{otherID}},
+			want:       StateVerified,
+		},
+		{
+			name:       "requirement without any reviews doesn't verify an untouched submission",
+			workflow:   noReviews,
+			submission: &types.ExtendedSubmission{},
+			want:       StateReceived,
+		},
+		{
+			name:       "requirement without any reviews needs one review",
+			workflow:   noReviews,
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}},
+			want:       StateVerified,
+		},
+		{
+			name:       "marked as added wins over the approvals",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}, VerifiedUserIDs: []int64{otherID}, DistinctActions: []string{constants.ActionMarkAdded}},
+			want:       StateAdded,
+		},
+		{
+			name:       "rejected wins over everything",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionMarkAdded, constants.ActionReject}},
+			want:       StateRejected,
+		},
+		{
+			name:       "frozen is not a review state",
+			workflow:   Default(),
+			submission: &types.ExtendedSubmission{IsFrozen: true, AssignedTestingUserIDs: []int64{testerID}},
+			want:       StateInTesting,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.workflow.ReviewState(tt.submission); got != tt.want {
+				t.Errorf("ReviewState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflow_State(t *testing.T) {
+	s := &types.ExtendedSubmission{IsFrozen: true, DistinctActions: []string{constants.ActionReject}}
+	if got := Default().State(s); got != StateFrozen {
+		t.Errorf("State() = %v, want %v", got, StateFrozen)
+	}
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	approved := &types.ExtendedSubmission{LastUploaderID: uploaderID, ApprovedUserIDs: []int64{testerID}}
+
+	tests := []struct {
+		name       string
+		uid        int64
+		action     string
+		submission *types.ExtendedSubmission
+		wantErr    bool
+	}{
+		{
+			name:       "unknown action",
+			uid:        testerID,
+			action:     "dance",
+			submission: &types.ExtendedSubmission{},
+			wantErr:    true,
+		},
+		{
+			name:       "anyone can comment on a rejected submission",
+			uid:        otherID,
+			action:     constants.ActionComment,
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionReject}},
+			wantErr:    false,
+		},
+		{
+			name:       "tester can assign a received submission for testing",
+			uid:        testerID,
+			action:     constants.ActionAssignTesting,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID},
+			wantErr:    false,
+		},
+		{
+			name:       "uploader cannot assign for testing",
+			uid:        uploaderID,
+			action:     constants.ActionAssignTesting,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID},
+			wantErr:    true,
+		},
+		{
+			name:       "uploader cannot assign an approved submission for verification",
+			uid:        uploaderID,
+			action:     constants.ActionAssignVerification,
+			submission: approved,
+			wantErr:    true,
+		},
+		{
+			name:       "someone else can assign an approved submission for verification",
+			uid:        otherID,
+			action:     constants.ActionAssignVerification,
+			submission: approved,
+			wantErr:    false,
+		},
+		{
+			name:       "uploader can unassign themselves from verification",
+			uid:        uploaderID,
+			action:     constants.ActionUnassignVerification,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, ApprovedUserIDs: []int64{testerID}, AssignedVerificationUserIDs: []int64{uploaderID}},
+			wantErr:    false,
+		},
+		{
+			name:       "submission cannot be assigned for verification before it's approved",
+			uid:        otherID,
+			action:     constants.ActionAssignVerification,
+			submission: &types.ExtendedSubmission{AssignedTestingUserIDs: []int64{testerID}},
+			wantErr:    true,
+		},
+		{
+			name:       "tester cannot approve without being assigned",
+			uid:        testerID,
+			action:     constants.ActionApprove,
+			submission: &types.ExtendedSubmission{RequestedChangesUserIDs: []int64{otherID}},
+			wantErr:    true,
+		},
+		{
+			name:       "assigned tester can approve",
+			uid:        testerID,
+			action:     constants.ActionApprove,
+			submission: &types.ExtendedSubmission{AssignedTestingUserIDs: []int64{testerID}},
+			wantErr:    false,
+		},
+		{
+			name:       "tester cannot verify what they approved",
+			uid:        testerID,
+			action:     constants.ActionVerify,
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}, AssignedVerificationUserIDs: []int64{testerID}},
+			wantErr:    true,
+		},
+		{
+			name:       "request changes on a submission in testing",
+			uid:        testerID,
+			action:     constants.ActionRequestChanges,
+			submission: &types.ExtendedSubmission{AssignedTestingUserIDs: []int64{testerID}},
+			wantErr:    false,
+		},
+		{
+			name:       "request changes on a rejected submission",
+			uid:        testerID,
+			action:     constants.ActionRequestChanges,
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionReject}},
+			wantErr:    true,
+		},
+		{
+			name:       "request changes on an added submission",
+			uid:        testerID,
+			action:     constants.ActionRequestChanges,
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionMarkAdded}},
+			wantErr:    true,
+		},
+		{
+			name:       "approved submission cannot be marked as added before it's verified",
+			uid:        otherID,
+			action:     constants.ActionMarkAdded,
+			submission: approved,
+			wantErr:    true,
+		},
+		{
+			name:       "verified submission can be marked as added",
+			uid:        otherID,
+			action:     constants.ActionMarkAdded,
+			submission: &types.ExtendedSubmission{ApprovedUserIDs: []int64{testerID}, VerifiedUserIDs: []int64{uploaderID}},
+			wantErr:    false,
+		},
+		{
+			name:       "new version can be uploaded to an added submission",
+			uid:        uploaderID,
+			action:     constants.ActionUpload,
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionMarkAdded}},
+			wantErr:    false,
+		},
+		{
+			name:       "new version cannot be uploaded to a rejected submission",
+			uid:        uploaderID,
+			action:     constants.ActionUpload,
+			submission: &types.ExtendedSubmission{DistinctActions: []string{constants.ActionReject}},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Default().Validate(tt.uid, tt.action, tt.submission); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkflow_Validate_levels(t *testing.T) {
+	wf := &Workflow{Transitions: []*Transition{
+		{Action: constants.ActionComment, Levels: []string{constants.SubmissionLevelStaff}},
+	}}
+	if err := wf.Validate(testerID, constants.ActionComment, &types.ExtendedSubmission{SubmissionLevel: constants.SubmissionLevelStaff}); err != nil {
+		t.Errorf("Validate() error = %v on an allowed level", err)
+	}
+	if err := wf.Validate(testerID, constants.ActionComment, &types.ExtendedSubmission{SubmissionLevel: constants.SubmissionLevelAudition}); err == nil {
+		t.Errorf("Validate() no error on a level the action is not available on")
+	}
+}
+
+func TestWorkflow_AvailableActions(t *testing.T) {
+	tester := []string{constants.RoleTester}
+	moderator := []string{constants.RoleModerator}
+
+	tests := []struct {
+		name       string
+		uid        int64
+		roles      []string
+		submission *types.ExtendedSubmission
+		want       []string
+	}{
+		{
+			name:       "tester on a received submission",
+			uid:        testerID,
+			roles:      tester,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID},
+			want:       []string{constants.ActionComment, constants.ActionAssignTesting, constants.ActionRequestChanges, constants.ActionReject},
+		},
+		{
+			name:       "uploader on their own received submission",
+			uid:        uploaderID,
+			roles:      tester,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID},
+			want:       []string{constants.ActionComment, constants.ActionReject},
+		},
+		{
+			name:       "user without roles can only comment",
+			uid:        otherID,
+			roles:      []string{},
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID},
+			want:       []string{constants.ActionComment},
+		},
+		{
+			name:       "assigned tester",
+			uid:        testerID,
+			roles:      tester,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, AssignedTestingUserIDs: []int64{testerID}},
+			want:       []string{constants.ActionComment, constants.ActionUnassignTesting, constants.ActionRequestChanges, constants.ActionApprove, constants.ActionReject},
+		},
+		{
+			name:       "moderator on a verified submission",
+			uid:        otherID,
+			roles:      moderator,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, ApprovedUserIDs: []int64{testerID}, VerifiedUserIDs: []int64{uploaderID}},
+			want: []string{constants.ActionComment, constants.ActionAssignTesting, constants.ActionAssignVerification,
+				constants.ActionRequestChanges, constants.ActionMarkAdded, constants.ActionReject},
+		},
+		{
+			name:       "tester on a frozen submission",
+			uid:        testerID,
+			roles:      tester,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, IsFrozen: true},
+			want:       []string{},
+		},
+		{
+			name:       "moderator on a frozen submission",
+			uid:        otherID,
+			roles:      moderator,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, IsFrozen: true},
+			want:       []string{constants.ActionComment, constants.ActionAssignTesting, constants.ActionRequestChanges, constants.ActionReject},
+		},
+		{
+			name:       "rejected submission can only be commented on",
+			uid:        otherID,
+			roles:      moderator,
+			submission: &types.ExtendedSubmission{LastUploaderID: uploaderID, DistinctActions: []string{constants.ActionReject}},
+			want:       []string{constants.ActionComment},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Default().AvailableActions(tt.uid, tt.roles, tt.submission); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AvailableActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflow_HasRequiredRole(t *testing.T) {
+	wf := &Workflow{Requirements: []*types.ReviewRequirement{
+		{Platform: "Flash", RequiredApprovals: 1, RequiredVerifications: 1},
+		{Platform: "Unity", RequiredApprovals: 2, RequiredVerifications: 1, RequiredRole: constants.RoleModerator},
+	}}
+	unity := &types.ExtendedSubmission{CurationPlatform: platform("HTML5; Unity")}
+	flash := &types.ExtendedSubmission{CurationPlatform: platform("Flash")}
+
+	tests := []struct {
+		name       string
+		roles      []string
+		action     string
+		submission *types.ExtendedSubmission
+		want       bool
+	}{
+		{
+			name:       "no required role",
+			roles:      []string{constants.RoleTester},
+			action:     constants.ActionApprove,
+			submission: flash,
+			want:       true,
+		},
+		{
+			name:       "approve without the required role",
+			roles:      []string{constants.RoleTester},
+			action:     constants.ActionApprove,
+			submission: unity,
+			want:       false,
+		},
+		{
+			name:       "verify without the required role",
+			roles:      []string{constants.RoleTester},
+			action:     constants.ActionVerify,
+			submission: unity,
+			want:       false,
+		},
+		{
+			name:       "approve with the required role",
+			roles:      []string{constants.RoleTester, constants.RoleModerator},
+			action:     constants.ActionApprove,
+			submission: unity,
+			want:       true,
+		},
+		{
+			name:       "other actions don't need the required role",
+			roles:      []string{constants.RoleTester},
+			action:     constants.ActionAssignTesting,
+			submission: unity,
+			want:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wf.HasRequiredRole(tt.roles, tt.action, tt.submission); got != tt.want {
+				t.Errorf("HasRequiredRole() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}